@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
+	"bitspark.dev/go-tree/pkg/analysis/architecture"
 	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
 )
 
 type analyzeOptions struct {
@@ -24,6 +27,7 @@ type analyzeOptions struct {
 	ShowTypes      bool
 	ShowFunctions  bool
 	ShowDeps       bool
+	RulesFile      string
 }
 
 var analyzeOpts analyzeOptions
@@ -47,6 +51,7 @@ func newAnalyzeCmd() *cobra.Command {
 	// Add subcommands
 	cmd.AddCommand(newStructureCmd())
 	cmd.AddCommand(newInterfacesCmd())
+	cmd.AddCommand(newArchitectureCmd())
 
 	return cmd
 }
@@ -83,6 +88,23 @@ func newInterfacesCmd() *cobra.Command {
 	return cmd
 }
 
+// newArchitectureCmd creates the architecture rules command
+func newArchitectureCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "architecture",
+		Short: "Check imports against architecture rules",
+		Long:  `Checks the imports of the module against allow and deny rules between packages, read from a JSON rules file.`,
+		RunE:  runArchitectureCmd,
+	}
+
+	cmd.Flags().StringVar(&analyzeOpts.RulesFile, "rules", "", "JSON file with import rules")
+	if err := cmd.MarkFlagRequired("rules"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
 // runStructureCmd executes the structure analysis
 func runStructureCmd(cmd *cobra.Command, args []string) error {
 	// Create a loader to load the module
@@ -92,6 +114,7 @@ func runStructureCmd(cmd *cobra.Command, args []string) error {
 	loadOpts := loader.DefaultLoadOptions()
 	loadOpts.IncludeTests = analyzeOpts.IncludeTests
 	loadOpts.LoadDocs = true
+	loadOpts.AllowErrors = true
 
 	// Load the module
 	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
@@ -99,6 +122,7 @@ func runStructureCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load module: %w", err)
 	}
+	warnDiagnostics(mod)
 
 	// Generate structure analysis
 	if analyzeOpts.Format == "json" {
@@ -220,6 +244,7 @@ func runInterfacesCmd(cmd *cobra.Command, args []string) error {
 	loadOpts := loader.DefaultLoadOptions()
 	loadOpts.IncludeTests = analyzeOpts.IncludeTests
 	loadOpts.LoadDocs = true
+	loadOpts.AllowErrors = true
 
 	// Load the module
 	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
@@ -227,6 +252,7 @@ func runInterfacesCmd(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load module: %w", err)
 	}
+	warnDiagnostics(mod)
 
 	// Find interfaces and their implementations
 	interfaces := make(map[string]map[string]bool) // interface name -> implementors
@@ -289,3 +315,67 @@ func runInterfacesCmd(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// runArchitectureCmd checks the module against architecture rules
+func runArchitectureCmd(cmd *cobra.Command, args []string) error {
+	rules, err := architecture.LoadRules(analyzeOpts.RulesFile)
+	if err != nil {
+		return err
+	}
+
+	loadOpts := loader.DefaultLoadOptions()
+	loadOpts.IncludeTests = analyzeOpts.IncludeTests
+
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(GlobalOptions.InputDir, loadOpts)
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+
+	violations, err := architecture.NewAnalyzer().CheckRules(mod, rules)
+	if err != nil {
+		return err
+	}
+
+	if analyzeOpts.Format == "json" {
+		type jsonViolation struct {
+			Package string `json:"package"`
+			File    string `json:"file"`
+			Import  string `json:"import"`
+			Rule    string `json:"rule"`
+		}
+		out := make([]jsonViolation, 0, len(violations))
+		for _, v := range violations {
+			out = append(out, jsonViolation{Package: v.Package, File: v.File, Import: v.Import, Rule: v.Rule.From + " -> " + v.Rule.To})
+		}
+		jsonData, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize violations to JSON: %w", err)
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		for _, v := range violations {
+			fmt.Println(v.String())
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%d architecture rule violation(s)", len(violations))
+	}
+	return nil
+}
+
+// warnDiagnostics reports the packages that failed to load, and with
+// --verbose every problem found
+func warnDiagnostics(mod *module.Module) {
+	if failed := mod.FailedPackages(); len(failed) == 1 {
+		fmt.Fprintf(os.Stderr, "Warning: 1 package failed to load: %s\n", failed[0])
+	} else if len(failed) > 1 {
+		fmt.Fprintf(os.Stderr, "Warning: %d packages failed to load: %s\n", len(failed), strings.Join(failed, ", "))
+	}
+	if GlobalOptions.Verbose {
+		for _, d := range mod.Diagnostics {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", d.Severity, d)
+		}
+	}
+}
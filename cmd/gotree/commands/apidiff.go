@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"bitspark.dev/go-tree/pkg/analysis/apidiff"
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+type apiDiffOptions struct {
+	OldDir string
+	NewDir string
+	Format string
+	Ignore []string
+}
+
+var apiDiffOpts apiDiffOptions
+
+// newAPIDiffRootCmd creates the apidiff command
+func newAPIDiffRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apidiff",
+		Short: "Compare the exported APIs of two module directories",
+		Long: `Compares the exported API of two versions of a module, listing breaking
+changes first. Exits with an error if there are breaking changes, for use
+as a release gate (e.g. gotree apidiff --old ../v1 --new .).`,
+		RunE: runAPIDiffRootCmd,
+	}
+
+	cmd.Flags().StringVar(&apiDiffOpts.OldDir, "old", "", "Directory of the older version of the module")
+	cmd.Flags().StringVar(&apiDiffOpts.NewDir, "new", "", "Directory of the newer version of the module (defaults to --input)")
+	cmd.Flags().StringVar(&apiDiffOpts.Format, "format", "text", "Output format (text, json)")
+	cmd.Flags().StringArrayVar(&apiDiffOpts.Ignore, "ignore", nil, "Package pattern relative to the module to leave out, e.g. \"tools/...\" (repeatable)")
+	if err := cmd.MarkFlagRequired("old"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// runAPIDiffRootCmd compares the APIs of two module directories
+func runAPIDiffRootCmd(cmd *cobra.Command, args []string) error {
+	if apiDiffOpts.Format != "text" && apiDiffOpts.Format != "json" {
+		return fmt.Errorf("unknown format %q (supported: json, text)", apiDiffOpts.Format)
+	}
+	// Breaking changes and load errors are no reason to print usage
+	cmd.SilenceUsage = true
+	newDir := apiDiffOpts.NewDir
+	if newDir == "" {
+		newDir = GlobalOptions.InputDir
+	}
+
+	analyzer := apidiff.NewAnalyzer()
+	analyzer.Ignore = apiDiffOpts.Ignore
+	diff, err := diffModuleDirs(analyzer, apiDiffOpts.OldDir, newDir)
+	if err != nil {
+		return err
+	}
+	if err := printAPIDiff(diff, apiDiffOpts.Format); err != nil {
+		return err
+	}
+
+	if breaking := len(diff.Breaking()); breaking > 0 {
+		return fmt.Errorf("%d breaking API change(s)", breaking)
+	}
+	return nil
+}
+
+// diffModuleDirs loads two versions of a module and compares their APIs
+func diffModuleDirs(analyzer *apidiff.Analyzer, oldDir, newDir string) (*apidiff.APIDiff, error) {
+	loadOpts := loader.DefaultLoadOptions()
+	loadOpts.IncludeAST = true
+
+	fmt.Fprintf(os.Stderr, "Loading old version from %s\n", oldDir)
+	oldMod, err := loader.NewGoModuleLoader().LoadWithOptions(oldDir, loadOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load old version: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", newDir)
+	newMod, err := loader.NewGoModuleLoader().LoadWithOptions(newDir, loadOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load module: %w", err)
+	}
+
+	return analyzer.DiffModules(oldMod, newMod)
+}
+
+// printAPIDiff prints an API diff as release notes followed by a summary,
+// or as JSON
+func printAPIDiff(diff *apidiff.APIDiff, format string) error {
+	if format == "json" {
+		type jsonChange struct {
+			apidiff.Change
+			Breaking bool
+		}
+		out := make([]jsonChange, 0, len(diff.Changes))
+		for _, c := range diff.Changes {
+			out = append(out, jsonChange{Change: c, Breaking: c.IsBreaking()})
+		}
+		jsonData, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize API diff to JSON: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if err := diff.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if len(diff.Changes) > 0 {
+		breaking := len(diff.Breaking())
+		fmt.Printf("\n%d breaking, %d compatible change(s)\n", breaking, len(diff.Changes)-breaking)
+	}
+	return nil
+}
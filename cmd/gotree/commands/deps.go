@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"bitspark.dev/go-tree/pkg/analysis/modgraph"
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+type depsOptions struct {
+	Format          string
+	HighlightCycles bool
+	ClusterDepth    int
+	Selected        bool
+}
+
+var depsOpts depsOptions
+
+// newDepsCmd creates the deps command
+func newDepsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Show the module dependency graph",
+		Long: `Shows the module requirement graph reported by go mod graph, as one
+edge per line or as a Graphviz DOT graph (e.g. gotree deps --format=dot | dot -Tsvg).`,
+		RunE: runDepsCmd,
+	}
+
+	cmd.Flags().StringVar(&depsOpts.Format, "format", "text", "Output format (text, dot)")
+	cmd.Flags().BoolVar(&depsOpts.HighlightCycles, "highlight-cycles", true, "Draw edges of dependency cycles in red (dot)")
+	cmd.Flags().IntVar(&depsOpts.ClusterDepth, "cluster-depth", 0, "Group modules by this many leading path elements (dot, 0 means no clusters)")
+	cmd.Flags().BoolVar(&depsOpts.Selected, "selected", false, "List the module versions selected by minimal version selection instead (text)")
+
+	return cmd
+}
+
+// runDepsCmd prints the dependency graph of the module
+func runDepsCmd(cmd *cobra.Command, args []string) error {
+	if depsOpts.Format != "text" && depsOpts.Format != "dot" {
+		return fmt.Errorf("unknown format %q (supported: dot, text)", depsOpts.Format)
+	}
+
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(GlobalOptions.InputDir, loader.DefaultLoadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+	if depsOpts.Selected {
+		return printSelectedVersions(mod)
+	}
+	graph, err := modgraph.NewAnalyzer().DependencyGraph(mod)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if depsOpts.Format == "dot" {
+		options := modgraph.DOTOptions{Root: mod.Path, HighlightCycles: depsOpts.HighlightCycles, ClusterDepth: depsOpts.ClusterDepth}
+		if err := modgraph.WriteDOT(graph, &buf, options); err != nil {
+			return err
+		}
+	} else {
+		modules := make([]string, 0, len(graph))
+		for from := range graph {
+			modules = append(modules, from)
+		}
+		sort.Strings(modules)
+		for _, from := range modules {
+			for _, to := range graph[from] {
+				fmt.Fprintf(&buf, "%s -> %s\n", from, to)
+			}
+		}
+	}
+	return writeVisualization(buf.Bytes(), GlobalOptions.OutputFile, "deps."+depsOpts.Format)
+}
+
+// printSelectedVersions prints the build list of the module, noting
+// versions raised above the go.mod requirement and replacements
+func printSelectedVersions(mod *module.Module) error {
+	selected, err := modgraph.NewAnalyzer().SelectedVersions(mod)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, v := range modgraph.SelectedRequirements(mod, selected) {
+		fmt.Fprintf(&buf, "%s %s", v.Path, v.Selected)
+		if v.Upgraded() {
+			fmt.Fprintf(&buf, " (go.mod requires %s)", v.Required)
+		}
+		if v.Replacement != "" {
+			fmt.Fprintf(&buf, " => %s", v.Replacement)
+		}
+		buf.WriteString("\n")
+	}
+	return writeVisualization(buf.Bytes(), GlobalOptions.OutputFile, "deps.txt")
+}
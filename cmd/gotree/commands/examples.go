@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/execute"
+)
+
+type examplesOptions struct {
+	ListOnly bool
+}
+
+var examplesOpts examplesOptions
+
+// newExamplesCmd creates the examples command
+func newExamplesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "examples [dir]",
+		Short: "Run the Example functions of a module",
+		Long: `Runs the Example functions in the test files of a module and checks their
+output against the "// Output:" and "// Unordered output:" comments.
+Examples without an output comment are only compiled. Exits with an error
+if any example fails.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runExamplesCmd,
+	}
+
+	cmd.Flags().BoolVar(&examplesOpts.ListOnly, "list", false, "List the examples without running them")
+
+	return cmd
+}
+
+// runExamplesCmd lists or runs the Example functions of a module
+func runExamplesCmd(cmd *cobra.Command, args []string) error {
+	dir := GlobalOptions.InputDir
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	// Failing examples are no reason to print usage
+	cmd.SilenceUsage = true
+
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", dir)
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, loader.DefaultLoadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+
+	examples, err := execute.FindExampleFuncs(mod)
+	if err != nil {
+		return err
+	}
+	if len(examples) == 0 {
+		fmt.Println("No examples found")
+		return nil
+	}
+
+	if examplesOpts.ListOnly {
+		for _, ex := range examples {
+			kind := "output"
+			switch {
+			case !ex.HasOutput:
+				kind = "compile only"
+			case ex.Unordered:
+				kind = "unordered output"
+			}
+			fmt.Printf("%s.%s (%s)\n", ex.Package, ex.Name, kind)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %d example(s) in %s\n", len(examples), dir)
+	results, err := execute.NewGoExecutor().ExecuteExampleFuncs(mod, examples)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+		switch r.Status {
+		case execute.ExamplePassed:
+			fmt.Printf("PASS  %s.%s\n", r.Package, r.Name)
+		case execute.ExampleCompiled:
+			fmt.Printf("ok    %s.%s (compiled)\n", r.Package, r.Name)
+		default:
+			fmt.Printf("FAIL  %s.%s\n", r.Package, r.Name)
+			if strings.Contains(r.Output, "got:\n") {
+				fmt.Printf("  got:\n%s\n  want:\n%s\n", indentLines(r.Got), indentLines(strings.TrimRight(r.ExampleFunc.Output, "\n")))
+			} else {
+				fmt.Println(indentLines(strings.TrimRight(r.Output, "\n")))
+			}
+		}
+	}
+
+	failed := counts[execute.ExampleFailed]
+	fmt.Printf("\n%d passed, %d compiled, %d failed\n", counts[execute.ExamplePassed], counts[execute.ExampleCompiled], failed)
+	if failed > 0 {
+		return fmt.Errorf("%d example(s) failed", failed)
+	}
+	return nil
+}
+
+// indentLines indents each line of text for display below a result line
+func indentLines(text string) string {
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -22,7 +23,18 @@ type executeOptions struct {
 	TestShort     bool
 	TestRace      bool
 	TestCover     bool
+	CoverProfile  string
+	TestFlags     []string
 	ExtraEnv      string
+
+	// Run each test in its own process, stopping it after this duration
+	PerTestTimeout time.Duration
+
+	// Benchmark options
+	BenchPattern string
+	BenchTime    string
+	BenchMem     bool
+	BenchCount   int
 }
 
 var executeOpts executeOptions
@@ -43,6 +55,7 @@ func newExecuteCmd() *cobra.Command {
 
 	// Add subcommands
 	cmd.AddCommand(newTestCmd())
+	cmd.AddCommand(newBenchCmd())
 	cmd.AddCommand(newRunCmd())
 
 	return cmd
@@ -63,6 +76,26 @@ func newTestCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&executeOpts.TestShort, "short", false, "Run short tests")
 	cmd.Flags().BoolVar(&executeOpts.TestRace, "race", false, "Enable race detection")
 	cmd.Flags().BoolVar(&executeOpts.TestCover, "cover", false, "Enable test coverage")
+	cmd.Flags().StringVar(&executeOpts.CoverProfile, "coverprofile", "", "Write a cover profile to this file")
+	cmd.Flags().StringSliceVar(&executeOpts.TestFlags, "test-flags", nil, "Additional flags passed to go test (comma-separated)")
+	cmd.Flags().DurationVar(&executeOpts.PerTestTimeout, "per-test-timeout", 0, "Run each test of a single package in its own process and stop tests that exceed this duration")
+
+	return cmd
+}
+
+// newBenchCmd creates the benchmark execution command
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench [packages]",
+		Short: "Run benchmarks in the module",
+		Long:  `Runs the Go benchmarks of the specified packages in the module, without their tests, and reports their measurements.`,
+		RunE:  runBenchCmd,
+	}
+
+	cmd.Flags().StringVar(&executeOpts.BenchPattern, "pattern", ".", "Regular expression selecting the benchmarks to run")
+	cmd.Flags().StringVar(&executeOpts.BenchTime, "benchtime", "", "Time or iterations per benchmark, e.g. 2s or 100x")
+	cmd.Flags().BoolVar(&executeOpts.BenchMem, "benchmem", false, "Report memory allocations")
+	cmd.Flags().IntVar(&executeOpts.BenchCount, "count", 1, "Run each benchmark this many times")
 
 	return cmd
 }
@@ -129,6 +162,8 @@ func runTestCmd(cmd *cobra.Command, args []string) error {
 
 	if executeOpts.TestRace {
 		testFlags = append(testFlags, "-race")
+		// Structured output attributes race reports to the tests
+		executor.JSONOutput = true
 	}
 
 	if executeOpts.TestCover {
@@ -139,9 +174,29 @@ func runTestCmd(cmd *cobra.Command, args []string) error {
 		testFlags = append(testFlags, "-timeout="+executeOpts.Timeout)
 	}
 
+	testFlags = append(testFlags, executeOpts.TestFlags...)
+
 	// Run tests
 	fmt.Fprintf(os.Stderr, "Running tests for %s\n", pkgPath)
-	result, err := executor.ExecuteTest(mod, pkgPath, testFlags...)
+	var result execute.TestResult
+	if executeOpts.PerTestTimeout > 0 {
+		if executeOpts.TestBenchmark || executeOpts.TestRace || executeOpts.TestCover || executeOpts.CoverProfile != "" || len(executeOpts.TestFlags) > 0 {
+			return fmt.Errorf("--per-test-timeout cannot be combined with --bench, --race, --cover, --coverprofile or --test-flags")
+		}
+		var binaryFlags []string
+		if executeOpts.TestShort {
+			binaryFlags = append(binaryFlags, "-test.short")
+		}
+		result, err = executor.ExecuteTestSupervised(mod, pkgPath, executeOpts.PerTestTimeout, binaryFlags...)
+	} else if executeOpts.CoverProfile != "" {
+		var profile *execute.CoverageProfile
+		profile, result, err = executor.ExecuteCoverage(mod, pkgPath, testFlags...)
+		if err == nil {
+			err = writeCoverProfile(profile, executeOpts.CoverProfile)
+		}
+	} else {
+		result, err = executor.ExecuteTest(mod, pkgPath, testFlags...)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to execute tests: %w", err)
 	}
@@ -152,6 +207,9 @@ func runTestCmd(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Tests Run: %d\n", len(result.Tests))
 	fmt.Printf("  Passed: %d\n", result.Passed)
 	fmt.Printf("  Failed: %d\n", result.Failed)
+	if result.TimedOut > 0 {
+		fmt.Printf("  Timed Out: %d\n", result.TimedOut)
+	}
 
 	// Print test output
 	if GlobalOptions.Verbose || executeOpts.TestVerbose {
@@ -163,6 +221,8 @@ func runTestCmd(cmd *cobra.Command, args []string) error {
 		fmt.Println(result.Output)
 	}
 
+	printRaces(result)
+
 	// Return error if any tests failed
 	if result.Failed > 0 {
 		return fmt.Errorf("tests failed")
@@ -171,6 +231,111 @@ func runTestCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runBenchCmd executes benchmarks on the module
+func runBenchCmd(cmd *cobra.Command, args []string) error {
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(GlobalOptions.InputDir, loader.DefaultLoadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+
+	executor := execute.NewGoExecutor()
+	executor.EnableCGO = !executeOpts.DisableCGO
+	if executeOpts.ExtraEnv != "" {
+		executor.AdditionalEnv = parseEnvVars(executeOpts.ExtraEnv)
+	}
+
+	pkgPath := "./..."
+	if len(args) > 0 {
+		pkgPath = args[0]
+	}
+	opts := execute.BenchmarkOptions{
+		Pattern:   executeOpts.BenchPattern,
+		BenchTime: executeOpts.BenchTime,
+		BenchMem:  executeOpts.BenchMem,
+		Count:     executeOpts.BenchCount,
+	}
+	if executeOpts.Timeout != "" {
+		opts.Flags = append(opts.Flags, "-timeout="+executeOpts.Timeout)
+	}
+
+	fmt.Fprintf(os.Stderr, "Running benchmarks for %s\n", pkgPath)
+	result, err := executor.ExecuteBenchmarks(mod, pkgPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to execute benchmarks: %w", err)
+	}
+
+	fmt.Printf("Benchmark Results:\n")
+	fmt.Printf("  Package: %s\n", result.Package)
+	for _, r := range result.Readings {
+		line := fmt.Sprintf("  %s %s: %d iterations, %.2f ns/op", r.Package, r.Name, r.Iterations, r.NsPerOp)
+		if executeOpts.BenchMem {
+			line += fmt.Sprintf(", %d B/op, %d allocs/op", r.BytesPerOp, r.AllocsPerOp)
+		}
+		fmt.Println(line)
+	}
+
+	if result.Error != nil {
+		fmt.Println("\nBenchmark Output:")
+		fmt.Println(result.Output)
+		return fmt.Errorf("benchmarks failed")
+	}
+	if GlobalOptions.Verbose {
+		fmt.Println("\nBenchmark Output:")
+		fmt.Println(result.Output)
+	}
+	return nil
+}
+
+// printRaces summarizes the data races detected by the tests
+func printRaces(result execute.TestResult) {
+	if len(result.Races) == 0 {
+		return
+	}
+	fmt.Printf("\nData Races: %d\n", len(result.Races))
+	printed := 0
+	for _, r := range result.Results {
+		for _, race := range r.Races {
+			fmt.Printf("  %s: %s\n", r.Name, describeRace(race))
+			printed++
+		}
+	}
+	// Races outside any test, or without structured output
+	for _, race := range result.Races[printed:] {
+		fmt.Printf("  %s\n", describeRace(race))
+	}
+}
+
+// describeRace formats the conflicting accesses of a race on one line
+func describeRace(race execute.RaceReport) string {
+	access := func(a execute.RaceAccess) string {
+		location := "unknown location"
+		if len(a.Stack) > 0 {
+			location = fmt.Sprintf("%s at %s:%d", a.Stack[0].Function, a.Stack[0].File, a.Stack[0].Line)
+		}
+		return fmt.Sprintf("%s by goroutine %d in %s", a.Op, a.Goroutine, location)
+	}
+	return access(race.Current) + ", previous " + access(race.Previous)
+}
+
+// writeCoverProfile writes a cover profile to a file and reports its
+// coverage
+func writeCoverProfile(profile *execute.CoverageProfile, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create cover profile: %w", err)
+	}
+	if _, err := profile.WriteTo(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write cover profile: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write cover profile: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Cover profile written to %s (%.1f%% of statements)\n", path, profile.Percent())
+	return nil
+}
+
 // runGoCmd executes a Go command on the module
 func runGoCmd(cmd *cobra.Command, args []string) error {
 	// Create a loader to load the module
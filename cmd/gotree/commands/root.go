@@ -51,6 +51,10 @@ are performed on a Go module as a single entity.`,
 	cmd.AddCommand(newAnalyzeCmd())
 	cmd.AddCommand(newExecuteCmd())
 	cmd.AddCommand(newRenameCmd())
+	cmd.AddCommand(newScaffoldCmd())
+	cmd.AddCommand(newDepsCmd())
+	cmd.AddCommand(newAPIDiffRootCmd())
+	cmd.AddCommand(newExamplesCmd())
 
 	return cmd
 }
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/saver"
+	"bitspark.dev/go-tree/pkg/transform/scaffold"
+)
+
+type scaffoldOptions struct {
+	// Common options
+	DryRun bool
+
+	// Package options
+	Name      string
+	WithTest  bool
+	Templates []string
+}
+
+var scaffoldOpts scaffoldOptions
+
+// newScaffoldCmd creates the scaffold command
+func newScaffoldCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scaffold",
+		Short: "Generate boilerplate for new code",
+		Long:  `Creates new elements of a Go module from templates.`,
+	}
+
+	// Add subcommands
+	cmd.AddCommand(newScaffoldPackageCmd())
+
+	return cmd
+}
+
+// newScaffoldPackageCmd creates the package scaffold command
+func newScaffoldPackageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "package <importpath>",
+		Short: "Create a new package",
+		Long: `Creates a package directory with a doc.go holding the package comment
+and, with --test, a test file. Files are rendered from Go text/templates
+that receive .Name, .Title, .ImportPath and .ModulePath; use --template
+to add or replace files (e.g. --template doc.go=tmpl/doc.go.tmpl).`,
+		Args: cobra.ExactArgs(1),
+		RunE: runScaffoldPackageCmd,
+	}
+
+	cmd.Flags().StringVar(&scaffoldOpts.Name, "name", "", "Package name (defaults to the last element of the import path)")
+	cmd.Flags().BoolVar(&scaffoldOpts.WithTest, "test", false, "Also create a test file")
+	cmd.Flags().StringArrayVar(&scaffoldOpts.Templates, "template", nil, "File template as <file>=<template path> (repeatable)")
+	cmd.Flags().BoolVar(&scaffoldOpts.DryRun, "dry-run", false, "Show the files without writing them")
+
+	return cmd
+}
+
+// runScaffoldPackageCmd executes the package scaffolding
+func runScaffoldPackageCmd(cmd *cobra.Command, args []string) error {
+	// Load the module the package is added to
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(GlobalOptions.InputDir, loader.DefaultLoadOptions())
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+
+	scaffolder := scaffold.NewPackageScaffolder(args[0], scaffoldOpts.WithTest, scaffoldOpts.DryRun)
+	scaffolder.Package = scaffoldOpts.Name
+	for _, spec := range scaffoldOpts.Templates {
+		fileName, templatePath, ok := strings.Cut(spec, "=")
+		if !ok || fileName == "" || templatePath == "" {
+			return fmt.Errorf("invalid template %q, expected <file>=<template path>", spec)
+		}
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		scaffolder.Templates[fileName] = string(content)
+	}
+
+	result := scaffolder.Transform(mod)
+	if !result.Success {
+		return fmt.Errorf("failed to scaffold package: %v", result.Error)
+	}
+
+	// If this was a dry run, display the files that would be created
+	if scaffoldOpts.DryRun {
+		fmt.Println("DRY RUN - No files written")
+		for _, change := range result.Changes {
+			fmt.Printf("\n--- %s\n%s", change.FilePath, change.New)
+		}
+		return nil
+	}
+
+	dir := mod.Dir
+	if GlobalOptions.OutputDir != "" {
+		dir = GlobalOptions.OutputDir
+	}
+	moduleSaver := saver.NewGoModuleSaver()
+	if err := moduleSaver.SavePackage(mod.Packages[args[0]], dir, saver.DefaultSaveOptions()); err != nil {
+		return fmt.Errorf("failed to save package: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s\n", result.Details)
+	return nil
+}
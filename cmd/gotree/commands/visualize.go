@@ -4,11 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/visual"
+	"bitspark.dev/go-tree/pkg/visual/graph"
+	"bitspark.dev/go-tree/pkg/visual/graphml"
 	"bitspark.dev/go-tree/pkg/visual/html"
+	"bitspark.dev/go-tree/pkg/visual/jsontree"
+	"bitspark.dev/go-tree/pkg/visual/markdown"
+	"bitspark.dev/go-tree/pkg/visual/mermaid"
+	"bitspark.dev/go-tree/pkg/visual/svg"
 )
 
 type visualizeOptions struct {
@@ -17,10 +25,55 @@ type visualizeOptions struct {
 	IncludeTests     bool
 	IncludeGenerated bool
 	Title            string
+	IncludeDocs      bool
 
 	// HTML-specific options
 	SyntaxHighlight bool
 	CustomCSS       string
+
+	// Direction of Mermaid graphs, TD or LR
+	GraphStyle string
+
+	// Comma-separated output formats
+	Formats string
+}
+
+// visualFormat is an output format of the visualize command
+type visualFormat struct {
+	ext         string // Extension of derived output files
+	defaultFile string // File name used in an output directory
+	needsAST    bool   // Whether the module must be loaded with IncludeAST
+	needsDocs   bool   // Whether the module must be loaded with LoadDocs
+	visualizer  func() visual.ModuleVisualizer
+}
+
+// visualFormats lists the formats the visualize command can produce
+var visualFormats = map[string]visualFormat{
+	"html": {ext: ".html", defaultFile: "index.html", visualizer: func() visual.ModuleVisualizer {
+		return html.NewHTMLVisualizer(htmlOptions())
+	}},
+	"json": {ext: ".json", defaultFile: "module.json", needsDocs: true, visualizer: func() visual.ModuleVisualizer {
+		return jsontree.NewJSONVisualizer(baseVisualizerOptions())
+	}},
+	"markdown": {ext: ".md", defaultFile: "README.md", visualizer: func() visual.ModuleVisualizer {
+		options := markdown.DefaultOptions()
+		options.IncludeDocs = visualizeOpts.IncludeDocs
+		return markdown.NewGenerator(options)
+	}},
+	"graphml": {ext: ".graphml", defaultFile: "module.graphml", needsAST: true, visualizer: func() visual.ModuleVisualizer {
+		options := graph.DefaultOptions()
+		options.BaseVisualizerOptions = baseVisualizerOptions()
+		return graphml.NewGraphMLVisualizer(options)
+	}},
+	"mermaid": {ext: ".graph.md", defaultFile: "GRAPH.md", needsAST: true, visualizer: func() visual.ModuleVisualizer {
+		options := mermaid.DefaultOptions()
+		options.BaseVisualizerOptions = baseVisualizerOptions()
+		options.Direction = visualizeOpts.GraphStyle
+		return mermaid.NewMermaidVisualizer(options)
+	}},
+	"svg": {ext: ".svg", defaultFile: "packages.svg", visualizer: func() visual.ModuleVisualizer {
+		return svg.NewSVGVisualizer(baseVisualizerOptions())
+	}},
 }
 
 var visualizeOpts visualizeOptions
@@ -33,12 +86,79 @@ func newVisualizeCmd() *cobra.Command {
 		Long:  `Generates visual representations of a Go module.`,
 	}
 
+	cmd.RunE = runVisualizeCmd
+	cmd.Flags().StringVar(&visualizeOpts.Formats, "format", "", "Comma-separated output formats (html, json, markdown, graphml, mermaid, svg)")
+	cmd.Flags().StringVar(&visualizeOpts.GraphStyle, "graph-style", "TD", "Direction of Mermaid graphs (TD, LR)")
+	cmd.Flags().BoolVar(&visualizeOpts.IncludePrivate, "include-private", false, "Include private (unexported) elements")
+	cmd.Flags().BoolVar(&visualizeOpts.IncludeTests, "include-tests", false, "Include test files")
+	cmd.Flags().BoolVar(&visualizeOpts.IncludeGenerated, "include-generated", false, "Include generated files")
+	cmd.Flags().StringVar(&visualizeOpts.Title, "title", "", "Custom title for documentation")
+	cmd.Flags().BoolVar(&visualizeOpts.IncludeDocs, "include-docs", false, "Render doc comments godoc-style in HTML and Markdown")
+	cmd.Flags().BoolVar(&visualizeOpts.SyntaxHighlight, "syntax-highlight", true, "Include CSS for syntax highlighting in HTML")
+	cmd.Flags().StringVar(&visualizeOpts.CustomCSS, "custom-css", "", "Custom CSS to include in HTML")
+
 	// Add subcommands
 	cmd.AddCommand(newHtmlCmd())
 
 	return cmd
 }
 
+// runVisualizeCmd loads the module once and renders it in every requested
+// format. With several formats, each is written to a file derived from
+// --output by replacing its extension, or to a default name in --out-dir.
+func runVisualizeCmd(cmd *cobra.Command, args []string) error {
+	if visualizeOpts.Formats == "" {
+		return cmd.Help()
+	}
+
+	var names []string
+	needsAST, needsDocs := false, false
+	for _, name := range strings.Split(visualizeOpts.Formats, ",") {
+		name = strings.TrimSpace(name)
+		format, ok := visualFormats[name]
+		if !ok {
+			return fmt.Errorf("unknown format %q (supported: graphml, html, json, markdown, mermaid, svg)", name)
+		}
+		names = append(names, name)
+		needsAST = needsAST || format.needsAST
+		needsDocs = needsDocs || format.needsDocs
+	}
+	if len(names) > 1 && GlobalOptions.OutputFile == "" && GlobalOptions.OutputDir == "" {
+		return fmt.Errorf("several formats need --output or --out-dir")
+	}
+
+	loadOpts := loader.DefaultLoadOptions()
+	loadOpts.IncludeTests = visualizeOpts.IncludeTests
+	loadOpts.IncludeGenerated = visualizeOpts.IncludeGenerated
+	loadOpts.IncludeAST = needsAST
+	loadOpts.LoadDocs = needsDocs || visualizeOpts.IncludeDocs
+
+	fmt.Fprintf(os.Stderr, "Loading module from %s\n", GlobalOptions.InputDir)
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(GlobalOptions.InputDir, loadOpts)
+	if err != nil {
+		return fmt.Errorf("failed to load module: %w", err)
+	}
+
+	for _, name := range names {
+		format := visualFormats[name]
+		visualizer := format.visualizer()
+		fmt.Fprintf(os.Stderr, "Generating %s output...\n", name)
+		content, err := visualizer.Visualize(mod)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", name, err)
+		}
+
+		outputFile := GlobalOptions.OutputFile
+		if outputFile != "" && len(names) > 1 {
+			outputFile = strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + format.ext
+		}
+		if err := writeVisualization(content, outputFile, format.defaultFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // newHtmlCmd creates the HTML visualization command
 func newHtmlCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -53,6 +173,7 @@ func newHtmlCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&visualizeOpts.IncludeTests, "include-tests", false, "Include test files")
 	cmd.Flags().BoolVar(&visualizeOpts.IncludeGenerated, "include-generated", false, "Include generated files")
 	cmd.Flags().StringVar(&visualizeOpts.Title, "title", "", "Custom title for documentation")
+	cmd.Flags().BoolVar(&visualizeOpts.IncludeDocs, "include-docs", false, "Render doc comments godoc-style in HTML and Markdown")
 	cmd.Flags().BoolVar(&visualizeOpts.SyntaxHighlight, "syntax-highlight", true, "Include CSS for syntax highlighting")
 	cmd.Flags().StringVar(&visualizeOpts.CustomCSS, "custom-css", "", "Custom CSS to include in HTML")
 
@@ -77,7 +198,30 @@ func runHtmlCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load module: %w", err)
 	}
 
-	// Configure the HTML visualizer
+	// Create and run the visualizer
+	visualizer := html.NewHTMLVisualizer(htmlOptions())
+	fmt.Fprintln(os.Stderr, "Generating HTML documentation...")
+
+	htmlBytes, err := visualizer.Visualize(mod)
+	if err != nil {
+		return fmt.Errorf("failed to generate HTML: %w", err)
+	}
+
+	return writeVisualization(htmlBytes, GlobalOptions.OutputFile, "index.html")
+}
+
+// baseVisualizerOptions returns the visualizer options shared by all formats
+func baseVisualizerOptions() visual.BaseVisualizerOptions {
+	return visual.BaseVisualizerOptions{
+		IncludePrivate:   visualizeOpts.IncludePrivate,
+		IncludeTests:     visualizeOpts.IncludeTests,
+		IncludeGenerated: visualizeOpts.IncludeGenerated,
+		Title:            visualizeOpts.Title,
+	}
+}
+
+// htmlOptions returns the HTML visualizer options from the flags
+func htmlOptions() html.Options {
 	htmlOpts := html.DefaultOptions()
 	htmlOpts.IncludePrivate = visualizeOpts.IncludePrivate
 	htmlOpts.IncludeTests = visualizeOpts.IncludeTests
@@ -88,44 +232,34 @@ func runHtmlCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	htmlOpts.IncludeCSS = visualizeOpts.SyntaxHighlight
+	htmlOpts.IncludeDocs = visualizeOpts.IncludeDocs
 	if visualizeOpts.CustomCSS != "" {
 		htmlOpts.CustomCSS = visualizeOpts.CustomCSS
 	}
+	return htmlOpts
+}
 
-	// Create and run the visualizer
-	visualizer := html.NewHTMLVisualizer(htmlOpts)
-	fmt.Fprintln(os.Stderr, "Generating HTML documentation...")
-
-	htmlBytes, err := visualizer.Visualize(mod)
-	if err != nil {
-		return fmt.Errorf("failed to generate HTML: %w", err)
-	}
-
-	// Determine output destination
-	if GlobalOptions.OutputFile != "" {
-		// Write to specified file
-		fmt.Fprintf(os.Stderr, "Writing HTML to %s\n", GlobalOptions.OutputFile)
-		if err := os.WriteFile(GlobalOptions.OutputFile, htmlBytes, 0600); err != nil {
-			return fmt.Errorf("failed to write HTML to file: %w", err)
-		}
-	} else if GlobalOptions.OutputDir != "" {
+// writeVisualization writes output to outputFile if set, otherwise to
+// defaultFile in the output directory if set, otherwise to stdout
+func writeVisualization(content []byte, outputFile, defaultFile string) error {
+	if outputFile == "" && GlobalOptions.OutputDir != "" {
 		// Create output directory if it doesn't exist
 		if err := os.MkdirAll(GlobalOptions.OutputDir, 0750); err != nil {
 			return fmt.Errorf("failed to create output directory: %w", err)
 		}
+		outputFile = filepath.Join(GlobalOptions.OutputDir, defaultFile)
+	}
 
-		// Save to index.html in the output directory
-		outputPath := filepath.Join(GlobalOptions.OutputDir, "index.html")
-		fmt.Fprintf(os.Stderr, "Writing HTML to %s\n", outputPath)
-		if err := os.WriteFile(outputPath, htmlBytes, 0600); err != nil {
-			return fmt.Errorf("failed to write HTML to file: %w", err)
-		}
-	} else {
-		// Write to stdout
-		if _, err := fmt.Fprintln(os.Stdout, string(htmlBytes)); err != nil {
-			return fmt.Errorf("failed to write HTML to stdout: %w", err)
+	if outputFile == "" {
+		if _, err := fmt.Fprintln(os.Stdout, string(content)); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
 		}
+		return nil
 	}
 
+	fmt.Fprintf(os.Stderr, "Writing %s\n", outputFile)
+	if err := os.WriteFile(outputFile, content, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
 	return nil
 }
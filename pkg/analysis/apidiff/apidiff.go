@@ -0,0 +1,575 @@
+// Package apidiff compares the exported API of two versions of a module.
+package apidiff
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// ChangeKind tells whether a symbol was added, removed or changed
+type ChangeKind string
+
+const (
+	// ChangeAdded marks symbols only in the new version
+	ChangeAdded ChangeKind = "added"
+
+	// ChangeRemoved marks symbols only in the old version
+	ChangeRemoved ChangeKind = "removed"
+
+	// ChangeModified marks symbols whose API differs between the versions
+	ChangeModified ChangeKind = "changed"
+)
+
+// DifferenceKind classifies a difference of a changed symbol
+type DifferenceKind string
+
+const (
+	// KindChanged marks a symbol declared as a different kind, e.g. a
+	// function that became a variable
+	KindChanged DifferenceKind = "kind-changed"
+
+	// TypeChanged marks a changed underlying type of a type, or a changed
+	// type of a variable or constant
+	TypeChanged DifferenceKind = "type-changed"
+
+	// TypeParamsChanged marks changed type parameters of a generic type
+	TypeParamsChanged DifferenceKind = "type-params-changed"
+
+	// FieldAdded marks an exported struct field added
+	FieldAdded DifferenceKind = "field-added"
+
+	// FieldRemoved marks an exported struct field removed
+	FieldRemoved DifferenceKind = "field-removed"
+
+	// FieldTypeChanged marks an exported struct field of a different type
+	FieldTypeChanged DifferenceKind = "field-type-changed"
+
+	// MethodAdded marks a method added to an interface
+	MethodAdded DifferenceKind = "method-added"
+
+	// MethodRemoved marks a method removed from an interface
+	MethodRemoved DifferenceKind = "method-removed"
+
+	// MethodChanged marks an interface method with a different signature
+	MethodChanged DifferenceKind = "method-changed"
+
+	// SignatureChanged marks a function or method with different type
+	// parameters
+	SignatureChanged DifferenceKind = "signature-changed"
+
+	// ParameterAdded marks a parameter added to a function or method
+	ParameterAdded DifferenceKind = "parameter-added"
+
+	// ParameterRemoved marks a parameter removed from a function or method
+	ParameterRemoved DifferenceKind = "parameter-removed"
+
+	// ParameterTypeChanged marks a parameter of a different type, including
+	// changes between a slice and a variadic parameter
+	ParameterTypeChanged DifferenceKind = "parameter-type-changed"
+
+	// ResultTypeChanged marks different results of a function or method
+	ResultTypeChanged DifferenceKind = "result-type-changed"
+
+	// ReceiverChanged marks a method moved between value and pointer receiver
+	ReceiverChanged DifferenceKind = "receiver-changed"
+
+	// ValueChanged marks a constant with a different value
+	ValueChanged DifferenceKind = "value-changed"
+)
+
+// Difference is one way a symbol's API changed
+type Difference struct {
+	Kind        DifferenceKind
+	Breaking    bool   // Whether code using the old API may no longer compile
+	Description string // What changed, e.g. "field Name removed"
+}
+
+// Change is an exported symbol that differs between two versions
+type Change struct {
+	ID          string            // Symbol ID in the version it is in, the new one if both
+	Kind        module.SymbolKind // Kind of the symbol, in the new version if present
+	Change      ChangeKind
+	Differences []Difference   // Differences of a changed symbol
+	Old         *module.Symbol `json:"-"` // Symbol in the old version, nil if added
+	New         *module.Symbol `json:"-"` // Symbol in the new version, nil if removed
+}
+
+// IsBreaking reports whether the change may break code using the old API:
+// removals and changes with a breaking difference
+func (c Change) IsBreaking() bool {
+	switch c.Change {
+	case ChangeRemoved:
+		return true
+	case ChangeModified:
+		for _, d := range c.Differences {
+			if d.Breaking {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// APIDiff is the difference of the exported API of two module versions
+type APIDiff struct {
+	Changes []Change // Changes, sorted by ID relative to the module unless sorted otherwise
+}
+
+// IsBreaking reports whether any change is breaking
+func (d *APIDiff) IsBreaking() bool {
+	for _, c := range d.Changes {
+		if c.IsBreaking() {
+			return true
+		}
+	}
+	return false
+}
+
+// Breaking returns the breaking changes
+func (d *APIDiff) Breaking() []Change {
+	var breaking []Change
+	for _, c := range d.Changes {
+		if c.IsBreaking() {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// Sort orders the changes by less, keeping the order of equal changes
+func (d *APIDiff) Sort(less func(a, b Change) bool) {
+	sort.SliceStable(d.Changes, func(i, j int) bool { return less(d.Changes[i], d.Changes[j]) })
+}
+
+// WriteText writes the diff as release notes, breaking changes first
+func (d *APIDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	for _, section := range []struct {
+		title    string
+		breaking bool
+	}{{"Breaking changes", true}, {"Compatible changes", false}} {
+		first := true
+		for _, c := range d.Changes {
+			if c.IsBreaking() != section.breaking {
+				continue
+			}
+			if first {
+				fmt.Fprintf(&b, "%s:\n", section.title)
+				first = false
+			}
+			fmt.Fprintf(&b, "  %s %s %s\n", c.Change, c.Kind, c.ID)
+			for _, diff := range c.Differences {
+				fmt.Fprintf(&b, "    - %s\n", diff.Description)
+			}
+		}
+	}
+	if len(d.Changes) == 0 {
+		b.WriteString("No API changes\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Analyzer compares module APIs
+type Analyzer struct {
+	// Package patterns to leave out of the comparison, matched against
+	// import paths relative to the module ("." for its root package) as by
+	// path.Match; a pattern ending in "/..." also matches the packages below
+	Ignore []string
+}
+
+// NewAnalyzer creates a new API diff analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// DiffModules compares the exported API of two versions of a module: the
+// exported declarations of its non-main packages outside internal
+// directories, and the exported methods of their exported types. Symbols
+// are matched by ID relative to the module path, so the module may have
+// moved. Both modules must be loaded with IncludeAST.
+func (a *Analyzer) DiffModules(oldMod, newMod *module.Module) (*APIDiff, error) {
+	for _, pattern := range a.Ignore {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/..."), ""); err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+	oldAPI, err := a.apiSymbols(oldMod)
+	if err != nil {
+		return nil, err
+	}
+	newAPI, err := a.apiSymbols(newMod)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[*Change]string)
+	var changes []*Change
+	for key, newSym := range newAPI {
+		oldSym, ok := oldAPI[key]
+		if !ok {
+			change := &Change{ID: newSym.ID, Kind: newSym.Kind, Change: ChangeAdded, New: newSym}
+			changes = append(changes, change)
+			keys[change] = key
+			continue
+		}
+		c := &comparer{
+			oldQualifier: relativeQualifier(oldMod.Path, oldSym.Package),
+			newQualifier: relativeQualifier(newMod.Path, newSym.Package),
+		}
+		differences := c.compare(oldSym, oldMod.LookupObject(oldSym), newSym, newMod.LookupObject(newSym))
+		if len(differences) > 0 {
+			change := &Change{
+				ID: newSym.ID, Kind: newSym.Kind, Change: ChangeModified,
+				Differences: differences, Old: oldSym, New: newSym,
+			}
+			changes = append(changes, change)
+			keys[change] = key
+		}
+	}
+	for key, oldSym := range oldAPI {
+		if _, ok := newAPI[key]; !ok {
+			change := &Change{ID: oldSym.ID, Kind: oldSym.Kind, Change: ChangeRemoved, Old: oldSym}
+			changes = append(changes, change)
+			keys[change] = key
+		}
+	}
+
+	// Sorted by ID relative to the module, as IDs of both versions mix
+	sort.Slice(changes, func(i, j int) bool { return keys[changes[i]] < keys[changes[j]] })
+	diff := &APIDiff{Changes: make([]Change, len(changes))}
+	for i, change := range changes {
+		diff.Changes[i] = *change
+	}
+	return diff, nil
+}
+
+// apiSymbols returns the exported API symbols of a module by ID relative
+// to the module path
+func (a *Analyzer) apiSymbols(mod *module.Module) (map[string]*module.Symbol, error) {
+	api := make(map[string]*module.Symbol)
+	for _, sym := range mod.Symbols() {
+		pkg := mod.Packages[sym.Package]
+		if pkg == nil || pkg.Name == "main" || isInternal(sym.Package) || sym.File == nil || sym.File.IsTest {
+			continue
+		}
+		if a.ignored(mod.Path, sym.Package) {
+			continue
+		}
+		if pkg.TypesPackage == nil {
+			return nil, fmt.Errorf("no type information for %s, load with IncludeAST", pkg.ImportPath)
+		}
+		obj := mod.LookupObject(sym)
+		if obj == nil || !obj.Exported() {
+			continue
+		}
+		if sym.Kind == module.SymbolMethod {
+			recv := obj.Type().(*types.Signature).Recv().Type()
+			if ptr, ok := recv.(*types.Pointer); ok {
+				recv = ptr.Elem()
+			}
+			if named, ok := recv.(*types.Named); !ok || !named.Obj().Exported() {
+				continue
+			}
+		}
+		api[strings.TrimPrefix(sym.ID, mod.Path)] = sym
+	}
+	return api, nil
+}
+
+// ignored reports whether a package matches an ignore pattern
+func (a *Analyzer) ignored(modPath, pkgPath string) bool {
+	rel := "."
+	if pkgPath != modPath {
+		rel = strings.TrimPrefix(pkgPath, modPath+"/")
+	}
+	for _, pattern := range a.Ignore {
+		if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+			// The package or one of its parents matches the prefix
+			elems := strings.Split(rel, "/")
+			for i := 1; i <= len(elems); i++ {
+				if matched, _ := path.Match(prefix, strings.Join(elems[:i], "/")); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isInternal reports whether an import path has an internal element
+func isInternal(path string) bool {
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// relativeQualifier leaves types of a symbol's own package unqualified and
+// qualifies other packages of the module by their path relative to the
+// module, as in "~/sub", so types of moved modules compare equal
+func relativeQualifier(modPath, ownPath string) types.Qualifier {
+	return func(p *types.Package) string {
+		path := p.Path()
+		switch {
+		case path == ownPath:
+			return ""
+		case path == modPath || strings.HasPrefix(path, modPath+"/"):
+			return "~" + strings.TrimPrefix(path, modPath)
+		}
+		return path
+	}
+}
+
+// comparer compares the declarations of two versions of a symbol
+type comparer struct {
+	oldQualifier, newQualifier types.Qualifier
+}
+
+// oldString and newString render types of either version comparably
+func (c *comparer) oldString(t types.Type) string { return types.TypeString(t, c.oldQualifier) }
+func (c *comparer) newString(t types.Type) string { return types.TypeString(t, c.newQualifier) }
+
+// compare returns the differences of two versions of a symbol
+func (c *comparer) compare(oldSym *module.Symbol, oldObj types.Object, newSym *module.Symbol, newObj types.Object) []Difference {
+	if oldSym.Kind != newSym.Kind {
+		return []Difference{{
+			Kind: KindChanged, Breaking: true,
+			Description: fmt.Sprintf("changed from %s to %s", oldSym.Kind, newSym.Kind),
+		}}
+	}
+
+	switch oldObj := oldObj.(type) {
+	case *types.TypeName:
+		return c.compareTypes(oldObj, newObj.(*types.TypeName))
+	case *types.Func:
+		return c.compareFunctions(oldObj, newObj.(*types.Func))
+	case *types.Var:
+		return c.compareValueTypes(oldObj.Type(), newObj.Type())
+	case *types.Const:
+		differences := c.compareValueTypes(oldObj.Type(), newObj.Type())
+		newConst := newObj.(*types.Const)
+		if oldObj.Val().ExactString() != newConst.Val().ExactString() {
+			differences = append(differences, Difference{
+				Kind:        ValueChanged,
+				Description: fmt.Sprintf("value changed from %s to %s", oldObj.Val().ExactString(), newConst.Val().ExactString()),
+			})
+		}
+		return differences
+	}
+	return nil
+}
+
+// compareValueTypes compares the types of a variable or constant
+func (c *comparer) compareValueTypes(oldType, newType types.Type) []Difference {
+	if o, n := c.oldString(oldType), c.newString(newType); o != n {
+		return []Difference{{
+			Kind: TypeChanged, Breaking: true,
+			Description: fmt.Sprintf("type changed from %s to %s", o, n),
+		}}
+	}
+	return nil
+}
+
+// signature renders a signature without receiver and parameter names
+func (c *comparer) signature(sig *types.Signature, q types.Qualifier) string {
+	var b strings.Builder
+	b.WriteString("func")
+	if tparams := sig.TypeParams(); tparams.Len() > 0 {
+		b.WriteString("[")
+		for i := 0; i < tparams.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(types.TypeString(tparams.At(i).Constraint(), q))
+		}
+		b.WriteString("]")
+	}
+	b.WriteString("(")
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		t := params.At(i).Type()
+		if sig.Variadic() && i == params.Len()-1 {
+			b.WriteString("...")
+			t = t.(*types.Slice).Elem()
+		}
+		b.WriteString(types.TypeString(t, q))
+	}
+	b.WriteString(")")
+	results := sig.Results()
+	switch results.Len() {
+	case 0:
+	case 1:
+		b.WriteString(" " + types.TypeString(results.At(0).Type(), q))
+	default:
+		b.WriteString(" (")
+		for i := 0; i < results.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(types.TypeString(results.At(i).Type(), q))
+		}
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// compareTypes compares two versions of a type declaration
+func (c *comparer) compareTypes(oldName, newName *types.TypeName) []Difference {
+	if oldName.IsAlias() != newName.IsAlias() {
+		from, to := "defined type", "alias"
+		if oldName.IsAlias() {
+			from, to = to, from
+		}
+		return []Difference{{
+			Kind: TypeChanged, Breaking: true,
+			Description: fmt.Sprintf("changed from %s to %s", from, to),
+		}}
+	}
+	if oldName.IsAlias() {
+		return c.compareValueTypes(oldName.Type(), newName.Type())
+	}
+
+	oldNamed, newNamed := oldName.Type().(*types.Named), newName.Type().(*types.Named)
+	if o, n := c.typeParams(oldNamed, c.oldQualifier), c.typeParams(newNamed, c.newQualifier); o != n {
+		return []Difference{{
+			Kind: TypeParamsChanged, Breaking: true,
+			Description: fmt.Sprintf("type parameters changed from [%s] to [%s]", o, n),
+		}}
+	}
+
+	oldUnderlying, newUnderlying := oldNamed.Underlying(), newNamed.Underlying()
+	if oldStruct, ok := oldUnderlying.(*types.Struct); ok {
+		if newStruct, ok := newUnderlying.(*types.Struct); ok {
+			return c.compareStructs(oldStruct, newStruct)
+		}
+	}
+	if oldIface, ok := oldUnderlying.(*types.Interface); ok {
+		if newIface, ok := newUnderlying.(*types.Interface); ok && oldIface.IsMethodSet() && newIface.IsMethodSet() {
+			return c.compareInterfaces(oldIface, newIface)
+		}
+	}
+	if o, n := c.oldString(oldUnderlying), c.newString(newUnderlying); o != n {
+		return []Difference{{
+			Kind: TypeChanged, Breaking: true,
+			Description: fmt.Sprintf("underlying type changed from %s to %s", o, n),
+		}}
+	}
+	return nil
+}
+
+// typeParams renders the type parameter constraints of a type
+func (c *comparer) typeParams(named *types.Named, q types.Qualifier) string {
+	tparams := named.TypeParams()
+	constraints := make([]string, tparams.Len())
+	for i := range constraints {
+		constraints[i] = types.TypeString(tparams.At(i).Constraint(), q)
+	}
+	return strings.Join(constraints, ", ")
+}
+
+// compareStructs compares the exported fields of two struct versions.
+// Adding a field is compatible, removing or retyping one is not.
+func (c *comparer) compareStructs(oldStruct, newStruct *types.Struct) []Difference {
+	newFields := make(map[string]*types.Var)
+	for i := 0; i < newStruct.NumFields(); i++ {
+		if f := newStruct.Field(i); f.Exported() {
+			newFields[f.Name()] = f
+		}
+	}
+
+	var differences []Difference
+	seen := make(map[string]bool)
+	for i := 0; i < oldStruct.NumFields(); i++ {
+		f := oldStruct.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		seen[f.Name()] = true
+		nf, ok := newFields[f.Name()]
+		if !ok {
+			differences = append(differences, Difference{
+				Kind: FieldRemoved, Breaking: true,
+				Description: fmt.Sprintf("field %s removed", f.Name()),
+			})
+			continue
+		}
+		if o, n := c.oldString(f.Type()), c.newString(nf.Type()); o != n {
+			differences = append(differences, Difference{
+				Kind: FieldTypeChanged, Breaking: true,
+				Description: fmt.Sprintf("field %s changed from %s to %s", f.Name(), o, n),
+			})
+		}
+	}
+	for i := 0; i < newStruct.NumFields(); i++ {
+		if f := newStruct.Field(i); f.Exported() && !seen[f.Name()] {
+			differences = append(differences, Difference{
+				Kind:        FieldAdded,
+				Description: fmt.Sprintf("field %s added", f.Name()),
+			})
+		}
+	}
+	return differences
+}
+
+// compareInterfaces compares the method sets of two interface versions.
+// Removing or changing a method breaks callers; adding one breaks
+// implementations, unless the interface could not be implemented outside
+// its package before, having unexported methods.
+func (c *comparer) compareInterfaces(oldIface, newIface *types.Interface) []Difference {
+	sealed := false
+	oldMethods := make(map[string]*types.Func)
+	for i := 0; i < oldIface.NumMethods(); i++ {
+		m := oldIface.Method(i)
+		oldMethods[m.Name()] = m
+		sealed = sealed || !m.Exported()
+	}
+
+	var differences []Difference
+	// Methods are sorted by name
+	for i := 0; i < newIface.NumMethods(); i++ {
+		m := newIface.Method(i)
+		old, ok := oldMethods[m.Name()]
+		delete(oldMethods, m.Name())
+		switch {
+		case !ok:
+			differences = append(differences, Difference{
+				Kind: MethodAdded, Breaking: !sealed,
+				Description: fmt.Sprintf("method %s added", m.Name()),
+			})
+		case m.Exported():
+			o := c.signature(old.Type().(*types.Signature), c.oldQualifier)
+			n := c.signature(m.Type().(*types.Signature), c.newQualifier)
+			if o != n {
+				differences = append(differences, Difference{
+					Kind: MethodChanged, Breaking: true,
+					Description: fmt.Sprintf("method %s changed from %s to %s", m.Name(), o, n),
+				})
+			}
+		}
+	}
+	for i := 0; i < oldIface.NumMethods(); i++ {
+		if m := oldIface.Method(i); oldMethods[m.Name()] != nil {
+			differences = append(differences, Difference{
+				Kind: MethodRemoved, Breaking: m.Exported(),
+				Description: fmt.Sprintf("method %s removed", m.Name()),
+			})
+		}
+	}
+	return differences
+}
@@ -0,0 +1,324 @@
+package apidiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// loadModule writes the files of a module to a directory and loads it
+func loadModule(t *testing.T, files map[string]string) *module.Module {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	return mod
+}
+
+func TestDiffModules(t *testing.T) {
+	oldMod := loadModule(t, map[string]string{
+		"go.mod": "module example.com/lib\n\ngo 1.21\n",
+		"lib.go": `package lib
+
+const Limit = 10
+
+var Default Config
+
+type Config struct {
+	Name    string
+	Retries int
+	Debug   bool
+	secret  string
+}
+
+type Store interface {
+	Get(key string) (string, error)
+	Delete(key string) error
+}
+
+type ID int
+
+func (c Config) Validate() error { return nil }
+
+func (c *Config) Reset() {}
+
+func Open(name string, opts ...string) (*Config, error) { return nil, nil }
+
+func Legacy() {}
+
+func Unchanged(a int) int { return a }
+
+func helper() {}
+`,
+		"internal/impl/impl.go": "package impl\n\nfunc Gone() {}\n",
+	})
+	newMod := loadModule(t, map[string]string{
+		"go.mod": "module example.com/lib/v2\n\ngo 1.21\n",
+		"lib.go": `package lib
+
+const Limit = 20
+
+var Default *Config
+
+type Config struct {
+	Name    string
+	Retries int64
+	Timeout int
+	secret  []byte
+}
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(key, value string) error
+}
+
+type ID string
+
+func (c *Config) Validate() error { return nil }
+
+func (c Config) Reset() {}
+
+func Open(path string, opts ...int) (*Config, error) { return nil, nil }
+
+func New() *Config { return nil }
+
+// Unchanged has a new body and parameter name only
+func Unchanged(b int) int { return b + 0 }
+
+func helper2() {}
+`,
+	})
+
+	diff, err := NewAnalyzer().DiffModules(oldMod, newMod)
+	if err != nil {
+		t.Fatalf("DiffModules failed: %v", err)
+	}
+
+	tests := []struct {
+		id       string
+		change   ChangeKind
+		breaking bool
+		details  string
+	}{
+		{"example.com/lib/v2.Config", ChangeModified, true, "field Retries changed from int to int64; field Debug removed; field Timeout added"},
+		{"example.com/lib/v2.Config.Reset", ChangeModified, false, "receiver changed from pointer to value"},
+		{"example.com/lib/v2.Config.Validate", ChangeModified, true, "receiver changed from value to pointer"},
+		{"example.com/lib/v2.Default", ChangeModified, true, "type changed from Config to *Config"},
+		{"example.com/lib/v2.ID", ChangeModified, true, "underlying type changed from int to string"},
+		{"example.com/lib.Legacy", ChangeRemoved, true, ""},
+		{"example.com/lib/v2.Limit", ChangeModified, false, "value changed from 10 to 20"},
+		{"example.com/lib/v2.New", ChangeAdded, false, ""},
+		{"example.com/lib/v2.Open", ChangeModified, true, "parameter 2 changed from ...string to ...int"},
+		{"example.com/lib/v2.Store", ChangeModified, true, "method Put added; method Delete removed"},
+	}
+	if len(diff.Changes) != len(tests) {
+		for _, c := range diff.Changes {
+			t.Logf("%s %s", c.Change, c.ID)
+		}
+		t.Fatalf("Expected %d changes, got %d", len(tests), len(diff.Changes))
+	}
+	for i, tt := range tests {
+		c := diff.Changes[i]
+		var details []string
+		for _, d := range c.Differences {
+			details = append(details, d.Description)
+		}
+		if c.ID != tt.id || c.Change != tt.change || c.IsBreaking() != tt.breaking || strings.Join(details, "; ") != tt.details {
+			t.Errorf("Change %d: expected %s %s (breaking %v) %q, got %s %s (breaking %v) %q",
+				i, tt.change, tt.id, tt.breaking, tt.details, c.Change, c.ID, c.IsBreaking(), strings.Join(details, "; "))
+		}
+	}
+
+	if !diff.IsBreaking() || len(diff.Breaking()) != 7 {
+		t.Errorf("Expected 7 breaking changes, got %d", len(diff.Breaking()))
+	}
+
+	var out strings.Builder
+	if err := diff.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	text := out.String()
+	if !strings.HasPrefix(text, "Breaking changes:\n  changed type example.com/lib/v2.Config\n    - field Retries changed from int to int64\n") ||
+		!strings.Contains(text, "Compatible changes:\n  changed method example.com/lib/v2.Config.Reset\n") {
+		t.Errorf("Unexpected text:\n%s", text)
+	}
+
+	// Identical versions have no changes
+	same, err := NewAnalyzer().DiffModules(oldMod, oldMod)
+	if err != nil {
+		t.Fatalf("DiffModules failed: %v", err)
+	}
+	if len(same.Changes) != 0 || same.IsBreaking() {
+		t.Errorf("Expected no changes, got %v", same.Changes)
+	}
+}
+
+func TestCompareFunctions(t *testing.T) {
+	oldMod := loadModule(t, map[string]string{
+		"go.mod": "module example.com/fn\n\ngo 1.21\n",
+		"fn.go": `package fn
+
+func AddParam(a int) {}
+
+func AddVariadic(a int) {}
+
+func RemoveParam(a, b int) {}
+
+func ChangeParam(a int, b string) {}
+
+func ToVariadic(a string, b int) {}
+
+func SliceToVariadic(names []string) {}
+
+func VariadicToSlice(names ...string) {}
+
+func ChangeResult() int { return 0 }
+
+func AddResult() int { return 0 }
+
+func Rename(a int) int { return a }
+
+func Generic[T any](v T) {}
+`,
+	})
+	newMod := loadModule(t, map[string]string{
+		"go.mod": "module example.com/fn\n\ngo 1.21\n",
+		"fn.go": `package fn
+
+func AddParam(a int, b bool) {}
+
+func AddVariadic(a int, opts ...string) {}
+
+func RemoveParam(a int) {}
+
+func ChangeParam(a int, b []byte) {}
+
+func ToVariadic(a string, b ...int) {}
+
+func SliceToVariadic(names ...string) {}
+
+func VariadicToSlice(names []string) {}
+
+func ChangeResult() int64 { return 0 }
+
+func AddResult() (int, error) { return 0, nil }
+
+func Rename(renamed int) int { return renamed }
+
+func Generic[T comparable](v T) {}
+`,
+	})
+
+	diff, err := NewAnalyzer().DiffModules(oldMod, newMod)
+	if err != nil {
+		t.Fatalf("DiffModules failed: %v", err)
+	}
+	changes := make(map[string]Change)
+	for _, c := range diff.Changes {
+		changes[strings.TrimPrefix(c.ID, "example.com/fn.")] = c
+	}
+
+	tests := []struct {
+		name     string
+		kind     DifferenceKind
+		breaking bool
+		details  string
+	}{
+		{"AddParam", ParameterAdded, true, "parameter 2 (bool) added"},
+		{"AddVariadic", ParameterAdded, false, "parameter 2 (...string) added"},
+		{"RemoveParam", ParameterRemoved, true, "parameter 2 (int) removed"},
+		{"ChangeParam", ParameterTypeChanged, true, "parameter 2 changed from string to []byte"},
+		{"ToVariadic", ParameterTypeChanged, false, "parameter 2 changed from int to ...int"},
+		{"SliceToVariadic", ParameterTypeChanged, true, "parameter 1 changed from []string to ...string"},
+		{"VariadicToSlice", ParameterTypeChanged, true, "parameter 1 changed from ...string to []string"},
+		{"ChangeResult", ResultTypeChanged, true, "results changed from (int) to (int64)"},
+		{"AddResult", ResultTypeChanged, true, "results changed from (int) to (int, error)"},
+		{"Generic", SignatureChanged, true, "type parameters changed from [any] to [comparable]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := changes[tt.name]
+			if !ok {
+				t.Fatalf("Expected %s to be changed", tt.name)
+			}
+			if len(c.Differences) != 1 {
+				t.Fatalf("Expected one difference, got %+v", c.Differences)
+			}
+			d := c.Differences[0]
+			if d.Kind != tt.kind || d.Breaking != tt.breaking || d.Description != tt.details {
+				t.Errorf("Expected %s (breaking %v) %q, got %s (breaking %v) %q",
+					tt.kind, tt.breaking, tt.details, d.Kind, d.Breaking, d.Description)
+			}
+		})
+	}
+
+	if _, ok := changes["Rename"]; ok {
+		t.Error("Expected renaming a parameter not to change the API")
+	}
+}
+
+func TestDiffModulesIgnore(t *testing.T) {
+	files := map[string]string{
+		"go.mod":             "module example.com/lib\n\ngo 1.21\n",
+		"lib.go":             "package lib\n\nfunc Root() {}\n",
+		"tools/gen/gen.go":   "package gen\n\nfunc Generate() {}\n",
+		"tools/tools.go":     "package tools\n\nfunc Tool() {}\n",
+		"client/client.go":   "package client\n\nfunc Dial() {}\n",
+		"client/v2/dial.go":  "package v2\n\nfunc Dial() {}\n",
+		"toolsets/sets.go":   "package toolsets\n\nfunc Set() {}\n",
+		"examples/ex/ex.go":  "package ex\n\nfunc Example() {}\n",
+		"examples/ex2/ex.go": "package ex2\n\nfunc Example() {}\n",
+	}
+	oldMod := loadModule(t, map[string]string{"go.mod": files["go.mod"], "lib.go": "package lib\n"})
+	newMod := loadModule(t, files)
+
+	tests := []struct {
+		ignore []string
+		want   string
+	}{
+		{nil, "~.Root,~/client.Dial,~/client/v2.Dial,~/examples/ex.Example,~/examples/ex2.Example,~/tools.Tool,~/tools/gen.Generate,~/toolsets.Set"},
+		{[]string{"."}, "~/client.Dial,~/client/v2.Dial,~/examples/ex.Example,~/examples/ex2.Example,~/tools.Tool,~/tools/gen.Generate,~/toolsets.Set"},
+		{[]string{"tools/..."}, "~.Root,~/client.Dial,~/client/v2.Dial,~/examples/ex.Example,~/examples/ex2.Example,~/toolsets.Set"},
+		{[]string{"client", "examples/*"}, "~.Root,~/client/v2.Dial,~/tools.Tool,~/tools/gen.Generate,~/toolsets.Set"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.ignore, ","), func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			analyzer.Ignore = tt.ignore
+			diff, err := analyzer.DiffModules(oldMod, newMod)
+			if err != nil {
+				t.Fatalf("DiffModules failed: %v", err)
+			}
+			var ids []string
+			for _, c := range diff.Changes {
+				ids = append(ids, "~"+strings.TrimPrefix(c.ID, "example.com/lib"))
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("Unexpected changes:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+
+	analyzer := NewAnalyzer()
+	analyzer.Ignore = []string{"[bad"}
+	if _, err := analyzer.DiffModules(oldMod, newMod); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
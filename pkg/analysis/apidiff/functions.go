@@ -0,0 +1,112 @@
+package apidiff
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// compareFunctions compares two versions of a function or method by their
+// receivers, type parameters, parameters and results; parameter names do
+// not matter. Changes that keep every call compiling are compatible: a
+// method moving to a value receiver, a variadic parameter added last, and
+// a last parameter T becoming ...T. Function values of the old type may
+// still not convert to the new one.
+func (c *comparer) compareFunctions(oldFn, newFn *types.Func) []Difference {
+	oldSig, newSig := oldFn.Type().(*types.Signature), newFn.Type().(*types.Signature)
+	var differences []Difference
+
+	if oldSig.Recv() != nil && newSig.Recv() != nil {
+		_, oldPtr := oldSig.Recv().Type().(*types.Pointer)
+		_, newPtr := newSig.Recv().Type().(*types.Pointer)
+		switch {
+		case !oldPtr && newPtr:
+			differences = append(differences, Difference{
+				Kind: ReceiverChanged, Breaking: true,
+				Description: "receiver changed from value to pointer",
+			})
+		case oldPtr && !newPtr:
+			differences = append(differences, Difference{
+				Kind:        ReceiverChanged,
+				Description: "receiver changed from pointer to value",
+			})
+		}
+	}
+
+	if o, n := c.typeParamList(oldSig, c.oldQualifier), c.typeParamList(newSig, c.newQualifier); o != n {
+		differences = append(differences, Difference{
+			Kind: SignatureChanged, Breaking: true,
+			Description: fmt.Sprintf("type parameters changed from [%s] to [%s]", o, n),
+		})
+	}
+
+	oldParams := c.params(oldSig, c.oldQualifier)
+	newParams := c.params(newSig, c.newQualifier)
+	for i := 0; i < len(oldParams) || i < len(newParams); i++ {
+		switch {
+		case i >= len(newParams):
+			differences = append(differences, Difference{
+				Kind: ParameterRemoved, Breaking: true,
+				Description: fmt.Sprintf("parameter %d (%s) removed", i+1, oldParams[i]),
+			})
+		case i >= len(oldParams):
+			differences = append(differences, Difference{
+				Kind: ParameterAdded,
+				// A variadic parameter may be left out of calls
+				Breaking:    !(newSig.Variadic() && i == len(newParams)-1),
+				Description: fmt.Sprintf("parameter %d (%s) added", i+1, newParams[i]),
+			})
+		case oldParams[i] != newParams[i]:
+			differences = append(differences, Difference{
+				Kind: ParameterTypeChanged,
+				// Arguments for T are valid for ...T
+				Breaking:    "..."+oldParams[i] != newParams[i] || i != len(oldParams)-1,
+				Description: fmt.Sprintf("parameter %d changed from %s to %s", i+1, oldParams[i], newParams[i]),
+			})
+		}
+	}
+
+	if o, n := c.results(oldSig, c.oldQualifier), c.results(newSig, c.newQualifier); o != n {
+		differences = append(differences, Difference{
+			Kind: ResultTypeChanged, Breaking: true,
+			Description: fmt.Sprintf("results changed from (%s) to (%s)", o, n),
+		})
+	}
+	return differences
+}
+
+// typeParamList renders the type parameter constraints of a signature
+func (c *comparer) typeParamList(sig *types.Signature, q types.Qualifier) string {
+	tparams := sig.TypeParams()
+	constraints := make([]string, tparams.Len())
+	for i := range constraints {
+		constraints[i] = types.TypeString(tparams.At(i).Constraint(), q)
+	}
+	return strings.Join(constraints, ", ")
+}
+
+// params renders the parameter types of a signature, the last as "...T"
+// if it is variadic
+func (c *comparer) params(sig *types.Signature, q types.Qualifier) []string {
+	params := sig.Params()
+	rendered := make([]string, params.Len())
+	for i := range rendered {
+		t := params.At(i).Type()
+		if sig.Variadic() && i == params.Len()-1 {
+			rendered[i] = "..." + types.TypeString(t.(*types.Slice).Elem(), q)
+			continue
+		}
+		rendered[i] = types.TypeString(t, q)
+	}
+	return rendered
+}
+
+// results renders the result types of a signature
+func (c *comparer) results(sig *types.Signature, q types.Qualifier) string {
+	results := sig.Results()
+	rendered := make([]string, results.Len())
+	for i := range rendered {
+		rendered[i] = types.TypeString(results.At(i).Type(), q)
+	}
+	return strings.Join(rendered, ", ")
+}
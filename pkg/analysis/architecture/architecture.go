@@ -0,0 +1,185 @@
+// Package architecture checks the imports of a module against allow and
+// deny rules between packages.
+package architecture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// Action is what a rule does with the imports it matches
+type Action string
+
+const (
+	// Allow permits matching imports
+	Allow Action = "allow"
+
+	// Deny forbids matching imports
+	Deny Action = "deny"
+)
+
+// Rule constrains imports from packages matching From to packages matching
+// To. Patterns are import paths in which "..." matches any string and "*"
+// matches within one path element; a trailing "/..." also matches the path
+// before it, and a leading "./" stands for the module path.
+type Rule struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Action Action `json:"action"`
+	Reason string `json:"reason,omitempty"` // Explanation shown with violations
+}
+
+// Violation is an import forbidden by a rule
+type Violation struct {
+	Rule     *Rule            // Rule forbidding the import
+	Package  string           // Importing package
+	File     string           // Path of the importing file
+	Import   string           // Forbidden import path
+	Position *module.Position // Position of the import spec, if known
+}
+
+// String describes the violation
+func (v Violation) String() string {
+	location := v.File
+	if v.Position != nil {
+		location = v.Position.String()
+	}
+	msg := fmt.Sprintf("%s: %s must not import %s", location, v.Package, v.Import)
+	if v.Rule.Reason != "" {
+		msg += " (" + v.Rule.Reason + ")"
+	}
+	return msg
+}
+
+// ruleSet is the configuration file format for rules
+type ruleSet struct {
+	Rules []Rule `json:"rules"`
+}
+
+// ReadRules reads rules from a JSON configuration of the form
+// {"rules": [{"from": "./internal/...", "to": "./cmd/...", "action": "deny"}]}
+func ReadRules(r io.Reader) ([]Rule, error) {
+	var set ruleSet
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	for i, rule := range set.Rules {
+		if rule.Action != Allow && rule.Action != Deny {
+			return nil, fmt.Errorf("rule %d: action must be %q or %q, got %q", i+1, Allow, Deny, rule.Action)
+		}
+		if rule.From == "" || rule.To == "" {
+			return nil, fmt.Errorf("rule %d: from and to are required", i+1)
+		}
+	}
+	return set.Rules, nil
+}
+
+// LoadRules reads rules from a JSON configuration file
+func LoadRules(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rules: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return ReadRules(f)
+}
+
+// Analyzer checks module imports against architecture rules
+type Analyzer struct{}
+
+// NewAnalyzer creates a new architecture analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// CheckRules returns the imports of the module's files that are denied by
+// the rules. The first rule matching both the importing package and the
+// import decides, so allow rules listed before a deny rule carve out
+// exceptions; imports no rule matches are allowed. Violations are sorted by
+// file and position.
+func (a *Analyzer) CheckRules(mod *module.Module, rules []Rule) ([]Violation, error) {
+	type compiledRule struct {
+		rule     *Rule
+		from, to *regexp.Regexp
+	}
+	compiled := make([]compiledRule, len(rules))
+	for i := range rules {
+		from, err := compilePattern(rules[i].From, mod.Path)
+		if err != nil {
+			return nil, err
+		}
+		to, err := compilePattern(rules[i].To, mod.Path)
+		if err != nil {
+			return nil, err
+		}
+		compiled[i] = compiledRule{rule: &rules[i], from: from, to: to}
+	}
+
+	var violations []Violation
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			for _, imp := range file.Imports {
+				for _, c := range compiled {
+					if !c.from.MatchString(pkg.ImportPath) || !c.to.MatchString(imp.Path) {
+						continue
+					}
+					if c.rule.Action == Deny {
+						violations = append(violations, Violation{
+							Rule:     c.rule,
+							Package:  pkg.ImportPath,
+							File:     file.Path,
+							Import:   imp.Path,
+							Position: imp.GetPosition(),
+						})
+					}
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		vi, vj := violations[i], violations[j]
+		if vi.File != vj.File {
+			return vi.File < vj.File
+		}
+		if vi.Position != nil && vj.Position != nil && vi.Position.LineStart != vj.Position.LineStart {
+			return vi.Position.LineStart < vj.Position.LineStart
+		}
+		return vi.Import < vj.Import
+	})
+	return violations, nil
+}
+
+// compilePattern turns an import path pattern into a regular expression
+func compilePattern(pattern, modulePath string) (*regexp.Regexp, error) {
+	switch {
+	case pattern == ".":
+		pattern = modulePath
+	case strings.HasPrefix(pattern, "./"):
+		pattern = modulePath + pattern[1:]
+	}
+
+	expr := regexp.QuoteMeta(pattern)
+	// "a/..." matches "a" itself as well as everything below it
+	if strings.HasSuffix(expr, `/\.\.\.`) {
+		expr = strings.TrimSuffix(expr, `/\.\.\.`) + `(/.*)?`
+	}
+	expr = strings.ReplaceAll(expr, `\.\.\.`, `.*`)
+	expr = strings.ReplaceAll(expr, `\*`, `[^/]*`)
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
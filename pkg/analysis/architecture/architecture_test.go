@@ -0,0 +1,97 @@
+package architecture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestCheckRules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                  "module example.com/layers\n\ngo 1.21\n",
+		"internal/store/store.go": "package store\n\n// Get returns a value\nfunc Get() string { return \"v\" }\n",
+		"internal/auth/auth.go": `package auth
+
+import "example.com/layers/api"
+
+// Check checks a token
+func Check() string { return api.Version }
+`,
+		"api/api.go": `package api
+
+import (
+	"os/exec"
+
+	"example.com/layers/internal/store"
+)
+
+// Version is the API version
+const Version = "v1"
+
+// Run runs a command
+func Run() (string, error) {
+	_, err := exec.LookPath(store.Get())
+	return Version, err
+}
+`,
+		"cmd/tool/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/layers/api"
+	"example.com/layers/internal/auth"
+)
+
+func main() { fmt.Println(api.Run()); fmt.Println(auth.Check()) }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	rules, err := ReadRules(strings.NewReader(`{"rules": [
+		{"from": "./internal/...", "to": "./api", "action": "deny", "reason": "internal packages must not depend on the API"},
+		{"from": "./api", "to": "./internal/store", "action": "allow"},
+		{"from": "./*", "to": "./internal/...", "action": "deny"},
+		{"from": "...", "to": "os/exec", "action": "deny"}
+	]}`))
+	if err != nil {
+		t.Fatalf("ReadRules failed: %v", err)
+	}
+
+	violations, err := NewAnalyzer().CheckRules(mod, rules)
+	if err != nil {
+		t.Fatalf("CheckRules failed: %v", err)
+	}
+
+	var got []string
+	for _, v := range violations {
+		got = append(got, filepath.Base(v.File)+" "+v.Import+" "+v.Rule.To)
+	}
+	expected := "api.go os/exec os/exec,auth.go example.com/layers/api ./api"
+	if strings.Join(got, ",") != expected {
+		t.Fatalf("Expected violations %s, got %s", expected, strings.Join(got, ","))
+	}
+	if msg := violations[1].String(); !strings.Contains(msg, "example.com/layers/internal/auth must not import example.com/layers/api (internal packages") {
+		t.Errorf("Unexpected message %q", msg)
+	}
+
+	if _, err := ReadRules(strings.NewReader(`{"rules": [{"from": "a", "to": "b", "action": "forbid"}]}`)); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
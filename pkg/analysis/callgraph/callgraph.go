@@ -0,0 +1,235 @@
+// Package callgraph builds the static call graph of the functions and
+// methods of a module.
+package callgraph
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// Options configures the construction of a call graph
+type Options struct {
+	// ExpandInterfaces adds, for each call of an interface method, an edge
+	// to the method of every type of the module implementing the interface
+	ExpandInterfaces bool
+}
+
+// Analyzer builds call graphs
+type Analyzer struct {
+	options Options
+}
+
+// NewAnalyzer creates a new call graph analyzer
+func NewAnalyzer(options Options) *Analyzer {
+	return &Analyzer{options: options}
+}
+
+// CallGraph records which functions and methods of a module call which,
+// keyed by symbol ID
+type CallGraph struct {
+	symbols map[string]*module.Symbol
+	callees map[string]map[string]bool
+	callers map[string]map[string]bool
+}
+
+// Callees returns the functions and methods of the module called by sym,
+// sorted by ID
+func (g *CallGraph) Callees(sym *module.Symbol) []*module.Symbol {
+	return g.resolve(g.callees[sym.ID])
+}
+
+// Callers returns the functions and methods of the module calling sym,
+// sorted by ID
+func (g *CallGraph) Callers(sym *module.Symbol) []*module.Symbol {
+	return g.resolve(g.callers[sym.ID])
+}
+
+// resolve returns the symbols of a set of IDs, sorted by ID
+func (g *CallGraph) resolve(ids map[string]bool) []*module.Symbol {
+	symbols := make([]*module.Symbol, 0, len(ids))
+	for id := range ids {
+		symbols = append(symbols, g.symbols[id])
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].ID < symbols[j].ID })
+	return symbols
+}
+
+// addEdge records a call
+func (g *CallGraph) addEdge(caller, callee *module.Symbol) {
+	if g.callees[caller.ID] == nil {
+		g.callees[caller.ID] = make(map[string]bool)
+	}
+	if g.callers[callee.ID] == nil {
+		g.callers[callee.ID] = make(map[string]bool)
+	}
+	g.callees[caller.ID][callee.ID] = true
+	g.callers[callee.ID][caller.ID] = true
+}
+
+// BuildCallGraph builds the call graph of the module from the call
+// expressions in the bodies of its functions and methods. Calls made in
+// function literals are attributed to the enclosing declaration; calls in
+// package-level variable initializers are not recorded. Calls of generic
+// functions are recorded as calls of the generic declaration. Functions
+// passed as values are not followed. The module must be loaded with
+// IncludeAST.
+func (a *Analyzer) BuildCallGraph(mod *module.Module) (*CallGraph, error) {
+	g := &CallGraph{
+		symbols: make(map[string]*module.Symbol),
+		callees: make(map[string]map[string]bool),
+		callers: make(map[string]map[string]bool),
+	}
+
+	// Declarations are matched to symbols by position, which also finds the
+	// init functions that are not in their package's scope
+	byPos := make(map[token.Pos]*module.Symbol)
+	for _, sym := range mod.Symbols() {
+		if fn, ok := sym.Element.(*module.Function); ok {
+			byPos[fn.Pos] = sym
+			g.symbols[sym.ID] = sym
+		}
+	}
+
+	typed := false
+	symbols := make(map[types.Object]*module.Symbol)
+	var decls []funcDecl
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		typed = true
+		for _, file := range pkg.Files {
+			if file.AST == nil {
+				continue
+			}
+			for _, decl := range file.AST.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				sym := byPos[fd.Pos()]
+				if sym == nil {
+					continue
+				}
+				if obj := pkg.TypesInfo.Defs[fd.Name]; obj != nil {
+					symbols[obj] = sym
+				}
+				if fd.Body != nil {
+					decls = append(decls, funcDecl{sym: sym, decl: fd, info: pkg.TypesInfo})
+				}
+			}
+		}
+	}
+	if !typed {
+		return nil, errors.New("no type information, load the module with IncludeAST")
+	}
+
+	var implementers *implementerIndex
+	if a.options.ExpandInterfaces {
+		implementers = newImplementerIndex(mod, symbols)
+	}
+
+	for _, d := range decls {
+		ast.Inspect(d.decl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			callee := calledFunc(d.info, call)
+			if callee == nil {
+				return true
+			}
+			if sym := symbols[callee]; sym != nil {
+				g.addEdge(d.sym, sym)
+			}
+			if implementers != nil {
+				for _, sym := range implementers.methods(callee) {
+					g.addEdge(d.sym, sym)
+				}
+			}
+			return true
+		})
+	}
+	return g, nil
+}
+
+// funcDecl is a function declaration with a body and its symbol
+type funcDecl struct {
+	sym  *module.Symbol
+	decl *ast.FuncDecl
+	info *types.Info
+}
+
+// calledFunc returns the declared function or method a call expression
+// calls, or nil for conversions, builtins and calls of function values
+func calledFunc(info *types.Info, call *ast.CallExpr) *types.Func {
+	fun := ast.Unparen(call.Fun)
+	// Explicit instantiations, as in f[int](x)
+	switch e := fun.(type) {
+	case *ast.IndexExpr:
+		fun = e.X
+	case *ast.IndexListExpr:
+		fun = e.X
+	}
+
+	var ident *ast.Ident
+	switch e := fun.(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return nil
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return fn.Origin()
+}
+
+// implementerIndex finds the methods of the module's types that implement
+// interface methods
+type implementerIndex struct {
+	mod     *module.Module
+	symbols map[types.Object]*module.Symbol
+	cache   map[*types.Func][]*module.Symbol
+}
+
+// newImplementerIndex creates an index over the module's types
+func newImplementerIndex(mod *module.Module, symbols map[types.Object]*module.Symbol) *implementerIndex {
+	return &implementerIndex{mod: mod, symbols: symbols, cache: make(map[*types.Func][]*module.Symbol)}
+}
+
+// methods returns the methods of the module implementing fn, if fn is an
+// interface method
+func (idx *implementerIndex) methods(fn *types.Func) []*module.Symbol {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	iface, ok := recv.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	if methods, ok := idx.cache[fn]; ok {
+		return methods
+	}
+
+	var methods []*module.Symbol
+	for _, t := range idx.mod.Implementers(iface) {
+		obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, fn.Pkg(), fn.Name())
+		if method, ok := obj.(*types.Func); ok {
+			if sym := idx.symbols[method.Origin()]; sym != nil {
+				methods = append(methods, sym)
+			}
+		}
+	}
+	idx.cache[fn] = methods
+	return methods
+}
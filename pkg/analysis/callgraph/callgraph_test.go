@@ -0,0 +1,128 @@
+package callgraph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestBuildCallGraph(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"main.go": `package main
+
+import "example.com/app/shapes"
+
+func main() {
+	var s shapes.Shape = shapes.NewSquare(2)
+	report(s)
+	go func() { shapes.Reset() }()
+}
+
+func report(s shapes.Shape) { println(s.Area(), shapes.Max(1, 2)) }
+`,
+		"shapes/shapes.go": `package shapes
+
+var count int
+
+func init() { Reset() }
+
+type Shape interface{ Area() int }
+
+type Square struct{ side int }
+
+func NewSquare(side int) *Square { return &Square{side: side} }
+
+func (s *Square) Area() int { return s.side * s.side }
+
+type Circle struct{ r int }
+
+func (c Circle) Area() int { return 3 * c.r * c.r }
+
+func Reset() { count = 0 }
+
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	symbols := make(map[string]*module.Symbol)
+	for _, sym := range mod.Symbols() {
+		symbols[sym.ID] = sym
+	}
+	ids := func(list []*module.Symbol) string {
+		var ids []string
+		for _, sym := range list {
+			ids = append(ids, sym.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	g, err := NewAnalyzer(Options{}).BuildCallGraph(mod)
+	if err != nil {
+		t.Fatalf("BuildCallGraph failed: %v", err)
+	}
+	tests := []struct {
+		name, id, callees, callers string
+	}{
+		{"calls and function literals", "example.com/app.main", "example.com/app.report,example.com/app/shapes.NewSquare,example.com/app/shapes.Reset", ""},
+		{"generic function", "example.com/app.report", "example.com/app/shapes.Max", "example.com/app.main"},
+		{"init function", "example.com/app/shapes.Reset", "", "example.com/app.main,example.com/app/shapes.init[shapes.go#1]"},
+		{"interface method not expanded", "example.com/app/shapes.Square.Area", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sym := symbols[tt.id]
+			if sym == nil {
+				t.Fatalf("Symbol %s not found", tt.id)
+			}
+			if got := ids(g.Callees(sym)); got != tt.callees {
+				t.Errorf("Expected callees %q, got %q", tt.callees, got)
+			}
+			if got := ids(g.Callers(sym)); got != tt.callers {
+				t.Errorf("Expected callers %q, got %q", tt.callers, got)
+			}
+		})
+	}
+
+	g, err = NewAnalyzer(Options{ExpandInterfaces: true}).BuildCallGraph(mod)
+	if err != nil {
+		t.Fatalf("BuildCallGraph failed: %v", err)
+	}
+	want := "example.com/app/shapes.Circle.Area,example.com/app/shapes.Max,example.com/app/shapes.Square.Area"
+	if got := ids(g.Callees(symbols["example.com/app.report"])); got != want {
+		t.Errorf("Expected expanded callees %q, got %q", want, got)
+	}
+
+	untyped, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	if _, err := NewAnalyzer(Options{}).BuildCallGraph(untyped); err == nil {
+		t.Error("Expected an error without type information")
+	}
+}
@@ -0,0 +1,132 @@
+// Package concurrency maps where a module starts goroutines.
+package concurrency
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// GoStatement is a go statement together with the function it starts
+type GoStatement struct {
+	Position *module.Position // Position of the go statement
+	Function string           // Enclosing function, qualified by receiver type
+	Callee   string           // Started function: its full name, e.g. "(*example.com/srv.Server).serve", or the call's function expression
+	Target   *module.Symbol   // Started function if declared in the module, otherwise nil
+	Literal  bool             // Whether the statement starts a function literal
+}
+
+// Analyzer finds the concurrency surface of a module
+type Analyzer struct{}
+
+// NewAnalyzer creates a new concurrency analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// FindGoStatements returns every go statement of the module, sorted by
+// position. The started function is resolved through type information, so
+// functions and methods of the module are reported as their symbol; function
+// literals, function values and interface methods have no target. The module
+// must be loaded with IncludeAST.
+func (a *Analyzer) FindGoStatements(mod *module.Module) []GoStatement {
+	symbols := make(map[string]*module.Symbol)
+	for _, sym := range mod.Symbols() {
+		symbols[sym.ID] = sym
+	}
+
+	var statements []GoStatement
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil || file.FileSet == nil {
+				continue
+			}
+			for _, decl := range file.AST.Decls {
+				function := ""
+				if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+					function = module.FuncDeclName(funcDecl)
+				}
+				ast.Inspect(decl, func(n ast.Node) bool {
+					stmt, ok := n.(*ast.GoStmt)
+					if !ok {
+						return true
+					}
+					statement := GoStatement{
+						Position: file.GetPositionInfo(stmt.Pos(), stmt.End()),
+						Function: function,
+						Callee:   types.ExprString(stmt.Call.Fun),
+					}
+					switch fun := ast.Unparen(stmt.Call.Fun).(type) {
+					case *ast.FuncLit:
+						statement.Literal = true
+						statement.Callee = "func literal"
+					default:
+						if fn := calledFunc(pkg.TypesInfo, fun); fn != nil {
+							statement.Callee = fn.FullName()
+							statement.Target = symbols[symbolID(fn)]
+						}
+					}
+					statements = append(statements, statement)
+					return true
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Position.Before(statements[j].Position)
+	})
+	return statements
+}
+
+// calledFunc returns the declared function or method an expression refers
+// to, or nil for other expressions
+func calledFunc(info *types.Info, fun ast.Expr) *types.Func {
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		// Explicitly instantiated generic function
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	var ident *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		ident = f
+	case *ast.SelectorExpr:
+		ident = f.Sel
+	default:
+		return nil
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return fn.Origin()
+}
+
+// symbolID returns the module symbol ID of a function or method, or an
+// empty string for interface methods
+func symbolID(fn *types.Func) string {
+	if fn.Pkg() == nil {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Pkg().Path() + "." + fn.Name()
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return ""
+	}
+	return fn.Pkg().Path() + "." + named.Obj().Name() + "." + fn.Name()
+}
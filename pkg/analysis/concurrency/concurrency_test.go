@@ -0,0 +1,70 @@
+package concurrency
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestFindGoStatements(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/srv\n\ngo 1.21\n",
+		"srv.go": `package srv
+
+import "net/http"
+
+type Server struct{ handler http.Handler }
+
+func (s *Server) serve(port int) {}
+
+func worker[T any](jobs chan T) {}
+
+func Start(s *Server, jobs chan int, done func()) {
+	go s.serve(8080)
+	go worker(jobs)
+	go func() {
+		defer done()
+	}()
+	go done()
+	go http.ListenAndServe(":80", s.handler)
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	var got []string
+	for _, stmt := range NewAnalyzer().FindGoStatements(mod) {
+		target := ""
+		if stmt.Target != nil {
+			target = stmt.Target.ID
+		}
+		got = append(got, fmt.Sprintf("%d %s %s [%s]", stmt.Position.LineStart, stmt.Function, stmt.Callee, target))
+	}
+
+	expected := []string{
+		"12 Start (*example.com/srv.Server).serve [example.com/srv.Server.serve]",
+		"13 Start example.com/srv.worker [example.com/srv.worker]",
+		"14 Start func literal []",
+		"17 Start done []",
+		"18 Start net/http.ListenAndServe []",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected go statements:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,224 @@
+// Package deprecation finds uses of deprecated symbols from dependencies.
+package deprecation
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// DeprecatedUsage is a reference from the module to a deprecated symbol
+type DeprecatedUsage struct {
+	Symbol   string           // Qualified deprecated symbol, e.g. "strings.Title"
+	Message  string           // The "Deprecated:" paragraph of its documentation
+	Position *module.Position // Location of the reference in the module
+	Caller   string           // Enclosing function of the reference, if any
+}
+
+// Analyzer finds uses of deprecated symbols
+type Analyzer struct {
+	// Parsed dependency sources, keyed by filename
+	files map[string]*parsedFile
+}
+
+// parsedFile holds the doc comments of a dependency source file
+type parsedFile struct {
+	docs map[declKey]string
+}
+
+// declKey identifies a declared name by its line and name
+type declKey struct {
+	line int
+	name string
+}
+
+// NewAnalyzer creates a new deprecation analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{files: make(map[string]*parsedFile)}
+}
+
+// FindDeprecatedUsage reports every reference in the module to a symbol of
+// another package whose documentation contains a "Deprecated:" paragraph.
+// Declarations are looked up in the dependencies' source files, so the
+// module must be loaded with IncludeAST for type information to be present.
+// Results are sorted by file and position.
+func (a *Analyzer) FindDeprecatedUsage(mod *module.Module) []DeprecatedUsage {
+	var usages []DeprecatedUsage
+	messages := make(map[types.Object]string)
+
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil || file.FileSet == nil {
+				continue
+			}
+
+			for _, decl := range file.AST.Decls {
+				caller := ""
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					caller = module.FuncDeclName(fn)
+				}
+
+				ast.Inspect(decl, func(n ast.Node) bool {
+					ident, ok := n.(*ast.Ident)
+					if !ok {
+						return true
+					}
+					obj := pkg.TypesInfo.Uses[ident]
+					if obj == nil || obj.Pkg() == nil {
+						return true
+					}
+					// Only symbols from outside the module are dependencies
+					if _, internal := mod.Packages[obj.Pkg().Path()]; internal {
+						return true
+					}
+
+					msg, seen := messages[obj]
+					if !seen {
+						msg = a.deprecationMessage(file.FileSet, obj)
+						messages[obj] = msg
+					}
+					if msg != "" {
+						usages = append(usages, DeprecatedUsage{
+							Symbol:   qualifiedName(obj),
+							Message:  msg,
+							Position: file.GetPositionInfo(ident.Pos(), ident.End()),
+							Caller:   caller,
+						})
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].Position.Before(usages[j].Position)
+	})
+
+	return usages
+}
+
+// deprecationMessage returns the deprecation notice of an object's declaration
+func (a *Analyzer) deprecationMessage(fset *token.FileSet, obj types.Object) string {
+	pos := fset.Position(obj.Pos())
+	if pos.Filename == "" {
+		return ""
+	}
+
+	if a.files == nil {
+		a.files = make(map[string]*parsedFile)
+	}
+	parsed, ok := a.files[pos.Filename]
+	if !ok {
+		parsed = parseDocs(pos.Filename)
+		a.files[pos.Filename] = parsed
+	}
+	if parsed == nil {
+		return ""
+	}
+
+	return ParseDeprecation(parsed.docs[declKey{line: pos.Line, name: obj.Name()}])
+}
+
+// parseDocs parses a source file and records the doc comment of each declared name
+func parseDocs(filename string) *parsedFile {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	parsed := &parsedFile{docs: make(map[declKey]string)}
+	record := func(ident *ast.Ident, groups ...*ast.CommentGroup) {
+		for _, g := range groups {
+			if g != nil {
+				parsed.docs[declKey{line: fset.Position(ident.Pos()).Line, name: ident.Name}] = g.Text()
+				return
+			}
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			record(d.Name, d.Doc)
+		case *ast.GenDecl:
+			// A lone spec may be documented on the declaration itself
+			var declDoc *ast.CommentGroup
+			if !d.Lparen.IsValid() {
+				declDoc = d.Doc
+			}
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					record(s.Name, s.Doc, declDoc)
+					recordMembers(s.Type, record)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						record(name, s.Doc, declDoc)
+					}
+				}
+			}
+		}
+	}
+
+	return parsed
+}
+
+// recordMembers records the docs of struct fields and interface methods
+func recordMembers(expr ast.Expr, record func(*ast.Ident, ...*ast.CommentGroup)) {
+	var fields *ast.FieldList
+	switch t := expr.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	}
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			record(name, field.Doc, field.Comment)
+		}
+	}
+}
+
+// ParseDeprecation extracts the "Deprecated:" paragraph from a doc comment,
+// following the Go convention that it starts a paragraph. It returns an
+// empty string if the doc comment contains no deprecation notice.
+func ParseDeprecation(doc string) string {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			return strings.Join(strings.Fields(paragraph), " ")
+		}
+	}
+	return ""
+}
+
+// qualifiedName returns a readable name for an object, including the
+// receiver type for methods
+func qualifiedName(obj types.Object) string {
+	if fn, ok := obj.(*types.Func); ok {
+		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+			recv := sig.Recv().Type()
+			if ptr, ok := recv.(*types.Pointer); ok {
+				recv = ptr.Elem()
+			}
+			if named, ok := recv.(*types.Named); ok {
+				return fmt.Sprintf("%s.%s.%s", obj.Pkg().Path(), named.Obj().Name(), obj.Name())
+			}
+		}
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
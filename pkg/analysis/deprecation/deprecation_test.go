@@ -0,0 +1,87 @@
+package deprecation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestFindDeprecatedUsage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/legacy\n\ngo 1.21\n",
+		"legacy.go": `package legacy
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Old is deprecated within the module itself and must not be reported.
+//
+// Deprecated: use New.
+func Old() {}
+
+func Read(r io.Reader) ([]byte, error) {
+	Old()
+	return ioutil.ReadAll(r)
+}
+
+func Headline(s string) string {
+	return strings.Title(strings.ToLower(s))
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	usages := NewAnalyzer().FindDeprecatedUsage(mod)
+	if len(usages) != 2 {
+		for _, u := range usages {
+			t.Logf("found %s in %s", u.Symbol, u.Caller)
+		}
+		t.Fatalf("Expected 2 deprecated usages, got %d", len(usages))
+	}
+
+	expected := []struct {
+		symbol, caller string
+		line           int
+	}{
+		{"io/ioutil.ReadAll", "Read", 16},
+		{"strings.Title", "Headline", 20},
+	}
+	for i, want := range expected {
+		got := usages[i]
+		if got.Symbol != want.symbol || got.Caller != want.caller || got.Position.LineStart != want.line {
+			t.Errorf("Usage %d: expected %s in %s at line %d, got %s in %s at line %d",
+				i, want.symbol, want.caller, want.line, got.Symbol, got.Caller, got.Position.LineStart)
+		}
+		if len(got.Message) < len("Deprecated: x") || got.Message[:11] != "Deprecated:" {
+			t.Errorf("Usage %d: expected a deprecation message, got %q", i, got.Message)
+		}
+	}
+}
+
+func TestParseDeprecation(t *testing.T) {
+	doc := "Title returns a copy.\n\nDeprecated: The rule Title uses\nis wrong. Use cases instead.\n\nMore text.\n"
+	want := "Deprecated: The rule Title uses is wrong. Use cases instead."
+	if got := ParseDeprecation(doc); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := ParseDeprecation("Not deprecated: really."); got != "" {
+		t.Errorf("Expected no deprecation, got %q", got)
+	}
+}
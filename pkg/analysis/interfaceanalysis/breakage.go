@@ -0,0 +1,95 @@
+package interfaceanalysis
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// WouldBreakImplementations lists the types of the module that implement an
+// interface of the module through the method, directly or by promotion, and
+// would no longer implement it if the method had newSignature. The signature
+// is written as in a declaration without receiver and name, for example
+// "(ctx context.Context) error", and may use the imports of the method's
+// file. The module must be loaded with IncludeAST. Results are type symbols
+// sorted by ID.
+func (a *Analyzer) WouldBreakImplementations(mod *module.Module, method *module.Symbol, newSignature string) ([]*module.Symbol, error) {
+	if method.Kind != module.SymbolMethod {
+		return nil, fmt.Errorf("%s is not a method", method.ID)
+	}
+	methodObj, ok := mod.LookupObject(method).(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("no type information for %s, load with IncludeAST", method.ID)
+	}
+	sig, err := parseSignature(method, newSignature)
+	if err != nil {
+		return nil, err
+	}
+
+	var interfaces []*types.Interface
+	for _, named := range mod.NamedTypes() {
+		if iface, ok := named.Underlying().(*types.Interface); ok && interfaceMethod(iface, methodObj.Name()) != nil {
+			interfaces = append(interfaces, iface)
+		}
+	}
+
+	symbols := make(map[string]*module.Symbol)
+	for _, sym := range mod.Symbols() {
+		if sym.Kind == module.SymbolType {
+			symbols[sym.ID] = sym
+		}
+	}
+
+	seen := make(map[*types.Named]bool)
+	var broken []*module.Symbol
+	for _, iface := range interfaces {
+		if types.Identical(interfaceMethod(iface, methodObj.Name()).Type(), sig) {
+			continue
+		}
+		for _, named := range mod.Implementers(iface) {
+			// The type must get this very method, declared or promoted
+			typeName := named.Obj()
+			if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, typeName.Pkg(), methodObj.Name()); obj != methodObj || seen[named] {
+				continue
+			}
+			seen[named] = true
+			if sym := symbols[typeName.Pkg().Path()+"."+typeName.Name()]; sym != nil {
+				broken = append(broken, sym)
+			}
+		}
+	}
+
+	sort.Slice(broken, func(i, j int) bool { return broken[i].ID < broken[j].ID })
+	return broken, nil
+}
+
+// parseSignature type-checks a signature in the scope of the method's file
+func parseSignature(method *module.Symbol, signature string) (*types.Signature, error) {
+	fn, ok := method.Element.(*module.Function)
+	if !ok || fn.File == nil || fn.File.FileSet == nil || fn.Package == nil {
+		return nil, fmt.Errorf("no source information for %s", method.ID)
+	}
+	expr := "func" + strings.TrimPrefix(strings.TrimSpace(signature), "func")
+	tv, err := types.Eval(fn.File.FileSet, fn.Package.TypesPackage, fn.Pos, expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %w", signature, err)
+	}
+	sig, ok := tv.Type.(*types.Signature)
+	if !ok || !tv.IsType() {
+		return nil, fmt.Errorf("invalid signature %q", signature)
+	}
+	return sig, nil
+}
+
+// interfaceMethod returns the method of an interface with the given name
+func interfaceMethod(iface *types.Interface, name string) *types.Func {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if m := iface.Method(i); m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package interfaceanalysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestWouldBreakImplementations tests finding implementations broken by a signature change
+func TestWouldBreakImplementations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/sink\n\ngo 1.21\n",
+		"sink.go": `package sink
+
+import "context"
+
+type Writer interface {
+	Write(p []byte) (int, error)
+}
+
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+type Base struct{}
+
+func (b *Base) Write(p []byte) (int, error)        { return len(p), nil }
+func (b *Base) Flush(ctx context.Context) error    { return nil }
+
+type Buffer struct{ *Base }
+
+type File struct{}
+
+func (f File) Write(p []byte) (int, error) { return 0, nil }
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	write, flush := "example.com/sink.Base.Write", "example.com/sink.Base.Flush"
+	symbol := func(id string) *module.Symbol {
+		for _, sym := range mod.Symbols() {
+			if sym.ID == id {
+				return sym
+			}
+		}
+		t.Fatalf("Symbol %s not found", id)
+		return nil
+	}
+
+	analyzer := NewAnalyzer()
+	ids := func(method, signature string) string {
+		broken, err := analyzer.WouldBreakImplementations(mod, symbol(method), signature)
+		if err != nil {
+			t.Fatalf("WouldBreakImplementations failed: %v", err)
+		}
+		var ids []string
+		for _, sym := range broken {
+			ids = append(ids, sym.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// Buffer implements Writer through the promoted method; File is unaffected
+	if got := ids(write, "(p []byte) error"); got != "example.com/sink.Base,example.com/sink.Buffer" {
+		t.Errorf("Unexpected broken implementations: %s", got)
+	}
+	// Renaming parameters keeps the signature identical
+	if got := ids(write, "func(data []byte) (n int, err error)"); got != "" {
+		t.Errorf("Expected no broken implementations, got %s", got)
+	}
+	// The signature may use the file's imports
+	if got := ids(flush, "(ctx context.Context, force bool) error"); got != "example.com/sink.Base,example.com/sink.Buffer" {
+		t.Errorf("Unexpected broken implementations for Flush: %s", got)
+	}
+
+	if _, err := analyzer.WouldBreakImplementations(mod, symbol(write), "(p []bytes) error"); err == nil {
+		t.Error("Expected an error for an invalid signature")
+	}
+}
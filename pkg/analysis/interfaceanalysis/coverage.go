@@ -0,0 +1,100 @@
+package interfaceanalysis
+
+import (
+	"go/ast"
+	"go/types"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// InterfaceCoverage reports, for each method of the interface type iface,
+// whether it is invoked through that interface anywhere in the module.
+//
+// This is a static approximation: a method counts as covered if any call
+// site, method value or method expression selects it on a value whose static
+// type is iface. Methods that are only ever called on concrete types are
+// reported as false, making them candidates for removal from the interface.
+// The module must be loaded with IncludeAST so type information is available.
+func (a *Analyzer) InterfaceCoverage(mod *module.Module, iface *module.Type) map[string]bool {
+	coverage := make(map[string]bool)
+	if mod == nil || iface == nil || iface.Kind != "interface" {
+		return coverage
+	}
+
+	named := lookupNamedInterface(iface)
+	if named == nil {
+		// Without type information fall back to the declared methods
+		for _, m := range iface.Interfaces {
+			if !m.IsEmbedded {
+				coverage[m.Name] = false
+			}
+		}
+		return coverage
+	}
+
+	// Seed with the full method set, including embedded interfaces
+	underlying := named.Underlying().(*types.Interface)
+	for i := 0; i < underlying.NumMethods(); i++ {
+		coverage[underlying.Method(i).Name()] = false
+	}
+
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil {
+				continue
+			}
+			ast.Inspect(file.AST, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				selection, ok := pkg.TypesInfo.Selections[sel]
+				if !ok || selection.Kind() == types.FieldVal {
+					return true
+				}
+				if sameNamed(selection.Recv(), named) {
+					if _, known := coverage[sel.Sel.Name]; known {
+						coverage[sel.Sel.Name] = true
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	return coverage
+}
+
+// lookupNamedInterface resolves a model type to its type-checked interface
+func lookupNamedInterface(iface *module.Type) *types.Named {
+	if iface.Package == nil || iface.Package.TypesPackage == nil {
+		return nil
+	}
+	obj, ok := iface.Package.TypesPackage.Scope().Lookup(iface.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Interface); !ok {
+		return nil
+	}
+	return named
+}
+
+// sameNamed reports whether t, ignoring a pointer, is the named type want
+func sameNamed(t types.Type, want *types.Named) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	return named.Obj() == want.Obj()
+}
@@ -0,0 +1,83 @@
+package interfaceanalysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+// TestInterfaceCoverage tests detecting which interface methods are called through the interface
+func TestInterfaceCoverage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/store\n\ngo 1.21\n",
+		"store.go": `package store
+
+type Closer interface {
+	Close() error
+}
+
+type Store interface {
+	Closer
+	Get(key string) string
+	Put(key, value string)
+	Delete(key string)
+}
+
+type memStore map[string]string
+
+func (m memStore) Get(key string) string { return m[key] }
+func (m memStore) Put(key, value string) { m[key] = value }
+func (m memStore) Delete(key string)     { delete(m, key) }
+func (m memStore) Close() error          { return nil }
+
+func Use(s Store) string {
+	defer s.Close()
+	put := s.Put
+	put("a", "b")
+	return s.Get("a")
+}
+
+func Concrete() {
+	m := memStore{}
+	m.Delete("a")
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	store := mod.Packages["example.com/store"].Types["Store"]
+	if store == nil {
+		t.Fatalf("Expected to find type Store")
+	}
+
+	coverage := NewAnalyzer().InterfaceCoverage(mod, store)
+
+	expected := map[string]bool{
+		"Close":  true,
+		"Get":    true,
+		"Put":    true,
+		"Delete": false,
+	}
+	if len(coverage) != len(expected) {
+		t.Errorf("Expected %d methods, got %d: %v", len(expected), len(coverage), coverage)
+	}
+	for name, want := range expected {
+		if got, ok := coverage[name]; !ok || got != want {
+			t.Errorf("Expected coverage[%s] = %v, got %v (present: %v)", name, want, got, ok)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package interfaceanalysis
+
+import (
+	"fmt"
+	"go/types"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// MissingMethod is a method of an interface that a type does not provide
+// with the required signature
+type MissingMethod struct {
+	// Name is the name of the method
+	Name string
+
+	// Want is the signature required by the interface
+	Want string
+
+	// Have is the signature of the type's method of that name, or empty if
+	// the type has none
+	Have string
+
+	// PointerReceiver is set if the method has the required signature but a
+	// pointer receiver, so only a pointer to the type provides it
+	PointerReceiver bool
+}
+
+// Implements reports whether the type concrete implements the interface
+// type iface, both type symbols of the module, and if not, which methods of
+// the interface are missing or have a different signature. Only the value
+// method set of concrete is considered: methods declared on its pointer are
+// reported with PointerReceiver set, so a pointer to the type implements the
+// interface if all missing methods are of that kind. The module must be
+// loaded with IncludeAST.
+func (a *Analyzer) Implements(mod *module.Module, concrete, iface *module.Symbol) (bool, []MissingMethod, error) {
+	typ, err := namedType(mod, concrete)
+	if err != nil {
+		return false, nil, err
+	}
+	ifaceType, err := namedType(mod, iface)
+	if err != nil {
+		return false, nil, err
+	}
+	underlying, ok := ifaceType.Underlying().(*types.Interface)
+	if !ok {
+		return false, nil, fmt.Errorf("%s is not an interface", iface.ID)
+	}
+	if types.Implements(typ, underlying) {
+		return true, nil, nil
+	}
+
+	qualifier := types.RelativeTo(typ.Obj().Pkg())
+	valueMethods := types.NewMethodSet(typ)
+	var missing []MissingMethod
+	// Methods are sorted by name, with embedded interfaces flattened
+	for i := 0; i < underlying.NumMethods(); i++ {
+		want := underlying.Method(i)
+		m := MissingMethod{Name: want.Name(), Want: types.TypeString(want.Type(), qualifier)}
+
+		obj, _, _ := types.LookupFieldOrMethod(typ, true, want.Pkg(), want.Name())
+		switch obj := obj.(type) {
+		case *types.Func:
+			m.Have = types.TypeString(obj.Type(), qualifier)
+			if !types.Identical(obj.Type(), want.Type()) {
+				missing = append(missing, m)
+			} else if valueMethods.Lookup(want.Pkg(), want.Name()) == nil {
+				m.PointerReceiver = true
+				missing = append(missing, m)
+			}
+		case *types.Var:
+			m.Have = "field " + types.TypeString(obj.Type(), qualifier)
+			missing = append(missing, m)
+		default:
+			missing = append(missing, m)
+		}
+	}
+	return false, missing, nil
+}
+
+// namedType returns the type-checked, non-generic named type of a type
+// symbol
+func namedType(mod *module.Module, sym *module.Symbol) (*types.Named, error) {
+	if sym.Kind != module.SymbolType {
+		return nil, fmt.Errorf("%s is not a type", sym.ID)
+	}
+	typeName, ok := mod.LookupObject(sym).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("no type information for %s, load with IncludeAST", sym.ID)
+	}
+	named, ok := typeName.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is an alias", sym.ID)
+	}
+	if named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%s is generic", sym.ID)
+	}
+	return named, nil
+}
@@ -0,0 +1,102 @@
+package interfaceanalysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestImplements tests checking whether a type implements an interface
+func TestImplements(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/store\n\ngo 1.21\n",
+		"store.go": `package store
+
+type Reader interface {
+	Get(key string) (string, error)
+}
+
+type Store interface {
+	Reader
+	Put(key, value string) error
+	Close() error
+}
+
+type Mem struct{}
+
+func (m Mem) Get(key string) (string, error) { return "", nil }
+func (m *Mem) Put(key, value string) error   { return nil }
+func (m Mem) Close() error                   { return nil }
+
+type Legacy struct {
+	Close func()
+}
+
+func (l Legacy) Get(key string) string { return "" }
+
+type Box[T any] struct{}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	symbol := func(name string) *module.Symbol {
+		for _, sym := range mod.Symbols() {
+			if sym.ID == "example.com/store."+name {
+				return sym
+			}
+		}
+		t.Fatalf("Symbol %s not found", name)
+		return nil
+	}
+
+	analyzer := NewAnalyzer()
+	tests := []struct {
+		concrete, iface string
+		want            bool
+		missing         []MissingMethod
+	}{
+		{"Mem", "Reader", true, nil},
+		{"Mem", "Store", false, []MissingMethod{
+			{Name: "Put", Want: "func(key string, value string) error", Have: "func(key string, value string) error", PointerReceiver: true},
+		}},
+		{"Legacy", "Store", false, []MissingMethod{
+			{Name: "Close", Want: "func() error", Have: "field func()"},
+			{Name: "Get", Want: "func(key string) (string, error)", Have: "func(key string) string"},
+			{Name: "Put", Want: "func(key string, value string) error"},
+		}},
+		{"Store", "Reader", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.concrete+" "+tt.iface, func(t *testing.T) {
+			ok, missing, err := analyzer.Implements(mod, symbol(tt.concrete), symbol(tt.iface))
+			if err != nil {
+				t.Fatalf("Implements failed: %v", err)
+			}
+			if ok != tt.want || !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("Expected %v %+v, got %v %+v", tt.want, tt.missing, ok, missing)
+			}
+		})
+	}
+
+	if _, _, err := analyzer.Implements(mod, symbol("Mem"), symbol("Legacy")); err == nil {
+		t.Error("Expected an error for a non-interface type")
+	}
+	if _, _, err := analyzer.Implements(mod, symbol("Box"), symbol("Reader")); err == nil {
+		t.Error("Expected an error for a generic type")
+	}
+}
@@ -0,0 +1,137 @@
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// ContextNotPropagatedRule flags fresh contexts created where one is available
+var ContextNotPropagatedRule = &Rule{
+	ID:          "GT1001",
+	Name:        "context-not-propagated",
+	Description: "Functions that receive a context.Context should pass it on instead of context.Background() or context.TODO()",
+	Severity:    SeverityWarning,
+}
+
+// ContextInStructRule flags contexts stored in struct fields
+var ContextInStructRule = &Rule{
+	ID:          "GT1002",
+	Name:        "context-in-struct",
+	Description: "A context.Context should be passed as a parameter, not stored in a struct field",
+	Severity:    SeverityWarning,
+}
+
+// FindContextMisuse reports functions that receive a context.Context but
+// pass context.Background() or context.TODO() to a call instead, and struct
+// fields of type context.Context. The module must be loaded with IncludeAST.
+func FindContextMisuse(mod *module.Module) []Finding {
+	var findings []Finding
+
+	forEachFile(mod, func(pkg *module.Package, file *module.File) {
+		info := pkg.TypesInfo
+		for _, decl := range file.AST.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Body == nil || !hasContextParam(info, d.Type) {
+					continue
+				}
+				ast.Inspect(d.Body, func(n ast.Node) bool {
+					// A function literal may legitimately detach from the parent context
+					if _, ok := n.(*ast.FuncLit); ok {
+						return false
+					}
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					for _, arg := range call.Args {
+						if name := freshContextCall(info, arg); name != "" {
+							findings = append(findings, Finding{
+								Rule: ContextNotPropagatedRule,
+								Message: fmt.Sprintf("%s receives a context but passes context.%s() to %s",
+									module.FuncDeclName(d), name, types.ExprString(call.Fun)),
+								Position: file.GetPositionInfo(arg.Pos(), arg.End()),
+								Symbol:   module.FuncDeclName(d),
+							})
+						}
+					}
+					return true
+				})
+
+			case *ast.GenDecl:
+				ast.Inspect(d, func(n ast.Node) bool {
+					spec, ok := n.(*ast.TypeSpec)
+					if !ok {
+						return true
+					}
+					structType, ok := spec.Type.(*ast.StructType)
+					if !ok {
+						return true
+					}
+					for _, field := range structType.Fields.List {
+						if !isContextType(info.TypeOf(field.Type)) {
+							continue
+						}
+						findings = append(findings, Finding{
+							Rule:     ContextInStructRule,
+							Message:  fmt.Sprintf("struct %s stores a context.Context", spec.Name.Name),
+							Position: file.GetPositionInfo(field.Pos(), field.End()),
+							Symbol:   spec.Name.Name,
+						})
+					}
+					return true
+				})
+			}
+		}
+	})
+
+	SortFindings(findings)
+	return findings
+}
+
+// hasContextParam reports whether a function type has a context.Context parameter
+func hasContextParam(info *types.Info, fn *ast.FuncType) bool {
+	if fn.Params == nil {
+		return false
+	}
+	for _, param := range fn.Params.List {
+		if isContextType(info.TypeOf(param.Type)) {
+			return true
+		}
+	}
+	return false
+}
+
+// freshContextCall returns "Background" or "TODO" if expr calls that
+// function of the context package, or an empty string otherwise
+func freshContextCall(info *types.Info, expr ast.Expr) string {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "context" {
+		return ""
+	}
+	if fn.Name() == "Background" || fn.Name() == "TODO" {
+		return fn.Name()
+	}
+	return ""
+}
+
+// isContextType reports whether t is context.Context
+func isContextType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
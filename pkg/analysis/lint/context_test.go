@@ -0,0 +1,48 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindContextMisuse(t *testing.T) {
+	mod := loadSource(t, `package sample
+
+import "context"
+
+type Server struct {
+	ctx  context.Context
+	name string
+}
+
+func fetch(ctx context.Context, id string) error { return ctx.Err() }
+
+func Handle(ctx context.Context, id string) error {
+	if err := fetch(context.Background(), id); err != nil {
+		return err
+	}
+	go func() {
+		_ = fetch(context.Background(), id)
+	}()
+	return fetch(ctx, id)
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	return fetch(context.TODO(), s.name)
+}
+
+func Main() error {
+	return fetch(context.Background(), "root")
+}
+`)
+
+	findings := FindContextMisuse(mod)
+
+	expected := "GT1002 Server:6,GT1001 Handle:13,GT1001 Server.Run:23"
+	if got := strings.Join(findingLines(findings), ","); got != expected {
+		t.Errorf("Expected findings %s, got %s", expected, got)
+	}
+	if len(findings) > 0 && !strings.Contains(findings[1].Message, "context.Background() to fetch") {
+		t.Errorf("Unexpected message: %s", findings[1].Message)
+	}
+}
@@ -0,0 +1,108 @@
+package lint
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// MissingDocRule flags exported symbols without a doc comment
+var MissingDocRule = &Rule{
+	ID:          "GT1005",
+	Name:        "missing-doc",
+	Description: "Exported symbols should have a doc comment",
+	Severity:    SeverityWarning,
+}
+
+// DocPrefixRule flags doc comments that do not start with the symbol's name
+var DocPrefixRule = &Rule{
+	ID:          "GT1006",
+	Name:        "doc-prefix",
+	Description: "The doc comment of an exported symbol should start with its name",
+	Severity:    SeverityInfo,
+}
+
+// FindUndocumentedExports reports exported functions, types, methods,
+// variables and constants outside test files that have no doc comment
+// (GT1005), and, if checkPrefix is set, those whose doc comment does not
+// start with the symbol's name, optionally preceded by "A", "An" or "The"
+// (GT1006). Methods of unexported types are not part of the API and are
+// skipped. Variables and constants may be documented by their group. The
+// module must be loaded with LoadDocs.
+func FindUndocumentedExports(mod *module.Module, checkPrefix bool) []Finding {
+	var findings []Finding
+
+	for _, sym := range mod.SymbolsIn(module.ScopeProduction) {
+		if !token.IsExported(sym.Name) {
+			continue
+		}
+
+		var doc, qualified string
+		var position *module.Position
+		var grouped bool
+		switch element := sym.Element.(type) {
+		case *module.Function:
+			doc, qualified, position = element.Doc, sym.Name, element.GetPosition()
+			if element.Receiver != nil {
+				recv := strings.TrimPrefix(element.Receiver.Type, "*")
+				if i := strings.IndexByte(recv, '['); i >= 0 {
+					recv = recv[:i]
+				}
+				if !token.IsExported(recv) {
+					continue
+				}
+				qualified = recv + "." + sym.Name
+			}
+		case *module.Type:
+			doc, qualified, position = element.Doc, sym.Name, element.GetPosition()
+		case *module.Variable:
+			doc, qualified, position, grouped = element.Doc, sym.Name, element.GetPosition(), true
+		case *module.Constant:
+			doc, qualified, position, grouped = element.Doc, sym.Name, element.GetPosition(), true
+		default:
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(doc) == "":
+			findings = append(findings, Finding{
+				Rule:     MissingDocRule,
+				Message:  fmt.Sprintf("exported %s %s has no doc comment", sym.Kind, qualified),
+				Position: position,
+				Symbol:   qualified,
+			})
+		case checkPrefix && !grouped && !docStartsWith(doc, sym.Name):
+			findings = append(findings, Finding{
+				Rule:     DocPrefixRule,
+				Message:  fmt.Sprintf("doc comment of %s should start with %q", qualified, sym.Name),
+				Position: position,
+				Symbol:   qualified,
+			})
+		}
+	}
+
+	SortFindings(findings)
+	return findings
+}
+
+// docStartsWith reports whether a doc comment starts with the name, allowing
+// a leading article
+func docStartsWith(doc, name string) bool {
+	doc = strings.TrimSpace(doc)
+	for _, article := range []string{"A ", "An ", "The "} {
+		doc = strings.TrimPrefix(doc, article)
+	}
+	if !strings.HasPrefix(doc, name) {
+		return false
+	}
+	rest := doc[len(name):]
+	return rest == "" || !isIdentRune(rune(rest[0]))
+}
+
+// isIdentRune reports whether r may continue an identifier
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
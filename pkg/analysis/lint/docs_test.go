@@ -0,0 +1,63 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindUndocumentedExports(t *testing.T) {
+	mod := loadSource(t, `package sample
+
+// Client talks to the server
+type Client struct{}
+
+func (c *Client) Close() error { return nil }
+
+// Opens a connection
+func (c *Client) Open() error { return nil }
+
+type hidden struct{}
+
+func (h hidden) Exported() {}
+
+func Run() {}
+
+// A Server serves requests
+type Server struct{}
+
+// Limits used by the client
+const (
+	MaxRetries = 3
+	MaxConns   = 10
+)
+
+var (
+	// Timeout is the default timeout in seconds
+	Timeout = 30
+	Verbose = false
+)
+
+func helper() {}
+
+// Messages of the protocol
+type (
+	// Request is sent by the client
+	Request struct{}
+)
+`)
+
+	findings := FindUndocumentedExports(mod, true)
+
+	expected := "GT1005 Client.Close:6,GT1006 Client.Open:9,GT1005 Run:15,GT1005 Verbose:29"
+	if got := strings.Join(findingLines(findings), ","); got != expected {
+		t.Fatalf("Expected findings %s, got %s", expected, got)
+	}
+	if want := "exported method Client.Close has no doc comment"; findings[0].Message != want {
+		t.Errorf("Expected message %q, got %q", want, findings[0].Message)
+	}
+
+	// Without the prefix check only missing docs are reported
+	if got := strings.Join(findingLines(FindUndocumentedExports(mod, false)), ","); got != "GT1005 Client.Close:6,GT1005 Run:15,GT1005 Verbose:29" {
+		t.Errorf("Unexpected findings without the prefix check: %s", got)
+	}
+}
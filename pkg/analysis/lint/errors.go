@@ -0,0 +1,247 @@
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// IgnoredErrorRule flags errors that are discarded or never checked
+var IgnoredErrorRule = &Rule{
+	ID:          "GT1007",
+	Name:        "ignored-error",
+	Description: "Errors returned by calls should be checked, not discarded or overwritten",
+	Severity:    SeverityWarning,
+}
+
+// DefaultAllowedErrorCalls lists calls whose errors are commonly ignored on
+// purpose, by the full name of the function as reported by types.Func
+var DefaultAllowedErrorCalls = []string{
+	"fmt.Print", "fmt.Printf", "fmt.Println",
+	"fmt.Fprint", "fmt.Fprintf", "fmt.Fprintln",
+	"(*strings.Builder).Write", "(*strings.Builder).WriteByte",
+	"(*strings.Builder).WriteRune", "(*strings.Builder).WriteString",
+	"(*bytes.Buffer).Write", "(*bytes.Buffer).WriteByte",
+	"(*bytes.Buffer).WriteRune", "(*bytes.Buffer).WriteString",
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// FindIgnoredErrors reports calls returning an error that is dropped: calls
+// used as statements, including go and defer statements, errors assigned to
+// the blank identifier, and errors assigned to a local variable that the
+// next statement of the block does not use. Calls of the functions in
+// allowed, given by full name such as "fmt.Fprintf" or
+// "(*bytes.Buffer).WriteString", are not reported; DefaultAllowedErrorCalls
+// is a sensible default. The module must be loaded with IncludeAST.
+func FindIgnoredErrors(mod *module.Module, allowed []string) []Finding {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+
+	var findings []Finding
+	forEachFile(mod, func(pkg *module.Package, file *module.File) {
+		info := pkg.TypesInfo
+		for _, decl := range file.AST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			symbol := module.FuncDeclName(fn)
+			report := func(node ast.Node, format string, args ...interface{}) {
+				findings = append(findings, Finding{
+					Rule:     IgnoredErrorRule,
+					Message:  fmt.Sprintf(format, args...),
+					Position: file.GetPositionInfo(node.Pos(), node.End()),
+					Symbol:   symbol,
+				})
+			}
+			// errorCall returns the call of expr, its function's name and the
+			// indexes of its error results, unless there are none or the
+			// function is allowed
+			errorCall := func(expr ast.Expr) (*ast.CallExpr, string, []int) {
+				call, ok := ast.Unparen(expr).(*ast.CallExpr)
+				if !ok {
+					return nil, "", nil
+				}
+				results := errorResults(info, call)
+				if len(results) == 0 {
+					return nil, "", nil
+				}
+				name := calleeName(info, call)
+				if allowedSet[name] {
+					return nil, "", nil
+				}
+				return call, name, results
+			}
+
+			results := make(map[types.Object]bool)
+			ast.Inspect(fn, func(n ast.Node) bool {
+				switch node := n.(type) {
+				case *ast.FuncType:
+					if node.Results != nil {
+						for _, field := range node.Results.List {
+							for _, name := range field.Names {
+								results[info.Defs[name]] = true
+							}
+						}
+					}
+				case *ast.ExprStmt:
+					if call, name, _ := errorCall(node.X); call != nil {
+						report(call, "error returned by %s is not checked", name)
+					}
+				case *ast.GoStmt:
+					if call, name, _ := errorCall(node.Call); call != nil {
+						report(call, "error returned by %s is not checked in go statement", name)
+					}
+				case *ast.DeferStmt:
+					if call, name, _ := errorCall(node.Call); call != nil {
+						report(call, "error returned by %s is not checked in defer statement", name)
+					}
+				case *ast.AssignStmt:
+					if len(node.Rhs) != 1 {
+						return true
+					}
+					call, name, errIndexes := errorCall(node.Rhs[0])
+					if call == nil {
+						return true
+					}
+					for _, i := range errIndexes {
+						if i < len(node.Lhs) && isBlank(node.Lhs[i]) {
+							report(node.Lhs[i], "error returned by %s is assigned to _", name)
+						}
+					}
+				}
+				if list := stmtList(n); list != nil {
+					for i := 0; i+1 < len(list); i++ {
+						obj, call, name := assignedError(info, list[i], errorCall)
+						if obj == nil || results[obj] || obj.Parent() == obj.Pkg().Scope() {
+							continue
+						}
+						if !usesObject(info, list[i+1], obj) {
+							report(call, "error returned by %s is assigned to %s but not checked", name, obj.Name())
+						}
+					}
+				}
+				return true
+			})
+		}
+	})
+
+	SortFindings(findings)
+	return findings
+}
+
+// errorResults returns the indexes of a call's results of type error
+func errorResults(info *types.Info, call *ast.CallExpr) []int {
+	if tv, ok := info.Types[call.Fun]; ok && tv.IsType() {
+		return nil
+	}
+	var results []int
+	switch t := info.TypeOf(call).(type) {
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if types.Identical(t.At(i).Type(), errorType) {
+				results = append(results, i)
+			}
+		}
+	case nil:
+	default:
+		if types.Identical(t, errorType) {
+			results = append(results, 0)
+		}
+	}
+	return results
+}
+
+// calleeName returns the full name of the function a call invokes, or the
+// call's function expression if it is not a declared function
+func calleeName(info *types.Info, call *ast.CallExpr) string {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	}
+	if ident != nil {
+		if fn, ok := info.Uses[ident].(*types.Func); ok {
+			return fn.FullName()
+		}
+	}
+	return types.ExprString(call.Fun)
+}
+
+// assignedError returns the variable a statement assigns the error of a
+// call to, along with the call and its name
+func assignedError(info *types.Info, stmt ast.Stmt, errorCall func(ast.Expr) (*ast.CallExpr, string, []int)) (*types.Var, *ast.CallExpr, string) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 || (assign.Tok != token.ASSIGN && assign.Tok != token.DEFINE) {
+		return nil, nil, ""
+	}
+	call, name, errIndexes := errorCall(assign.Rhs[0])
+	if call == nil {
+		return nil, nil, ""
+	}
+	i := errIndexes[len(errIndexes)-1]
+	if i >= len(assign.Lhs) {
+		return nil, nil, ""
+	}
+	ident, ok := assign.Lhs[i].(*ast.Ident)
+	if !ok || ident.Name == "_" {
+		return nil, nil, ""
+	}
+	v, ok := info.ObjectOf(ident).(*types.Var)
+	if !ok {
+		return nil, nil, ""
+	}
+	return v, call, name
+}
+
+// stmtList returns the statements of a block, case clause or select clause
+func stmtList(n ast.Node) []ast.Stmt {
+	switch node := n.(type) {
+	case *ast.BlockStmt:
+		return node.List
+	case *ast.CaseClause:
+		return node.Body
+	case *ast.CommClause:
+		return node.Body
+	}
+	return nil
+}
+
+// usesObject reports whether a node reads obj; assigning to it does not count
+func usesObject(info *types.Info, node ast.Node, obj types.Object) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if _, ok := lhs.(*ast.Ident); !ok {
+					found = found || usesObject(info, lhs, obj)
+				}
+			}
+			for _, rhs := range n.Rhs {
+				found = found || usesObject(info, rhs, obj)
+			}
+			return false
+		case *ast.Ident:
+			if info.Uses[n] == obj {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isBlank reports whether expr is the blank identifier
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
@@ -0,0 +1,74 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindIgnoredErrors(t *testing.T) {
+	mod := loadSource(t, `package sample
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func Parse(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func Write(name string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "hello\n")
+	f.Sync()
+	_, err = f.WriteString("x")
+	return
+}
+
+func Overwrite() error {
+	err := os.Remove("a")
+	err = os.Remove("b")
+	if err != nil {
+		return err
+	}
+	go func() {
+		_ = os.Remove("c")
+	}()
+	return nil
+}
+
+func Checked(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+`)
+
+	findings := FindIgnoredErrors(mod, DefaultAllowedErrorCalls)
+
+	expected := "GT1007 Parse:10,GT1007 Write:19,GT1007 Write:21,GT1007 Overwrite:27,GT1007 Overwrite:33"
+	if got := strings.Join(findingLines(findings), ","); got != expected {
+		t.Errorf("Expected findings %s, got %s", expected, got)
+	}
+	if len(findings) == 5 {
+		if msg := findings[3].Message; msg != "error returned by os.Remove is assigned to err but not checked" {
+			t.Errorf("Unexpected message: %s", msg)
+		}
+		if msg := findings[1].Message; !strings.Contains(msg, "(*os.File).Close") {
+			t.Errorf("Unexpected message: %s", msg)
+		}
+	}
+
+	// Without an allow list fmt.Fprintf is reported too
+	if got := len(FindIgnoredErrors(mod, nil)); got != 6 {
+		t.Errorf("Expected 6 findings without allowed calls, got %d", got)
+	}
+}
@@ -0,0 +1,225 @@
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// MutableGlobalRule flags package-level variables written from several functions
+var MutableGlobalRule = &Rule{
+	ID:          "GT1003",
+	Name:        "mutable-global",
+	Description: "Package-level variables written from more than one function are a common source of data races",
+	Severity:    SeverityWarning,
+}
+
+// writeSite is a statement writing a package-level variable
+type writeSite struct {
+	function string
+	position token.Position
+}
+
+// FindMutableGlobals reports package-level variables that are written from
+// more than one function of the module, listing the write sites. Writes in
+// init functions and test files are ignored, and variables declared in the
+// same var block as a sync.Mutex or sync.RWMutex, or that contain one, are
+// assumed to be protected. The module must be loaded with IncludeAST.
+func FindMutableGlobals(mod *module.Module) []Finding {
+	writes := make(map[*types.Var][]writeSite)
+
+	forEachFile(mod, func(pkg *module.Package, file *module.File) {
+		if file.IsTest {
+			return
+		}
+		for _, decl := range file.AST.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil || (fd.Recv == nil && fd.Name.Name == "init") {
+				continue
+			}
+			record := func(expr ast.Expr) {
+				if v := writtenGlobal(pkg.TypesInfo, expr); v != nil {
+					writes[v] = append(writes[v], writeSite{
+						function: module.FuncDeclName(fd),
+						position: file.FileSet.Position(expr.Pos()),
+					})
+				}
+			}
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				switch stmt := n.(type) {
+				case *ast.AssignStmt:
+					for _, lhs := range stmt.Lhs {
+						record(lhs)
+					}
+				case *ast.IncDecStmt:
+					record(stmt.X)
+				case *ast.RangeStmt:
+					if stmt.Tok == token.ASSIGN {
+						if stmt.Key != nil {
+							record(stmt.Key)
+						}
+						if stmt.Value != nil {
+							record(stmt.Value)
+						}
+					}
+				}
+				return true
+			})
+		}
+	})
+
+	var findings []Finding
+	for v, sites := range writes {
+		functions := make(map[string]bool)
+		for _, site := range sites {
+			functions[site.function] = true
+		}
+		if len(functions) < 2 || isMutexProtected(mod, v) {
+			continue
+		}
+
+		pkg := mod.Packages[v.Pkg().Path()]
+		if pkg == nil {
+			continue
+		}
+		var position *module.Position
+		if variable := pkg.Variables[v.Name()]; variable != nil {
+			position = variable.GetPosition()
+		}
+
+		sort.Slice(sites, func(i, j int) bool {
+			if sites[i].position.Filename != sites[j].position.Filename {
+				return sites[i].position.Filename < sites[j].position.Filename
+			}
+			return sites[i].position.Offset < sites[j].position.Offset
+		})
+		descriptions := make([]string, 0, len(sites))
+		for _, site := range sites {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s:%d)",
+				site.function, filepath.Base(site.position.Filename), site.position.Line))
+		}
+
+		findings = append(findings, Finding{
+			Rule: MutableGlobalRule,
+			Message: fmt.Sprintf("package-level variable %s is written by %d functions: %s",
+				v.Name(), len(functions), strings.Join(descriptions, ", ")),
+			Position: position,
+			Symbol:   v.Name(),
+		})
+	}
+
+	SortFindings(findings)
+	return findings
+}
+
+// writtenGlobal returns the package-level variable of the module whose
+// value or contents an assignment to expr modifies
+func writtenGlobal(info *types.Info, expr ast.Expr) *types.Var {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return packageVar(info.Uses[e])
+		case *ast.SelectorExpr:
+			// A qualified identifier refers to another package's variable
+			if v := packageVar(info.Uses[e.Sel]); v != nil {
+				return v
+			}
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// packageVar returns obj if it is a package-level variable
+func packageVar(obj types.Object) *types.Var {
+	v, ok := obj.(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return nil
+	}
+	return v
+}
+
+// isMutexProtected reports whether a variable contains a mutex or is
+// declared in the same var block as one
+func isMutexProtected(mod *module.Module, v *types.Var) bool {
+	if containsMutex(v.Type()) {
+		return true
+	}
+
+	pkg := mod.Packages[v.Pkg().Path()]
+	if pkg == nil || pkg.TypesInfo == nil {
+		return false
+	}
+	for _, file := range pkg.Files {
+		if file.AST == nil {
+			continue
+		}
+		for _, decl := range file.AST.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			declaresVar, declaresMutex := false, false
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					obj := pkg.TypesInfo.Defs[name]
+					if obj == v {
+						declaresVar = true
+					} else if obj != nil && isMutex(obj.Type()) {
+						declaresMutex = true
+					}
+				}
+			}
+			if declaresVar {
+				return declaresMutex
+			}
+		}
+	}
+	return false
+}
+
+// containsMutex reports whether t is a mutex or a struct with a mutex field
+func containsMutex(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if isMutex(t) {
+		return true
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if isMutex(st.Field(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMutex reports whether t is sync.Mutex, sync.RWMutex or a pointer to one
+func isMutex(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "sync" && (obj.Name() == "Mutex" || obj.Name() == "RWMutex")
+}
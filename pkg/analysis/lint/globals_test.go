@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindMutableGlobals(t *testing.T) {
+	mod := loadSource(t, `package sample
+
+import "sync"
+
+var counter int
+
+var config = map[string]string{}
+
+var readOnly = []string{"a"}
+
+var (
+	mu    sync.Mutex
+	cache = map[string]int{}
+)
+
+var registry struct {
+	sync.Mutex
+	items []string
+}
+
+func init() {
+	counter = 1
+	readOnly = append(readOnly, "b")
+}
+
+func Increment() { counter++ }
+
+func Reset() { counter = 0 }
+
+func Set(key, value string) { config[key] = value }
+
+func Only() { readOnly[0] = "c" }
+
+func Store(key string, n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	cache[key] = n
+}
+
+func Clear() {
+	mu.Lock()
+	defer mu.Unlock()
+	cache = map[string]int{}
+}
+
+func Register(item string) { registry.items = append(registry.items, item) }
+
+func Drop() { registry.items = nil }
+
+type Loader struct{}
+
+func (l *Loader) Load() { config = map[string]string{} }
+`)
+
+	findings := FindMutableGlobals(mod)
+
+	expected := "GT1003 counter:5,GT1003 config:7"
+	if got := strings.Join(findingLines(findings), ","); got != expected {
+		t.Fatalf("Expected findings %s, got %s", expected, got)
+	}
+	want := "package-level variable counter is written by 2 functions: Increment (sample.go:26), Reset (sample.go:28)"
+	if findings[0].Message != want {
+		t.Errorf("Expected message %q, got %q", want, findings[0].Message)
+	}
+	if !strings.Contains(findings[1].Message, "Loader.Load (sample.go:") {
+		t.Errorf("Expected method write site, got %q", findings[1].Message)
+	}
+}
@@ -0,0 +1,105 @@
+// Package lint defines structured analysis findings and report formats for them.
+package lint
+
+import (
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// Severity indicates how serious a finding is
+type Severity string
+
+const (
+	// SeverityError marks findings that are very likely bugs
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks findings that should usually be fixed
+	SeverityWarning Severity = "warning"
+
+	// SeverityInfo marks findings that are informational only
+	SeverityInfo Severity = "info"
+)
+
+// Rule describes a check that produces findings
+type Rule struct {
+	ID          string   // Stable identifier, e.g. "GT001"
+	Name        string   // Short name, e.g. "ignored-error"
+	Description string   // One-sentence description of what the rule checks
+	Severity    Severity // Default severity of findings for this rule
+	HelpURI     string   // Optional link to further documentation
+}
+
+// Finding is a single issue reported by an analysis
+type Finding struct {
+	Rule     *Rule            // Rule that produced this finding
+	Severity Severity         // Severity (defaults to the rule's severity if empty)
+	Message  string           // Human-readable description of the issue
+	Position *module.Position // Location of the issue, if known
+	Symbol   string           // Name of the enclosing symbol, if known
+}
+
+// EffectiveSeverity returns the finding's severity, falling back to its rule
+func (f Finding) EffectiveSeverity() Severity {
+	if f.Severity != "" {
+		return f.Severity
+	}
+	if f.Rule != nil && f.Rule.Severity != "" {
+		return f.Rule.Severity
+	}
+	return SeverityWarning
+}
+
+// SortFindings orders findings by file, line, column and rule
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		fi, li, ci := location(findings[i])
+		fj, lj, cj := location(findings[j])
+		if fi != fj {
+			return fi < fj
+		}
+		if li != lj {
+			return li < lj
+		}
+		if ci != cj {
+			return ci < cj
+		}
+		return ruleID(findings[i]) < ruleID(findings[j])
+	})
+}
+
+// location returns the file path, line and column of a finding
+func location(f Finding) (string, int, int) {
+	if f.Position == nil {
+		return "", 0, 0
+	}
+	path := ""
+	if f.Position.File != nil {
+		path = f.Position.File.Path
+	}
+	return path, f.Position.LineStart, f.Position.ColStart
+}
+
+// ruleID returns the ID of the finding's rule, or an empty string
+func ruleID(f Finding) string {
+	if f.Rule == nil {
+		return ""
+	}
+	return f.Rule.ID
+}
+
+// forEachFile calls fn for every file with syntax and type information,
+// which requires the module to be loaded with IncludeAST
+func forEachFile(mod *module.Module, fn func(pkg *module.Package, file *module.File)) {
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil || file.FileSet == nil {
+				continue
+			}
+			fn(pkg, file)
+		}
+	}
+}
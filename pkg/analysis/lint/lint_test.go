@@ -0,0 +1,49 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// loadSource writes a single-file module with the given source and loads it
+// with the syntax and type information the checks need
+func loadSource(t *testing.T, source string) *module.Module {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module example.com/lint\n\ngo 1.21\n",
+		"sample.go": source,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	return mod
+}
+
+// findingLines returns the symbol and line of each finding
+func findingLines(findings []Finding) []string {
+	var lines []string
+	for _, f := range findings {
+		line := 0
+		if f.Position != nil {
+			line = f.Position.LineStart
+		}
+		lines = append(lines, f.Rule.ID+" "+f.Symbol+":"+strconv.Itoa(line))
+	}
+	return lines
+}
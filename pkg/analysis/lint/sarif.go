@@ -0,0 +1,242 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "go-tree"
+)
+
+// SARIF document structure (the subset of SARIF 2.1.0 that we emit)
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifact `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult            `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     *sarifMessage      `json:"shortDescription,omitempty"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration *sarifRuleDefaults `json:"defaultConfiguration,omitempty"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	LogicalLocations []sarifLogical        `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// sarifRootID is the URI base of files in the module directory
+const sarifRootID = "%SRCROOT%"
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifLogical struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log to w. Each distinct
+// rule is listed once in the tool's rule table and results refer to it by
+// index. Files in the directory of the findings' module are emitted as URIs
+// relative to the %SRCROOT% base, which the run maps to that directory, and
+// relative file paths as relative URIs, so consumers such as code scanning
+// can resolve them against the repository root. Other files get absolute
+// file:// URIs.
+func WriteSARIF(findings []Finding, w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:  toolName,
+			Rules: []sarifRule{},
+		}},
+		Results: make([]sarifResult, 0, len(findings)),
+	}
+
+	root := sarifRoot(findings)
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifact{sarifRootID: {URI: fileURI(root) + "/"}}
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, f := range findings {
+		id := ruleID(f)
+		if id == "" {
+			return fmt.Errorf("finding %q has no rule", f.Message)
+		}
+
+		index, ok := ruleIndex[id]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[id] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(f.Rule))
+		}
+
+		result := sarifResult{
+			RuleID:    id,
+			RuleIndex: index,
+			Level:     sarifLevel(f.EffectiveSeverity()),
+			Message:   sarifMessage{Text: f.Message},
+		}
+		if loc := newSARIFLocation(f, root); loc != nil {
+			result.Locations = []sarifLocation{*loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(log); err != nil {
+		return fmt.Errorf("failed to write SARIF report: %w", err)
+	}
+	return nil
+}
+
+// newSARIFRule converts a rule to its SARIF descriptor
+func newSARIFRule(rule *Rule) sarifRule {
+	r := sarifRule{
+		ID:      rule.ID,
+		Name:    rule.Name,
+		HelpURI: rule.HelpURI,
+	}
+	if rule.Description != "" {
+		r.ShortDescription = &sarifMessage{Text: rule.Description}
+	}
+	if rule.Severity != "" {
+		r.DefaultConfiguration = &sarifRuleDefaults{Level: sarifLevel(rule.Severity)}
+	}
+	return r
+}
+
+// sarifRoot returns the absolute directory of the module of the first
+// finding whose file belongs to one, or an empty string
+func sarifRoot(findings []Finding) string {
+	for _, f := range findings {
+		if f.Position == nil || f.Position.File == nil || f.Position.File.Package == nil {
+			continue
+		}
+		if mod := f.Position.File.Package.Module; mod != nil && mod.Dir != "" {
+			if root, err := filepath.Abs(mod.Dir); err == nil {
+				return root
+			}
+		}
+	}
+	return ""
+}
+
+// newSARIFLocation converts a finding's position to a SARIF location, with
+// files below root relative to it
+func newSARIFLocation(f Finding, root string) *sarifLocation {
+	if f.Position == nil || f.Position.File == nil || f.Position.File.Path == "" {
+		return nil
+	}
+
+	artifact := sarifArtifact{URI: fileURI(f.Position.File.Path)}
+	if path := f.Position.File.Path; root != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			artifact = sarifArtifact{URI: (&url.URL{Path: filepath.ToSlash(rel)}).String(), URIBaseID: sarifRootID}
+		}
+	}
+	loc := &sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact},
+	}
+
+	if f.Position.LineStart > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   f.Position.LineStart,
+			StartColumn: f.Position.ColStart,
+			EndLine:     f.Position.LineEnd,
+			EndColumn:   f.Position.ColEnd,
+		}
+	}
+
+	if f.Symbol != "" {
+		loc.LogicalLocations = []sarifLogical{{FullyQualifiedName: f.Symbol}}
+	}
+
+	return loc
+}
+
+// fileURI converts a file path to a relative or file:// URI
+func fileURI(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !filepath.IsAbs(path) {
+		return strings.TrimPrefix(slashed, "./")
+	}
+	if !strings.HasPrefix(slashed, "/") {
+		// Windows drive paths need a leading slash in URIs
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String()
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,123 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestWriteSARIF tests mapping findings to a SARIF 2.1.0 document
+func TestWriteSARIF(t *testing.T) {
+	ignored := &Rule{ID: "GT001", Name: "ignored-error", Description: "Errors must be handled", Severity: SeverityError}
+	docs := &Rule{ID: "GT002", Name: "undocumented", Description: "Exported symbols need docs", Severity: SeverityInfo}
+
+	file := module.NewFile("pkg/store/store.go", "store.go", false)
+	findings := []Finding{
+		{Rule: ignored, Message: "error from Close is ignored", Symbol: "store.Save",
+			Position: &module.Position{File: file, LineStart: 12, ColStart: 2, LineEnd: 12, ColEnd: 15}},
+		{Rule: docs, Message: "Save is undocumented",
+			Position: &module.Position{File: file, LineStart: 10, ColStart: 1}},
+		{Rule: ignored, Severity: SeverityWarning, Message: "error from Flush is ignored"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(findings, &buf); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected one SARIF 2.1.0 run, got version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("Expected 2 distinct rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	if run.Tool.Driver.Rules[1].ID != "GT002" || run.Tool.Driver.Rules[1].DefaultConfiguration.Level != "note" {
+		t.Errorf("Unexpected second rule: %+v", run.Tool.Driver.Rules[1])
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(run.Results))
+	}
+
+	first := run.Results[0]
+	if first.RuleID != "GT001" || first.RuleIndex != 0 || first.Level != "error" {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+	if len(first.Locations) != 1 {
+		t.Fatalf("Expected first result to have a location")
+	}
+	loc := first.Locations[0]
+	if loc.PhysicalLocation.ArtifactLocation.URI != "pkg/store/store.go" {
+		t.Errorf("Expected relative URI, got %q", loc.PhysicalLocation.ArtifactLocation.URI)
+	}
+	if loc.PhysicalLocation.Region.StartLine != 12 || loc.PhysicalLocation.Region.EndColumn != 15 {
+		t.Errorf("Unexpected region: %+v", loc.PhysicalLocation.Region)
+	}
+	if len(loc.LogicalLocations) != 1 || loc.LogicalLocations[0].FullyQualifiedName != "store.Save" {
+		t.Errorf("Expected logical location store.Save, got %+v", loc.LogicalLocations)
+	}
+
+	// Explicit severity overrides the rule default; no position means no location
+	last := run.Results[2]
+	if last.Level != "warning" || last.RuleIndex != 0 || len(last.Locations) != 0 {
+		t.Errorf("Unexpected last result: %+v", last)
+	}
+}
+
+// TestWriteSARIF_ModuleRelative tests that files of the module are emitted
+// relative to the source root
+func TestWriteSARIF_ModuleRelative(t *testing.T) {
+	rule := &Rule{ID: "GT001", Name: "ignored-error"}
+	root := t.TempDir()
+	mod := module.NewModule("example.com/app", root)
+	pkg := module.NewPackage("store", "example.com/app/store", filepath.Join(root, "store"))
+	mod.AddPackage(pkg)
+	file := module.NewFile(filepath.Join(root, "store", "my store.go"), "my store.go", false)
+	pkg.AddFile(file)
+	outside := module.NewFile(filepath.Join(filepath.Dir(root), "other.go"), "other.go", false)
+
+	findings := []Finding{
+		{Rule: rule, Message: "in the module", Position: &module.Position{File: file, LineStart: 1}},
+		{Rule: rule, Message: "outside the module", Position: &module.Position{File: outside, LineStart: 1}},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(findings, &buf); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	run := log.Runs[0]
+	if base := run.OriginalURIBaseIDs["%SRCROOT%"].URI; base != fileURI(root)+"/" {
+		t.Errorf("Expected the module directory as source root, got %q", base)
+	}
+	inside := run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation
+	if inside.URI != "store/my%20store.go" || inside.URIBaseID != "%SRCROOT%" {
+		t.Errorf("Expected a URI relative to the source root, got %+v", inside)
+	}
+	other := run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation
+	if !strings.HasPrefix(other.URI, "file://") || other.URIBaseID != "" {
+		t.Errorf("Expected an absolute URI outside the module, got %+v", other)
+	}
+}
+
+// TestWriteSARIF_RequiresRule tests that findings without a rule are rejected
+func TestWriteSARIF_RequiresRule(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF([]Finding{{Message: "orphan"}}, &buf); err == nil {
+		t.Error("Expected an error for a finding without a rule")
+	}
+}
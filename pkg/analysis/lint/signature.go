@@ -0,0 +1,61 @@
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// LongSignatureRule flags functions with too many parameters or results
+var LongSignatureRule = &Rule{
+	ID:          "GT1004",
+	Name:        "long-signature",
+	Description: "Functions with many parameters or results are hard to call correctly; consider a struct parameter",
+	Severity:    SeverityInfo,
+}
+
+// FindLongSignatures reports functions and methods with more than maxParams
+// parameters or more than maxResults results. The receiver is not counted
+// and a variadic parameter counts once. A limit of zero or less disables
+// that check. The module must be loaded with IncludeAST.
+func FindLongSignatures(mod *module.Module, maxParams, maxResults int) []Finding {
+	var findings []Finding
+
+	forEachFile(mod, func(pkg *module.Package, file *module.File) {
+		for _, decl := range file.AST.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			fn, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			sig := fn.Type().(*types.Signature)
+
+			var problems []string
+			if params := sig.Params().Len(); maxParams > 0 && params > maxParams {
+				problems = append(problems, fmt.Sprintf("%d parameters (max %d)", params, maxParams))
+			}
+			if results := sig.Results().Len(); maxResults > 0 && results > maxResults {
+				problems = append(problems, fmt.Sprintf("%d results (max %d)", results, maxResults))
+			}
+			if len(problems) == 0 {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				Rule:     LongSignatureRule,
+				Message:  fmt.Sprintf("%s has %s", module.FuncDeclName(fd), strings.Join(problems, " and ")),
+				Position: file.GetPositionInfo(fd.Name.Pos(), fd.Name.End()),
+				Symbol:   module.FuncDeclName(fd),
+			})
+		}
+	})
+
+	SortFindings(findings)
+	return findings
+}
@@ -0,0 +1,36 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindLongSignatures(t *testing.T) {
+	mod := loadSource(t, `package sample
+
+func Short(a, b int) error { return nil }
+
+func Wide(a, b, c int, d string, opts ...string) {}
+
+func Split() (int, int, string, error) { return 0, 0, "", nil }
+
+type Client struct{}
+
+func (cl *Client) Both(a, b, c, d int) (x, y, z int, err error) { return }
+`)
+
+	findings := FindLongSignatures(mod, 3, 3)
+
+	expected := "GT1004 Wide:5,GT1004 Split:7,GT1004 Client.Both:11"
+	if got := strings.Join(findingLines(findings), ","); got != expected {
+		t.Fatalf("Expected findings %s, got %s", expected, got)
+	}
+	if want := "Client.Both has 4 parameters (max 3) and 4 results (max 3)"; findings[2].Message != want {
+		t.Errorf("Expected message %q, got %q", want, findings[2].Message)
+	}
+
+	// A non-positive limit disables the check
+	if got := strings.Join(findingLines(FindLongSignatures(mod, 0, 3)), ","); got != "GT1004 Split:7,GT1004 Client.Both:11" {
+		t.Errorf("Unexpected findings without a parameter limit: %s", got)
+	}
+}
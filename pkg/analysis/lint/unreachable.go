@@ -0,0 +1,241 @@
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// UnreachableCodeRule flags statements and cases that can never execute
+var UnreachableCodeRule = &Rule{
+	ID:          "GT1008",
+	Name:        "unreachable-code",
+	Description: "Code that can never execute should be removed",
+	Severity:    SeverityWarning,
+}
+
+// noReturnCalls lists the functions that never return to their caller, by
+// full name as reported by types.Func
+var noReturnCalls = map[string]bool{
+	"os.Exit":        true,
+	"runtime.Goexit": true,
+	"log.Fatal":      true, "log.Fatalf": true, "log.Fatalln": true,
+	"log.Panic": true, "log.Panicf": true, "log.Panicln": true,
+	"(*log.Logger).Fatal": true, "(*log.Logger).Fatalf": true, "(*log.Logger).Fatalln": true,
+	"(*log.Logger).Panic": true, "(*log.Logger).Panicf": true, "(*log.Logger).Panicln": true,
+	"(*testing.common).FailNow": true, "(*testing.common).Fatal": true, "(*testing.common).Fatalf": true,
+	"(*testing.common).SkipNow": true, "(*testing.common).Skip": true, "(*testing.common).Skipf": true,
+}
+
+// FindUnreachableCode reports statements that follow a statement which never
+// completes normally within the same block: a return, goto, break, continue
+// or fallthrough, a call of panic, os.Exit, log.Fatal and the like, an
+// infinite loop without break, or an if, switch or select statement none of
+// whose branches completes. Only the first unreachable statement of a block
+// is reported, and labeled statements are assumed to be goto targets. It also
+// reports type switch cases that an earlier interface case always matches
+// first. The module must be loaded with IncludeAST.
+func FindUnreachableCode(mod *module.Module) []Finding {
+	var findings []Finding
+	forEachFile(mod, func(pkg *module.Package, file *module.File) {
+		info := pkg.TypesInfo
+		for _, decl := range file.AST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			symbol := module.FuncDeclName(fn)
+			report := func(node ast.Node, format string, args ...interface{}) {
+				findings = append(findings, Finding{
+					Rule:     UnreachableCodeRule,
+					Message:  fmt.Sprintf(format, args...),
+					Position: file.GetPositionInfo(node.Pos(), node.End()),
+					Symbol:   symbol,
+				})
+			}
+
+			ast.Inspect(fn, func(n ast.Node) bool {
+				if typeSwitch, ok := n.(*ast.TypeSwitchStmt); ok {
+					for _, shadowed := range shadowedTypeCases(info, typeSwitch) {
+						report(shadowed.expr, "case %s is unreachable, %s matches first", types.ExprString(shadowed.expr), types.ExprString(shadowed.by))
+					}
+				}
+				list := stmtList(n)
+				for i := 0; i+1 < len(list); i++ {
+					reason := completionBlocker(info, list[i], nil)
+					if reason == "" {
+						continue
+					}
+					if next := list[i+1]; !isLabeled(next) && !isEmpty(next) {
+						report(next, "unreachable code after %s", reason)
+					}
+					break
+				}
+				return true
+			})
+		}
+	})
+
+	SortFindings(findings)
+	return findings
+}
+
+// completionBlocker describes why a statement never completes normally,
+// or returns an empty string if it may; label is the statement's label
+func completionBlocker(info *types.Info, stmt ast.Stmt, label *ast.Ident) string {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return "return statement"
+	case *ast.BranchStmt:
+		return s.Tok.String() + " statement"
+	case *ast.ExprStmt:
+		if call, ok := ast.Unparen(s.X).(*ast.CallExpr); ok {
+			if ident, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+				if builtin, ok := info.Uses[ident].(*types.Builtin); ok && builtin.Name() == "panic" {
+					return "call to panic"
+				}
+			}
+			if name := calleeName(info, call); noReturnCalls[name] {
+				return "call to " + name
+			}
+		}
+	case *ast.LabeledStmt:
+		return completionBlocker(info, s.Stmt, s.Label)
+	case *ast.BlockStmt:
+		if reason := lastBlocker(info, s.List); reason != "" {
+			return reason
+		}
+	case *ast.IfStmt:
+		if s.Else != nil && lastBlocker(info, s.Body.List) != "" && completionBlocker(info, s.Else, nil) != "" {
+			return "if statement whose branches all end early"
+		}
+	case *ast.ForStmt:
+		if s.Cond == nil && !hasBreak(s, label) {
+			return "infinite loop"
+		}
+	case *ast.SwitchStmt:
+		if clausesBlock(info, s.Body, true) && !hasBreak(s, label) {
+			return "switch statement whose cases all end early"
+		}
+	case *ast.TypeSwitchStmt:
+		if clausesBlock(info, s.Body, true) && !hasBreak(s, label) {
+			return "switch statement whose cases all end early"
+		}
+	case *ast.SelectStmt:
+		if clausesBlock(info, s.Body, false) && !hasBreak(s, label) {
+			return "select statement whose cases all end early"
+		}
+	}
+	return ""
+}
+
+// lastBlocker describes why the last statement of a list never completes,
+// or returns an empty string if it may
+func lastBlocker(info *types.Info, list []ast.Stmt) string {
+	for i := len(list) - 1; i >= 0; i-- {
+		if !isEmpty(list[i]) {
+			return completionBlocker(info, list[i], nil)
+		}
+	}
+	return ""
+}
+
+// clausesBlock reports whether every clause of a switch or select body ends
+// early; switches also need a default clause
+func clausesBlock(info *types.Info, body *ast.BlockStmt, needDefault bool) bool {
+	hasDefault := !needDefault
+	for _, stmt := range body.List {
+		if clause, ok := stmt.(*ast.CaseClause); ok && clause.List == nil {
+			hasDefault = true
+		}
+		if lastBlocker(info, stmtList(stmt)) == "" {
+			return false
+		}
+	}
+	return hasDefault
+}
+
+// hasBreak reports whether a loop, switch or select statement contains a
+// break that leaves it, either unlabeled or referring to its label
+func hasBreak(stmt ast.Stmt, label *ast.Ident) bool {
+	return findBreak(stmt, label, false)
+}
+
+// findBreak looks for a break leaving the statement hasBreak checks in node;
+// nested reports whether node is another breakable statement within it
+func findBreak(node ast.Node, label *ast.Ident, nested bool) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BranchStmt:
+			if n.Tok == token.BREAK {
+				if n.Label == nil {
+					found = !nested
+				} else {
+					found = label != nil && n.Label.Name == label.Name
+				}
+			}
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			if n != node && !nested {
+				found = findBreak(n, label, true)
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// shadowedCase is a type switch case that an earlier case always matches
+type shadowedCase struct {
+	expr ast.Expr // Unreachable case type
+	by   ast.Expr // Earlier interface case type that matches it
+}
+
+// shadowedTypeCases returns the cases of a type switch whose type
+// implements the interface of a case of an earlier clause
+func shadowedTypeCases(info *types.Info, stmt *ast.TypeSwitchStmt) []shadowedCase {
+	var shadowed []shadowedCase
+	var earlier []ast.Expr
+	for _, stmt := range stmt.Body.List {
+		clause, ok := stmt.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		for _, expr := range clause.List {
+			t := info.TypeOf(expr)
+			if t == nil || types.Identical(t, types.Typ[types.UntypedNil]) {
+				continue
+			}
+			for _, prev := range earlier {
+				iface, ok := info.TypeOf(prev).Underlying().(*types.Interface)
+				if ok && types.Implements(t, iface) {
+					shadowed = append(shadowed, shadowedCase{expr: expr, by: prev})
+					break
+				}
+			}
+		}
+		earlier = append(earlier, clause.List...)
+	}
+	return shadowed
+}
+
+// isLabeled reports whether a statement has a label
+func isLabeled(stmt ast.Stmt) bool {
+	_, ok := stmt.(*ast.LabeledStmt)
+	return ok
+}
+
+// isEmpty reports whether a statement is an empty statement
+func isEmpty(stmt ast.Stmt) bool {
+	_, ok := stmt.(*ast.EmptyStmt)
+	return ok
+}
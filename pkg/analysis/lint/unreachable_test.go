@@ -0,0 +1,96 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindUnreachableCode(t *testing.T) {
+	mod := loadSource(t, `package sample
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+type codeError struct{}
+
+func (codeError) Error() string { return "code" }
+
+func Return() int {
+	return 1
+	fmt.Println("after return")
+	return 2
+}
+
+func Exit() {
+	os.Exit(1)
+	fmt.Println("after exit")
+}
+
+func Branches(ok bool) int {
+	if ok {
+		return 1
+	} else {
+		panic("no")
+	}
+	return 0
+}
+
+func Loop(ch chan int) int {
+	for {
+		select {
+		case v := <-ch:
+			return v
+		}
+	}
+	return 0
+}
+
+func Reachable(items []int) int {
+	for {
+		if len(items) == 0 {
+			break
+		}
+		items = items[1:]
+	}
+outer:
+	for _, item := range items {
+		switch item {
+		case 0:
+			continue
+		default:
+			break outer
+		}
+	}
+	goto end
+end:
+	return 0
+}
+
+func Kind(err error) string {
+	switch err.(type) {
+	case nil:
+		return "none"
+	case error:
+		return "error"
+	case codeError:
+		return "code"
+	}
+	return errors.New("unknown").Error()
+}
+`)
+
+	findings := FindUnreachableCode(mod)
+
+	expected := "GT1008 Return:15,GT1008 Exit:21,GT1008 Branches:30,GT1008 Loop:40,GT1008 Kind:70"
+	if got := strings.Join(findingLines(findings), ","); got != expected {
+		t.Errorf("Expected findings %s, got %s", expected, got)
+	}
+	for _, f := range findings {
+		if f.Symbol == "Kind" && f.Message != "case codeError is unreachable, error matches first" {
+			t.Errorf("Unexpected message: %s", f.Message)
+		}
+	}
+}
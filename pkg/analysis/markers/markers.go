@@ -0,0 +1,268 @@
+// Package markers finds tagged comments such as TODO and FIXME in Go modules.
+package markers
+
+import (
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// DefaultTags are the marker tags searched for when none are given
+var DefaultTags = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// Marker is a tagged comment found in the source
+type Marker struct {
+	Tag      string           // Matched tag, e.g. "TODO"
+	Text     string           // Text following the tag
+	Position *module.Position // Location of the tag within the file
+	Symbol   string           // Enclosing or documented symbol, e.g. "User.Login" (empty at file scope)
+}
+
+// Analyzer finds markers in a module
+type Analyzer struct{}
+
+// NewAnalyzer creates a new marker analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// FindMarkers scans every comment in the module for the given tags and
+// returns the matches sorted by file and position. A tag only matches as a
+// whole word, e.g. "TODO" matches "TODO: x" and "TODO(bob) x" but not
+// "TODOS". Markers inside a declaration, or in the doc comment directly
+// above it, are attributed to that declaration.
+func (a *Analyzer) FindMarkers(mod *module.Module, tags []string) []Marker {
+	if len(tags) == 0 {
+		tags = DefaultTags
+	}
+
+	var markers []Marker
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			markers = append(markers, a.findFileMarkers(file, tags)...)
+		}
+	}
+
+	sort.SliceStable(markers, func(i, j int) bool {
+		return markers[i].Position.Before(markers[j].Position)
+	})
+
+	return markers
+}
+
+// comment is a scanned comment with its position
+type comment struct {
+	text    string
+	line    int
+	column  int
+	endLine int // Last line of the block of adjacent comments this one belongs to
+}
+
+// findFileMarkers scans the comments of a single file
+func (a *Analyzer) findFileMarkers(file *module.File, tags []string) []Marker {
+	if len(file.SourceCode) == 0 {
+		return nil
+	}
+
+	comments := scanComments(file)
+	symbols := symbolRanges(file)
+
+	var markers []Marker
+	for _, c := range comments {
+		for _, m := range matchTags(c.text, tags) {
+			// Columns are byte offsets within the line, so compute them on the
+			// comment text before converting to a position
+			line, column := c.line, c.column+m.offset
+			if nl := strings.LastIndex(c.text[:m.offset], "\n"); nl >= 0 {
+				line += strings.Count(c.text[:m.offset], "\n")
+				column = m.offset - nl
+			}
+
+			markers = append(markers, Marker{
+				Tag:  m.tag,
+				Text: m.text,
+				Position: &module.Position{
+					File:      file,
+					LineStart: line,
+					ColStart:  column,
+					LineEnd:   line,
+					ColEnd:    column + len(m.tag),
+				},
+				Symbol: enclosingSymbol(symbols, c.line, c.endLine),
+			})
+		}
+	}
+
+	return markers
+}
+
+// scanComments returns all comments in the file's source code
+func scanComments(file *module.File) []comment {
+	fset := token.NewFileSet()
+	tokFile := fset.AddFile(file.Path, -1, len(file.SourceCode))
+
+	var s scanner.Scanner
+	s.Init(tokFile, []byte(file.SourceCode), nil, scanner.ScanComments)
+
+	var comments []comment
+	lastCodeLine, prevEnd, blockStart := 0, -1, 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		position := fset.Position(pos)
+		if tok != token.COMMENT {
+			// The scanner inserts implicit semicolons at newlines
+			if !(tok == token.SEMICOLON && lit == "\n") {
+				lastCodeLine = position.Line
+			}
+			continue
+		}
+
+		endLine := fset.Position(pos + token.Pos(len(lit))).Line
+		if position.Line == lastCodeLine || position.Line != prevEnd+1 {
+			blockStart = len(comments)
+		}
+		comments = append(comments, comment{text: lit, line: position.Line, column: position.Column})
+
+		// Every comment in a block of adjacent own-line comments shares its end
+		for i := blockStart; i < len(comments); i++ {
+			comments[i].endLine = endLine
+		}
+		prevEnd = endLine
+	}
+
+	return comments
+}
+
+// tagMatch is a tag occurrence within a comment
+type tagMatch struct {
+	tag    string
+	text   string
+	offset int
+}
+
+// matchTags finds whole-word occurrences of the tags in a comment
+func matchTags(text string, tags []string) []tagMatch {
+	var matches []tagMatch
+	for _, tag := range tags {
+		start := 0
+		for {
+			idx := strings.Index(text[start:], tag)
+			if idx < 0 {
+				break
+			}
+			offset := start + idx
+			start = offset + len(tag)
+
+			if offset > 0 && isWordRune(rune(text[offset-1])) {
+				continue
+			}
+			if start < len(text) && isWordRune(rune(text[start])) {
+				continue
+			}
+
+			matches = append(matches, tagMatch{tag: tag, text: markerText(text[start:]), offset: offset})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].offset < matches[j].offset })
+	return matches
+}
+
+// markerText extracts the text following a tag up to the end of its line
+func markerText(rest string) string {
+	if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+		rest = rest[:nl]
+	}
+	rest = strings.TrimSuffix(strings.TrimSpace(rest), "*/")
+
+	// Skip an optional "(owner)" and separator
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.IndexByte(rest, ')'); end >= 0 {
+			rest = rest[end+1:]
+		}
+	}
+	rest = strings.TrimLeft(rest, ":- \t")
+
+	return strings.TrimSpace(rest)
+}
+
+// isWordRune reports whether r can be part of an identifier
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// symbolRange is the line range covered by a declaration
+type symbolRange struct {
+	name      string
+	startLine int
+	endLine   int
+}
+
+// symbolRanges returns the line ranges of the file's declarations
+func symbolRanges(file *module.File) []symbolRange {
+	if file.FileSet == nil {
+		return nil
+	}
+
+	var ranges []symbolRange
+	add := func(name string, pos, end token.Pos) {
+		if pos == token.NoPos || end == token.NoPos {
+			return
+		}
+		ranges = append(ranges, symbolRange{
+			name:      name,
+			startLine: file.FileSet.Position(pos).Line,
+			endLine:   file.FileSet.Position(end).Line,
+		})
+	}
+
+	for _, t := range file.Types {
+		add(t.Name, t.Pos, t.End)
+	}
+	for _, fn := range file.Functions {
+		name := fn.Name
+		if fn.Receiver != nil {
+			name = strings.TrimPrefix(fn.Receiver.Type, "*") + "." + fn.Name
+		}
+		add(name, fn.Pos, fn.End)
+	}
+	for _, v := range file.Variables {
+		add(v.Name, v.Pos, v.End)
+	}
+	for _, c := range file.Constants {
+		add(c.Name, c.Pos, c.End)
+	}
+
+	return ranges
+}
+
+// enclosingSymbol returns the innermost declaration containing the comment,
+// or the declaration the comment block documents
+func enclosingSymbol(ranges []symbolRange, line, blockEnd int) string {
+	best := -1
+	for i, r := range ranges {
+		if r.startLine <= line && line <= r.endLine {
+			if best < 0 || r.endLine-r.startLine < ranges[best].endLine-ranges[best].startLine {
+				best = i
+			}
+		}
+	}
+	if best >= 0 {
+		return ranges[best].name
+	}
+
+	for _, r := range ranges {
+		if r.startLine == blockEnd+1 {
+			return r.name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,85 @@
+package markers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+// TestFindMarkers tests finding tagged comments and their enclosing symbols
+func TestFindMarkers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/todo\n\ngo 1.21\n",
+		"todo.go": `package todo
+
+// TODO: split this file
+
+// User is a user.
+// FIXME(alice): add validation
+type User struct {
+	Name string // HACK keep in sync with the schema
+}
+
+// Login logs in.
+func (u *User) Login() error {
+	/* XXX - no rate limiting */
+	return nil // TODOS is not a marker
+}
+
+var limit = 10 // TODO tune
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	markers := NewAnalyzer().FindMarkers(mod, nil)
+
+	expected := []struct {
+		tag, text, symbol string
+		line, col         int
+	}{
+		{"TODO", "split this file", "", 3, 4},
+		{"FIXME", "add validation", "User", 6, 4},
+		{"HACK", "keep in sync with the schema", "User", 8, 17},
+		{"XXX", "no rate limiting", "User.Login", 13, 5},
+		{"TODO", "tune", "limit", 17, 19},
+	}
+
+	if len(markers) != len(expected) {
+		for _, m := range markers {
+			t.Logf("found %s %q in %s at %d:%d", m.Tag, m.Text, m.Symbol, m.Position.LineStart, m.Position.ColStart)
+		}
+		t.Fatalf("Expected %d markers, got %d", len(expected), len(markers))
+	}
+
+	for i, want := range expected {
+		got := markers[i]
+		if got.Tag != want.tag || got.Text != want.text || got.Symbol != want.symbol {
+			t.Errorf("Marker %d: expected %s %q in %q, got %s %q in %q",
+				i, want.tag, want.text, want.symbol, got.Tag, got.Text, got.Symbol)
+		}
+		if got.Position.LineStart != want.line || got.Position.ColStart != want.col {
+			t.Errorf("Marker %d: expected position %d:%d, got %d:%d",
+				i, want.line, want.col, got.Position.LineStart, got.Position.ColStart)
+		}
+	}
+}
+
+// TestFindMarkers_CustomTags tests restricting the search to specific tags
+func TestFindMarkers_CustomTags(t *testing.T) {
+	matches := matchTags("// NOTE: a; TODO: b", []string{"NOTE"})
+	if len(matches) != 1 || matches[0].tag != "NOTE" || matches[0].text != "a; TODO: b" {
+		t.Errorf("Unexpected matches: %+v", matches)
+	}
+}
@@ -0,0 +1,110 @@
+// Package metrics provides package-level design metrics for Go modules.
+package metrics
+
+import (
+	"math"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// PackageMetrics holds the coupling and abstractness metrics of a package
+type PackageMetrics struct {
+	ImportPath string // Import path of the package
+
+	Afferent    int     // Ca: number of module packages that import this package
+	Efferent    int     // Ce: number of module packages this package imports
+	Instability float64 // I = Ce / (Ca + Ce), 0 when the package has no couplings
+
+	AbstractTypes int     // Number of interface types
+	TotalTypes    int     // Number of type declarations
+	Abstractness  float64 // A = AbstractTypes / TotalTypes, 0 when there are no types
+
+	Distance float64 // D = |A + I - 1|, distance from the main sequence
+}
+
+// Analyzer computes design metrics for a module
+type Analyzer struct{}
+
+// NewAnalyzer creates a new metrics analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// CouplingReport computes Martin's coupling metrics for every package in the
+// module, sorted by import path. Only imports between packages of the module
+// itself are counted, since those are the dependencies the module controls.
+func (a *Analyzer) CouplingReport(mod *module.Module) []PackageMetrics {
+	if mod == nil {
+		return nil
+	}
+
+	// Build the internal import graph from file imports
+	efferent := make(map[string]map[string]bool, len(mod.Packages))
+	afferent := make(map[string]map[string]bool, len(mod.Packages))
+	for path, pkg := range mod.Packages {
+		efferent[path] = make(map[string]bool)
+		if afferent[path] == nil {
+			afferent[path] = make(map[string]bool)
+		}
+		for _, imp := range importPaths(pkg) {
+			if imp == path {
+				continue
+			}
+			if _, ok := mod.Packages[imp]; !ok {
+				continue
+			}
+			efferent[path][imp] = true
+			if afferent[imp] == nil {
+				afferent[imp] = make(map[string]bool)
+			}
+			afferent[imp][path] = true
+		}
+	}
+
+	report := make([]PackageMetrics, 0, len(mod.Packages))
+	for path, pkg := range mod.Packages {
+		m := PackageMetrics{
+			ImportPath: path,
+			Afferent:   len(afferent[path]),
+			Efferent:   len(efferent[path]),
+		}
+
+		if total := m.Afferent + m.Efferent; total > 0 {
+			m.Instability = float64(m.Efferent) / float64(total)
+		}
+
+		for _, typ := range pkg.Types {
+			m.TotalTypes++
+			if typ.Kind == "interface" {
+				m.AbstractTypes++
+			}
+		}
+		if m.TotalTypes > 0 {
+			m.Abstractness = float64(m.AbstractTypes) / float64(m.TotalTypes)
+		}
+
+		m.Distance = math.Abs(m.Abstractness + m.Instability - 1)
+		report = append(report, m)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].ImportPath < report[j].ImportPath
+	})
+
+	return report
+}
+
+// importPaths returns the paths imported by a package and its files
+func importPaths(pkg *module.Package) []string {
+	var paths []string
+	for path := range pkg.Imports {
+		paths = append(paths, path)
+	}
+	for _, file := range pkg.Files {
+		for _, imp := range file.Imports {
+			paths = append(paths, imp.Path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestCouplingReport tests computing coupling metrics over a small import graph
+func TestCouplingReport(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+
+	// api <- service <- cmd, with service also importing a standard package
+	api := addPackage(mod, "api")
+	api.AddType(module.NewType("Store", "interface", true))
+	api.AddType(module.NewType("Reader", "interface", true))
+
+	service := addPackage(mod, "service", "example.com/app/api", "fmt")
+	service.AddType(module.NewType("Service", "struct", true))
+	service.AddType(module.NewType("Handler", "interface", true))
+
+	addPackage(mod, "cmd", "example.com/app/service", "example.com/app/api")
+
+	report := NewAnalyzer().CouplingReport(mod)
+	if len(report) != 3 {
+		t.Fatalf("Expected 3 packages, got %d", len(report))
+	}
+
+	byPath := make(map[string]PackageMetrics)
+	for _, m := range report {
+		byPath[m.ImportPath] = m
+	}
+
+	tests := []struct {
+		path         string
+		ca, ce       int
+		instability  float64
+		abstractness float64
+		distance     float64
+	}{
+		{"example.com/app/api", 2, 0, 0, 1, 0},
+		{"example.com/app/service", 1, 1, 0.5, 0.5, 0},
+		{"example.com/app/cmd", 0, 2, 1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		m, ok := byPath[tt.path]
+		if !ok {
+			t.Errorf("Missing metrics for %s", tt.path)
+			continue
+		}
+		if m.Afferent != tt.ca || m.Efferent != tt.ce {
+			t.Errorf("%s: expected Ca=%d Ce=%d, got Ca=%d Ce=%d", tt.path, tt.ca, tt.ce, m.Afferent, m.Efferent)
+		}
+		if !almostEqual(m.Instability, tt.instability) {
+			t.Errorf("%s: expected I=%v, got %v", tt.path, tt.instability, m.Instability)
+		}
+		if !almostEqual(m.Abstractness, tt.abstractness) {
+			t.Errorf("%s: expected A=%v, got %v", tt.path, tt.abstractness, m.Abstractness)
+		}
+		if !almostEqual(m.Distance, tt.distance) {
+			t.Errorf("%s: expected D=%v, got %v", tt.path, tt.distance, m.Distance)
+		}
+	}
+
+	if report[0].ImportPath != "example.com/app/api" || report[2].ImportPath != "example.com/app/service" {
+		t.Errorf("Expected report sorted by import path, got %s, %s, %s",
+			report[0].ImportPath, report[1].ImportPath, report[2].ImportPath)
+	}
+}
+
+// addPackage adds a package with a single file importing the given paths
+func addPackage(mod *module.Module, name string, imports ...string) *module.Package {
+	pkg := module.NewPackage(name, mod.Path+"/"+name, "")
+	file := module.NewFile(name+".go", name+".go", false)
+	for _, path := range imports {
+		file.AddImport(module.NewImport(path, "", false))
+	}
+	pkg.AddFile(file)
+	mod.AddPackage(pkg)
+	return pkg
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,276 @@
+package modgraph
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// DOTOptions configures the Graphviz rendering of a dependency graph
+type DOTOptions struct {
+	// Root is the main module; modules it requires directly are colored
+	// differently from transitive ones (empty means no coloring)
+	Root string
+
+	// HighlightCycles draws edges that are part of a dependency cycle in red
+	HighlightCycles bool
+
+	// ClusterDepth groups modules by the first ClusterDepth elements of
+	// their path, e.g. "github.com/spf13" for 2 (0 means no clusters)
+	ClusterDepth int
+}
+
+// Node colors of the DOT output
+const (
+	dotRootColor       = "lightblue"
+	dotDirectColor     = "palegreen"
+	dotTransitiveColor = "lightgray"
+	dotCycleColor      = "red"
+)
+
+// DependencyGraph returns the module requirement graph of the module as
+// reported by `go mod graph`, mapping each module path to the sorted paths
+// of the modules it requires. Versions are dropped, so several versions of a
+// module are one node.
+func (a *Analyzer) DependencyGraph(mod *module.Module) (map[string][]string, error) {
+	if mod == nil {
+		return nil, fmt.Errorf("module is nil")
+	}
+	graph, err := a.modGraph(mod)
+	if err != nil {
+		return nil, err
+	}
+	return parseModGraph(mod.Path, graph), nil
+}
+
+// modGraph runs `go mod graph` in the module directory and returns its
+// output
+func (a *Analyzer) modGraph(mod *module.Module) (io.Reader, error) {
+	goCmd := a.GoCommand
+	if goCmd == "" {
+		goCmd = "go"
+	}
+
+	cmd := exec.Command(goCmd, "mod", "graph")
+	cmd.Dir = mod.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list module graph: %w: %s", err, stderr.String())
+	}
+	return &stdout, nil
+}
+
+// modGraphEdges parses the output of `go mod graph` into pairs of required
+// and requiring path@version nodes; the main module has no version. The go
+// and toolchain requirements are left out, as they are not modules.
+func modGraphEdges(r io.Reader) [][2]string {
+	var edges [][2]string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if path, _, _ := strings.Cut(fields[1], "@"); path == "go" || path == "toolchain" {
+			continue
+		}
+		edges = append(edges, [2]string{fields[0], fields[1]})
+	}
+	return edges
+}
+
+// parseModGraph parses the output of `go mod graph`
+func parseModGraph(mainPath string, r io.Reader) map[string][]string {
+	edges := map[string]map[string]bool{mainPath: {}}
+	for _, edge := range modGraphEdges(r) {
+		from, _, _ := strings.Cut(edge[0], "@")
+		to, _, _ := strings.Cut(edge[1], "@")
+		if from == to {
+			continue
+		}
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		if edges[to] == nil {
+			edges[to] = make(map[string]bool)
+		}
+		edges[from][to] = true
+	}
+
+	graph := make(map[string][]string, len(edges))
+	for from, targets := range edges {
+		graph[from] = sortedSet(targets)
+	}
+	return graph
+}
+
+// WriteDOT writes a dependency graph, as returned by DependencyGraph, in
+// the Graphviz DOT language. Nodes and edges are written in sorted order.
+func WriteDOT(graph map[string][]string, w io.Writer, opts DOTOptions) error {
+	nodes := make(map[string]bool)
+	for from, targets := range graph {
+		nodes[from] = true
+		for _, to := range targets {
+			nodes[to] = true
+		}
+	}
+	names := sortedSet(nodes)
+
+	var cyclic map[string]int
+	if opts.HighlightCycles {
+		cyclic = cycleComponents(graph, names)
+	}
+	direct := make(map[string]bool)
+	if opts.Root != "" {
+		for _, to := range graph[opts.Root] {
+			direct[to] = true
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph dependencies {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [shape=box, style=filled, fillcolor=white];\n")
+
+	node := func(indent, name string) {
+		attrs := ""
+		switch {
+		case opts.Root == "":
+		case name == opts.Root:
+			attrs = " [fillcolor=" + dotRootColor + "]"
+		case direct[name]:
+			attrs = " [fillcolor=" + dotDirectColor + "]"
+		default:
+			attrs = " [fillcolor=" + dotTransitiveColor + "]"
+		}
+		fmt.Fprintf(&buf, "%s%s%s;\n", indent, strconv.Quote(name), attrs)
+	}
+
+	if opts.ClusterDepth > 0 {
+		clusters := make(map[string][]string)
+		for _, name := range names {
+			prefix := clusterPrefix(name, opts.ClusterDepth)
+			clusters[prefix] = append(clusters[prefix], name)
+		}
+		prefixes := make([]string, 0, len(clusters))
+		for prefix := range clusters {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		for i, prefix := range prefixes {
+			fmt.Fprintf(&buf, "    subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&buf, "        label=%s;\n", strconv.Quote(prefix))
+			for _, name := range clusters[prefix] {
+				node("        ", name)
+			}
+			buf.WriteString("    }\n")
+		}
+	} else {
+		for _, name := range names {
+			node("    ", name)
+		}
+	}
+
+	for _, from := range names {
+		targets := append([]string(nil), graph[from]...)
+		sort.Strings(targets)
+		for _, to := range targets {
+			attrs := ""
+			if c, ok := cyclic[from]; ok && inComponent(cyclic, to, c) {
+				attrs = " [color=" + dotCycleColor + ", penwidth=2]"
+			}
+			fmt.Fprintf(&buf, "    %s -> %s%s;\n", strconv.Quote(from), strconv.Quote(to), attrs)
+		}
+	}
+	buf.WriteString("}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// cycleComponents returns, for each node in a dependency cycle, the number
+// of its strongly connected component; edges within a component are part
+// of a cycle
+func cycleComponents(graph map[string][]string, names []string) map[string]int {
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	components := make(map[string]int)
+	count := 0
+
+	var visit func(string)
+	visit = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range graph[v] {
+			if _, seen := index[w]; !seen {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var members []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			members = append(members, w)
+			if w == v {
+				break
+			}
+		}
+		if len(members) > 1 {
+			for _, m := range members {
+				components[m] = count
+			}
+			count++
+		}
+	}
+	for _, name := range names {
+		if _, seen := index[name]; !seen {
+			visit(name)
+		}
+	}
+	return components
+}
+
+// inComponent reports whether a node belongs to a cycle component
+func inComponent(components map[string]int, name string, component int) bool {
+	c, ok := components[name]
+	return ok && c == component
+}
+
+// clusterPrefix returns the first depth elements of a module path
+func clusterPrefix(path string, depth int) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// sortedSet returns the elements of a set in order
+func sortedSet(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,80 @@
+package modgraph
+
+import (
+	"bytes"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	graph := parseModGraph("example.com/app", strings.NewReader(`example.com/app go@1.21
+example.com/app github.com/acme/a@v1.0.0
+example.com/app github.com/acme/b@v1.2.0
+github.com/acme/a@v1.0.0 github.com/acme/b@v1.1.0
+github.com/acme/b@v1.2.0 github.com/acme/a@v1.0.0
+github.com/acme/b@v1.1.0 golang.org/x/text@v0.3.0
+golang.org/x/text@v0.3.0 toolchain@go1.21.0
+`))
+	expectedGraph := map[string][]string{
+		"example.com/app":   {"github.com/acme/a", "github.com/acme/b"},
+		"github.com/acme/a": {"github.com/acme/b"},
+		"github.com/acme/b": {"github.com/acme/a", "golang.org/x/text"},
+		"golang.org/x/text": {},
+	}
+	if !reflect.DeepEqual(graph, expectedGraph) {
+		t.Fatalf("Unexpected graph: %v", graph)
+	}
+	graph["golang.org/x/text"] = append(graph["golang.org/x/text"], "golang.org/x/sys")
+
+	var buf bytes.Buffer
+	if err := WriteDOT(graph, &buf, DOTOptions{Root: "example.com/app", HighlightCycles: true, ClusterDepth: 2}); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	expected := `digraph dependencies {
+    rankdir=LR;
+    node [shape=box, style=filled, fillcolor=white];
+    subgraph cluster_0 {
+        label="example.com/app";
+        "example.com/app" [fillcolor=lightblue];
+    }
+    subgraph cluster_1 {
+        label="github.com/acme";
+        "github.com/acme/a" [fillcolor=palegreen];
+        "github.com/acme/b" [fillcolor=palegreen];
+    }
+    subgraph cluster_2 {
+        label="golang.org/x";
+        "golang.org/x/sys" [fillcolor=lightgray];
+        "golang.org/x/text" [fillcolor=lightgray];
+    }
+    "example.com/app" -> "github.com/acme/a";
+    "example.com/app" -> "github.com/acme/b";
+    "github.com/acme/a" -> "github.com/acme/b" [color=red, penwidth=2];
+    "github.com/acme/b" -> "github.com/acme/a" [color=red, penwidth=2];
+    "github.com/acme/b" -> "golang.org/x/text";
+    "golang.org/x/text" -> "golang.org/x/sys";
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected DOT output:\n%s", buf.String())
+	}
+
+	// Validate the syntax with Graphviz where it is installed
+	if dot, err := exec.LookPath("dot"); err == nil {
+		cmd := exec.Command(dot, "-Tsvg")
+		cmd.Stdin = &buf
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("dot rejected the output: %v\n%s", err, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteDOT(map[string][]string{"a": {"b"}}, &buf, DOTOptions{}); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "    \"a\";\n    \"b\";\n    \"a\" -> \"b\";\n") {
+		t.Errorf("Expected plain nodes and edges, got:\n%s", buf.String())
+	}
+}
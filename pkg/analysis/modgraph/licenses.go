@@ -0,0 +1,216 @@
+package modgraph
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// UnknownLicense is the identifier of licenses that could not be detected
+const UnknownLicense = "UNKNOWN"
+
+// License is the license of a dependency module
+type License struct {
+	Path    string // Module path
+	Version string // Version in use
+	Replace string // Path of the replacement module, if replaced
+	ID      string // SPDX identifier, or UnknownLicense
+	File    string // Path of the license file, empty if none was found
+	Text    string // Full license text
+}
+
+// licenseFileNames are the base names of license files, lower case and
+// without extension
+var licenseFileNames = []string{"license", "licence", "copying", "unlicense"}
+
+// licensePatterns identifies licenses by phrases of their text; all phrases
+// of an entry must occur. Of the matching entries, the one whose first phrase
+// occurs first wins, the earlier entry on ties
+var licensePatterns = []struct {
+	id      string
+	phrases []string
+}{
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "version 2.0"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and", "distribute this software for any purpose"}},
+	{"Unlicense", []string{"this is free and unencumbered software"}},
+}
+
+// Licenses returns the licenses of the modules providing packages that the
+// module's packages import, excluding tests, sorted by module path. The
+// license is read from the module's LICENSE, LICENCE or COPYING file;
+// modules that are not in the module cache have no license text.
+func (a *Analyzer) Licenses(mod *module.Module) ([]License, error) {
+	if mod == nil {
+		return nil, fmt.Errorf("module is nil")
+	}
+
+	pkgs, err := a.listPackages(mod.Dir, "-json=ImportPath,Standard,Module")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var licenses []License
+	for _, pkg := range pkgs {
+		m := pkg.Module
+		if pkg.Standard || m == nil || m.Main || seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+
+		license := License{Path: m.Path, Version: m.Version, ID: UnknownLicense}
+		dir := m.Dir
+		if m.Replace != nil {
+			license.Replace = m.Replace.Path
+			license.Version = m.Replace.Version
+			dir = m.Replace.Dir
+		}
+		if dir != "" {
+			if license.File, err = findLicenseFile(dir); err != nil {
+				return nil, err
+			}
+		}
+		if license.File != "" {
+			text, err := os.ReadFile(license.File)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read license of %s: %w", m.Path, err)
+			}
+			license.Text = string(text)
+			license.ID = DetectLicense(license.Text)
+		}
+		licenses = append(licenses, license)
+	}
+
+	sort.Slice(licenses, func(i, j int) bool { return licenses[i].Path < licenses[j].Path })
+	return licenses, nil
+}
+
+// findLicenseFile returns the path of the license file in dir, or an empty
+// string if there is none
+func findLicenseFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read module directory: %w", err)
+	}
+	for _, want := range licenseFileNames {
+		for _, entry := range entries {
+			name := strings.ToLower(entry.Name())
+			if entry.Type().IsRegular() && strings.TrimSuffix(name, filepath.Ext(name)) == want {
+				return filepath.Join(dir, entry.Name()), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// DetectLicense returns the SPDX identifier of a license text, or
+// UnknownLicense if it is not recognized
+func DetectLicense(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	id, first := UnknownLicense, -1
+	for _, pattern := range licensePatterns {
+		matched := true
+		for _, phrase := range pattern.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		// License texts name other licenses, as the GPL recommends the LGPL
+		// for libraries; the title comes first
+		if at := strings.Index(normalized, pattern.phrases[0]); matched && (first < 0 || at < first) {
+			id, first = pattern.id, at
+		}
+	}
+	return id
+}
+
+// GenerateAttributions writes a third-party notices file for the module,
+// listing each dependency with its version, license identifier and full
+// license text. The format is "text" or "markdown".
+func (a *Analyzer) GenerateAttributions(mod *module.Module, w io.Writer, format string) error {
+	if format != "text" && format != "markdown" {
+		return fmt.Errorf("unsupported attributions format %q", format)
+	}
+	licenses, err := a.Licenses(mod)
+	if err != nil {
+		return err
+	}
+	if format == "markdown" {
+		return writeMarkdownAttributions(mod, licenses, w)
+	}
+	return writeTextAttributions(mod, licenses, w)
+}
+
+// writeTextAttributions writes attributions as plain text
+func writeTextAttributions(mod *module.Module, licenses []License, w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("THIRD-PARTY SOFTWARE NOTICES\n\n")
+	fmt.Fprintf(&b, "%s uses the following third-party modules.\n", mod.Path)
+	for _, license := range licenses {
+		b.WriteString("\n" + strings.Repeat("=", 80) + "\n")
+		fmt.Fprintf(&b, "%s\n", moduleTitle(license))
+		fmt.Fprintf(&b, "License: %s\n", license.ID)
+		b.WriteString(strings.Repeat("-", 80) + "\n")
+		b.WriteString(licenseText(license))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownAttributions writes attributions as a markdown document
+func writeMarkdownAttributions(mod *module.Module, licenses []License, w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Third-Party Notices\n\n")
+	fmt.Fprintf(&b, "`%s` uses the following third-party modules.\n\n", mod.Path)
+	b.WriteString("| Module | Version | License |\n")
+	b.WriteString("|--------|---------|---------|\n")
+	for _, license := range licenses {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", license.Path, license.Version, license.ID)
+	}
+	for _, license := range licenses {
+		fmt.Fprintf(&b, "\n## %s\n\n", moduleTitle(license))
+		fmt.Fprintf(&b, "License: %s\n\n", license.ID)
+		fence := "```"
+		for strings.Contains(license.Text, fence) {
+			fence += "`"
+		}
+		b.WriteString(fence + "text\n" + licenseText(license) + fence + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// moduleTitle returns the module path and version of a dependency
+func moduleTitle(license License) string {
+	title := license.Path
+	if license.Replace != "" && license.Replace != license.Path {
+		title += " => " + license.Replace
+	}
+	if license.Version != "" {
+		title += " " + license.Version
+	}
+	return title
+}
+
+// licenseText returns the license text ending in a newline
+func licenseText(license License) string {
+	if license.Text == "" {
+		return "No license file found.\n"
+	}
+	return strings.TrimRight(license.Text, "\n") + "\n"
+}
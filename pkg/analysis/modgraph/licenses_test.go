@@ -0,0 +1,84 @@
+package modgraph
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestDetectLicense tests identifying licenses by their text
+func TestDetectLicense(t *testing.T) {
+	for text, want := range map[string]string{
+		"MIT License\n\nPermission is hereby granted, free of charge, to any person":                                                     "MIT",
+		"Apache License\n   Version 2.0, January 2004":                                                                                   "Apache-2.0",
+		"Redistribution and use in source and binary forms, with or without\nmodification, are permitted ... Neither the name of Google": "BSD-3-Clause",
+		"Redistribution and use in source and binary forms, with or without modification":                                                "BSD-2-Clause",
+		"All rights reserved.": UnknownLicense,
+	} {
+		if got := DetectLicense(text); got != want {
+			t.Errorf("DetectLicense(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+// TestDetectLicenseFiles tests identifying full license texts that mention
+// other licenses
+func TestDetectLicenseFiles(t *testing.T) {
+	for name, want := range map[string]string{
+		"GPL-2.0.txt":  "GPL-2.0",
+		"GPL-3.0.txt":  "GPL-3.0",
+		"LGPL-3.0.txt": "LGPL-3.0",
+	} {
+		text, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if got := DetectLicense(string(text)); got != want {
+			t.Errorf("DetectLicense(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+// TestGenerateAttributions tests the notices of this repository's dependencies
+func TestGenerateAttributions(t *testing.T) {
+	mod := module.NewModule("bitspark.dev/go-tree", "../../..")
+	analyzer := NewAnalyzer()
+
+	licenses, err := analyzer.Licenses(mod)
+	if err != nil {
+		t.Fatalf("Licenses failed: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, license := range licenses {
+		ids[license.Path] = license.ID
+	}
+	if ids["github.com/spf13/cobra"] != "Apache-2.0" || ids["golang.org/x/tools"] != "BSD-3-Clause" {
+		t.Errorf("Unexpected licenses: %v", ids)
+	}
+
+	var text bytes.Buffer
+	if err := analyzer.GenerateAttributions(mod, &text, "text"); err != nil {
+		t.Fatalf("GenerateAttributions failed: %v", err)
+	}
+	if !strings.Contains(text.String(), "github.com/spf13/cobra v1.9.1\nLicense: Apache-2.0\n") ||
+		!strings.Contains(text.String(), "Apache License") {
+		t.Errorf("Unexpected text attributions:\n%s", text.String())
+	}
+
+	var markdown bytes.Buffer
+	if err := analyzer.GenerateAttributions(mod, &markdown, "markdown"); err != nil {
+		t.Fatalf("GenerateAttributions failed: %v", err)
+	}
+	if !strings.Contains(markdown.String(), "| github.com/spf13/cobra | v1.9.1 | Apache-2.0 |") ||
+		!strings.Contains(markdown.String(), "## golang.org/x/tools v0.33.0\n\nLicense: BSD-3-Clause\n\n```text\n") {
+		t.Errorf("Unexpected markdown attributions:\n%s", markdown.String())
+	}
+
+	if err := analyzer.GenerateAttributions(mod, &markdown, "html"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,185 @@
+// Package modgraph analyzes the module dependency graph of Go modules.
+package modgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// RequireAction is the recommended change for a go.mod require directive
+type RequireAction string
+
+const (
+	// ActionKeep means the requirement is needed as currently declared
+	ActionKeep RequireAction = "keep"
+
+	// ActionRemove means no package of the required module is used
+	ActionRemove RequireAction = "remove"
+
+	// ActionDemote means the module is only needed transitively and should
+	// be marked "// indirect"
+	ActionDemote RequireAction = "demote"
+
+	// ActionPromote means the module is imported directly but marked
+	// "// indirect"
+	ActionPromote RequireAction = "promote"
+)
+
+// Require is a go.mod requirement together with the recommended action
+type Require struct {
+	Path     string        // Module path
+	Version  string        // Required version
+	Indirect bool          // Whether go.mod currently marks it "// indirect"
+	Action   RequireAction // Recommended action
+}
+
+// Analyzer analyzes module dependency graphs
+type Analyzer struct {
+	// GoCommand is the go binary to invoke (defaults to "go")
+	GoCommand string
+}
+
+// NewAnalyzer creates a new module graph analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{GoCommand: "go"}
+}
+
+// listedPackage is the subset of `go list -json` output we need
+type listedPackage struct {
+	ImportPath string
+	Standard   bool
+	Imports    []string
+	Module     *listedModule
+}
+
+// listedModule is the module information of a listed package
+type listedModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Dir     string
+	Replace *listedModule
+}
+
+// MinimalRequires determines which requirements of the module are actually
+// needed, based on the package import closure of the module including its
+// tests. Modules providing a package imported by the main module are direct;
+// modules only reached through other dependencies are indirect; modules
+// providing no package in the closure can be removed.
+//
+// This is a static analysis of the import graph, similar to what `go mod
+// tidy` does for direct requirements; it does not account for requirements
+// that only exist to raise the version selected through another module.
+func (a *Analyzer) MinimalRequires(mod *module.Module) ([]Require, error) {
+	if mod == nil {
+		return nil, fmt.Errorf("module is nil")
+	}
+
+	pkgs, err := a.listDeps(mod.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return classifyRequires(mod, pkgs), nil
+}
+
+// listDeps lists the import closure of all packages and tests in dir
+func (a *Analyzer) listDeps(dir string) ([]listedPackage, error) {
+	return a.listPackages(dir, "-test", "-json=ImportPath,Standard,Imports,Module")
+}
+
+// listPackages runs `go list -e -deps` on all packages in dir with the given
+// extra flags and decodes its JSON output
+func (a *Analyzer) listPackages(dir string, flags ...string) ([]listedPackage, error) {
+	goCmd := a.GoCommand
+	if goCmd == "" {
+		goCmd = "go"
+	}
+
+	args := append([]string{"list", "-e", "-deps"}, flags...)
+	cmd := exec.Command(goCmd, append(args, "./...")...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list dependencies: %w: %s", err, stderr.String())
+	}
+
+	var pkgs []listedPackage
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs, nil
+}
+
+// classifyRequires recommends an action for each requirement of the module
+func classifyRequires(mod *module.Module, pkgs []listedPackage) []Require {
+	// Map each package to the module that provides it
+	owner := make(map[string]string, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Module != nil {
+			owner[pkg.ImportPath] = pkg.Module.Path
+		}
+	}
+
+	used := make(map[string]bool)
+	direct := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.Standard || pkg.Module == nil {
+			continue
+		}
+		if !pkg.Module.Main {
+			used[pkg.Module.Path] = true
+			continue
+		}
+		for _, imp := range pkg.Imports {
+			if path, ok := owner[imp]; ok && path != pkg.Module.Path {
+				direct[path] = true
+			}
+		}
+	}
+
+	requires := make([]Require, 0, len(mod.Dependencies))
+	for _, dep := range mod.Dependencies {
+		req := Require{
+			Path:     dep.Path,
+			Version:  dep.Version,
+			Indirect: dep.Indirect,
+			Action:   ActionKeep,
+		}
+
+		switch {
+		case direct[dep.Path] && dep.Indirect:
+			req.Action = ActionPromote
+		case direct[dep.Path]:
+			req.Action = ActionKeep
+		case used[dep.Path] && !dep.Indirect:
+			req.Action = ActionDemote
+		case used[dep.Path]:
+			req.Action = ActionKeep
+		default:
+			req.Action = ActionRemove
+		}
+
+		requires = append(requires, req)
+	}
+
+	sort.Slice(requires, func(i, j int) bool { return requires[i].Path < requires[j].Path })
+	return requires
+}
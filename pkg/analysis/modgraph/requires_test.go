@@ -0,0 +1,77 @@
+package modgraph
+
+import (
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestClassifyRequires tests recommending actions from an import closure
+func TestClassifyRequires(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+	mod.AddDependency("example.com/direct", "v1.0.0", false)
+	mod.AddDependency("example.com/marked", "v1.0.0", true)
+	mod.AddDependency("example.com/transitive", "v1.0.0", false)
+	mod.AddDependency("example.com/indirect", "v1.0.0", true)
+	mod.AddDependency("example.com/unused", "v1.0.0", false)
+
+	main := &listedModule{Path: "example.com/app", Main: true}
+	dep := func(path string) *listedModule { return &listedModule{Path: path} }
+
+	pkgs := []listedPackage{
+		{ImportPath: "fmt", Standard: true},
+		{ImportPath: "example.com/transitive/util", Module: dep("example.com/transitive")},
+		{ImportPath: "example.com/indirect/util", Module: dep("example.com/indirect")},
+		{ImportPath: "example.com/direct/api", Module: dep("example.com/direct"),
+			Imports: []string{"example.com/transitive/util", "example.com/indirect/util"}},
+		{ImportPath: "example.com/marked", Module: dep("example.com/marked")},
+		{ImportPath: "example.com/app/internal", Module: main},
+		{ImportPath: "example.com/app", Module: main,
+			Imports: []string{"fmt", "example.com/app/internal", "example.com/direct/api", "example.com/marked"}},
+	}
+
+	expected := map[string]RequireAction{
+		"example.com/direct":     ActionKeep,
+		"example.com/marked":     ActionPromote,
+		"example.com/transitive": ActionDemote,
+		"example.com/indirect":   ActionKeep,
+		"example.com/unused":     ActionRemove,
+	}
+
+	requires := classifyRequires(mod, pkgs)
+	if len(requires) != len(expected) {
+		t.Fatalf("Expected %d requires, got %d", len(expected), len(requires))
+	}
+	for _, req := range requires {
+		if req.Action != expected[req.Path] {
+			t.Errorf("%s: expected action %q, got %q", req.Path, expected[req.Path], req.Action)
+		}
+	}
+	if requires[0].Path != "example.com/direct" {
+		t.Errorf("Expected requires sorted by path, got %s first", requires[0].Path)
+	}
+}
+
+// TestMinimalRequires tests the analysis against this repository's go.mod
+func TestMinimalRequires(t *testing.T) {
+	mod := module.NewModule("bitspark.dev/go-tree", "../../..")
+	mod.AddDependency("github.com/spf13/cobra", "v1.9.1", false)
+	mod.AddDependency("github.com/spf13/pflag", "v1.0.6", true)
+	mod.AddDependency("example.com/not/used", "v1.0.0", false)
+
+	requires, err := NewAnalyzer().MinimalRequires(mod)
+	if err != nil {
+		t.Fatalf("MinimalRequires failed: %v", err)
+	}
+
+	expected := map[string]RequireAction{
+		"github.com/spf13/cobra": ActionKeep,
+		"github.com/spf13/pflag": ActionKeep,
+		"example.com/not/used":   ActionRemove,
+	}
+	for _, req := range requires {
+		if req.Action != expected[req.Path] {
+			t.Errorf("%s: expected action %q, got %q", req.Path, expected[req.Path], req.Action)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package modgraph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// SelectedVersion is a module required by the main module together with
+// the version minimal version selection picks for it
+type SelectedVersion struct {
+	Path        string // Module path
+	Required    string // Version go.mod requires, empty if only required transitively
+	Selected    string // Version selected by MVS
+	Replacement string // Replacement as "path@version" or a directory, if replaced
+}
+
+// Upgraded reports whether another module raised the version above the
+// one go.mod requires
+func (s SelectedVersion) Upgraded() bool {
+	return s.Required != "" && s.Required != s.Selected
+}
+
+// SelectedVersions computes the build list of the module by minimal
+// version selection: for each module reachable from the main module in
+// the requirement graph reported by `go mod graph`, the highest version
+// any reachable module version requires. The graph already honors replace
+// directives, as the requirements of a replaced module are read from its
+// replacement, and graph pruning. The main module is not included.
+func (a *Analyzer) SelectedVersions(mod *module.Module) (map[string]string, error) {
+	if mod == nil {
+		return nil, fmt.Errorf("module is nil")
+	}
+	graph, err := a.modGraph(mod)
+	if err != nil {
+		return nil, err
+	}
+	return selectVersions(mod.Path, graph), nil
+}
+
+// SelectedRequirements compares the versions go.mod requires with the
+// selected ones, as returned by SelectedVersions, and applies the
+// module's replace directives. Results are sorted by path.
+func SelectedRequirements(mod *module.Module, selected map[string]string) []SelectedVersion {
+	required := make(map[string]string, len(mod.Dependencies))
+	for _, dep := range mod.Dependencies {
+		required[dep.Path] = dep.Version
+	}
+
+	versions := make([]SelectedVersion, 0, len(selected))
+	for path, version := range selected {
+		versions = append(versions, SelectedVersion{
+			Path:        path,
+			Required:    required[path],
+			Selected:    version,
+			Replacement: replacement(mod, path, version),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Path < versions[j].Path })
+	return versions
+}
+
+// replacement returns the replacement of a module version, preferring a
+// replace directive for that version over one for all versions
+func replacement(mod *module.Module, path, version string) string {
+	var match *module.ModuleReplace
+	for _, r := range mod.Replace {
+		if r.Old == nil || r.New == nil || r.Old.Path != path {
+			continue
+		}
+		if r.Old.Version == version || (r.Old.Version == "" && match == nil) {
+			match = r
+		}
+	}
+	if match == nil {
+		return ""
+	}
+	if match.New.Version == "" {
+		return match.New.Path
+	}
+	return match.New.Path + "@" + match.New.Version
+}
+
+// selectVersions runs minimal version selection on the output of
+// `go mod graph`, walking the module versions reachable from the main
+// module
+func selectVersions(mainPath string, r io.Reader) map[string]string {
+	requires := make(map[string][]string)
+	for _, edge := range modGraphEdges(r) {
+		requires[edge[0]] = append(requires[edge[0]], edge[1])
+	}
+
+	selected := make(map[string]string)
+	visited := map[string]bool{mainPath: true}
+	queue := []string{mainPath}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, req := range requires[node] {
+			path, version, ok := strings.Cut(req, "@")
+			if !ok || path == mainPath {
+				continue
+			}
+			if current, ok := selected[path]; !ok || semver.Compare(version, current) > 0 {
+				selected[path] = version
+			}
+			if !visited[req] {
+				visited[req] = true
+				queue = append(queue, req)
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,69 @@
+package modgraph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TestSelectVersions tests minimal version selection on a module graph
+func TestSelectVersions(t *testing.T) {
+	graph := `example.com/app go@1.21
+example.com/app github.com/acme/a@v1.0.0
+example.com/app github.com/acme/b@v1.2.0
+example.com/app github.com/acme/local@v0.1.0
+github.com/acme/a@v1.0.0 github.com/acme/c@v1.0.0
+github.com/acme/b@v1.2.0 github.com/acme/a@v1.3.0
+github.com/acme/b@v1.2.0 example.com/app@v0.9.0
+github.com/acme/a@v1.3.0 github.com/acme/c@v1.1.0
+github.com/acme/a@v1.3.0 toolchain@go1.21.0
+github.com/acme/a@v1.1.0 github.com/acme/old@v1.0.0
+github.com/acme/c@v1.1.0 github.com/acme/c@v1.0.0
+`
+	selected := selectVersions("example.com/app", strings.NewReader(graph))
+	expected := map[string]string{
+		"github.com/acme/a":     "v1.3.0",
+		"github.com/acme/b":     "v1.2.0",
+		"github.com/acme/c":     "v1.1.0",
+		"github.com/acme/local": "v0.1.0",
+	}
+	if !reflect.DeepEqual(selected, expected) {
+		t.Fatalf("Expected %v, got %v", expected, selected)
+	}
+
+	mod := module.NewModule("example.com/app", "")
+	mod.AddDependency("github.com/acme/a", "v1.0.0", false)
+	mod.AddDependency("github.com/acme/b", "v1.2.0", false)
+	mod.AddDependency("github.com/acme/local", "v0.1.0", false)
+	mod.AddReplace("github.com/acme/local", "", "../local", "")
+	mod.AddReplace("github.com/acme/c", "v1.0.0", "github.com/fork/c", "v1.0.1")
+	mod.AddReplace("github.com/acme/c", "v1.1.0", "github.com/fork/c", "v1.1.1")
+
+	expectedVersions := []SelectedVersion{
+		{Path: "github.com/acme/a", Required: "v1.0.0", Selected: "v1.3.0"},
+		{Path: "github.com/acme/b", Required: "v1.2.0", Selected: "v1.2.0"},
+		{Path: "github.com/acme/c", Selected: "v1.1.0", Replacement: "github.com/fork/c@v1.1.1"},
+		{Path: "github.com/acme/local", Required: "v0.1.0", Selected: "v0.1.0", Replacement: "../local"},
+	}
+	versions := SelectedRequirements(mod, selected)
+	if !reflect.DeepEqual(versions, expectedVersions) {
+		t.Fatalf("Expected %+v, got %+v", expectedVersions, versions)
+	}
+	if !versions[0].Upgraded() || versions[1].Upgraded() || versions[2].Upgraded() {
+		t.Error("Expected only github.com/acme/a to be upgraded")
+	}
+}
+
+// TestSelectedVersions tests selection against this repository's module graph
+func TestSelectedVersions(t *testing.T) {
+	mod := module.NewModule("bitspark.dev/go-tree", "../../..")
+	selected, err := NewAnalyzer().SelectedVersions(mod)
+	if err != nil {
+		t.Fatalf("SelectedVersions failed: %v", err)
+	}
+	if selected["github.com/spf13/cobra"] == "" || selected["golang.org/x/mod"] == "" {
+		t.Errorf("Expected cobra and x/mod to be selected, got %v", selected)
+	}
+}
@@ -0,0 +1,293 @@
+// Package reachability computes which declarations of a module can be
+// reached from a set of entry points.
+package reachability
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// Analyzer computes reachability over a module's symbols
+type Analyzer struct{}
+
+// NewAnalyzer creates a new reachability analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// ReachableFrom returns the symbols of the module transitively referenced
+// by the entry points, including the entry points themselves. References
+// are found in function bodies, signatures, type definitions and variable
+// initializers. Interface dispatch is handled conservatively: every method
+// of a reachable type is reachable. The init functions of a package are
+// reachable as soon as any of its symbols is. The module must be loaded
+// with IncludeAST; symbols without type information are only reachable as
+// entry points. Symbols are matched by ID, so entry points may come from a
+// different call of Module.Symbols than the returned keys.
+func (a *Analyzer) ReachableFrom(mod *module.Module, entryPoints []*module.Symbol) map[*module.Symbol]bool {
+	idx := newIndex(mod)
+	reachable := make(map[*module.Symbol]bool)
+	reachedPkgs := make(map[string]bool)
+
+	var queue []*module.Symbol
+	mark := func(sym *module.Symbol) {
+		if sym == nil || reachable[sym] {
+			return
+		}
+		reachable[sym] = true
+		queue = append(queue, sym)
+	}
+	for _, sym := range entryPoints {
+		mark(idx.byID[sym.ID])
+	}
+
+	for len(queue) > 0 {
+		sym := queue[0]
+		queue = queue[1:]
+
+		if !reachedPkgs[sym.Package] {
+			reachedPkgs[sym.Package] = true
+			for _, init := range idx.inits[sym.Package] {
+				mark(init)
+			}
+			for _, ref := range idx.initRefs[sym.Package] {
+				mark(idx.symbols[ref])
+			}
+		}
+
+		obj := idx.objects[sym]
+		if obj == nil {
+			continue
+		}
+		for _, ref := range idx.refs[obj] {
+			mark(idx.symbols[ref])
+		}
+		if typeName, ok := obj.(*types.TypeName); ok {
+			for _, method := range idx.methods[typeName] {
+				mark(method)
+			}
+		}
+	}
+
+	return reachable
+}
+
+// Unreachable returns the symbols of the module that are not reachable from
+// the entry points, sorted by ID
+func (a *Analyzer) Unreachable(mod *module.Module, entryPoints []*module.Symbol) []*module.Symbol {
+	reachable := make(map[string]bool)
+	for sym := range a.ReachableFrom(mod, entryPoints) {
+		reachable[sym.ID] = true
+	}
+
+	var dead []*module.Symbol
+	for _, sym := range mod.Symbols() {
+		if !reachable[sym.ID] {
+			dead = append(dead, sym)
+		}
+	}
+	return dead
+}
+
+// DefaultEntryPoints returns the usual roots of a module: main functions of
+// main packages, the exported symbols of other packages that are not
+// internal, and, if the scope includes tests, the test functions of test
+// files. Only symbols in the given scope are returned.
+func DefaultEntryPoints(mod *module.Module, scope module.Scope) []*module.Symbol {
+	var entries []*module.Symbol
+	for _, sym := range mod.Symbols() {
+		if !scope.Includes(sym.File) {
+			continue
+		}
+		pkg := mod.Packages[sym.Package]
+		if pkg == nil {
+			continue
+		}
+
+		if fn, ok := sym.Element.(*module.Function); ok && fn.TestKind != module.TestKindNone && !fn.IsTestHelper() {
+			entries = append(entries, sym)
+			continue
+		}
+		if pkg.Name == "main" {
+			if sym.Kind == module.SymbolFunction && sym.Name == "main" {
+				entries = append(entries, sym)
+			}
+			continue
+		}
+		if isInternal(pkg.ImportPath) || !ast.IsExported(sym.Name) {
+			continue
+		}
+		if sym.Kind == module.SymbolMethod && !ast.IsExported(receiverName(sym.Element)) {
+			continue
+		}
+		entries = append(entries, sym)
+	}
+	return entries
+}
+
+// index maps between module symbols and type-checked objects and records
+// the package-level objects each declaration refers to
+type index struct {
+	byID    map[string]*module.Symbol
+	symbols map[types.Object]*module.Symbol
+	objects map[*module.Symbol]types.Object
+	refs    map[types.Object][]types.Object
+	methods map[*types.TypeName][]*module.Symbol
+	inits   map[string][]*module.Symbol
+
+	// init functions are not in the package scope, so their references are
+	// recorded by package
+	initRefs map[string][]types.Object
+}
+
+// newIndex builds the index of a module
+func newIndex(mod *module.Module) *index {
+	idx := &index{
+		byID:    make(map[string]*module.Symbol),
+		symbols: make(map[types.Object]*module.Symbol),
+		objects: make(map[*module.Symbol]types.Object),
+		refs:    make(map[types.Object][]types.Object),
+		methods: make(map[*types.TypeName][]*module.Symbol),
+		inits:   make(map[string][]*module.Symbol),
+
+		initRefs: make(map[string][]types.Object),
+	}
+
+	for _, sym := range mod.Symbols() {
+		idx.byID[sym.ID] = sym
+		if sym.Kind == module.SymbolFunction && sym.Name == "init" {
+			idx.inits[sym.Package] = append(idx.inits[sym.Package], sym)
+		}
+		pkg := mod.Packages[sym.Package]
+		if pkg == nil || pkg.TypesPackage == nil {
+			continue
+		}
+		obj := mod.LookupObject(sym)
+		if obj == nil {
+			continue
+		}
+		idx.symbols[obj] = sym
+		idx.objects[sym] = obj
+		if sym.Kind == module.SymbolMethod {
+			if recv := receiverTypeName(obj); recv != nil {
+				idx.methods[recv] = append(idx.methods[recv], sym)
+			}
+		}
+	}
+
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil {
+				continue
+			}
+			for _, decl := range file.AST.Decls {
+				if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.Name == "init" {
+					idx.initRefs[pkg.ImportPath] = append(idx.initRefs[pkg.ImportPath], usedObjects(pkg.TypesInfo, nil, fd)...)
+					continue
+				}
+				idx.addDecl(pkg.TypesInfo, decl)
+			}
+		}
+	}
+
+	return idx
+}
+
+// addDecl records the references of the declarations in decl
+func (idx *index) addDecl(info *types.Info, decl ast.Decl) {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if obj := info.Defs[d.Name]; obj != nil {
+			idx.addRefs(info, obj, d)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if obj := info.Defs[s.Name]; obj != nil {
+					idx.addRefs(info, obj, s)
+				}
+			case *ast.ValueSpec:
+				// Names sharing a spec share its type and initializers
+				for _, name := range s.Names {
+					if obj := info.Defs[name]; obj != nil {
+						idx.addRefs(info, obj, s)
+					}
+				}
+			}
+		}
+	}
+}
+
+// addRefs records the objects used within node as references of obj
+func (idx *index) addRefs(info *types.Info, obj types.Object, node ast.Node) {
+	idx.refs[obj] = append(idx.refs[obj], usedObjects(info, obj, node)...)
+}
+
+// usedObjects returns the distinct objects used within node, except self
+func usedObjects(info *types.Info, self types.Object, node ast.Node) []types.Object {
+	var used []types.Object
+	seen := make(map[types.Object]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := info.Uses[ident]
+		if obj == nil {
+			return true
+		}
+		obj = module.Origin(obj)
+		if obj != self && !seen[obj] {
+			seen[obj] = true
+			used = append(used, obj)
+		}
+		return true
+	})
+	return used
+}
+
+// receiverName returns the receiver type name of a method element
+func receiverName(element interface{}) string {
+	fn, ok := element.(*module.Function)
+	if !ok || fn.Receiver == nil {
+		return ""
+	}
+	name := strings.TrimPrefix(fn.Receiver.Type, "*")
+	if idx := strings.IndexByte(name, '['); idx >= 0 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// receiverTypeName returns the declared receiver type of a method
+func receiverTypeName(obj types.Object) *types.TypeName {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Origin().Obj()
+	}
+	return nil
+}
+
+// isInternal reports whether an import path is below an internal directory
+func isInternal(importPath string) bool {
+	return strings.HasSuffix(importPath, "/internal") || strings.Contains(importPath, "/internal/") ||
+		strings.HasPrefix(importPath, "internal/") || importPath == "internal"
+}
@@ -0,0 +1,112 @@
+package reachability
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func loadModule(t *testing.T) *module.Module {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"main.go": `package main
+
+import "example.com/app/internal/shapes"
+
+func main() {
+	var s shapes.Shape = shapes.NewSquare(2)
+	println(s.Area())
+}
+`,
+		"internal/shapes/shapes.go": `package shapes
+
+var registry = map[string]int{}
+
+func init() { registry["square"] = defaultSide }
+
+const defaultSide = 1
+
+type Shape interface{ Area() int }
+
+type Square struct{ side int }
+
+func NewSquare(side int) *Square { return &Square{side: scale(side)} }
+
+func (s *Square) Area() int { return s.side * s.side }
+
+func (s *Square) Perimeter() int { return 4 * s.side }
+
+func scale(n int) int { return n }
+
+type Circle struct{ r int }
+
+func (c *Circle) Area() int { return 3 * c.r * c.r }
+
+func Unused() int { return helper() }
+
+func helper() int { return 1 }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	return mod
+}
+
+func TestReachableFrom(t *testing.T) {
+	mod := loadModule(t)
+	analyzer := NewAnalyzer()
+
+	entries := DefaultEntryPoints(mod, module.ScopeProduction)
+	if len(entries) != 1 || entries[0].ID != "example.com/app.main" {
+		t.Fatalf("Expected main as the only entry point, got %v", symbolIDs(entries))
+	}
+
+	var dead []string
+	for _, sym := range analyzer.Unreachable(mod, entries) {
+		dead = append(dead, strings.TrimPrefix(sym.ID, "example.com/app/internal/shapes."))
+	}
+	expected := "Circle,Circle.Area,Unused,helper"
+	if got := strings.Join(dead, ","); got != expected {
+		t.Errorf("Expected unreachable %s, got %s", expected, got)
+	}
+
+	// Methods of reachable types are kept conservatively
+	reachable := make(map[string]bool)
+	for sym := range analyzer.ReachableFrom(mod, entries) {
+		reachable[sym.ID] = true
+	}
+	if !reachable["example.com/app/internal/shapes.Square.Perimeter"] {
+		t.Error("Expected Square.Perimeter to be reachable")
+	}
+}
+
+func symbolIDs(symbols []*module.Symbol) []string {
+	ids := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		ids = append(ids, sym.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,160 @@
+// Package typeconv finds the type assertions and conversions of a module
+// together with their resolved types.
+package typeconv
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// TypeAssertion is an x.(T) expression or a case of a type switch
+type TypeAssertion struct {
+	Position *module.Position // Position of T
+	Function string           // Enclosing function, qualified by receiver type
+	Source   types.Type       // Static type of x, an interface
+	Target   types.Type       // Asserted type T
+	CommaOk  bool             // Whether the assertion reports success as a second value
+	Switch   bool             // Whether T is a case of a type switch
+}
+
+// Conversion is a T(x) expression
+type Conversion struct {
+	Position *module.Position // Position of the conversion
+	Function string           // Enclosing function, empty at package level
+	Source   types.Type       // Type of x; a constant x has the type T
+	Target   types.Type       // Type T converted to
+}
+
+// Analyzer finds type assertions and conversions
+type Analyzer struct{}
+
+// NewAnalyzer creates a new type assertion and conversion analyzer
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// FindTypeAssertions returns every type assertion of the module, including
+// each type listed in the cases of a type switch; the nil case and the
+// default case assert nothing. Results are sorted by position. The module
+// must be loaded with IncludeAST.
+func (a *Analyzer) FindTypeAssertions(mod *module.Module) []TypeAssertion {
+	var assertions []TypeAssertion
+	// Assignments are visited before the assertions they contain
+	commaOk := make(map[*ast.TypeAssertExpr]bool)
+	walk(mod, func(pkg *module.Package, file *module.File, function string, n ast.Node) {
+		info := pkg.TypesInfo
+		switch node := n.(type) {
+		case *ast.TypeSwitchStmt:
+			var x ast.Expr
+			switch assign := node.Assign.(type) {
+			case *ast.ExprStmt:
+				x = assign.X.(*ast.TypeAssertExpr).X
+			case *ast.AssignStmt:
+				x = assign.Rhs[0].(*ast.TypeAssertExpr).X
+			}
+			for _, stmt := range node.Body.List {
+				for _, expr := range stmt.(*ast.CaseClause).List {
+					if tv, ok := info.Types[expr]; ok && !tv.IsNil() {
+						assertions = append(assertions, TypeAssertion{
+							Position: file.GetPositionInfo(expr.Pos(), expr.End()),
+							Function: function,
+							Source:   info.TypeOf(x),
+							Target:   tv.Type,
+							Switch:   true,
+						})
+					}
+				}
+			}
+
+		case *ast.AssignStmt:
+			// v, ok := x.(T)
+			if len(node.Lhs) == 2 && len(node.Rhs) == 1 {
+				if assert, ok := ast.Unparen(node.Rhs[0]).(*ast.TypeAssertExpr); ok {
+					commaOk[assert] = true
+				}
+			}
+
+		case *ast.ValueSpec:
+			// var v, ok = x.(T)
+			if len(node.Names) == 2 && len(node.Values) == 1 {
+				if assert, ok := ast.Unparen(node.Values[0]).(*ast.TypeAssertExpr); ok {
+					commaOk[assert] = true
+				}
+			}
+
+		case *ast.TypeAssertExpr:
+			// The x.(type) of a type switch has no type
+			if node.Type != nil {
+				assertions = append(assertions, TypeAssertion{
+					Position: file.GetPositionInfo(node.Type.Pos(), node.Type.End()),
+					Function: function,
+					Source:   info.TypeOf(node.X),
+					Target:   info.TypeOf(node.Type),
+					CommaOk:  commaOk[node],
+				})
+			}
+		}
+	})
+
+	sort.SliceStable(assertions, func(i, j int) bool {
+		return assertions[i].Position.Before(assertions[j].Position)
+	})
+	return assertions
+}
+
+// FindConversions returns every conversion of the module, sorted by
+// position. The module must be loaded with IncludeAST.
+func (a *Analyzer) FindConversions(mod *module.Module) []Conversion {
+	var conversions []Conversion
+	walk(mod, func(pkg *module.Package, file *module.File, function string, n ast.Node) {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return
+		}
+		tv, ok := pkg.TypesInfo.Types[call.Fun]
+		if !ok || !tv.IsType() {
+			return
+		}
+		conversions = append(conversions, Conversion{
+			Position: file.GetPositionInfo(call.Pos(), call.End()),
+			Function: function,
+			Source:   pkg.TypesInfo.TypeOf(call.Args[0]),
+			Target:   tv.Type,
+		})
+	})
+
+	sort.SliceStable(conversions, func(i, j int) bool {
+		return conversions[i].Position.Before(conversions[j].Position)
+	})
+	return conversions
+}
+
+// walk calls fn for every node of every file with syntax and type
+// information, along with the name of the enclosing function
+func walk(mod *module.Module, fn func(pkg *module.Package, file *module.File, function string, n ast.Node)) {
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil || file.FileSet == nil {
+				continue
+			}
+			for _, decl := range file.AST.Decls {
+				function := ""
+				if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+					function = module.FuncDeclName(funcDecl)
+				}
+				ast.Inspect(decl, func(n ast.Node) bool {
+					if n != nil {
+						fn(pkg, file, function, n)
+					}
+					return true
+				})
+			}
+		}
+	}
+}
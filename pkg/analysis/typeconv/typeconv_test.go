@@ -0,0 +1,85 @@
+package typeconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestFindAssertionsAndConversions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/conv\n\ngo 1.21\n",
+		"conv.go": `package conv
+
+import "fmt"
+
+type ID int64
+
+type Handler struct{}
+
+var Default = ID(7)
+
+func (h *Handler) Handle(v any) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	n := v.(int)
+	switch x := v.(type) {
+	case ID, nil:
+		return fmt.Sprint(int64(x.(ID)))
+	case error:
+		return x.Error()
+	}
+	return string(rune(n))
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	analyzer := NewAnalyzer()
+
+	var got []string
+	for _, a := range analyzer.FindTypeAssertions(mod) {
+		got = append(got, fmt.Sprintf("%d %s %s->%s ok=%v switch=%v", a.Position.LineStart, a.Function, a.Source, a.Target, a.CommaOk, a.Switch))
+	}
+	expected := []string{
+		"12 Handler.Handle any->fmt.Stringer ok=true switch=false",
+		"15 Handler.Handle any->int ok=false switch=false",
+		"17 Handler.Handle any->example.com/conv.ID ok=false switch=true",
+		"18 Handler.Handle any->example.com/conv.ID ok=false switch=false",
+		"19 Handler.Handle any->error ok=false switch=true",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected assertions:\n%s", strings.Join(got, "\n"))
+	}
+
+	got = got[:0]
+	for _, c := range analyzer.FindConversions(mod) {
+		got = append(got, fmt.Sprintf("%d %s %s->%s", c.Position.LineStart, c.Function, c.Source, c.Target))
+	}
+	expected = []string{
+		// Constants take the type they are converted to
+		"9  example.com/conv.ID->example.com/conv.ID",
+		"18 Handler.Handle example.com/conv.ID->int64",
+		"22 Handler.Handle rune->string",
+		"22 Handler.Handle int->rune",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected conversions:\n%s", strings.Join(got, "\n"))
+	}
+}
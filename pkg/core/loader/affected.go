@@ -0,0 +1,156 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// listedPackage is the subset of `go list -json` output needed to find
+// affected and changed packages
+type listedPackage struct {
+	ImportPath   string
+	Dir          string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+	GoFiles      []string
+	CgoFiles     []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+// LoadAffected loads the packages of a module affected by changes to the
+// given files, with default options. See GoModuleLoader.LoadAffected.
+func LoadAffected(mod *module.Module, changedFiles []string) (*module.Module, error) {
+	return NewGoModuleLoader().LoadAffected(mod, changedFiles, DefaultLoadOptions())
+}
+
+// LoadAffected loads only the packages of a module affected by changes to
+// the given files: the packages in the directories of the changed files and
+// every package of the module that imports them, directly or transitively,
+// including imports of their tests, whose results the change may alter.
+// Files are given relative to the module directory or as absolute paths,
+// and may have been deleted. A change to go.mod or go.sum affects every
+// package. The import graph is read with `go list`, which does not
+// type-check, so only the affected packages and their dependencies are
+// type-checked. options.PackagePaths is replaced by the affected packages;
+// if there are none, the returned module has no packages.
+func (l *GoModuleLoader) LoadAffected(mod *module.Module, changedFiles []string, options LoadOptions) (*module.Module, error) {
+	if mod == nil || mod.Dir == "" {
+		return nil, fmt.Errorf("module must be loaded from a directory")
+	}
+
+	affected, all, err := l.affectedPackages(mod.Dir, changedFiles, options.BuildTags)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		options.PackagePaths = nil
+		return l.LoadWithOptions(mod.Dir, options)
+	}
+	if len(affected) == 0 {
+		empty := module.NewModule(mod.Path, mod.Dir)
+		empty.GoVersion = mod.GoVersion
+		empty.Dependencies = mod.Dependencies
+		empty.Replace = mod.Replace
+		empty.Exclude = mod.Exclude
+		empty.Retract = mod.Retract
+		empty.BuildTags = append(empty.BuildTags, options.BuildTags...)
+		return empty, nil
+	}
+
+	options.PackagePaths = affected
+	return l.LoadWithOptions(mod.Dir, options)
+}
+
+// affectedPackages returns the sorted import paths of the packages of the
+// module in dir affected by changes to files; all reports that every
+// package is affected
+func (l *GoModuleLoader) affectedPackages(dir string, changedFiles, buildTags []string) ([]string, bool, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changedDirs := make(map[string]bool)
+	for _, file := range changedFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(absDir, file)
+		}
+		file = filepath.Clean(file)
+		if file == filepath.Join(absDir, "go.mod") || file == filepath.Join(absDir, "go.sum") {
+			return nil, true, nil
+		}
+		changedDirs[filepath.Dir(file)] = true
+	}
+
+	pkgs, err := l.listModulePackages(absDir, buildTags)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Invert the import graph and seed it with the changed packages
+	importers := make(map[string][]string)
+	var queue []string
+	for _, pkg := range pkgs {
+		for _, imports := range [][]string{pkg.Imports, pkg.TestImports, pkg.XTestImports} {
+			for _, imp := range imports {
+				importers[imp] = append(importers[imp], pkg.ImportPath)
+			}
+		}
+		if changedDirs[filepath.Clean(pkg.Dir)] {
+			queue = append(queue, pkg.ImportPath)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var affected []string
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		affected = append(affected, path)
+		queue = append(queue, importers[path]...)
+	}
+	sort.Strings(affected)
+	return affected, false, nil
+}
+
+// listModulePackages lists the packages of the module in dir with their
+// imports, including those of tests, and files under the build tags,
+// without type-checking them
+func (l *GoModuleLoader) listModulePackages(dir string, buildTags []string) ([]listedPackage, error) {
+	cmd := exec.Command("go", "list", "-e", "-tags="+strings.Join(buildTags, ","), "-json=ImportPath,Dir,Imports,TestImports,XTestImports,GoFiles,CgoFiles,TestGoFiles,XTestGoFiles", "./...")
+	cmd.Dir = dir
+	cmd.Env = l.env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []listedPackage
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
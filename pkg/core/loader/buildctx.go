@@ -0,0 +1,271 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// BuildContext is a target platform and set of build tags to load a module
+// for
+type BuildContext struct {
+	GOOS   string   // Target operating system; empty means the current one
+	GOARCH string   // Target architecture; empty means the current one
+	Tags   []string // Build tags in addition to LoadOptions.BuildTags
+}
+
+// String returns the context as "goos/goarch", followed by its tags as
+// ",tag1,tag2"; unset platform parts are left empty
+func (c BuildContext) String() string {
+	s := c.GOOS + "/" + c.GOARCH
+	if len(c.Tags) > 0 {
+		s += "," + strings.Join(c.Tags, ",")
+	}
+	return s
+}
+
+// environ returns the environment variables selecting the platform
+func (c BuildContext) environ() []string {
+	var env []string
+	if c.GOOS != "" {
+		env = append(env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		env = append(env, "GOARCH="+c.GOARCH)
+	}
+	return env
+}
+
+// loadContexts loads a module once per build context of the options and
+// merges the loads. Files and declarations only some contexts include are
+// added to the first load, which provides the type information.
+func (l *GoModuleLoader) loadContexts(dir string, options LoadOptions) (*module.Module, error) {
+	if options.RecordTo != "" {
+		return nil, errors.New("loads in several build contexts cannot be recorded")
+	}
+
+	var merged *module.Module
+	for _, ctx := range options.BuildContexts {
+		ctxOptions := options
+		ctxOptions.BuildContexts = nil
+		ctxOptions.BuildTags = append(append([]string(nil), options.BuildTags...), ctx.Tags...)
+		mod, err := l.withEnv(ctx.environ()...).LoadWithOptions(dir, ctxOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load for %s: %w", ctx, err)
+		}
+		for _, pkg := range mod.Packages {
+			for _, file := range pkg.Files {
+				file.BuildContexts = append(file.BuildContexts, ctx.String())
+			}
+		}
+		if merged == nil {
+			merged = mod
+			continue
+		}
+		mergeModule(merged, mod)
+	}
+
+	// Tags of single contexts do not apply to the merged module
+	merged.BuildTags = append([]string{}, options.BuildTags...)
+	merged.LinkEmbeddedInterfaces()
+	return merged, nil
+}
+
+// mergeModule adds the packages, files and declarations of a load that
+// the module does not have yet. Declarations already present win.
+func mergeModule(mod, from *module.Module) {
+	paths := make([]string, 0, len(from.Packages))
+	for path := range from.Packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pkg := from.Packages[path]
+		target := mod.Packages[path]
+		if target == nil {
+			mod.AddPackage(pkg)
+			continue
+		}
+
+		names := make([]string, 0, len(pkg.Files))
+		for name := range pkg.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			file := pkg.Files[name]
+			if existing := target.Files[name]; existing != nil {
+				existing.BuildContexts = append(existing.BuildContexts, file.BuildContexts...)
+				continue
+			}
+			target.Files[name] = file
+			file.Package = target
+			for _, typ := range file.Types {
+				if target.Types[typ.Name] == nil {
+					target.Types[typ.Name] = typ
+				}
+				typ.Package = target
+			}
+			for _, fn := range file.Functions {
+				if target.Functions[fn.Name] == nil {
+					target.Functions[fn.Name] = fn
+				}
+				fn.Package = target
+				if fn.IsMethod && fn.Receiver != nil {
+					attachMethod(target, fn)
+				}
+			}
+			for _, v := range file.Variables {
+				if target.Variables[v.Name] == nil {
+					target.Variables[v.Name] = v
+				}
+				v.Package = target
+			}
+			for _, c := range file.Constants {
+				if target.Constants[c.Name] == nil {
+					target.Constants[c.Name] = c
+				}
+				c.Package = target
+			}
+		}
+	}
+	mod.Diagnostics = append(mod.Diagnostics, from.Diagnostics...)
+}
+
+// attachMethod adds a method of a merged file to its receiver type, unless
+// the type already has a method of that name, as when the type itself was
+// merged or another context's file declares the method as well
+func attachMethod(pkg *module.Package, fn *module.Function) {
+	name := strings.TrimPrefix(fn.Receiver.Type, "*")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	typ := pkg.Types[name]
+	if typ == nil {
+		return
+	}
+	for _, method := range typ.Methods {
+		if method.Name == fn.Name {
+			return
+		}
+	}
+	typ.Methods = append(typ.Methods, &module.Method{
+		Name:      fn.Name,
+		Signature: fn.Signature,
+		Doc:       fn.Doc,
+		Parent:    typ,
+		Pos:       fn.Pos,
+		End:       fn.End,
+	})
+}
+
+// knownOS and knownArch are the GOOS and GOARCH values recognized in file
+// names, as by the go command
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// fileBuildConstraint returns the build constraint of a file, combining
+// its //go:build line, or its // +build lines, with the platform implied
+// by its name (e.g. "_windows.go"), and the sorted tags the constraint
+// refers to. Both are empty if the file is unconditional.
+func fileBuildConstraint(file *ast.File, fileName string) (string, []string) {
+	var expr constraint.Expr
+	var plusBuild []constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			switch {
+			case constraint.IsGoBuild(comment.Text):
+				if e, err := constraint.Parse(comment.Text); err == nil && expr == nil {
+					expr = e
+				}
+			case constraint.IsPlusBuild(comment.Text):
+				if e, err := constraint.Parse(comment.Text); err == nil {
+					plusBuild = append(plusBuild, e)
+				}
+			}
+		}
+	}
+	if expr == nil {
+		for _, e := range plusBuild {
+			expr = and(expr, e)
+		}
+	}
+	for _, tag := range fileNameTags(fileName) {
+		expr = and(expr, &constraint.TagExpr{Tag: tag})
+	}
+	if expr == nil {
+		return "", nil
+	}
+
+	seen := make(map[string]bool)
+	var tags []string
+	var walk func(constraint.Expr)
+	walk = func(e constraint.Expr) {
+		switch e := e.(type) {
+		case *constraint.TagExpr:
+			if !seen[e.Tag] {
+				seen[e.Tag] = true
+				tags = append(tags, e.Tag)
+			}
+		case *constraint.NotExpr:
+			walk(e.X)
+		case *constraint.AndExpr:
+			walk(e.X)
+			walk(e.Y)
+		case *constraint.OrExpr:
+			walk(e.X)
+			walk(e.Y)
+		}
+	}
+	walk(expr)
+	sort.Strings(tags)
+	return expr.String(), tags
+}
+
+// and combines two constraints, either of which may be nil
+func and(x, y constraint.Expr) constraint.Expr {
+	if x == nil {
+		return y
+	}
+	return &constraint.AndExpr{X: x, Y: y}
+}
+
+// fileNameTags returns the GOOS and GOARCH a file is restricted to by a
+// name of the form name_GOOS_GOARCH.go, name_GOOS.go or name_GOARCH.go
+func fileNameTags(fileName string) []string {
+	name := strings.TrimSuffix(strings.TrimSuffix(fileName, ".go"), "_test")
+	parts := strings.Split(name, "_")
+	if len(parts) < 2 {
+		return nil
+	}
+	last := parts[len(parts)-1]
+	if len(parts) >= 3 && knownOS[parts[len(parts)-2]] && knownArch[last] {
+		return []string{parts[len(parts)-2], last}
+	}
+	if knownOS[last] || knownArch[last] {
+		return []string{last}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// cacheVersion is the format version of module caches written by this
+// package
+const cacheVersion = 1
+
+// moduleCache is the content of a module cache file
+type moduleCache struct {
+	Version   int            // Format version of the cache
+	Options   LoadOptions    // Options the module was loaded with
+	GoModHash string         // Hash of go.mod when the module was loaded
+	Module    *module.Module // Loaded module
+}
+
+// LoadWithCache loads the module in dir like LoadWithOptions, keeping a
+// copy of the result in the file at cachePath to speed up later loads. A
+// cached module is used if it was loaded from the same directory with the
+// same options and go.mod is unchanged; its packages whose Go files, and
+// test files with IncludeTests, were added, removed or changed since, as
+// seen by `go list`, are loaded again, so unchanged packages are not
+// type-checked. Packages from the cache are not passed to OnPackageLoaded.
+// The cache holds no ASTs or type information, so with IncludeAST,
+// PackagePaths, Overlay or RecordTo set the cache is not used.
+func (l *GoModuleLoader) LoadWithCache(dir, cachePath string, options LoadOptions) (*module.Module, error) {
+	if options.IncludeAST || len(options.PackagePaths) > 0 || options.RecordTo != "" || len(options.Overlay) > 0 {
+		return l.LoadWithOptions(dir, options)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	goMod, err := os.ReadFile(filepath.Join(absDir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	goModHash := module.SourceHash(goMod)
+
+	mod := readCache(cachePath, absDir, options, goModHash)
+	if mod != nil {
+		changed, err := l.refreshPackages(mod, options)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			return mod, nil
+		}
+	} else if mod, err = l.LoadWithOptions(absDir, options); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&moduleCache{Version: cacheVersion, Options: options, GoModHash: goModHash, Module: mod})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode module cache: %w", err)
+	}
+	tmp := cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write module cache: %w", err)
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		return nil, fmt.Errorf("failed to write module cache: %w", err)
+	}
+	return mod, nil
+}
+
+// readCache returns the module cached at path if it can be used for a load
+// of dir with the options, or nil
+func readCache(path, dir string, options LoadOptions, goModHash string) *module.Module {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cache moduleCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Version != cacheVersion || cache.Module == nil {
+		return nil
+	}
+	if cache.GoModHash != goModHash || cache.Module.Dir != dir {
+		return nil
+	}
+	cached, err := json.Marshal(cache.Options)
+	if err != nil {
+		return nil
+	}
+	current, err := json.Marshal(options)
+	if err != nil || !bytes.Equal(cached, current) {
+		return nil
+	}
+	return cache.Module
+}
+
+// refreshPackages brings a cached module up to date with its files on disk
+// and reports whether anything changed
+func (l *GoModuleLoader) refreshPackages(mod *module.Module, options LoadOptions) (bool, error) {
+	listed, err := l.listModulePackages(mod.Dir, options.BuildTags)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	current := make(map[string]bool)
+	var stale []string
+	for _, pkg := range listed {
+		current[pkg.ImportPath] = true
+		isStale := packageChanged(mod.Packages[pkg.ImportPath], pkg.Dir, packageFiles(pkg, options.IncludeTests))
+		if options.IncludeTests && len(pkg.XTestGoFiles) > 0 {
+			// The external test package is loaded along with the package
+			xtest := pkg.ImportPath + "_test"
+			current[xtest] = true
+			isStale = isStale || packageChanged(mod.Packages[xtest], pkg.Dir, pkg.XTestGoFiles)
+		}
+		if isStale {
+			stale = append(stale, pkg.ImportPath)
+		}
+	}
+	for importPath := range mod.Packages {
+		if !current[importPath] {
+			delete(mod.Packages, importPath)
+			changed = true
+		}
+	}
+
+	if len(stale) > 0 {
+		options.PackagePaths = stale
+		reloaded, err := l.LoadWithOptions(mod.Dir, options)
+		if err != nil {
+			return false, err
+		}
+		for _, importPath := range stale {
+			delete(mod.Packages, importPath)
+			delete(mod.Packages, importPath+"_test")
+		}
+		for _, pkg := range reloaded.Packages {
+			mod.AddPackage(pkg)
+		}
+		changed = true
+	}
+	if changed {
+		mod.LinkEmbeddedInterfaces()
+	}
+	return changed, nil
+}
+
+// packageFiles returns the names of the files the loader reads for a
+// listed package
+func packageFiles(listed listedPackage, includeTests bool) []string {
+	names := append(append([]string(nil), listed.GoFiles...), listed.CgoFiles...)
+	if includeTests {
+		names = append(names, listed.TestGoFiles...)
+	}
+	return names
+}
+
+// packageChanged reports whether the named files in dir differ from the
+// files of the cached package
+func packageChanged(cached *module.Package, dir string, names []string) bool {
+	if cached == nil || len(cached.Files) != len(names) {
+		return true
+	}
+	for _, name := range names {
+		file := cached.Files[name]
+		if file == nil {
+			return true
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || module.SourceHash(content) != module.SourceHash([]byte(file.SourceCode)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package loader
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/gcexportdata"
+)
+
+// exportDataReadable caches whether the export data of a Go toolchain can be
+// read, by GOVERSION
+var exportDataReadable sync.Map
+
+// canReadExportData reports whether go/packages can type-check against the
+// export data the go command writes for dir. Toolchains newer than
+// golang.org/x/tools write export data it cannot decode; dependencies must
+// then be type-checked from source.
+func (l *GoModuleLoader) canReadExportData(dir string) bool {
+	version, err := l.goCommand(dir, "env", "GOVERSION")
+	if err != nil {
+		return false
+	}
+	if readable, ok := exportDataReadable.Load(version); ok {
+		return readable.(bool)
+	}
+
+	readable := false
+	if exportFile, err := l.goCommand(dir, "list", "-export", "-f", "{{.Export}}", "errors"); err == nil {
+		readable = readExportData(exportFile, "errors") == nil
+	}
+	exportDataReadable.Store(version, readable)
+	return readable
+}
+
+// goCommand runs the go command in dir with the loader's environment and
+// returns its trimmed output
+func (l *GoModuleLoader) goCommand(dir string, args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = l.env
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go %s failed: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// readExportData decodes the export data file of a package
+func readExportData(path, importPath string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader, err := gcexportdata.NewReader(file)
+	if err != nil {
+		return err
+	}
+	_, err = gcexportdata.Read(reader, token.NewFileSet(), make(map[string]*types.Package), importPath)
+	return err
+}
@@ -6,11 +6,12 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
-	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
 
 	"bitspark.dev/go-tree/pkg/core/module"
@@ -62,6 +63,14 @@ func safeReadFile(filePath, baseDir string) ([]byte, error) {
 // GoModuleLoader implements ModuleLoader for Go modules
 type GoModuleLoader struct {
 	fset *token.FileSet
+
+	// Environment of the go command; nil means the current environment
+	env []string
+
+	// Type information of packages that are not loaded again, by import
+	// path; if set, loaded packages are type-checked against it instead of
+	// type-checking their dependencies, see ReloadFile
+	keptTypes map[string]*types.Package
 }
 
 // NewGoModuleLoader creates a new module loader for Go modules
@@ -71,6 +80,20 @@ func NewGoModuleLoader() *GoModuleLoader {
 	}
 }
 
+// withEnv returns a loader that shares the file set of l and runs the go
+// command with additional environment variables. l itself is unchanged, so
+// it can keep being used concurrently.
+func (l *GoModuleLoader) withEnv(vars ...string) *GoModuleLoader {
+	env := l.env
+	if env == nil {
+		env = os.Environ()
+	}
+	return &GoModuleLoader{
+		fset: l.fset,
+		env:  append(append([]string(nil), env...), vars...),
+	}
+}
+
 // Load loads a Go module with default options
 func (l *GoModuleLoader) Load(dir string) (*module.Module, error) {
 	return l.LoadWithOptions(dir, DefaultLoadOptions())
@@ -78,6 +101,13 @@ func (l *GoModuleLoader) Load(dir string) (*module.Module, error) {
 
 // LoadWithOptions loads a Go module with the specified options
 func (l *GoModuleLoader) LoadWithOptions(dir string, options LoadOptions) (*module.Module, error) {
+	if len(options.BuildContexts) > 0 {
+		return l.loadContexts(dir, options)
+	}
+	if len(options.Overlay) > 0 && options.RecordTo != "" {
+		return nil, errors.New("loads with an overlay cannot be recorded")
+	}
+
 	// Check if dir is a valid Go module
 	goModPath := filepath.Join(dir, "go.mod")
 	if _, err := os.Stat(goModPath); os.IsNotExist(err) {
@@ -90,34 +120,35 @@ func (l *GoModuleLoader) LoadWithOptions(dir string, options LoadOptions) (*modu
 		return nil, fmt.Errorf("failed to read go.mod: %w", err)
 	}
 
-	modFile, err := modfile.Parse(goModPath, modContent, nil)
+	mod, err := newModuleFromGoMod(goModPath, dir, modContent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
-	}
-
-	// Create module
-	mod := module.NewModule(modFile.Module.Mod.Path, dir)
-	mod.GoVersion = modFile.Go.Version
-
-	// Add dependencies
-	for _, req := range modFile.Require {
-		mod.AddDependency(req.Mod.Path, req.Mod.Version, req.Indirect)
-	}
-
-	// Add replacements
-	for _, rep := range modFile.Replace {
-		mod.AddReplace(rep.Old.Path, rep.Old.Version, rep.New.Path, rep.New.Version)
+		return nil, err
 	}
+	mod.BuildTags = append(mod.BuildTags, options.BuildTags...)
 
 	// Load packages
-	pkgs, err := l.loadPackages(dir, options)
+	pkgs, diagnostics, err := l.loadPackages(dir, options)
+	if options.RecordTo != "" {
+		// Failed loads are recorded too, they are the ones worth reproducing
+		if recErr := l.record(dir, options, err); recErr != nil {
+			return nil, fmt.Errorf("failed to record load: %w", recErr)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to load packages: %w", err)
 	}
 
+	mod.Diagnostics = diagnostics
+	overlay := overlayFiles(dir, options.Overlay)
+
 	// Convert loaded packages to module packages
 	for _, pkg := range pkgs {
+		// Packages that could not be listed or parsed have nothing to describe
+		if len(pkg.Errors) > 0 && len(pkg.Syntax) == 0 {
+			continue
+		}
 		modPkg := module.NewPackage(pkg.Name, pkg.PkgPath, pkg.Dir)
+		modPkg.IsTest = pkg.ForTest != "" && pkg.ForTest != pkg.PkgPath
 
 		// Set package position if available
 		if len(pkg.Syntax) > 0 {
@@ -141,9 +172,10 @@ func (l *GoModuleLoader) LoadWithOptions(dir string, options LoadOptions) (*modu
 
 			// Use the shared FileSet for all files
 			modFile.FileSet = l.fset
+			modFile.BuildConstraint, modFile.BuildTags = fileBuildConstraint(file, fileName)
 
 			// Get the source code
-			fileContent, err := safeReadFile(filePath, pkg.Dir)
+			fileContent, err := readSource(filePath, pkg.Dir, overlay)
 			if err == nil {
 				modFile.SourceCode = string(fileContent)
 
@@ -151,10 +183,6 @@ func (l *GoModuleLoader) LoadWithOptions(dir string, options LoadOptions) (*modu
 				// Important: Use the same FileSet that was used to parse the AST
 				// and pass position 1 (not base position) for correct position mapping
 				modFile.TokenFile = l.fset.AddFile(filePath, -1, len(fileContent))
-
-				// Debug print
-				fmt.Printf("DEBUG: Created TokenFile for %s: Base=%v, Size=%v\n",
-					fileName, modFile.TokenFile.Base(), modFile.TokenFile.Size())
 			}
 
 			// Add imports with position information
@@ -194,26 +222,82 @@ func (l *GoModuleLoader) LoadWithOptions(dir string, options LoadOptions) (*modu
 			modPkg.AddFile(modFile)
 		}
 
+		// Keep type information alongside the AST it refers to
+		if options.IncludeAST {
+			modPkg.TypesPackage = pkg.Types
+			modPkg.TypesInfo = pkg.TypesInfo
+		}
+
 		// Second pass: Associate methods with their receiver types
 		// This needs to be done after all types are loaded
 		l.associateMethodsWithTypes(modPkg)
 
+		markUnmodified(modPkg)
+
 		// Add package to module
 		mod.AddPackage(modPkg)
+
+		if options.OnPackageLoaded != nil {
+			options.OnPackageLoaded(modPkg)
+		}
 	}
 
+	// Embedded interfaces may refer to any package of the module
+	mod.LinkEmbeddedInterfaces()
+
 	return mod, nil
 }
 
-// loadPackages loads Go packages using the go/packages API
-func (l *GoModuleLoader) loadPackages(dir string, options LoadOptions) ([]*packages.Package, error) {
-	// Configure the packages.Load call
+// overlayFiles returns an overlay with absolute paths, resolving relative
+// ones against the module directory
+func overlayFiles(dir string, overlay map[string][]byte) map[string][]byte {
+	if len(overlay) == 0 {
+		return nil
+	}
+	absDir := absPath(dir)
+	files := make(map[string][]byte, len(overlay))
+	for path, content := range overlay {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(absDir, path)
+		}
+		files[filepath.Clean(path)] = content
+	}
+	return files
+}
+
+// readSource returns the content of a loaded file, from the overlay if it
+// is overlaid
+func readSource(filePath, baseDir string, overlay map[string][]byte) ([]byte, error) {
+	if content, ok := overlay[filepath.Clean(filePath)]; ok {
+		return content, nil
+	}
+	return safeReadFile(filePath, baseDir)
+}
+
+// packagesConfig returns the packages.Load configuration and patterns for
+// loading a module. Dependencies are type-checked from source only if the
+// toolchain's export data cannot be read.
+func (l *GoModuleLoader) packagesConfig(dir string, options LoadOptions) (*packages.Config, []string) {
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+		packages.NeedTypes | packages.NeedTypesInfo
+	switch {
+	case l.keptTypes != nil:
+		// Only parsed, the packages are type-checked by checkAgainstKept
+		mode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypesSizes
+	case !l.canReadExportData(dir):
+		mode |= packages.NeedImports | packages.NeedDeps
+	}
+	if options.IncludeTests {
+		mode |= packages.NeedForTest
+	}
 	config := &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
-			packages.NeedTypes | packages.NeedTypesInfo,
+		Mode:       mode,
 		Dir:        dir,
+		Env:        l.env,
 		Fset:       l.fset,
 		BuildFlags: []string{fmt.Sprintf("-tags=%s", strings.Join(options.BuildTags, ","))},
+		Overlay:    overlayFiles(dir, options.Overlay),
+		Tests:      options.IncludeTests,
 	}
 
 	// Determine patterns to load
@@ -221,26 +305,129 @@ func (l *GoModuleLoader) loadPackages(dir string, options LoadOptions) ([]*packa
 	if len(options.PackagePaths) > 0 {
 		patterns = options.PackagePaths
 	}
+	return config, patterns
+}
+
+// loadPackages loads Go packages using the go/packages API. Errors in the
+// packages fail the load unless options allow them; they are returned as
+// diagnostics either way.
+func (l *GoModuleLoader) loadPackages(dir string, options LoadOptions) ([]*packages.Package, []module.Diagnostic, error) {
+	config, patterns := l.packagesConfig(dir, options)
 
 	// Load the packages
 	pkgs, err := packages.Load(config, patterns...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load packages: %w", err)
+		return nil, nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	if options.IncludeTests {
+		pkgs = testVariants(pkgs)
+	}
+
+	// go/packages type-checks at the go.mod version, redo it on override
+	var goVersion string
+	if options.GoVersion != "" {
+		if goVersion, err = normalizeGoVersion(options.GoVersion); err != nil {
+			return nil, nil, err
+		}
+	}
+	switch {
+	case l.keptTypes != nil:
+		l.checkAgainstKept(dir, pkgs, goVersion, options)
+	case goVersion != "":
+		retypeCheck(l.fset, pkgs, goVersion)
 	}
 
 	// Check for errors in packages
+	isRoot := make(map[*packages.Package]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		isRoot[pkg] = true
+	}
 	var errs []error
+	var diagnostics []module.Diagnostic
 	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
 		for _, err := range pkg.Errors {
 			errs = append(errs, fmt.Errorf("error in package %q: %v", pkg.PkgPath, err))
+			diagnostics = append(diagnostics, newDiagnostic(pkg, err, isRoot[pkg]))
 		}
 	})
 
-	if len(errs) > 0 {
-		return nil, errors.Join(errs...)
+	if len(errs) > 0 && !options.AllowErrors {
+		return nil, nil, errors.Join(errs...)
 	}
 
-	return pkgs, nil
+	return pkgs, diagnostics, nil
+}
+
+// testVariants reduces packages loaded with their tests to the variant of
+// each package that includes its test files and the external test packages,
+// dropping the generated test main packages
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	withTests := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.ForTest == pkg.PkgPath {
+			withTests[pkg.PkgPath] = true
+		}
+	}
+	var variants []*packages.Package
+	for _, pkg := range pkgs {
+		switch {
+		case strings.HasSuffix(pkg.ID, ".test") && pkg.ForTest == "":
+			// Generated test main
+		case pkg.ForTest == "" && withTests[pkg.PkgPath]:
+			// Superseded by the variant with test files
+		default:
+			variants = append(variants, pkg)
+		}
+	}
+	return variants
+}
+
+// newDiagnostic converts an error of a loaded package; errors outside the
+// loaded packages of the module are warnings
+func newDiagnostic(pkg *packages.Package, err packages.Error, inModule bool) module.Diagnostic {
+	d := module.Diagnostic{
+		Severity: module.SeverityWarning,
+		Package:  pkg.PkgPath,
+		Message:  err.Msg,
+	}
+	if inModule {
+		d.Severity = module.SeverityError
+	}
+	if d.Package == "" {
+		d.Package = pkg.ID
+	}
+	switch err.Kind {
+	case packages.ListError:
+		d.Kind = "list"
+	case packages.ParseError:
+		d.Kind = "parse"
+	case packages.TypeError:
+		d.Kind = "type"
+	}
+
+	// Positions are "file:line:col", "file:line", "file", or "-" if unknown
+	pos := err.Pos
+	if pos == "-" {
+		pos = ""
+	}
+	for _, field := range []*int{&d.Column, &d.Line} {
+		i := strings.LastIndexByte(pos, ':')
+		if i < 0 {
+			break
+		}
+		n, convErr := strconv.Atoi(pos[i+1:])
+		if convErr != nil {
+			break
+		}
+		*field = n
+		pos = pos[:i]
+	}
+	if d.Line == 0 && d.Column != 0 {
+		// Only a line was given
+		d.Line, d.Column = d.Column, 0
+	}
+	d.File = pos
+	return d
 }
 
 // processDeclaration processes a declaration in a file
@@ -260,11 +447,16 @@ func (l *GoModuleLoader) processFunction(funcDecl *ast.FuncDecl, file *module.Fi
 	name := funcDecl.Name.Name
 	isExported := ast.IsExported(name)
 
-	// Check if it's a test function
-	isTest := strings.HasPrefix(name, "Test") && file.IsTest
+	// Classify the function's role in tests
+	testKind := module.TestKindNone
+	if file.IsTest {
+		testKind = module.ClassifyTestFunction(funcDecl)
+	}
+	isTest := testKind == module.TestKindTest
 
 	// Create function
 	fn := module.NewFunction(name, isExported, isTest)
+	fn.TestKind = testKind
 
 	// Set position information
 	fn.SetPosition(funcDecl.Pos(), funcDecl.End())
@@ -284,17 +476,7 @@ func (l *GoModuleLoader) processFunction(funcDecl *ast.FuncDecl, file *module.Fi
 		}
 
 		// Determine receiver type and whether it's a pointer
-		recvType := ""
-		isPointer := false
-		switch rt := recvField.Type.(type) {
-		case *ast.StarExpr:
-			isPointer = true
-			if ident, ok := rt.X.(*ast.Ident); ok {
-				recvType = ident.Name
-			}
-		case *ast.Ident:
-			recvType = rt.Name
-		}
+		recvType, isPointer := module.ReceiverTypeName(recvField.Type)
 
 		// Set receiver
 		fn.SetReceiver(recvName, recvType, isPointer)
@@ -342,33 +524,33 @@ func (l *GoModuleLoader) processGenDecl(genDecl *ast.GenDecl, file *module.File,
 			case *ast.InterfaceType:
 				kind = "interface"
 			}
+			if typeSpec.Assign.IsValid() {
+				kind = "alias"
+			}
 
 			// Create type
 			typ := module.NewType(name, kind, isExported)
+			if kind == "type" || kind == "alias" {
+				typ.Underlying = types.ExprString(typeSpec.Type)
+			}
 
 			// Set position information
 			typ.SetPosition(typeSpec.Pos(), typeSpec.End())
 
-			// Set documentation if requested
+			// Set documentation if requested; as in go/doc, a spec's own
+			// comment wins over that of its group
 			if options.LoadDocs {
-				if genDecl.Doc != nil {
-					typ.Doc = genDecl.Doc.Text()
-				} else if typeSpec.Doc != nil {
+				if typeSpec.Doc != nil {
 					typ.Doc = typeSpec.Doc.Text()
+				} else if genDecl.Doc != nil {
+					typ.Doc = genDecl.Doc.Text()
 				}
 			}
 
 			// Process struct fields or interface methods (simplified)
 			if structType, ok := typeSpec.Type.(*ast.StructType); ok && structType.Fields != nil {
 				for _, field := range structType.Fields.List {
-					fieldName := ""
-					isEmbedded := len(field.Names) == 0
-
-					if !isEmbedded && len(field.Names) > 0 {
-						fieldName = field.Names[0].Name
-					}
-
-					fieldType := "any" // Simplified, would extract actual type in full implementation
+					fieldType := types.ExprString(field.Type)
 					tag := ""
 
 					if field.Tag != nil {
@@ -380,22 +562,36 @@ func (l *GoModuleLoader) processGenDecl(genDecl *ast.GenDecl, file *module.File,
 						doc = field.Doc.Text()
 					}
 
+					// Embedded fields have no names; "a, b int" declares several fields
+					names := []string{""}
+					isEmbedded := len(field.Names) == 0
+					if !isEmbedded {
+						names = names[:0]
+						for _, ident := range field.Names {
+							names = append(names, ident.Name)
+						}
+					}
+
 					// Add field with position information
-					f := typ.AddField(fieldName, fieldType, tag, isEmbedded, doc)
-					f.SetPosition(field.Pos(), field.End())
+					for _, fieldName := range names {
+						f := typ.AddField(fieldName, fieldType, tag, isEmbedded, doc)
+						f.SetPosition(field.Pos(), field.End())
+					}
 				}
 			} else if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok && interfaceType.Methods != nil {
 				for _, method := range interfaceType.Methods.List {
-					methodName := ""
 					isEmbedded := len(method.Names) == 0
 
-					if !isEmbedded && len(method.Names) > 0 {
+					// Embedded interfaces are named by their type expression
+					methodName := types.ExprString(method.Type)
+					if !isEmbedded {
 						methodName = method.Names[0].Name
 					}
 
+					// Signatures omit the "func" keyword, e.g. "(ctx context.Context) error"
 					signature := ""
 					if !isEmbedded {
-						signature = "func(...) ..." // Simplified
+						signature = strings.TrimPrefix(types.ExprString(method.Type), "func")
 					}
 
 					doc := ""
@@ -426,17 +622,24 @@ func (l *GoModuleLoader) processGenDecl(genDecl *ast.GenDecl, file *module.File,
 				name := ident.Name
 				isExported := ast.IsExported(name)
 
-				typeName := "any" // Simplified
+				typeName := ""
+				if valueSpec.Type != nil {
+					typeName = types.ExprString(valueSpec.Type)
+				}
 				value := ""
 
 				if i < len(valueSpec.Values) {
-					// Simplified: In a real implementation, we would extract the actual value
-					value = "..."
+					value = types.ExprString(valueSpec.Values[i])
 				}
 
 				doc := ""
-				if options.LoadDocs && genDecl.Doc != nil {
-					doc = genDecl.Doc.Text()
+				if options.LoadDocs {
+					// A spec's own doc wins over the doc of its group
+					if valueSpec.Doc != nil {
+						doc = valueSpec.Doc.Text()
+					} else if genDecl.Doc != nil {
+						doc = genDecl.Doc.Text()
+					}
 				}
 
 				variable := module.NewVariable(name, typeName, value, isExported)
@@ -462,17 +665,24 @@ func (l *GoModuleLoader) processGenDecl(genDecl *ast.GenDecl, file *module.File,
 				name := ident.Name
 				isExported := ast.IsExported(name)
 
-				typeName := "any" // Simplified
+				typeName := ""
+				if valueSpec.Type != nil {
+					typeName = types.ExprString(valueSpec.Type)
+				}
 				value := ""
 
 				if i < len(valueSpec.Values) {
-					// Simplified: In a real implementation, we would extract the actual value
-					value = "..."
+					value = types.ExprString(valueSpec.Values[i])
 				}
 
 				doc := ""
-				if options.LoadDocs && genDecl.Doc != nil {
-					doc = genDecl.Doc.Text()
+				if options.LoadDocs {
+					// A spec's own doc wins over the doc of its group
+					if valueSpec.Doc != nil {
+						doc = valueSpec.Doc.Text()
+					} else if genDecl.Doc != nil {
+						doc = genDecl.Doc.Text()
+					}
 				}
 
 				constant := module.NewConstant(name, typeName, value, isExported)
@@ -488,13 +698,27 @@ func (l *GoModuleLoader) processGenDecl(genDecl *ast.GenDecl, file *module.File,
 	}
 }
 
+// markUnmodified clears the IsModified flags the model's Add methods set
+// while the loader builds a package. The flags mean modified since loading
+// and the saver regenerates modified files from the model, so without this
+// every loaded file would be regenerated on save and appear in patches.
+func markUnmodified(pkg *module.Package) {
+	pkg.IsModified = false
+	for _, file := range pkg.Files {
+		file.IsModified = false
+	}
+}
+
 // associateMethodsWithTypes associates methods with their receiver types
 func (l *GoModuleLoader) associateMethodsWithTypes(pkg *module.Package) {
-	// Find all methods in the package
+	// Find all methods in the package; the files keep methods of different
+	// types that share a name, unlike pkg.Functions
 	var methods []*module.Function
-	for _, fn := range pkg.Functions {
-		if fn.IsMethod && fn.Receiver != nil {
-			methods = append(methods, fn)
+	for _, file := range pkg.Files {
+		for _, fn := range file.Functions {
+			if fn.IsMethod && fn.Receiver != nil {
+				methods = append(methods, fn)
+			}
 		}
 	}
 
@@ -526,9 +750,6 @@ func (l *GoModuleLoader) associateMethodsWithTypes(pkg *module.Package) {
 
 			// Add to the type's methods
 			typ.Methods = append(typ.Methods, methodObj)
-
-			// Debug
-			// fmt.Printf("DEBUG: Associated method %s with type %s\n", method.Name, typ.Name)
 		}
 	}
 }
@@ -1,6 +1,13 @@
 package loader
 
 import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 
 	"go/token"
@@ -264,3 +271,1368 @@ func TestEncodedStructTags(t *testing.T) {
 		t.Error("Expected to find Email field with tag")
 	}
 }
+
+// updateGolden rewrites golden files with the current output
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestModuleDumpIsDeterministic(t *testing.T) {
+	dump := func() string {
+		mod, err := NewGoModuleLoader().Load("../../../testdata")
+		if err != nil {
+			t.Fatalf("Failed to load module: %v", err)
+		}
+		var buf strings.Builder
+		if err := mod.Dump(&buf); err != nil {
+			t.Fatalf("Dump failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	first := dump()
+	for i := 0; i < 3; i++ {
+		if got := dump(); got != first {
+			t.Fatalf("Dump output differs between loads:\n--- first\n%s\n--- got\n%s", first, got)
+		}
+	}
+
+	golden := filepath.Join("testdata", "samplepackage.dump")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0750); err != nil {
+			t.Fatalf("Failed to create testdata: %v", err)
+		}
+		if err := os.WriteFile(golden, []byte(first), 0644); err != nil {
+			t.Fatalf("Failed to update %s: %v", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read %s (run with -update to create it): %v", golden, err)
+	}
+	if first != string(want) {
+		t.Errorf("Dump differs from %s (run with -update to accept):\n--- want\n%s\n--- got\n%s", golden, want, first)
+	}
+}
+
+func TestDiffSymbolsBetweenLoads(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	write("go.mod", "module example.com/diff\n\ngo 1.21\n")
+	write("diff.go", `package diff
+
+type User struct{ Name string }
+
+func (u *User) Greet() string { return "hi " + u.Name }
+
+func Keep() int { return 1 }
+
+func Remove() {}
+
+const Version = "1"
+`)
+
+	before, err := NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	// Move Keep without editing it, change Greet and Version, drop Remove, add Added
+	write("diff.go", `package diff
+
+func Keep() int { return 1 }
+
+type User struct{ Name string }
+
+func (u *User) Greet() string { return "hello " + u.Name }
+
+func Added() {}
+
+const Version = "2"
+`)
+
+	after, err := NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to reload module: %v", err)
+	}
+
+	added, removed, changed := module.DiffSymbols(before, after)
+
+	ids := func(symbols []*module.Symbol) string {
+		var names []string
+		for _, s := range symbols {
+			names = append(names, strings.TrimPrefix(s.ID, "example.com/diff."))
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := ids(added); got != "Added" {
+		t.Errorf("Expected added [Added], got [%s]", got)
+	}
+	if got := ids(removed); got != "Remove" {
+		t.Errorf("Expected removed [Remove], got [%s]", got)
+	}
+	if got := ids(changed); got != "User.Greet,Version" {
+		t.Errorf("Expected changed [User.Greet,Version], got [%s]", got)
+	}
+}
+
+func TestGenericReceiverSymbols(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/gen\n\ngo 1.21\n",
+		"gen.go": `package gen
+
+type Box[T any] struct{ v T }
+
+func (b *Box[T]) Get() T { return b.v }
+
+type Pair[K comparable, V any] struct{ k K }
+
+func (p Pair[K, V]) Get() K { return p.k }
+
+func Get() {}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod, err := NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	receivers := make(map[string]bool)
+	for _, file := range mod.Packages["example.com/gen"].Files {
+		for _, fn := range file.Functions {
+			if fn.Receiver != nil {
+				receivers[fn.Receiver.Type] = fn.Receiver.IsPointer
+			}
+		}
+	}
+	if isPointer, ok := receivers["Box"]; !ok || !isPointer {
+		t.Errorf("Expected pointer receiver Box, got %v", receivers)
+	}
+	if isPointer, ok := receivers["Pair"]; !ok || isPointer {
+		t.Errorf("Expected value receiver Pair, got %v", receivers)
+	}
+
+	var ids []string
+	for _, sym := range mod.Symbols() {
+		ids = append(ids, strings.TrimPrefix(sym.ID, "example.com/gen."))
+	}
+	expected := "Box,Box.Get,Get,Pair,Pair.Get"
+	if got := strings.Join(ids, ","); got != expected {
+		t.Errorf("Expected symbols %s, got %s", expected, got)
+	}
+}
+
+func TestLoadWithBuildTags(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/tags\n\ngo 1.21\n",
+		"common.go": `package tags
+
+func Common() {}
+`,
+		"community.go": `//go:build !enterprise
+
+package tags
+
+func Edition() string { return "community" }
+`,
+		"enterprise.go": `//go:build enterprise
+
+package tags
+
+func Edition() string { return "enterprise" }
+
+func Audit() {}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	community, err := NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	options := DefaultLoadOptions()
+	options.BuildTags = []string{"enterprise"}
+	enterprise, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module with tags: %v", err)
+	}
+
+	if len(community.BuildTags) != 0 {
+		t.Errorf("Expected no build tags, got %v", community.BuildTags)
+	}
+	for _, sym := range enterprise.Symbols() {
+		if strings.Join(sym.BuildTags, ",") != "enterprise" {
+			t.Errorf("Expected %s to record build tags [enterprise], got %v", sym.ID, sym.BuildTags)
+		}
+	}
+
+	added, removed, changed := module.DiffSymbols(community, enterprise)
+	if len(added) != 1 || added[0].ID != "example.com/tags.Audit" {
+		t.Errorf("Expected Audit to be added by the enterprise tag, got %v", added)
+	}
+	if len(removed) != 0 {
+		t.Errorf("Expected no removed symbols, got %v", removed)
+	}
+	if len(changed) != 1 || changed[0].ID != "example.com/tags.Edition" {
+		t.Errorf("Expected Edition to differ between tag sets, got %v", changed)
+	}
+}
+
+func TestLoadPackageSubset(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":             "module example.com/subset\n\ngo 1.21\n",
+		"root.go":            "package subset\n",
+		"api/api.go":         "package api\n\nimport \"example.com/subset/model\"\n\nfunc Get() model.Item { return model.Item{} }\n",
+		"model/item.go":      "package model\n\ntype Item struct{}\n",
+		"tools/gen/gen.go":   "package gen\n",
+		"tools/lint/lint.go": "package lint\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	load := func(patterns ...string) string {
+		options := DefaultLoadOptions()
+		options.PackagePaths = patterns
+		mod, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+		if err != nil {
+			t.Fatalf("Failed to load %v: %v", patterns, err)
+		}
+		var paths []string
+		for path := range mod.Packages {
+			paths = append(paths, strings.TrimPrefix(path, "example.com/subset"))
+		}
+		sort.Strings(paths)
+		return strings.Join(paths, ",")
+	}
+
+	if got := load(); got != ",/api,/model,/tools/gen,/tools/lint" {
+		t.Errorf("Expected all packages without patterns, got %s", got)
+	}
+	if got := load("./api", "example.com/subset/tools/..."); got != "/api,/tools/gen,/tools/lint" {
+		t.Errorf("Expected only the matching packages, got %s", got)
+	}
+}
+
+func TestEmbeddedInterfacesAreLinked(t *testing.T) {
+	mod, err := NewGoModuleLoader().Load("../../../testdata")
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	pkg := mod.Packages["test/samplepackage"]
+	authenticator := pkg.Types["Authenticator"]
+	validator := pkg.Types["Validator"]
+
+	if len(authenticator.Embeds) != 1 || authenticator.Embeds[0] != validator {
+		t.Fatalf("Expected Authenticator to embed Validator, got %v", authenticator.Embeds)
+	}
+
+	methods := make(map[string]bool)
+	for _, m := range authenticator.InterfaceMethods() {
+		methods[m.Name] = true
+	}
+	for _, name := range []string{"Login", "Logout", "Validate"} {
+		if !methods[name] {
+			t.Errorf("Expected method set of Authenticator to contain %s", name)
+		}
+	}
+}
+
+func TestLoadAtGoVersion(t *testing.T) {
+	// Ranging over an integer needs go1.22, the module declares go 1.21
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/ranged\n\ngo 1.21\n",
+		"ranged.go": `package ranged
+
+func Sum() int {
+	total := 0
+	for i := range 10 {
+		total += i
+	}
+	return total
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	loader := NewGoModuleLoader()
+	if _, err := loader.LoadWithOptions(dir, DefaultLoadOptions()); err == nil || !strings.Contains(err.Error(), "go1.22") {
+		t.Fatalf("Expected the go.mod version to reject range over int, got %v", err)
+	}
+
+	options := DefaultLoadOptions()
+	options.IncludeAST = true
+	options.GoVersion = "1.22"
+	mod, err := loader.LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Expected load at go1.22 to succeed: %v", err)
+	}
+	pkg := mod.Packages["example.com/ranged"]
+	if pkg.TypesPackage == nil || pkg.TypesPackage.Scope().Lookup("Sum") == nil {
+		t.Error("Expected type information from the re-check")
+	}
+
+	options.GoVersion = "1.x"
+	if _, err := loader.LoadWithOptions(dir, options); err == nil {
+		t.Error("Expected an error for an invalid Go version")
+	}
+}
+
+func TestRecordAndReplayLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":            "module example.com/recorded\n\ngo 1.21\n",
+		"README.md":         "# Not needed for loading\n",
+		"data/greeting.txt": "hello\n",
+		"recorded.go": `package recorded
+
+import _ "embed"
+
+//go:embed data/greeting.txt
+var greeting string
+
+func Greeting() int { return greeting }
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// A failing load is recorded along with its error
+	bundle := filepath.Join(t.TempDir(), "load.json")
+	options := DefaultLoadOptions()
+	options.RecordTo = bundle
+	if _, err := NewGoModuleLoader().LoadWithOptions(dir, options); err == nil {
+		t.Fatal("Expected the load to fail")
+	}
+
+	rec, err := ReadRecording(bundle)
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	var names []string
+	for name := range rec.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "data/greeting.txt,go.mod,recorded.go" {
+		t.Errorf("Unexpected recorded files: %s", got)
+	}
+	if rec.Options.RecordTo != "" || rec.Env["GOOS"] == "" {
+		t.Errorf("Unexpected options or env: %+v %v", rec.Options, rec.Env)
+	}
+	data, err := os.ReadFile(bundle)
+	if err != nil {
+		t.Fatalf("Failed to read bundle: %v", err)
+	}
+	if strings.Contains(string(data), dir) {
+		t.Error("Expected the recording to be scrubbed of the module directory")
+	}
+
+	_, err = ReplayLoad(bundle)
+	if err == nil || err.Error() != "failed to load packages: "+rec.Error {
+		t.Errorf("Expected replay to reproduce %q, got %v", rec.Error, err)
+	}
+
+	// A successful load replays to the same module
+	fixed := strings.Replace(files["recorded.go"], "func Greeting() int", "func Greeting() string", 1)
+	if err := os.WriteFile(filepath.Join(dir, "recorded.go"), []byte(fixed), 0644); err != nil {
+		t.Fatalf("Failed to write recorded.go: %v", err)
+	}
+	if _, err := NewGoModuleLoader().LoadWithOptions(dir, options); err != nil {
+		t.Fatalf("Expected the load to succeed: %v", err)
+	}
+	mod, err := ReplayLoad(bundle)
+	if err != nil {
+		t.Fatalf("ReplayLoad failed: %v", err)
+	}
+	if pkg := mod.Packages["example.com/recorded"]; pkg == nil || pkg.Functions["Greeting"] == nil {
+		t.Errorf("Expected the replayed module to contain Greeting")
+	}
+}
+
+func TestFindMethodsWithPromotion(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/methods\n\ngo 1.21\n",
+		"base/base.go": `package base
+
+type Core struct{}
+
+func (Core) Version() string { return "1" }
+
+type Base struct{ Core }
+
+func (b *Base) Close() error { return nil }
+func (b *Base) ID() string   { return "" }
+`,
+		"service.go": `package methods
+
+import "example.com/methods/base"
+
+type Logger interface {
+	Log(msg string)
+	Flush()
+}
+
+type Inner struct{}
+
+func (Inner) Close() error { return nil }
+func (Inner) Reset()       {}
+func (*Inner) Size() int   { return 0 }
+
+type Other struct{}
+
+func (Other) Reset() {}
+
+type Service struct {
+	*base.Base
+	Inner
+	Other
+	Logger
+	Size int
+}
+
+func (s *Service) Close() error { return nil }
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod, err := NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	describe := func(promoted bool) string {
+		entries, err := mod.FindMethods("example.com/methods", "Service", promoted)
+		if err != nil {
+			t.Fatalf("FindMethods failed: %v", err)
+		}
+		var parts []string
+		for _, e := range entries {
+			parts = append(parts, e.Method.Name+"@"+e.Method.Parent.Name+"["+strings.Join(e.Via, ".")+"]")
+		}
+		return strings.Join(parts, ",")
+	}
+
+	// Close is shadowed, Reset is ambiguous and Size is hidden by the field
+	expected := "Close@Service[],Flush@Logger[Logger],ID@Base[Base],Log@Logger[Logger],Version@Core[Base.Core]"
+	if got := describe(true); got != expected {
+		t.Errorf("Expected method set %s, got %s", expected, got)
+	}
+	if got := describe(false); got != "Close@Service[]" {
+		t.Errorf("Expected only declared methods, got %s", got)
+	}
+	if _, err := mod.FindMethods("example.com/methods", "Missing", true); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
+
+func TestOnPackageLoaded(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":      "module example.com/progress\n\ngo 1.21\n",
+		"progress.go": "package progress\n\n// Root is declared in the root package\nfunc Root() {}\n",
+		"sub/sub.go":  "package sub\n\n// Sub is declared in a subpackage\nfunc Sub() {}\n",
+		"sub/more.go": "package sub\n\n// More is declared in a second file\nfunc More() {}\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	var loaded []string
+	options := DefaultLoadOptions()
+	options.OnPackageLoaded = func(pkg *module.Package) {
+		// Packages are complete when reported
+		loaded = append(loaded, fmt.Sprintf("%s:%d", pkg.ImportPath, len(pkg.Functions)))
+	}
+	mod, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	sort.Strings(loaded)
+	if got := strings.Join(loaded, ","); got != "example.com/progress/sub:2,example.com/progress:1" {
+		t.Errorf("Unexpected loaded packages: %s", got)
+	}
+	if len(mod.Packages) != len(loaded) {
+		t.Errorf("Expected a callback per package, got %d for %d packages", len(loaded), len(mod.Packages))
+	}
+}
+
+func TestLoadAffected(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/affected\n\ngo 1.21\n",
+		"README.md":    "# affected\n",
+		"store/db.go":  "package store\n\nfunc Get() string { return \"\" }\n",
+		"api/api.go":   "package api\n\nimport \"example.com/affected/store\"\n\nfunc Handle() string { return store.Get() }\n",
+		"cmd/main.go":  "package main\n\nimport \"example.com/affected/api\"\n\nfunc main() { _ = api.Handle() }\n",
+		"util/util.go": "package util\n\nfunc Noop() {}\n",
+		// Packages whose tests import a changed package are affected too
+		"check/check.go":      "package check\n",
+		"check/check_test.go": "package check\n\nimport (\n\t\"testing\"\n\n\t\"example.com/affected/store\"\n)\n\nfunc TestGet(t *testing.T) { _ = store.Get() }\n",
+		"web/web.go":          "package web\n",
+		"web/web_test.go":     "package web_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/affected/api\"\n)\n\nfunc TestHandle(t *testing.T) { _ = api.Handle() }\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod := module.NewModule("example.com/affected", dir)
+
+	loaded := func(changed ...string) string {
+		t.Helper()
+		affected, err := LoadAffected(mod, changed)
+		if err != nil {
+			t.Fatalf("LoadAffected(%v) failed: %v", changed, err)
+		}
+		var paths []string
+		for path := range affected.Packages {
+			paths = append(paths, strings.TrimPrefix(path, "example.com/affected/"))
+		}
+		sort.Strings(paths)
+		return strings.Join(paths, ",")
+	}
+
+	if got := loaded("store/db.go"); got != "api,check,cmd,store,web" {
+		t.Errorf("Expected the changed package and its importers, got %s", got)
+	}
+	if got := loaded(filepath.Join(dir, "util", "util.go")); got != "util" {
+		t.Errorf("Expected only the changed package, got %s", got)
+	}
+	if got := loaded("README.md"); got != "" {
+		t.Errorf("Expected no packages for a non-package change, got %s", got)
+	}
+	if got := loaded("go.mod"); got != "api,check,cmd,store,util,web" {
+		t.Errorf("Expected all packages for a go.mod change, got %s", got)
+	}
+}
+
+func TestReloadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("go.mod", "module example.com/reload\n\ngo 1.21\n")
+	write("store/db.go", "package store\n\nfunc Get() string { return \"\" }\n")
+	write("store/cache.go", "package store\n\nfunc Cached() bool { return false }\n")
+	write("api/api.go", "package api\n\nfunc Handle() {}\n")
+
+	mod, err := NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	api := mod.Packages["example.com/reload/api"]
+
+	symbols := func() string {
+		var ids []string
+		for _, sym := range mod.Symbols() {
+			ids = append(ids, strings.TrimPrefix(sym.ID, "example.com/reload/"))
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// A changed file
+	write("store/db.go", "package store\n\nfunc Get() string { return \"\" }\n\nfunc Put(string) {}\n")
+	if err := ReloadFile(mod, "store/db.go"); err != nil {
+		t.Fatalf("ReloadFile failed: %v", err)
+	}
+	if got := symbols(); got != "api.Handle,store.Cached,store.Get,store.Put" {
+		t.Errorf("Unexpected symbols after change: %s", got)
+	}
+	if mod.Packages["example.com/reload/api"] != api {
+		t.Error("Expected the unaffected package to be kept")
+	}
+
+	// A compile error leaves the module intact
+	write("store/db.go", "package store\n\nfunc Get() string { return 1 }\n")
+	if err := ReloadFile(mod, "store/db.go"); err == nil {
+		t.Error("Expected an error for a file that does not compile")
+	}
+	if got := symbols(); got != "api.Handle,store.Cached,store.Get,store.Put" {
+		t.Errorf("Expected symbols to be unchanged after a failed reload, got %s", got)
+	}
+
+	// A file moved to another package
+	if err := os.Remove(filepath.Join(dir, "store", "cache.go")); err != nil {
+		t.Fatal(err)
+	}
+	write("store/db.go", "package store\n\nfunc Get() string { return \"\" }\n")
+	write("api/cache.go", "package api\n\nfunc Cached() bool { return false }\n")
+	for _, path := range []string{filepath.Join(dir, "store", "cache.go"), "api/cache.go"} {
+		if err := ReloadFile(mod, path); err != nil {
+			t.Fatalf("ReloadFile(%s) failed: %v", path, err)
+		}
+	}
+	if got := symbols(); got != "api.Cached,api.Handle,store.Get" {
+		t.Errorf("Unexpected symbols after move: %s", got)
+	}
+
+	// The last file of a package deleted
+	if err := os.Remove(filepath.Join(dir, "store", "db.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadFile(mod, "store/db.go"); err != nil {
+		t.Fatalf("ReloadFile failed for a deleted file: %v", err)
+	}
+	if _, ok := mod.Packages["example.com/reload/store"]; ok {
+		t.Error("Expected the package without files to be removed")
+	}
+}
+
+func TestReloadFileSharesKeptTypes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("go.mod", "module example.com/reload\n\ngo 1.21\n")
+	write("util/util.go", "package util\n\ntype ID string\n")
+	write("store/store.go", "package store\n\nimport \"example.com/reload/util\"\n\nfunc Get(id util.ID) string { return string(id) }\n")
+	write("api/api.go", "package api\n\nimport \"example.com/reload/store\"\n\nfunc Handle() string { return store.Get(\"1\") }\n")
+	write("admin/admin.go", "package admin\n\nimport \"example.com/reload/util\"\n\nvar Root util.ID = \"root\"\n")
+
+	options := DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	util := mod.Packages["example.com/reload/util"]
+	admin := mod.Packages["example.com/reload/admin"]
+
+	// Only the edited package and its importers are loaded again, also
+	// when the edit imports a package no package imported before
+	write("store/store.go", `package store
+
+import (
+	"net/url"
+
+	"example.com/reload/util"
+)
+
+func Get(id util.ID) string { return url.PathEscape(string(id)) }
+`)
+	var loaded []string
+	options.OnPackageLoaded = func(pkg *module.Package) {
+		loaded = append(loaded, strings.TrimPrefix(pkg.ImportPath, "example.com/reload/"))
+	}
+	l := NewGoModuleLoader()
+	l.fset = moduleFileSet(mod)
+	if err := l.ReloadFile(mod, "store/store.go", options); err != nil {
+		t.Fatalf("ReloadFile failed: %v", err)
+	}
+	sort.Strings(loaded)
+	if got := strings.Join(loaded, ","); got != "api,store" {
+		t.Errorf("Expected api and store to be reloaded, got %s", got)
+	}
+	if mod.Packages["example.com/reload/util"] != util || mod.Packages["example.com/reload/admin"] != admin {
+		t.Error("Expected util and admin to be kept")
+	}
+
+	// Reloaded packages refer to the kept and to each other's new objects
+	store := mod.Packages["example.com/reload/store"]
+	api := mod.Packages["example.com/reload/api"]
+	id := util.TypesPackage.Scope().Lookup("ID")
+	get := store.TypesPackage.Scope().Lookup("Get")
+	uses := func(pkg *module.Package, obj types.Object) bool {
+		for _, used := range pkg.TypesInfo.Uses {
+			if used == obj {
+				return true
+			}
+		}
+		return false
+	}
+	if !uses(store, id) {
+		t.Error("Expected the reloaded store to use the kept util.ID")
+	}
+	if !uses(api, get) {
+		t.Error("Expected the reloaded api to use the reloaded store.Get")
+	}
+	if len(mod.Diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", mod.Diagnostics)
+	}
+}
+
+func TestLoadWithCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("go.mod", "module example.com/cached\n\ngo 1.21\n")
+	write("store/db.go", "package store\n\n// Get reads\nfunc Get() string { return \"\" }\n")
+	write("api/api.go", "package api\n\nimport \"example.com/cached/store\"\n\nfunc Handle() string { return store.Get() }\n")
+	cachePath := filepath.Join(t.TempDir(), "module.json")
+
+	load := func() (*module.Module, string) {
+		t.Helper()
+		var loaded []string
+		options := DefaultLoadOptions()
+		options.OnPackageLoaded = func(pkg *module.Package) {
+			loaded = append(loaded, strings.TrimPrefix(pkg.ImportPath, "example.com/cached/"))
+		}
+		mod, err := NewGoModuleLoader().LoadWithCache(dir, cachePath, options)
+		if err != nil {
+			t.Fatalf("LoadWithCache failed: %v", err)
+		}
+		sort.Strings(loaded)
+		return mod, strings.Join(loaded, ",")
+	}
+
+	if _, loaded := load(); loaded != "api,store" {
+		t.Errorf("Expected a full load without cache, got %s", loaded)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("Expected the cache to be written: %v", err)
+	}
+
+	mod, loaded := load()
+	if loaded != "" {
+		t.Errorf("Expected no packages to be loaded from an up to date cache, got %s", loaded)
+	}
+	get := mod.Packages["example.com/cached/store"].Functions["Get"]
+	if get == nil || get.Doc != "Get reads\n" {
+		t.Fatalf("Expected Get with its doc from the cache, got %+v", get)
+	}
+	if pos := get.File.GetPositionInfo(get.Pos, get.End); pos == nil || pos.LineStart != 4 {
+		t.Errorf("Expected Get on line 4, got %+v", pos)
+	}
+
+	// Only the changed and the new package are loaded again
+	write("store/db.go", "package store\n\nfunc Get() string { return \"\" }\n\nfunc Put() {}\n")
+	write("util/util.go", "package util\n")
+	mod, loaded = load()
+	if loaded != "store,util" {
+		t.Errorf("Expected the changed and new packages to be loaded, got %s", loaded)
+	}
+	if mod.Packages["example.com/cached/store"].Functions["Put"] == nil {
+		t.Error("Expected Put after reloading the changed package")
+	}
+
+	// A removed package is dropped
+	if err := os.RemoveAll(filepath.Join(dir, "util")); err != nil {
+		t.Fatal(err)
+	}
+	mod, loaded = load()
+	if _, ok := mod.Packages["example.com/cached/util"]; ok || loaded != "" {
+		t.Errorf("Expected util to be dropped without loading, loaded %s", loaded)
+	}
+
+	// Changing go.mod invalidates the cache
+	write("go.mod", "module example.com/cached\n\ngo 1.22\n")
+	if _, loaded := load(); loaded != "api,store" {
+		t.Errorf("Expected a full load after a go.mod change, got %s", loaded)
+	}
+}
+
+func TestLoadWithCacheTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("go.mod", "module example.com/cached\n\ngo 1.21\n")
+	write("store/db.go", "package store\n\nfunc Get() string { return \"\" }\n")
+	write("store/db_test.go", "package store\n\nimport \"testing\"\n\nfunc TestGet(t *testing.T) {}\n")
+	write("api/api.go", "package api\n")
+	cachePath := filepath.Join(t.TempDir(), "module.json")
+
+	load := func() (*module.Module, string) {
+		t.Helper()
+		var loaded []string
+		options := DefaultLoadOptions()
+		options.IncludeTests = true
+		options.OnPackageLoaded = func(pkg *module.Package) {
+			loaded = append(loaded, strings.TrimPrefix(pkg.ImportPath, "example.com/cached/"))
+		}
+		mod, err := NewGoModuleLoader().LoadWithCache(dir, cachePath, options)
+		if err != nil {
+			t.Fatalf("LoadWithCache failed: %v", err)
+		}
+		sort.Strings(loaded)
+		return mod, strings.Join(loaded, ",")
+	}
+	testNames := func(pkg *module.Package) string {
+		var names []string
+		for _, file := range pkg.Files {
+			for _, fn := range file.Functions {
+				if fn.IsTest {
+					names = append(names, fn.Name)
+				}
+			}
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+
+	if _, loaded := load(); loaded != "api,store" {
+		t.Errorf("Expected a full load without cache, got %s", loaded)
+	}
+
+	// Editing only a test file reloads its package
+	write("store/db_test.go", "package store\n\nimport \"testing\"\n\nfunc TestGetEmpty(t *testing.T) {}\n")
+	mod, loaded := load()
+	if loaded != "store" {
+		t.Errorf("Expected store to be loaded after a test edit, got %s", loaded)
+	}
+	if got := testNames(mod.Packages["example.com/cached/store"]); got != "TestGetEmpty" {
+		t.Errorf("Expected the edited test, got %s", got)
+	}
+
+	// Adding an external test loads the package and its test package
+	write("store/store_test.go", "package store_test\n\nimport \"testing\"\n\nfunc TestExternal(t *testing.T) {}\n")
+	mod, loaded = load()
+	if loaded != "store,store_test" {
+		t.Errorf("Expected store and store_test to be loaded after adding a test, got %s", loaded)
+	}
+	xtest := mod.Packages["example.com/cached/store_test"]
+	if xtest == nil || !xtest.IsTest || testNames(xtest) != "TestExternal" {
+		t.Fatalf("Expected the external test package, got %+v", xtest)
+	}
+
+	// Deleting test files drops them
+	for _, name := range []string{"store/db_test.go", "store/store_test.go"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mod, loaded = load()
+	if loaded != "store" {
+		t.Errorf("Expected store to be loaded after deleting its tests, got %s", loaded)
+	}
+	if _, ok := mod.Packages["example.com/cached/store_test"]; ok {
+		t.Error("Expected the external test package to be dropped")
+	}
+	if got := testNames(mod.Packages["example.com/cached/store"]); got != "" {
+		t.Errorf("Expected no tests after deleting them, got %s", got)
+	}
+
+	if _, loaded := load(); loaded != "" {
+		t.Errorf("Expected no packages to be loaded from an up to date cache, got %s", loaded)
+	}
+}
+
+func TestParseGoModDirectives(t *testing.T) {
+	tests := []struct {
+		name   string
+		goMod  string
+		assert func(t *testing.T, mod *module.Module)
+	}{
+		{
+			name:  "indirect requirement",
+			goMod: "module example.com/m\n\ngo 1.21.0\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.2.0 // indirect\n)\n",
+			assert: func(t *testing.T, mod *module.Module) {
+				if mod.GoVersion != "1.21.0" {
+					t.Errorf("Expected go version 1.21.0, got %q", mod.GoVersion)
+				}
+				if len(mod.Dependencies) != 2 {
+					t.Fatalf("Expected 2 requirements, got %d", len(mod.Dependencies))
+				}
+				a, b := mod.Dependencies[0], mod.Dependencies[1]
+				if a.Path != "example.com/a" || a.Version != "v1.0.0" || a.Indirect {
+					t.Errorf("Unexpected direct requirement %+v", a)
+				}
+				if b.Path != "example.com/b" || b.Version != "v1.2.0" || !b.Indirect {
+					t.Errorf("Unexpected indirect requirement %+v", b)
+				}
+			},
+		},
+		{
+			name:  "versioned replacements",
+			goMod: "module example.com/m\n\nreplace (\n\texample.com/a v1.0.0 => example.com/fork v1.0.1\n\texample.com/b => ../b\n)\n",
+			assert: func(t *testing.T, mod *module.Module) {
+				if len(mod.Replace) != 2 {
+					t.Fatalf("Expected 2 replacements, got %d", len(mod.Replace))
+				}
+				a, b := mod.Replace[0], mod.Replace[1]
+				if a.Old.Path != "example.com/a" || a.Old.Version != "v1.0.0" || a.New.Path != "example.com/fork" || a.New.Version != "v1.0.1" {
+					t.Errorf("Unexpected versioned replacement %+v", a)
+				}
+				if b.Old.Path != "example.com/b" || b.Old.Version != "" || b.New.Path != "../b" {
+					t.Errorf("Unexpected local replacement %+v", b)
+				}
+			},
+		},
+		{
+			name:  "exclude",
+			goMod: "module example.com/m\n\nexclude example.com/a v1.1.0\n",
+			assert: func(t *testing.T, mod *module.Module) {
+				if len(mod.Exclude) != 1 || mod.Exclude[0].Path != "example.com/a" || mod.Exclude[0].Version != "v1.1.0" {
+					t.Errorf("Unexpected exclusions %+v", mod.Exclude)
+				}
+			},
+		},
+		{
+			name:  "retract",
+			goMod: "module example.com/m\n\nretract (\n\t// Published by mistake.\n\tv1.0.0\n\t[v1.1.0, v1.1.5]\n)\n",
+			assert: func(t *testing.T, mod *module.Module) {
+				if len(mod.Retract) != 2 {
+					t.Fatalf("Expected 2 retractions, got %+v", mod.Retract)
+				}
+				if r := mod.Retract[0]; r.Low != "v1.0.0" || r.High != "v1.0.0" || r.Rationale != "Published by mistake." {
+					t.Errorf("Unexpected retraction %+v", r)
+				}
+				if r := mod.Retract[1]; r.Low != "v1.1.0" || r.High != "v1.1.5" {
+					t.Errorf("Unexpected retracted range %+v", r)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, err := newModuleFromGoMod("go.mod", t.TempDir(), []byte(tt.goMod))
+			if err != nil {
+				t.Fatalf("Failed to parse go.mod: %v", err)
+			}
+			tt.assert(t, mod)
+		})
+	}
+
+	if _, err := newModuleFromGoMod("go.mod", t.TempDir(), []byte("go 1.21\n")); err == nil {
+		t.Error("Expected an error for a go.mod without module directive")
+	}
+}
+
+func TestLoadWithErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/partial\n\ngo 1.21\n",
+		"good/good.go": `package good
+
+// Hello greets
+func Hello() string { return "hello" }
+`,
+		"typed/typed.go": `package typed
+
+// Count is declared with the wrong type
+func Count() int {
+	return "many"
+}
+
+// Name is fine
+func Name() string { return "typed" }
+`,
+		"user/user.go": `package user
+
+import "example.com/partial/typed"
+
+// Uses depends on a package with errors
+func Uses() int { return typed.Count() }
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// By default errors fail the load
+	if _, err := NewGoModuleLoader().LoadWithOptions(dir, DefaultLoadOptions()); err == nil {
+		t.Fatal("Expected the load to fail")
+	}
+
+	options := DefaultLoadOptions()
+	options.AllowErrors = true
+	mod, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Expected a partial load: %v", err)
+	}
+	for _, path := range []string{"example.com/partial/good", "example.com/partial/typed", "example.com/partial/user"} {
+		if mod.Packages[path] == nil {
+			t.Errorf("Expected package %s to be loaded", path)
+		}
+	}
+	if pkg := mod.Packages["example.com/partial/typed"]; pkg != nil && pkg.Functions["Name"] == nil {
+		t.Error("Expected the declarations of the package with errors")
+	}
+
+	if len(mod.Diagnostics) != 1 {
+		t.Fatalf("Expected one diagnostic, got %v", mod.Diagnostics)
+	}
+	d := mod.Diagnostics[0]
+	if d.Severity != module.SeverityError || d.Package != "example.com/partial/typed" || d.Kind != "type" ||
+		filepath.Base(d.File) != "typed.go" || d.Line != 5 || d.Column == 0 || !strings.Contains(d.Message, "many") {
+		t.Errorf("Unexpected diagnostic: %+v", d)
+	}
+	if got := mod.FailedPackages(); len(got) != 1 || got[0] != "example.com/partial/typed" {
+		t.Errorf("Unexpected failed packages: %v", got)
+	}
+}
+
+func TestLoadBuildContexts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/platform\n\ngo 1.21\n",
+		"common.go": `package platform
+
+// Common is available everywhere
+func Common() {}
+
+// Handle is a platform handle
+type Handle struct{}
+`,
+		"sys_linux.go": `package platform
+
+// Open opens a file on Linux
+func Open() string { return "linux" }
+`,
+		"sys_windows.go": `package platform
+
+// Open opens a file on Windows
+func Open() string { return "windows" }
+
+// Registry is only available on Windows
+func Registry() {}
+
+// Win is a method only available on Windows
+func (Handle) Win() {}
+`,
+		"edition.go": `//go:build enterprise || (pro && !cgo)
+
+package platform
+
+// Audit is only in commercial editions
+func Audit() {}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// A single load records the constraints of the files it includes
+	options := DefaultLoadOptions()
+	options.BuildTags = []string{"enterprise"}
+	mod, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	pkg := mod.Packages["example.com/platform"]
+	if file := pkg.Files["common.go"]; file.BuildConstraint != "" || len(file.BuildTags) != 0 {
+		t.Errorf("Expected common.go to be unconditional, got %q %v", file.BuildConstraint, file.BuildTags)
+	}
+	if file := pkg.Files["edition.go"]; file.BuildConstraint != "enterprise || (pro && !cgo)" || strings.Join(file.BuildTags, ",") != "cgo,enterprise,pro" {
+		t.Errorf("Unexpected constraint of edition.go: %q %v", file.BuildConstraint, file.BuildTags)
+	}
+
+	// Loads in several contexts are merged
+	options = DefaultLoadOptions()
+	options.BuildContexts = []BuildContext{
+		{GOOS: "linux", GOARCH: "amd64"},
+		{GOOS: "windows", GOARCH: "amd64", Tags: []string{"enterprise"}},
+	}
+	// The loader can be used concurrently while loading in several contexts
+	l := NewGoModuleLoader()
+	done := make(chan error)
+	go func() {
+		_, err := l.Load(dir)
+		done <- err
+	}()
+	mod, err = l.LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module in several contexts: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Concurrent load failed: %v", err)
+	}
+	pkg = mod.Packages["example.com/platform"]
+	if handle := pkg.Types["Handle"]; handle == nil || len(handle.Methods) != 1 || handle.Methods[0].Name != "Win" || handle.Methods[0].Parent != handle {
+		t.Errorf("Expected the method of a merged file to be attached to its receiver type, got %+v", handle)
+	}
+	for _, fn := range []string{"Common", "Open", "Registry", "Audit"} {
+		if pkg.Functions[fn] == nil {
+			t.Errorf("Expected function %s in the merged module", fn)
+		}
+	}
+	if pkg.Functions["Registry"] != nil && pkg.Functions["Registry"].Package != pkg {
+		t.Error("Expected merged declarations to belong to the merged package")
+	}
+
+	tests := []struct {
+		file       string
+		constraint string
+		contexts   string
+	}{
+		{"common.go", "", "linux/amd64 windows/amd64,enterprise"},
+		{"sys_linux.go", "linux", "linux/amd64"},
+		{"sys_windows.go", "windows", "windows/amd64,enterprise"},
+		{"edition.go", "enterprise || (pro && !cgo)", "windows/amd64,enterprise"},
+	}
+	for _, tt := range tests {
+		file := pkg.Files[tt.file]
+		if file == nil {
+			t.Errorf("Expected file %s in the merged module", tt.file)
+			continue
+		}
+		if file.BuildConstraint != tt.constraint {
+			t.Errorf("Expected constraint %q for %s, got %q", tt.constraint, tt.file, file.BuildConstraint)
+		}
+		if got := strings.Join(file.BuildContexts, " "); got != tt.contexts {
+			t.Errorf("Expected contexts %q for %s, got %q", tt.contexts, tt.file, got)
+		}
+	}
+}
+
+func TestLoadZip(t *testing.T) {
+	writeZip := func(t *testing.T, files map[string]string) string {
+		path := filepath.Join(t.TempDir(), "module.zip")
+		out, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("Failed to create zip: %v", err)
+		}
+		w := zip.NewWriter(out)
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f, err := w.Create(name)
+			if err != nil {
+				t.Fatalf("Failed to add %s: %v", name, err)
+			}
+			if _, err := f.Write([]byte(files[name])); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Failed to close zip: %v", err)
+		}
+		if err := out.Close(); err != nil {
+			t.Fatalf("Failed to close zip: %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantVersion string
+		wantErr     bool
+	}{
+		{
+			name: "module zip",
+			files: map[string]string{
+				"example.com/zipped@v1.2.0/go.mod":     "module example.com/zipped\n\ngo 1.21\n",
+				"example.com/zipped@v1.2.0/zipped.go":  "package zipped\n\nfunc Hello() string { return \"hello\" }\n",
+				"example.com/zipped@v1.2.0/sub/sub.go": "package sub\n\nimport \"example.com/zipped\"\n\nfunc Hi() string { return zipped.Hello() }\n",
+			},
+			wantVersion: "v1.2.0",
+		},
+		{
+			name: "module zip without go.mod",
+			files: map[string]string{
+				"example.com/zipped@v1.0.0/zipped.go":  "package zipped\n\nfunc Hello() string { return \"hello\" }\n",
+				"example.com/zipped@v1.0.0/sub/sub.go": "package sub\n\nimport \"example.com/zipped\"\n\nfunc Hi() string { return zipped.Hello() }\n",
+			},
+			wantVersion: "v1.0.0",
+		},
+		{
+			name: "vcs archive",
+			files: map[string]string{
+				"zipped-main/README.md":  "# zipped\n",
+				"zipped-main/go.mod":     "module example.com/zipped\n\ngo 1.21\n",
+				"zipped-main/zipped.go":  "package zipped\n\nfunc Hello() string { return \"hello\" }\n",
+				"zipped-main/sub/sub.go": "package sub\n\nimport \"example.com/zipped\"\n\nfunc Hi() string { return zipped.Hello() }\n",
+			},
+		},
+		{
+			name: "escaping path",
+			files: map[string]string{
+				"go.mod":    "module example.com/zipped\n",
+				"../out.go": "package out\n",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, err := NewGoModuleLoader().LoadZip(writeZip(t, tt.files), DefaultLoadOptions())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected LoadZip to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadZip failed: %v", err)
+			}
+			if mod.Path != "example.com/zipped" || mod.Version != tt.wantVersion {
+				t.Errorf("Expected example.com/zipped at %q, got %s at %q", tt.wantVersion, mod.Path, mod.Version)
+			}
+			sub := mod.Packages["example.com/zipped/sub"]
+			if sub == nil || sub.Functions["Hi"] == nil {
+				t.Fatalf("Expected package sub with Hi, got %v", mod.Packages)
+			}
+			if file := sub.Files["sub.go"]; file == nil || !strings.Contains(file.SourceCode, "zipped.Hello()") {
+				t.Error("Expected the source of sub.go to be kept in memory")
+			}
+			if _, err := os.Stat(mod.Dir); !os.IsNotExist(err) {
+				t.Errorf("Expected the extracted module to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadWithOverlay(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":      "module example.com/overlaid\n\ngo 1.21\n",
+		"edit/doc.go": "package edit\n\nfunc Title() string { return \"saved\" }\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// An unsaved edit that does not compile
+	options := DefaultLoadOptions()
+	options.Overlay = map[string][]byte{
+		"edit/doc.go": []byte("package edit\n\nfunc Title() string { return 1 }\n"),
+	}
+	if _, err := NewGoModuleLoader().LoadWithOptions(dir, options); err == nil {
+		t.Error("Expected the overlaid file to fail type-checking")
+	}
+
+	// An unsaved edit that compiles, and a file not saved yet
+	edited := "package edit\n\n// Padding moves the declarations down\n\nfunc Title() string { return Draft() }\n"
+	options.Overlay = map[string][]byte{
+		filepath.Join(dir, "edit", "doc.go"): []byte(edited),
+		"edit/draft.go":                      []byte("package edit\n\nfunc Draft() string { return \"draft\" }\n"),
+	}
+	mod, err := NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load with overlay: %v", err)
+	}
+	pkg := mod.Packages["example.com/overlaid/edit"]
+	if pkg == nil || pkg.Functions["Draft"] == nil {
+		t.Fatal("Expected the overlaid file to be loaded")
+	}
+	if got := pkg.Files["doc.go"].SourceCode; got != edited {
+		t.Errorf("Expected the overlaid source, got %q", got)
+	}
+	if pos := pkg.Functions["Title"].GetPosition(); pos == nil || pos.LineStart != 5 {
+		t.Errorf("Expected Title at line 5 of the overlaid source, got %v", pos)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "edit", "doc.go"))
+	if err != nil || string(data) != files["edit/doc.go"] {
+		t.Errorf("Expected the file on disk to be unchanged, got %q, %v", data, err)
+	}
+
+	// A reload checks an unsaved edit of a single file
+	mod, err = NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	reloadOptions := DefaultLoadOptions()
+	reloadOptions.Overlay = map[string][]byte{"edit/doc.go": []byte("package edit\n\nfunc Title() string { return 1 }\n")}
+	if err := NewGoModuleLoader().ReloadFile(mod, "edit/doc.go", reloadOptions); err == nil {
+		t.Error("Expected the reload of a broken edit to fail")
+	}
+	reloadOptions.Overlay = map[string][]byte{"edit/doc.go": []byte("package edit\n\nfunc Subtitle() string { return \"\" }\n")}
+	if err := NewGoModuleLoader().ReloadFile(mod, "edit/doc.go", reloadOptions); err != nil {
+		t.Fatalf("ReloadFile failed: %v", err)
+	}
+	if pkg := mod.Packages["example.com/overlaid/edit"]; pkg.Functions["Subtitle"] == nil || pkg.Functions["Title"] != nil {
+		t.Errorf("Expected the reloaded package to reflect the overlay, got %v", pkg.Functions)
+	}
+}
+
+func TestLoadedFilesAreUnmodified(t *testing.T) {
+	mod, err := NewGoModuleLoader().Load("../../../testdata")
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	for _, pkg := range mod.Packages {
+		if pkg.IsModified {
+			t.Errorf("Expected freshly loaded package %s not to be modified", pkg.ImportPath)
+		}
+		for _, file := range pkg.Files {
+			if file.IsModified || file.IsSourceEdited {
+				t.Errorf("Expected freshly loaded file %s not to be modified", file.Path)
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package loader
+
+import (
+	"fmt"
+
+	"golang.org/x/mod/modfile"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// newModuleFromGoMod creates a module from the content of its go.mod file,
+// with its Go version, requirements, replacements, exclusions and
+// retractions
+func newModuleFromGoMod(goModPath, dir string, content []byte) (*module.Module, error) {
+	modFile, err := modfile.Parse(goModPath, content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	if modFile.Module == nil {
+		return nil, fmt.Errorf("failed to parse go.mod: no module directive")
+	}
+
+	mod := module.NewModule(modFile.Module.Mod.Path, dir)
+	if modFile.Go != nil {
+		mod.GoVersion = modFile.Go.Version
+	}
+	for _, req := range modFile.Require {
+		mod.AddDependency(req.Mod.Path, req.Mod.Version, req.Indirect)
+	}
+	for _, rep := range modFile.Replace {
+		mod.AddReplace(rep.Old.Path, rep.Old.Version, rep.New.Path, rep.New.Version)
+	}
+	for _, exc := range modFile.Exclude {
+		mod.AddExclude(exc.Mod.Path, exc.Mod.Version)
+	}
+	for _, ret := range modFile.Retract {
+		mod.AddRetract(ret.Low, ret.High, ret.Rationale)
+	}
+	return mod, nil
+}
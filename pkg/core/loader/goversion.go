@@ -0,0 +1,106 @@
+// Package loader provides implementations for loading Go modules.
+package loader
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// normalizeGoVersion turns "1.21" or "go1.21" into the "go1.21" form used by
+// go/types, returning an error for anything that is not a Go version
+func normalizeGoVersion(v string) (string, error) {
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	if !version.IsValid(v) {
+		return "", fmt.Errorf("invalid Go version %q", strings.TrimPrefix(v, "go"))
+	}
+	return v, nil
+}
+
+// retypeCheck type-checks the loaded packages again at the given language
+// version. go/packages always checks at the version declared in go.mod, so
+// this replaces the types and type errors of the requested packages.
+// Packages are visited dependencies first, so importers see the new types.
+func retypeCheck(fset *token.FileSet, roots []*packages.Package, goVersion string) {
+	isRoot := make(map[*packages.Package]bool, len(roots))
+	for _, pkg := range roots {
+		isRoot[pkg] = true
+	}
+
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		if !isRoot[pkg] || len(pkg.Syntax) == 0 {
+			return
+		}
+		checkPackage(fset, pkg, goVersion, importerFunc(func(path string) (*types.Package, error) {
+			if imp := pkg.Imports[path]; imp != nil && imp.Types != nil {
+				return imp.Types, nil
+			}
+			return nil, fmt.Errorf("no type information for %s", path)
+		}))
+	})
+}
+
+// checkPackage type-checks the syntax of a loaded package at the given
+// language version, resolving imports other than unsafe with the importer,
+// and replaces its types and type errors
+func checkPackage(fset *token.FileSet, pkg *packages.Package, goVersion string, importer types.Importer) {
+	// Keep list and parse errors, the type errors are recomputed
+	var errs []packages.Error
+	for _, err := range pkg.Errors {
+		if err.Kind != packages.TypeError {
+			errs = append(errs, err)
+		}
+	}
+
+	info := &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		FileVersions: make(map[*ast.File]string),
+	}
+	config := &types.Config{
+		GoVersion: goVersion,
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			return importer.Import(path)
+		}),
+		Sizes: pkg.TypesSizes,
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok {
+				errs = append(errs, packages.Error{
+					Pos:  terr.Fset.Position(terr.Pos).String(),
+					Msg:  terr.Msg,
+					Kind: packages.TypeError,
+				})
+			}
+		},
+	}
+
+	typesPkg := types.NewPackage(pkg.PkgPath, pkg.Name)
+	_ = types.NewChecker(config, fset, typesPkg, info).Files(pkg.Syntax)
+
+	pkg.Types = typesPkg
+	pkg.TypesInfo = info
+	pkg.Errors = errs
+}
+
+// importerFunc implements types.Importer with a function
+type importerFunc func(path string) (*types.Package, error)
+
+// Import implements the types.Importer interface
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
@@ -7,16 +7,28 @@ import (
 
 // LoadOptions defines options for module loading
 type LoadOptions struct {
-	// Include test files in the loaded module
+	// Include test files in the loaded module; external test packages
+	// (package foo_test) are loaded as packages of their own with IsTest set
 	IncludeTests bool
 
 	// Include generated files in the loaded module
 	IncludeGenerated bool
 
-	// Include build tags to control which files are loaded
+	// Build tags that select the files to load, passed to the go command as
+	// -tags and recorded in Module.BuildTags
 	BuildTags []string
 
-	// Load only specific packages (empty means all packages)
+	// Build contexts to load the module in, merging the loads so files
+	// excluded by the current platform or tags are included too. Each file
+	// records the contexts it was loaded in. Type information is that of
+	// the first context. Empty means the current platform only.
+	BuildContexts []BuildContext
+
+	// Package patterns to load instead of "./...", as understood by the go
+	// command: import paths, directories relative to the module such as
+	// "./cmd/...", or patterns like "example.com/mod/internal/...". Only the
+	// matching packages become part of the module; their dependencies are
+	// still type-checked. Empty means all packages.
 	PackagePaths []string
 
 	// Maximum depth for loading dependencies (0 means only direct dependencies)
@@ -27,6 +39,32 @@ type LoadOptions struct {
 
 	// Whether to include AST nodes in the module
 	IncludeAST bool
+
+	// Go language version to type-check against (e.g. "1.21"); empty means
+	// the version declared by the module's go.mod
+	GoVersion string
+
+	// Load packages with errors instead of failing, as far as their source
+	// allows, and report the errors in Module.Diagnostics. Packages that
+	// could not be listed or parsed at all are left out.
+	AllowErrors bool
+
+	// Content of files that differs from disk, such as unsaved edits, by
+	// path; relative paths are relative to the module directory. Overlaid
+	// files are parsed, type-checked and kept as File.SourceCode in place
+	// of their content on disk, and may be new files of an existing
+	// package directory. Positions refer to the overlaid content.
+	Overlay map[string][]byte `json:"-"`
+
+	// Path of a file to record the inputs of the load to, for reproducing
+	// it with ReplayLoad; empty means no recording
+	RecordTo string
+
+	// Called with each package as soon as it is converted, before the next
+	// package is processed. Calls are made one at a time from the goroutine
+	// running the load. Interface embedding across packages is only linked
+	// once all packages are loaded, so Type.Embeds may still be empty.
+	OnPackageLoaded func(*module.Package) `json:"-"`
 }
 
 // DefaultLoadOptions returns the default load options
@@ -0,0 +1,293 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// recordingVersion is the format version of recordings written by this
+// package
+const recordingVersion = 1
+
+// moduleDirPlaceholder replaces the module directory in recorded text
+const moduleDirPlaceholder = "$MODULE"
+
+// recordedEnv lists the go env variables that influence a load
+var recordedEnv = []string{
+	"GOOS", "GOARCH", "GOAMD64", "GOARM", "GOEXPERIMENT", "GOFLAGS",
+	"CGO_ENABLED", "GO111MODULE", "GOVERSION",
+}
+
+// sourceExtensions are the extensions of files the go command may build
+var sourceExtensions = map[string]bool{
+	".go": true, ".s": true, ".S": true, ".c": true, ".h": true, ".cc": true,
+	".cpp": true, ".cxx": true, ".hh": true, ".hpp": true, ".hxx": true,
+	".m": true, ".f": true, ".F": true, ".for": true, ".f90": true,
+	".swig": true, ".swigcxx": true, ".syso": true,
+}
+
+// Recording holds the inputs of a module load. It is self-contained: file
+// paths are relative to the module directory, and the module directory is
+// replaced by "$MODULE" wherever it appears in recorded text.
+type Recording struct {
+	Version    int               // Format version of the recording
+	Options    LoadOptions       // Options of the load
+	Patterns   []string          // Package patterns passed to packages.Load
+	Mode       string            // packages.Load mode
+	BuildFlags []string          // Build flags passed to packages.Load
+	Env        map[string]string // Relevant go env variables
+	Files      map[string][]byte // Module files by slash-separated relative path
+	Error      string            // Error of the recorded load, if it failed
+}
+
+// record writes the inputs of a load of dir to options.RecordTo
+func (l *GoModuleLoader) record(dir string, options LoadOptions, loadErr error) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	config, patterns := l.packagesConfig(dir, options)
+	rec := &Recording{
+		Version:    recordingVersion,
+		Options:    options,
+		Patterns:   patterns,
+		Mode:       config.Mode.String(),
+		BuildFlags: config.BuildFlags,
+	}
+	rec.Options.RecordTo = ""
+
+	if rec.Env, err = l.goEnv(dir); err != nil {
+		return err
+	}
+	for key, value := range rec.Env {
+		rec.Env[key] = scrubPath(value, absDir)
+	}
+	if rec.Files, err = moduleFiles(absDir); err != nil {
+		return err
+	}
+	if loadErr != nil {
+		rec.Error = scrubPath(loadErr.Error(), absDir)
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(options.RecordTo, data, 0600)
+}
+
+// ReadRecording reads a recording written by a load with RecordTo
+func ReadRecording(bundle string) (*Recording, error) {
+	data, err := os.ReadFile(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse recording: %w", err)
+	}
+	if rec.Version != recordingVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", rec.Version)
+	}
+	return &rec, nil
+}
+
+// ReplayLoad loads a module from a recording written by a load with
+// RecordTo. The recorded files are restored to a temporary directory and
+// loaded with the recorded options and go env; the workspace file is
+// ignored and the local toolchain is used, whose version may differ from
+// Recording.Env["GOVERSION"]. The directory is removed before returning,
+// so the module's sources are only available in memory. Errors refer to
+// the module directory as "$MODULE", like the recorded error.
+func ReplayLoad(bundle string) (*module.Module, error) {
+	rec, err := ReadRecording(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "gotree-replay-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	for name, content := range rec.Files {
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return nil, fmt.Errorf("recorded file %s is outside the module", name)
+		}
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filePath, content, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	env := append(os.Environ(), "GOWORK=off", "GOTOOLCHAIN=local")
+	for key, value := range rec.Env {
+		if key != "GOVERSION" {
+			env = append(env, key+"="+strings.ReplaceAll(value, moduleDirPlaceholder, dir))
+		}
+	}
+
+	l := NewGoModuleLoader()
+	l.env = env
+	mod, err := l.LoadWithOptions(dir, rec.Options)
+	if err != nil {
+		return nil, errors.New(scrubPath(err.Error(), dir))
+	}
+	return mod, nil
+}
+
+// goEnv returns the recorded go env variables as seen from dir
+func (l *GoModuleLoader) goEnv(dir string) (map[string]string, error) {
+	cmd := exec.Command("go", append([]string{"env", "-json"}, recordedEnv...)...)
+	cmd.Dir = dir
+	cmd.Env = l.env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go env failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	env := make(map[string]string)
+	if err := json.Unmarshal(out, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse go env output: %w", err)
+	}
+	for key, value := range env {
+		if value == "" {
+			delete(env, key)
+		}
+	}
+	return env, nil
+}
+
+// moduleFiles reads the files of the module in dir that the go command may
+// use: go.mod and go.sum, buildable sources, embedded files and the vendor
+// directory. Like the go command, it skips testdata, directories starting
+// with "." or "_", and nested modules.
+func moduleFiles(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	add := func(filePath string) error {
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	}
+
+	var embeds []string
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		rel, _ := filepath.Rel(dir, filePath)
+		vendored := rel == "vendor" || strings.HasPrefix(filepath.ToSlash(rel), "vendor/")
+
+		if entry.IsDir() {
+			if filePath == dir || vendored {
+				return nil
+			}
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(filePath, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		switch {
+		case vendored, filePath == filepath.Join(dir, "go.mod"), filePath == filepath.Join(dir, "go.sum"):
+		case strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || !sourceExtensions[filepath.Ext(name)]:
+			return nil
+		case filepath.Ext(name) == ".go":
+			patterns, err := embedPatterns(filePath)
+			if err != nil {
+				return err
+			}
+			for _, pattern := range patterns {
+				embeds = append(embeds, filepath.Join(filepath.Dir(filePath), filepath.FromSlash(pattern)))
+			}
+		}
+		return add(filePath)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module files: %w", err)
+	}
+
+	// Embedded files must exist for the go command to load a package
+	sort.Strings(embeds)
+	for _, pattern := range embeds {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			if !strings.HasPrefix(match, dir+string(filepath.Separator)) {
+				continue
+			}
+			err := filepath.WalkDir(match, func(filePath string, entry fs.DirEntry, err error) error {
+				if err != nil || !entry.Type().IsRegular() {
+					return err
+				}
+				return add(filePath)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read embedded files: %w", err)
+			}
+		}
+	}
+	return files, nil
+}
+
+// embedPatterns returns the patterns of the //go:embed directives of a file
+func embedPatterns(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "//go:embed ") {
+			continue
+		}
+		for _, pattern := range strings.Fields(strings.TrimPrefix(line, "//go:embed ")) {
+			pattern = strings.TrimPrefix(strings.Trim(pattern, "\"`"), "all:")
+			if pattern != "" && !path.IsAbs(pattern) {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// scrubPath replaces the module directory in text with a placeholder
+func scrubPath(text, dir string) string {
+	return strings.ReplaceAll(text, dir, moduleDirPlaceholder)
+}
@@ -0,0 +1,320 @@
+package loader
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// ReloadFile updates a module after a change to one of its files, with
+// default options, the module's build tags, and syntax if its files carry
+// it. See GoModuleLoader.ReloadFile. The reload shares the file set of the
+// module's files, so positions of reloaded and kept packages stay
+// comparable.
+func ReloadFile(mod *module.Module, path string) error {
+	options := DefaultLoadOptions()
+	options.BuildTags = mod.BuildTags
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			options.IncludeAST = options.IncludeAST || file.AST != nil
+		}
+	}
+	l := NewGoModuleLoader()
+	if fset := moduleFileSet(mod); fset != nil {
+		l.fset = fset
+	}
+	return l.ReloadFile(mod, path, options)
+}
+
+// moduleFileSet returns the file set the module's files were parsed with,
+// or nil if it has no files
+func moduleFileSet(mod *module.Module) *token.FileSet {
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			if file.FileSet != nil {
+				return file.FileSet
+			}
+		}
+	}
+	return nil
+}
+
+// ReloadFile updates a module in place after a file was changed, created or
+// deleted on disk. The package in the file's directory is parsed and
+// type-checked again, along with the package that held the file before if
+// it moved, and the packages of the module importing them, directly or
+// indirectly, as their type information refers to the reloaded objects.
+// Other packages are kept. If the module holds type information, the
+// reloaded packages are type-checked against the kept packages' types, so
+// objects are shared as in a full load and kept packages are not checked
+// again. The reloaded packages replace the old ones, and packages left
+// without Go files are removed. Symbol IDs do not depend on the load, so
+// unchanged symbols keep their IDs. If the reload fails, for instance
+// because the file no longer compiles, the error is returned and the module
+// is left as it was. The path is relative to the module directory or
+// absolute; options.PackagePaths is ignored. To check an unsaved edit,
+// pass the file's content in options.Overlay; the file then need not exist
+// on disk.
+func (l *GoModuleLoader) ReloadFile(mod *module.Module, path string, options LoadOptions) error {
+	if mod == nil || mod.Dir == "" {
+		return fmt.Errorf("module must be loaded from a directory")
+	}
+	absDir, err := filepath.Abs(mod.Dir)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(absDir, path)
+	}
+	path = filepath.Clean(path)
+
+	// The file's directory, and the directories of packages holding it
+	dirs := map[string]bool{filepath.Dir(path): true}
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			if filepath.Clean(file.Path) == path {
+				dirs[absPath(pkg.Dir)] = true
+			}
+		}
+	}
+	for _, dir := range importerDirs(mod, dirs) {
+		dirs[dir] = true
+	}
+
+	overlay := overlayFiles(absDir, options.Overlay)
+	var patterns []string
+	for dir := range dirs {
+		rel, err := filepath.Rel(absDir, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is not in the module directory", path)
+		}
+		hasFiles, err := hasGoFiles(dir, overlay)
+		if err != nil {
+			return err
+		}
+		if hasFiles {
+			patterns = append(patterns, "./"+filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(patterns)
+
+	// Load before touching the module, so a failed load leaves it intact
+	var reloaded *module.Module
+	if len(patterns) > 0 {
+		options.PackagePaths = patterns
+		reloader := l
+		if kept := keptTypes(mod, dirs); options.IncludeAST && kept != nil {
+			reloader = &GoModuleLoader{fset: l.fset, env: l.env, keptTypes: kept}
+			if options.GoVersion == "" {
+				options.GoVersion = mod.GoVersion
+			}
+		}
+		if reloaded, err = reloader.LoadWithOptions(absDir, options); err != nil {
+			return fmt.Errorf("failed to reload %s: %w", path, err)
+		}
+	}
+
+	for importPath, pkg := range mod.Packages {
+		if dirs[absPath(pkg.Dir)] {
+			delete(mod.Packages, importPath)
+		}
+	}
+	if reloaded != nil {
+		for _, pkg := range reloaded.Packages {
+			mod.AddPackage(pkg)
+		}
+	}
+	mod.LinkEmbeddedInterfaces()
+	return nil
+}
+
+// importerDirs returns the directories of the module's packages that
+// import the packages in dirs, directly or through other packages of the
+// module
+func importerDirs(mod *module.Module, dirs map[string]bool) []string {
+	importers := make(map[string][]string)
+	var queue []string
+	for importPath, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			for _, imp := range file.Imports {
+				if mod.Packages[imp.Path] != nil {
+					importers[imp.Path] = append(importers[imp.Path], importPath)
+				}
+			}
+		}
+		if dirs[absPath(pkg.Dir)] {
+			queue = append(queue, importPath)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var found []string
+	for len(queue) > 0 {
+		importPath := queue[0]
+		queue = queue[1:]
+		if seen[importPath] {
+			continue
+		}
+		seen[importPath] = true
+		if dir := absPath(mod.Packages[importPath].Dir); !dirs[dir] {
+			found = append(found, dir)
+		}
+		queue = append(queue, importers[importPath]...)
+	}
+	sort.Strings(found)
+	return found
+}
+
+// keptTypes returns the type information of the module's packages outside
+// dirs and of the packages they and the packages in dirs import, directly
+// or indirectly, by import path; nil if the module holds none
+func keptTypes(mod *module.Module, dirs map[string]bool) map[string]*types.Package {
+	reloaded := make(map[string]bool)
+	for importPath, pkg := range mod.Packages {
+		if dirs[absPath(pkg.Dir)] {
+			reloaded[importPath] = true
+		}
+	}
+
+	kept := make(map[string]*types.Package)
+	var visit func(*types.Package)
+	visit = func(pkg *types.Package) {
+		if reloaded[pkg.Path()] || kept[pkg.Path()] != nil {
+			return
+		}
+		kept[pkg.Path()] = pkg
+		for _, imp := range pkg.Imports() {
+			visit(imp)
+		}
+	}
+	for importPath, pkg := range mod.Packages {
+		if pkg.TypesPackage == nil {
+			continue
+		}
+		if !reloaded[importPath] {
+			visit(pkg.TypesPackage)
+			continue
+		}
+		for _, imp := range pkg.TypesPackage.Imports() {
+			visit(imp)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// checkAgainstKept type-checks the parsed packages, dependencies first,
+// resolving imports of other packages to the loader's kept types. Imports
+// without kept types, such as a package a file starts to import, are
+// loaded and type-checked from scratch.
+func (l *GoModuleLoader) checkAgainstKept(dir string, pkgs []*packages.Package, goVersion string, options LoadOptions) {
+	byPath := make(map[string]*packages.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		byPath[pkg.PkgPath] = pkg
+	}
+
+	// Imports neither loaded nor kept
+	missing := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, spec := range file.Imports {
+				importPath, err := strconv.Unquote(spec.Path.Value)
+				if err == nil && importPath != "unsafe" && importPath != "C" && byPath[importPath] == nil && l.keptTypes[importPath] == nil {
+					missing[importPath] = true
+				}
+			}
+		}
+	}
+	extra := make(map[string]*types.Package)
+	if len(missing) > 0 {
+		loader := &GoModuleLoader{fset: l.fset, env: l.env}
+		config, _ := loader.packagesConfig(dir, LoadOptions{BuildTags: options.BuildTags, Overlay: options.Overlay})
+		patterns := make([]string, 0, len(missing))
+		for importPath := range missing {
+			patterns = append(patterns, importPath)
+		}
+		// Failures surface as type errors of the importing package
+		if loaded, err := packages.Load(config, patterns...); err == nil {
+			for _, pkg := range loaded {
+				if pkg.Types != nil {
+					extra[pkg.PkgPath] = pkg.Types
+				}
+			}
+		}
+	}
+
+	checked := make(map[*packages.Package]bool)
+	var check func(pkg *packages.Package)
+	check = func(pkg *packages.Package) {
+		if checked[pkg] {
+			return
+		}
+		checked[pkg] = true
+		checkPackage(l.fset, pkg, goVersion, importerFunc(func(path string) (*types.Package, error) {
+			if imp := byPath[path]; imp != nil && imp != pkg {
+				if !checked[imp] {
+					check(imp)
+				}
+				if imp.Types != nil && imp.Types.Complete() {
+					return imp.Types, nil
+				}
+				return nil, fmt.Errorf("import cycle through %s", path)
+			}
+			if kept := l.keptTypes[path]; kept != nil {
+				return kept, nil
+			}
+			if typesPkg := extra[path]; typesPkg != nil {
+				return typesPkg, nil
+			}
+			return nil, fmt.Errorf("no type information for %s", path)
+		}))
+	}
+	for _, pkg := range pkgs {
+		check(pkg)
+	}
+}
+
+// hasGoFiles reports whether a directory contains non-test Go files, on
+// disk or in the overlay
+func hasGoFiles(dir string, overlay map[string][]byte) (bool, error) {
+	for path := range overlay {
+		name := filepath.Base(path)
+		if filepath.Dir(path) == dir && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return true, nil
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// absPath returns the absolute form of a path, or the path itself if it
+// cannot be made absolute
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
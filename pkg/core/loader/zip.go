@@ -0,0 +1,150 @@
+package loader
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// maxZipFileSize limits the size of a single extracted file, as the go
+// command limits module zips
+const maxZipFileSize = 500 << 20
+
+// LoadZip loads a module from a zip archive, such as a module zip from the
+// module cache ("path@version/" prefix) or a VCS archive ("repo-main/"
+// prefix). The module root is the shallowest directory of the archive
+// holding a go.mod; a module zip without one, as published before modules,
+// gets the go.mod the go command would synthesize.
+//
+// go/packages only reads real files, so the module root is extracted to a
+// temporary directory, loaded and removed before returning, like
+// ReplayLoad: the module's sources are only available in memory, and its
+// directory and file paths refer to the removed directory. Dependencies are
+// resolved from the module cache as for any module.
+func (l *GoModuleLoader) LoadZip(zipPath string, options LoadOptions) (*module.Module, error) {
+	if options.RecordTo != "" {
+		return nil, fmt.Errorf("loads from a zip archive cannot be recorded")
+	}
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", zipPath, err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	root, modPath, version, err := zipModuleRoot(archive.File)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", zipPath, err)
+	}
+
+	dir, err := os.MkdirTemp("", "gotree-zip-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if err := extractZip(archive.File, root, dir); err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", zipPath, err)
+	}
+	if modPath != "" {
+		goMod := []byte(fmt.Sprintf("module %s\n", modPath))
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), goMod, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	mod, err := l.withEnv("GOWORK=off").LoadWithOptions(dir, options)
+	if err != nil {
+		return nil, err
+	}
+	if mod.Version == "" {
+		mod.Version = version
+	}
+	return mod, nil
+}
+
+// zipModuleRoot finds the directory of the module in an archive, with a
+// trailing slash unless it is the archive root. For a module zip without a
+// go.mod it also returns the module path to synthesize one for; the version
+// is that of a "path@version/" prefix, if any.
+func zipModuleRoot(files []*zip.File) (root, modPath, version string, err error) {
+	found := false
+	for _, f := range files {
+		if path.Base(f.Name) != "go.mod" || f.FileInfo().IsDir() {
+			continue
+		}
+		dir := strings.TrimSuffix(f.Name, "go.mod")
+		if !found || strings.Count(dir, "/") < strings.Count(root, "/") {
+			root, found = dir, true
+		}
+	}
+
+	// Module zips put every file below "path@version/"
+	prefix := ""
+	if len(files) > 0 {
+		if i := strings.Index(files[0].Name, "@"); i > 0 {
+			if j := strings.Index(files[0].Name[i:], "/"); j > 0 {
+				prefix = files[0].Name[:i+j+1]
+			}
+		}
+	}
+	if prefix != "" {
+		at := strings.LastIndex(prefix, "@")
+		version = strings.TrimSuffix(prefix[at+1:], "/")
+		if !found {
+			return prefix, prefix[:at], version, nil
+		}
+	}
+	if !found {
+		return "", "", "", fmt.Errorf("no go.mod file found in archive")
+	}
+	return root, "", version, nil
+}
+
+// extractZip writes the regular files of an archive below root to dir
+func extractZip(files []*zip.File, root, dir string) error {
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name, root) || !f.Mode().IsRegular() {
+			continue
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(f.Name, root))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("file %s is outside the module", f.Name)
+		}
+		if f.UncompressedSize64 > maxZipFileSize {
+			return fmt.Errorf("file %s is too large", f.Name)
+		}
+		if err := extractZipFile(f, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes a single file of an archive
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, io.LimitReader(r, maxZipFileSize)); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,61 @@
+// Package module defines the diagnostics reported while loading modules.
+package module
+
+import "fmt"
+
+// Severity classifies diagnostics
+type Severity string
+
+const (
+	// SeverityError marks problems in the module's own packages; the
+	// package is missing or only partly described
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks problems outside the module's packages, such
+	// as in dependencies, that may leave type information incomplete
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found while loading a module
+type Diagnostic struct {
+	Severity Severity // Error or warning
+	Package  string   // Import path of the package the problem was found in
+	Kind     string   // Origin of the problem: "list", "parse" or "type", if known
+	File     string   // Path of the file, if known
+	Line     int      // Line in the file, if known
+	Column   int      // Column in the file, if known
+	Message  string   // Description of the problem
+}
+
+// String formats the diagnostic as "file:line:col: message", with the
+// package in place of an unknown position
+func (d Diagnostic) String() string {
+	switch {
+	case d.File == "":
+		return fmt.Sprintf("%s: %s", d.Package, d.Message)
+	case d.Line == 0:
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	case d.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+}
+
+// AddDiagnostic records a problem found while loading the module
+func (m *Module) AddDiagnostic(d Diagnostic) {
+	m.Diagnostics = append(m.Diagnostics, d)
+}
+
+// FailedPackages returns the import paths of the module's packages with
+// errors, in the order they were reported
+func (m *Module) FailedPackages() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, d := range m.Diagnostics {
+		if d.Severity == SeverityError && !seen[d.Package] {
+			seen[d.Package] = true
+			paths = append(paths, d.Package)
+		}
+	}
+	return paths
+}
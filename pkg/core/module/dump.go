@@ -0,0 +1,275 @@
+// Package module defines a canonical textual dump of the module data model.
+package module
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dump writes a deterministic, human-readable dump of the module to w. Every
+// package, file, and declaration is listed in sorted order with positions
+// relative to the module directory, so two dumps of the same module are
+// byte-identical across runs and operating systems and can be diffed.
+func (m *Module) Dump(w io.Writer) error {
+	d := &dumper{w: bufio.NewWriter(w), dir: m.Dir}
+
+	d.line(0, "module %s", m.Path)
+	if m.Version != "" {
+		d.line(1, "version %s", m.Version)
+	}
+	if m.GoVersion != "" {
+		d.line(1, "go %s", m.GoVersion)
+	}
+
+	deps := append([]*ModuleDependency(nil), m.Dependencies...)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	for _, dep := range deps {
+		d.line(1, "require %s %s%s", dep.Path, dep.Version, flag(dep.Indirect, " // indirect"))
+	}
+
+	replaces := append([]*ModuleReplace(nil), m.Replace...)
+	sort.Slice(replaces, func(i, j int) bool { return replaces[i].Old.Path < replaces[j].Old.Path })
+	for _, rep := range replaces {
+		d.line(1, "replace %s => %s", joinVersion(rep.Old), joinVersion(rep.New))
+	}
+
+	excludes := append([]*ModuleDependency(nil), m.Exclude...)
+	sort.Slice(excludes, func(i, j int) bool { return joinVersion(excludes[i]) < joinVersion(excludes[j]) })
+	for _, exc := range excludes {
+		d.line(1, "exclude %s", joinVersion(exc))
+	}
+
+	for _, ret := range m.Retract {
+		versions := ret.Low
+		if ret.High != ret.Low {
+			versions = "[" + ret.Low + ", " + ret.High + "]"
+		}
+		d.line(1, "retract %s%s", versions, flag(ret.Rationale != "", " // "+ret.Rationale))
+	}
+
+	for _, path := range sortedKeys(m.Packages) {
+		d.dumpPackage(m.Packages[path])
+	}
+
+	return d.flush()
+}
+
+// dumper accumulates dump output and remembers the first write error
+type dumper struct {
+	w   *bufio.Writer
+	dir string
+	err error
+}
+
+// line writes a single indented line
+func (d *dumper) line(depth int, format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, "%s%s\n", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+// flush flushes buffered output and returns the first error encountered
+func (d *dumper) flush() error {
+	if d.err != nil {
+		return d.err
+	}
+	return d.w.Flush()
+}
+
+// dumpPackage writes a package and its files
+func (d *dumper) dumpPackage(pkg *Package) {
+	d.line(1, "package %s %s%s", pkg.Name, pkg.ImportPath, flag(pkg.IsTest, " [test]"))
+
+	for _, name := range sortedKeys(pkg.Files) {
+		d.dumpFile(pkg.Files[name])
+	}
+}
+
+// dumpFile writes a file and its declarations
+func (d *dumper) dumpFile(file *File) {
+	d.line(2, "file %s%s%s", d.relPath(file.Path, file.Name),
+		flag(file.IsTest, " [test]"), flag(file.IsGenerated, " [generated]"))
+
+	if len(file.BuildTags) > 0 {
+		tags := append([]string(nil), file.BuildTags...)
+		sort.Strings(tags)
+		d.line(3, "tags %s", strings.Join(tags, " "))
+	}
+
+	imports := append([]*Import(nil), file.Imports...)
+	sort.Slice(imports, func(i, j int) bool { return imports[i].Path < imports[j].Path })
+	for _, imp := range imports {
+		name := ""
+		if imp.Name != "" {
+			name = imp.Name + " "
+		}
+		d.line(3, "import %s%q %s", name, imp.Path, d.pos(file, imp.Pos))
+	}
+
+	constants := append([]*Constant(nil), file.Constants...)
+	sort.Slice(constants, func(i, j int) bool { return constants[i].Name < constants[j].Name })
+	for _, c := range constants {
+		d.line(3, "const %s%s %s", valueSpec(c.Name, c.Type, c.Value), exported(c.IsExported), d.pos(file, c.Pos))
+	}
+
+	variables := append([]*Variable(nil), file.Variables...)
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	for _, v := range variables {
+		d.line(3, "var %s%s %s", valueSpec(v.Name, v.Type, v.Value), exported(v.IsExported), d.pos(file, v.Pos))
+	}
+
+	types := append([]*Type(nil), file.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		d.dumpType(file, t)
+	}
+
+	functions := append([]*Function(nil), file.Functions...)
+	sort.Slice(functions, func(i, j int) bool { return functionKey(functions[i]) < functionKey(functions[j]) })
+	for _, fn := range functions {
+		d.line(3, "func %s %s%s%s %s", functionKey(fn), fn.Signature, exported(fn.IsExported),
+			flag(fn.IsTest, " [test]"), d.pos(file, fn.Pos))
+	}
+}
+
+// dumpType writes a type with its fields and methods
+func (d *dumper) dumpType(file *File, t *Type) {
+	d.line(3, "type %s %s%s %s", t.Name, t.Kind, exported(t.IsExported), d.pos(file, t.Pos))
+	if t.Underlying != "" {
+		d.line(4, "underlying %s", t.Underlying)
+	}
+
+	// Fields keep their declaration order, which is significant for structs
+	for _, f := range t.Fields {
+		name := f.Name
+		if f.IsEmbedded {
+			name = "(embedded)"
+		}
+		tag := ""
+		if f.Tag != "" {
+			tag = " " + f.Tag
+		}
+		d.line(4, "field %s %s%s %s", name, f.Type, tag, d.pos(file, f.Pos))
+	}
+
+	for _, m := range sortedMethods(t.Interfaces) {
+		if m.IsEmbedded {
+			d.line(4, "interface embedded %s %s", m.Name, d.pos(file, m.Pos))
+			continue
+		}
+		d.line(4, "interface method %s %s %s", m.Name, m.Signature, d.pos(file, m.Pos))
+	}
+
+	// Methods may be declared in other files, so only their names are listed
+	// here; the declarations appear with positions under their own file
+	for _, m := range sortedMethods(t.Methods) {
+		d.line(4, "method %s", m.Name)
+	}
+}
+
+// relPath returns a slash-separated path relative to the module directory
+func (d *dumper) relPath(path, fallback string) string {
+	if path == "" {
+		return fallback
+	}
+	if d.dir != "" {
+		dir, dirErr := filepath.Abs(d.dir)
+		abs, pathErr := filepath.Abs(path)
+		if dirErr == nil && pathErr == nil {
+			if rel, err := filepath.Rel(dir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return fallback
+}
+
+// pos renders a position as "@line:col", or "@-" if it is unknown
+func (d *dumper) pos(file *File, pos token.Pos) string {
+	if file == nil || file.FileSet == nil || pos == token.NoPos {
+		return "@-"
+	}
+	p := file.FileSet.Position(pos)
+	return fmt.Sprintf("@%d:%d", p.Line, p.Column)
+}
+
+// functionKey returns the name of a function qualified by its receiver type
+func functionKey(fn *Function) string {
+	if fn.Receiver != nil {
+		return "(" + formatReceiverType(fn.Receiver) + ")." + fn.Name
+	}
+	return fn.Name
+}
+
+// formatReceiverType returns the receiver type including a pointer marker
+func formatReceiverType(r *Receiver) string {
+	if r.IsPointer && !strings.HasPrefix(r.Type, "*") {
+		return "*" + r.Type
+	}
+	return r.Type
+}
+
+// sortedMethods returns a copy of the methods sorted by name and position
+func sortedMethods(methods []*Method) []*Method {
+	sorted := append([]*Method(nil), methods...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Pos < sorted[j].Pos
+	})
+	return sorted
+}
+
+// sortedKeys returns the sorted keys of a map
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// joinVersion renders a module path with an optional version
+func joinVersion(dep *ModuleDependency) string {
+	if dep == nil {
+		return ""
+	}
+	if dep.Version == "" {
+		return dep.Path
+	}
+	return dep.Path + " " + dep.Version
+}
+
+// valueSpec formats a constant or variable like its declaration, leaving
+// out the type or value if it has none
+func valueSpec(name, typ, value string) string {
+	spec := name
+	if typ != "" {
+		spec += " " + typ
+	}
+	if value != "" {
+		spec += " = " + value
+	}
+	return spec
+}
+
+// exported returns a marker for unexported declarations
+func exported(isExported bool) string {
+	return flag(!isExported, " [unexported]")
+}
+
+// flag returns s if cond is true, otherwise an empty string
+func flag(cond bool, s string) string {
+	if cond {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+// Package module defines resolution of interface embedding within the module data model.
+package module
+
+import (
+	"path"
+	"strings"
+)
+
+// LinkEmbeddedInterfaces resolves the embedded entries of every interface in
+// the module to the interface types they name, setting Method.Embedded and
+// Type.Embeds. Names are resolved against the declaring package, or for
+// qualified names ("pkg.Name") against the imports of the declaring file.
+// Interfaces from outside the module remain unresolved.
+func (m *Module) LinkEmbeddedInterfaces() {
+	for _, pkg := range m.Packages {
+		for _, typ := range pkg.Types {
+			if typ.Kind != "interface" {
+				continue
+			}
+			typ.Embeds = typ.Embeds[:0]
+			for _, method := range typ.Interfaces {
+				if !method.IsEmbedded {
+					continue
+				}
+				method.Embedded = m.resolveEmbedded(typ, method.Name)
+				if method.Embedded != nil {
+					typ.Embeds = append(typ.Embeds, method.Embedded)
+				}
+			}
+		}
+	}
+}
+
+// resolveEmbedded finds the interface type named by an embedded entry of typ
+func (m *Module) resolveEmbedded(typ *Type, name string) *Type {
+	return interfaceType(m.resolveTypeName(typ, name))
+}
+
+// resolveTypeName finds the type of the module a name in the declaration of
+// typ refers to, resolving qualified names through the file's imports
+func (m *Module) resolveTypeName(typ *Type, name string) *Type {
+	pkgName, typeName, qualified := strings.Cut(name, ".")
+	if !qualified {
+		if typ.Package == nil {
+			return nil
+		}
+		return typ.Package.Types[name]
+	}
+
+	if typ.File == nil {
+		return nil
+	}
+	for _, imp := range typ.File.Imports {
+		localName := imp.Name
+		if localName == "" {
+			localName = path.Base(imp.Path)
+		}
+		if localName != pkgName {
+			continue
+		}
+		if target, ok := m.Packages[imp.Path]; ok {
+			return target.Types[typeName]
+		}
+	}
+	return nil
+}
+
+// interfaceType returns t if it is an interface type
+func interfaceType(t *Type) *Type {
+	if t == nil || t.Kind != "interface" {
+		return nil
+	}
+	return t
+}
+
+// InterfaceMethods returns the full method set of an interface type,
+// including methods of embedded interfaces resolved within the module.
+// Embedded interfaces that could not be resolved are returned as their
+// embedded entries so callers can tell the method set is incomplete.
+func (t *Type) InterfaceMethods() []*Method {
+	var methods []*Method
+	seen := make(map[string]bool)
+	visited := make(map[*Type]bool)
+
+	var collect func(*Type)
+	collect = func(current *Type) {
+		if visited[current] {
+			return
+		}
+		visited[current] = true
+
+		for _, method := range current.Interfaces {
+			switch {
+			case method.IsEmbedded && method.Embedded != nil:
+				collect(method.Embedded)
+			case !seen[method.Name]:
+				seen[method.Name] = true
+				methods = append(methods, method)
+			}
+		}
+	}
+	collect(t)
+
+	return methods
+}
@@ -0,0 +1,72 @@
+package module
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLinkEmbeddedInterfaces(t *testing.T) {
+	mod := NewModule("example.com/app", "")
+
+	// io package with Reader and Closer, and ReadCloser embedding both
+	ioPkg := NewPackage("io", "example.com/app/io", "")
+	ioFile := NewFile("io/io.go", "io.go", false)
+	ioPkg.AddFile(ioFile)
+	mod.AddPackage(ioPkg)
+
+	addInterface := func(pkg *Package, file *File, name string, methods ...string) *Type {
+		typ := NewType(name, "interface", true)
+		for _, m := range methods {
+			if strings.HasPrefix(m, "embed:") {
+				typ.AddInterfaceMethod(strings.TrimPrefix(m, "embed:"), "", true, "")
+				continue
+			}
+			typ.AddInterfaceMethod(m, "()", false, "")
+		}
+		file.AddType(typ)
+		pkg.AddType(typ)
+		return typ
+	}
+
+	reader := addInterface(ioPkg, ioFile, "Reader", "Read")
+	closer := addInterface(ioPkg, ioFile, "Closer", "Close")
+	readCloser := addInterface(ioPkg, ioFile, "ReadCloser", "embed:Reader", "embed:Closer", "Read")
+
+	// store package embedding a qualified module interface and an external one
+	storePkg := NewPackage("store", "example.com/app/store", "")
+	storeFile := NewFile("store/store.go", "store.go", false)
+	storeFile.AddImport(NewImport("example.com/app/io", "appio", false))
+	storeFile.AddImport(NewImport("fmt", "", false))
+	storePkg.AddFile(storeFile)
+	mod.AddPackage(storePkg)
+
+	store := addInterface(storePkg, storeFile, "Store", "embed:appio.ReadCloser", "embed:fmt.Stringer", "Get")
+
+	mod.LinkEmbeddedInterfaces()
+
+	if len(readCloser.Embeds) != 2 || readCloser.Embeds[0] != reader || readCloser.Embeds[1] != closer {
+		t.Errorf("Expected ReadCloser to embed Reader and Closer, got %v", readCloser.Embeds)
+	}
+	if len(store.Embeds) != 1 || store.Embeds[0] != readCloser {
+		t.Errorf("Expected Store to embed only the module's ReadCloser, got %v", store.Embeds)
+	}
+	if store.Interfaces[1].Embedded != nil {
+		t.Error("Expected fmt.Stringer to remain unresolved")
+	}
+
+	var names []string
+	for _, m := range store.InterfaceMethods() {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "Close,Get,Read,fmt.Stringer" {
+		t.Errorf("Unexpected method set: %s", got)
+	}
+
+	// Linking twice must not duplicate relations
+	mod.LinkEmbeddedInterfaces()
+	if len(readCloser.Embeds) != 2 {
+		t.Errorf("Expected relinking to be idempotent, got %d embeds", len(readCloser.Embeds))
+	}
+}
@@ -13,7 +13,7 @@ type File struct {
 	// File identity
 	Path    string   // Absolute path to file
 	Name    string   // File name
-	Package *Package // Package this file belongs to
+	Package *Package `json:"-"` // Package this file belongs to
 
 	// File content
 	Imports   []*Import   // Imports in this file
@@ -24,17 +24,25 @@ type File struct {
 
 	// Source information
 	SourceCode string         // Original source code (preserved)
-	AST        *ast.File      // AST representation (optional, may be nil)
-	FileSet    *token.FileSet // FileSet used to parse this file (for position information)
-	TokenFile  *token.File    // Token file for precise position mapping
+	AST        *ast.File      `json:"-"` // AST representation (optional, may be nil)
+	FileSet    *token.FileSet `json:"-"` // FileSet used to parse this file (for position information)
+	TokenFile  *token.File    `json:"-"` // Token file for precise position mapping
 
 	// Build information
-	BuildTags   []string // Build constraints
-	IsTest      bool     // Whether this is a test file
-	IsGenerated bool     // Whether this file is generated
-
-	// Tracking
-	IsModified bool // Whether this file has been modified since loading
+	BuildTags       []string // Tags the file's build constraint refers to, including a GOOS or GOARCH in its name
+	BuildConstraint string   // Build constraint of the file, e.g. "linux && !cgo"; empty if unconditional
+	BuildContexts   []string // Build contexts the file was loaded in, for loads in several contexts
+	IsTest          bool     // Whether this is a test file
+	IsGenerated     bool     // Whether this file is generated
+
+	// Tracking. IsModified is set when the model of the file changes, e.g.
+	// through AddFunction or a rename of a variable, and the saver
+	// regenerates the file from the model. IsSourceEdited is set by SetSource
+	// when transforms edit the text directly, as module path rewriting,
+	// import normalization and symbol renames do, and the saver writes
+	// SourceCode as-is.
+	IsModified     bool // Whether the model of this file has been modified since loading
+	IsSourceEdited bool // Whether SourceCode has been edited since loading
 }
 
 // Position represents a position in the source code
@@ -65,6 +73,18 @@ func NewFile(path, name string, isTest bool) *File {
 	}
 }
 
+// SetSource replaces the source code of the file with edited text, which is
+// saved as-is. It supersedes earlier changes to the model, so transforms
+// should keep the model consistent with the text or reload the file.
+func (f *File) SetSource(source string) {
+	f.SourceCode = source
+	f.IsSourceEdited = true
+	f.IsModified = false
+	if f.Package != nil {
+		f.Package.IsModified = true
+	}
+}
+
 // AddImport adds an import to the file
 func (f *File) AddImport(i *Import) {
 	f.Imports = append(f.Imports, i)
@@ -125,8 +145,6 @@ func (f *File) GetPositionInfo(pos token.Pos, end token.Pos) *Position {
 func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 	// Check if the position is within this file
 	if f.FileSet == nil || pos == token.NoPos {
-		// DEBUG
-		fmt.Printf("DEBUG: FindElementAtPosition: FileSet is nil or position is NoPos\n")
 		return nil
 	}
 
@@ -137,15 +155,10 @@ func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 	// Check if this position is in this file
 	if filepath.Base(filePath) != f.Name {
 		// Different file
-		fmt.Printf("DEBUG: FindElementAtPosition: Position is in file %s, not %s\n",
-			filepath.Base(filePath), f.Name)
 		return nil
 	}
 
-	// DEBUG: Print all types with positions
-	fmt.Printf("DEBUG: FindElementAtPosition: Checking %d types in file %s\n",
-		len(f.Types), f.Name)
-
+	// Check types
 	for _, t := range f.Types {
 		if t.Pos == token.NoPos || t.End == token.NoPos {
 			continue
@@ -155,19 +168,14 @@ func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 		typeStartPos := f.FileSet.Position(t.Pos)
 		typeEndPos := f.FileSet.Position(t.End)
 
-		fmt.Printf("DEBUG: Type %s: Pos=%v (line %d), End=%v (line %d)\n",
-			t.Name, t.Pos, typeStartPos.Line, t.End, typeEndPos.Line)
-
 		// Check if the position is within the type's range
 		if typeStartPos.Filename == posInfo.Filename &&
 			typeStartPos.Line <= posInfo.Line && posInfo.Line <= typeEndPos.Line {
-			fmt.Printf("DEBUG: FindElementAtPosition: Found type %s (line match)\n", t.Name)
 			return t
 		}
 	}
 
 	// Check functions
-	fmt.Printf("DEBUG: FindElementAtPosition: Checking %d functions\n", len(f.Functions))
 	for _, fn := range f.Functions {
 		if fn.Pos == token.NoPos || fn.End == token.NoPos {
 			continue
@@ -180,13 +188,11 @@ func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 		// Check if the position is within the function's range
 		if fnStartPos.Filename == posInfo.Filename &&
 			fnStartPos.Line <= posInfo.Line && posInfo.Line <= fnEndPos.Line {
-			fmt.Printf("DEBUG: FindElementAtPosition: Found function %s\n", fn.Name)
 			return fn
 		}
 	}
 
 	// Check variables
-	fmt.Printf("DEBUG: FindElementAtPosition: Checking %d variables\n", len(f.Variables))
 	for _, v := range f.Variables {
 		if v.Pos == token.NoPos || v.End == token.NoPos {
 			continue
@@ -199,13 +205,11 @@ func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 		// Check if the position is within the variable's range
 		if varStartPos.Filename == posInfo.Filename &&
 			varStartPos.Line <= posInfo.Line && posInfo.Line <= varEndPos.Line {
-			fmt.Printf("DEBUG: FindElementAtPosition: Found variable %s\n", v.Name)
 			return v
 		}
 	}
 
 	// Check constants
-	fmt.Printf("DEBUG: FindElementAtPosition: Checking %d constants\n", len(f.Constants))
 	for _, c := range f.Constants {
 		if c.Pos == token.NoPos || c.End == token.NoPos {
 			continue
@@ -218,13 +222,11 @@ func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 		// Check if the position is within the constant's range
 		if constStartPos.Filename == posInfo.Filename &&
 			constStartPos.Line <= posInfo.Line && posInfo.Line <= constEndPos.Line {
-			fmt.Printf("DEBUG: FindElementAtPosition: Found constant %s\n", c.Name)
 			return c
 		}
 	}
 
 	// Check imports
-	fmt.Printf("DEBUG: FindElementAtPosition: Checking %d imports\n", len(f.Imports))
 	for _, i := range f.Imports {
 		if i.Pos == token.NoPos || i.End == token.NoPos {
 			continue
@@ -237,13 +239,10 @@ func (f *File) FindElementAtPosition(pos token.Pos) interface{} {
 		// Check if the position is within the import's range
 		if importStartPos.Filename == posInfo.Filename &&
 			importStartPos.Line <= posInfo.Line && posInfo.Line <= importEndPos.Line {
-			fmt.Printf("DEBUG: FindElementAtPosition: Found import %s\n", i.Path)
 			return i
 		}
 	}
 
-	fmt.Printf("DEBUG: FindElementAtPosition: No element found at position %v (line %d)\n",
-		pos, posInfo.Line)
 	return nil
 }
 
@@ -259,3 +258,22 @@ func (p *Position) String() string {
 
 	return fmt.Sprintf("%s:%d:%d-%d:%d", p.File.Path, p.LineStart, p.ColStart, p.LineEnd, p.ColEnd)
 }
+
+// Before orders positions by file path and offset, or line and column if
+// they have no offset; nil positions sort last
+func (p *Position) Before(q *Position) bool {
+	if p == nil || q == nil {
+		return p != nil
+	}
+	if p.File.Path != q.File.Path {
+		return p.File.Path < q.File.Path
+	}
+	if p.Pos.IsValid() && q.Pos.IsValid() {
+		return p.Pos < q.Pos
+	}
+	// Positions built from line and column alone, e.g. within comments
+	if p.LineStart != q.LineStart {
+		return p.LineStart < q.LineStart
+	}
+	return p.ColStart < q.ColStart
+}
@@ -10,8 +10,8 @@ import (
 type Function struct {
 	// Function identity
 	Name    string   // Function name
-	File    *File    // File where this function is defined
-	Package *Package // Package this function belongs to
+	File    *File    `json:"-"` // File where this function is defined
+	Package *Package `json:"-"` // Package this function belongs to
 
 	// Function information
 	Signature  string       // Function signature
@@ -21,10 +21,11 @@ type Function struct {
 	IsExported bool         // Whether the function is exported
 	IsMethod   bool         // Whether this is a method
 	IsTest     bool         // Whether this is a test function
+	TestKind   TestKind     // Role in tests (only set for functions in test files)
 
 	// Function body
 	Body string        // Function body as source code
-	AST  *ast.FuncDecl // AST node (optional, may be nil)
+	AST  *ast.FuncDecl `json:"-"` // AST node (optional, may be nil)
 
 	// Position information
 	Pos token.Pos // Start position in source
@@ -131,3 +132,37 @@ func (p *Parameter) SetPosition(pos, end token.Pos) {
 	p.Pos = pos
 	p.End = end
 }
+
+// FuncDeclName returns the name of a function declaration, qualified by its
+// receiver type without type parameters (e.g. "List.Len")
+func FuncDeclName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	if recv, _ := ReceiverTypeName(decl.Recv.List[0].Type); recv != "" {
+		return recv + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+// ReceiverTypeName returns the name of a receiver's base type without type
+// parameters, and whether the receiver is a pointer
+func ReceiverTypeName(expr ast.Expr) (name string, isPointer bool) {
+	if paren, ok := expr.(*ast.ParenExpr); ok {
+		expr = paren.X
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+		isPointer = true
+	}
+	switch r := expr.(type) {
+	case *ast.IndexExpr:
+		expr = r.X
+	case *ast.IndexListExpr:
+		expr = r.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name, isPointer
+	}
+	return "", isPointer
+}
@@ -0,0 +1,116 @@
+// Package module defines streaming iteration over the symbols of a module.
+package module
+
+import (
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// SymbolFilter restricts the symbols visited by IterateSymbolsWithFilter.
+// Zero fields do not restrict.
+type SymbolFilter struct {
+	Kinds         []SymbolKind // Only symbols of these kinds
+	ExportedOnly  bool         // Only exported symbols
+	PackagePrefix string       // Only packages at or below this import path
+}
+
+// Match reports whether a symbol passes the filter
+func (f SymbolFilter) Match(sym *Symbol) bool {
+	if f.ExportedOnly && !token.IsExported(sym.Name) {
+		return false
+	}
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, kind := range f.Kinds {
+			found = found || kind == sym.Kind
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.matchPackage(sym.Package)
+}
+
+// matchPackage reports whether a package import path passes the filter
+func (f SymbolFilter) matchPackage(importPath string) bool {
+	prefix := strings.TrimSuffix(f.PackagePrefix, "/")
+	return prefix == "" || importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+}
+
+// IterateSymbols calls fn for every symbol of the module until fn returns
+// false. Symbols are built one file at a time instead of all at once and
+// visited by package import path, then file path, then position.
+func (m *Module) IterateSymbols(fn func(*Symbol) bool) {
+	m.IterateSymbolsWithFilter(SymbolFilter{}, fn)
+}
+
+// IterateSymbolsWithFilter is IterateSymbols restricted to the symbols
+// matching the filter
+func (m *Module) IterateSymbolsWithFilter(filter SymbolFilter, fn func(*Symbol) bool) {
+	paths := make([]string, 0, len(m.Packages))
+	for path := range m.Packages {
+		if filter.matchPackage(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if !m.Packages[path].iterateSymbols(filter, fn) {
+			return
+		}
+	}
+}
+
+// IterateSymbols calls fn for every symbol of the package until fn returns
+// false, by file path and then position
+func (p *Package) IterateSymbols(fn func(*Symbol) bool) {
+	p.iterateSymbols(SymbolFilter{}, fn)
+}
+
+// IterateSymbolsWithFilter is IterateSymbols restricted to the symbols
+// matching the filter
+func (p *Package) IterateSymbolsWithFilter(filter SymbolFilter, fn func(*Symbol) bool) {
+	p.iterateSymbols(filter, fn)
+}
+
+// iterateSymbols visits the package's matching symbols and reports whether
+// fn asked to continue
+func (p *Package) iterateSymbols(filter SymbolFilter, fn func(*Symbol) bool) bool {
+	if !filter.matchPackage(p.ImportPath) {
+		return true
+	}
+
+	files := make([]*File, 0, len(p.Files))
+	for _, file := range p.Files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	for _, file := range files {
+		symbols := fileSymbols(p, file)
+		sort.SliceStable(symbols, func(i, j int) bool { return symbolPos(symbols[i]) < symbolPos(symbols[j]) })
+		for _, sym := range symbols {
+			if filter.Match(sym) && !fn(sym) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// symbolPos returns the start position of a symbol's declaration
+func symbolPos(sym *Symbol) token.Pos {
+	switch element := sym.Element.(type) {
+	case *Function:
+		return element.Pos
+	case *Type:
+		return element.Pos
+	case *Variable:
+		return element.Pos
+	case *Constant:
+		return element.Pos
+	}
+	return token.NoPos
+}
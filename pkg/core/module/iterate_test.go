@@ -0,0 +1,64 @@
+package module
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestIterateSymbols(t *testing.T) {
+	mod := NewModule("example.com/iter", "")
+
+	root := NewPackage("iter", "example.com/iter", "")
+	mod.AddPackage(root)
+	b := NewFile("/iter/b.go", "b.go", false)
+	root.AddFile(b)
+	b.AddFunction(&Function{Name: "Later", IsExported: true, Pos: token.Pos(20)})
+	b.AddType(&Type{Name: "Earlier", Kind: "struct", IsExported: true, Pos: token.Pos(10)})
+	a := NewFile("/iter/a.go", "a.go", false)
+	root.AddFile(a)
+	a.AddConstant(&Constant{Name: "limit", Value: "1", Pos: token.Pos(5)})
+
+	sub := NewPackage("sub", "example.com/iter/sub", "")
+	mod.AddPackage(sub)
+	s := NewFile("/iter/sub/sub.go", "sub.go", false)
+	sub.AddFile(s)
+	s.AddFunction(&Function{Name: "Run", IsExported: true})
+
+	other := NewPackage("iterx", "example.com/iterx", "")
+	mod.AddPackage(other)
+	x := NewFile("/iterx/x.go", "x.go", false)
+	other.AddFile(x)
+	x.AddVariable(&Variable{Name: "X", IsExported: true})
+
+	visit := func(filter SymbolFilter, limit int) string {
+		var ids []string
+		mod.IterateSymbolsWithFilter(filter, func(sym *Symbol) bool {
+			ids = append(ids, strings.TrimPrefix(sym.ID, "example.com/"))
+			return len(ids) < limit
+		})
+		return strings.Join(ids, ",")
+	}
+
+	if got := visit(SymbolFilter{}, 100); got != "iter.limit,iter.Earlier,iter.Later,iter/sub.Run,iterx.X" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+	if got := visit(SymbolFilter{}, 2); got != "iter.limit,iter.Earlier" {
+		t.Errorf("Expected iteration to stop after two symbols, got %s", got)
+	}
+	if got := visit(SymbolFilter{PackagePrefix: "example.com/iter"}, 100); got != "iter.limit,iter.Earlier,iter.Later,iter/sub.Run" {
+		t.Errorf("Unexpected symbols below example.com/iter: %s", got)
+	}
+	if got := visit(SymbolFilter{Kinds: []SymbolKind{SymbolFunction}, ExportedOnly: true}, 100); got != "iter.Later,iter/sub.Run" {
+		t.Errorf("Unexpected exported functions: %s", got)
+	}
+
+	var count int
+	sub.IterateSymbols(func(*Symbol) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Errorf("Expected 1 symbol in sub, got %d", count)
+	}
+}
@@ -0,0 +1,80 @@
+// Package module defines locking primitives for concurrent modification of the module data model.
+package module
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// LockFile acquires the lock for the file at the given path, blocking until it
+// is available. Edits to different files can proceed in parallel while edits
+// touching the same file are serialized; the declaration maps of a package
+// that such edits update through Package.AddFunction and the like are
+// guarded by the package itself.
+func (m *Module) LockFile(path string) {
+	m.fileLock(path).Lock()
+}
+
+// UnlockFile releases the lock for the file at the given path
+func (m *Module) UnlockFile(path string) {
+	m.fileLock(path).Unlock()
+}
+
+// LockFiles acquires the locks for all given files and returns a function that
+// releases them again. Locks are always acquired in sorted path order, so
+// concurrent callers locking overlapping file sets cannot deadlock.
+func (m *Module) LockFiles(paths ...string) (unlock func()) {
+	// Deduplicate and sort the paths to get a global lock order
+	seen := make(map[string]bool, len(paths))
+	ordered := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			ordered = append(ordered, path)
+		}
+	}
+	sort.Strings(ordered)
+
+	locks := make([]*sync.Mutex, len(ordered))
+	for i, path := range ordered {
+		locks[i] = m.fileLock(path)
+		locks[i].Lock()
+	}
+
+	return func() {
+		// Release in reverse acquisition order
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// LockPackage acquires the locks for all files of a package, see LockFiles
+func (m *Module) LockPackage(pkg *Package) (unlock func()) {
+	paths := make([]string, 0, len(pkg.Files))
+	for _, file := range pkg.Files {
+		paths = append(paths, file.Path)
+	}
+	return m.LockFiles(paths...)
+}
+
+// fileLock returns the lock for a file, creating it on first use
+func (m *Module) fileLock(path string) *sync.Mutex {
+	path = filepath.Clean(path)
+
+	m.locksMu.Lock()
+	defer m.locksMu.Unlock()
+
+	if m.fileLocks == nil {
+		m.fileLocks = make(map[string]*sync.Mutex)
+	}
+
+	lock, ok := m.fileLocks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.fileLocks[path] = lock
+	}
+	return lock
+}
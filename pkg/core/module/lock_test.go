@@ -0,0 +1,128 @@
+package module
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFiles_Stress(t *testing.T) {
+	mod := NewModule("example.com/locks", "/test")
+
+	// Files of two packages are edited concurrently, each while holding
+	// the file's lock; the race detector flags unsynchronized access to the
+	// files' declarations or the packages' declaration maps.
+	const fileCount = 8
+	paths := make([]string, fileCount)
+	files := make(map[string]*File, fileCount)
+	for _, name := range []string{"a", "b"} {
+		mod.AddPackage(NewPackage(name, "example.com/locks/"+name, "/test/"+name))
+	}
+	for i := range paths {
+		pkg := mod.Packages["example.com/locks/"+[]string{"a", "b"}[i%2]]
+		paths[i] = fmt.Sprintf("%s/file%d.go", pkg.Dir, i)
+		files[paths[i]] = NewFile(paths[i], fmt.Sprintf("file%d.go", i), false)
+		pkg.AddFile(files[paths[i]])
+	}
+
+	const workers = 16
+	const iterations = 200
+
+	// addFunction declares a new function in a file and its package
+	addFunction := func(prefix string, w, i int, path string) {
+		file := files[path]
+		fn := NewFunction(fmt.Sprintf("%s%d_%d_%s", prefix, w, i, strings.TrimSuffix(file.Name, ".go")), true, false)
+		file.AddFunction(fn)
+		file.Package.AddFunction(fn)
+		if file.Package.GetFunction(fn.Name) != fn {
+			t.Errorf("Expected %s to be declared in its package", fn.Name)
+		}
+	}
+
+	var wg sync.WaitGroup
+	expected := make([]map[string]int, workers)
+	for w := 0; w < workers; w++ {
+		expected[w] = make(map[string]int)
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+
+			for i := 0; i < iterations; i++ {
+				// Lock a random subset in random order, including duplicates
+				n := 1 + rng.Intn(3)
+				subset := make([]string, n)
+				for j := range subset {
+					subset[j] = paths[rng.Intn(fileCount)]
+				}
+
+				unlock := mod.LockFiles(subset...)
+				touched := make(map[string]bool)
+				for _, path := range subset {
+					if !touched[path] {
+						touched[path] = true
+						addFunction("Files", w, i, path)
+						expected[w][path]++
+					}
+				}
+				unlock()
+
+				// Exercise the single-file API as well
+				path := paths[rng.Intn(fileCount)]
+				mod.LockFile(path)
+				addFunction("File", w, i, path)
+				expected[w][path]++
+				mod.UnlockFile(path)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	declared := 0
+	for _, path := range paths {
+		want := 0
+		for w := 0; w < workers; w++ {
+			want += expected[w][path]
+		}
+		if got := len(files[path].Functions); got != want {
+			t.Errorf("Functions in %s = %d, want %d", path, got, want)
+		}
+		declared += want
+	}
+	if got := len(mod.Packages["example.com/locks/a"].Functions) + len(mod.Packages["example.com/locks/b"].Functions); got != declared {
+		t.Errorf("Functions in packages = %d, want %d", got, declared)
+	}
+}
+
+func TestLockPackage_BlocksFileLock(t *testing.T) {
+	mod := NewModule("example.com/locks", "/test")
+	pkg := NewPackage("pkg", "example.com/locks/pkg", "/test/pkg")
+	mod.AddPackage(pkg)
+	pkg.AddFile(NewFile("/test/pkg/a.go", "a.go", false))
+	pkg.AddFile(NewFile("/test/pkg/b.go", "b.go", false))
+
+	unlock := mod.LockPackage(pkg)
+
+	acquired := make(chan struct{})
+	go func() {
+		mod.LockFile("/test/pkg/./b.go")
+		close(acquired)
+		mod.UnlockFile("/test/pkg/b.go")
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected file lock to block while the package is locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected file lock to be acquired after unlocking the package")
+	}
+}
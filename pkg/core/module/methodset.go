@@ -0,0 +1,194 @@
+// Package module defines method set queries including promoted methods.
+package module
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// MethodSetEntry is a method in the method set of a type
+type MethodSetEntry struct {
+	Method *Method  // Declared method; Method.Parent is the declaring type
+	Via    []string // Embedded fields the method is promoted through, outermost first
+}
+
+// Promoted reports whether the method comes from an embedded type
+func (e *MethodSetEntry) Promoted() bool {
+	return len(e.Via) > 0
+}
+
+// FindMethods returns the methods of a type sorted by name: the declared
+// methods, or for an interface its full method set. With includePromoted,
+// methods promoted through embedded struct fields are included the way the
+// compiler selects them: a method at a shallower embedding depth, or a field
+// of the same name, hides deeper ones, and names found more than once at
+// the same depth are ambiguous and left out. The method set is that of
+// the pointer type; receivers are not distinguished. Embedded types from
+// outside the module contribute no methods.
+func (m *Module) FindMethods(pkgPath, typeName string, includePromoted bool) ([]*MethodSetEntry, error) {
+	pkg := m.Packages[pkgPath]
+	if pkg == nil {
+		return nil, fmt.Errorf("package %s not found", pkgPath)
+	}
+	typ := pkg.Types[typeName]
+	if typ == nil {
+		return nil, fmt.Errorf("type %s not found in package %s", typeName, pkgPath)
+	}
+
+	return m.methodSet(typ, includePromoted, nil), nil
+}
+
+// methodSet computes the method set of typ as if the extra types were
+// embedded in it as well
+func (m *Module) methodSet(typ *Type, includePromoted bool, extra []*Type) []*MethodSetEntry {
+	type embedding struct {
+		typ *Type
+		via []string
+	}
+
+	var entries []*MethodSetEntry
+	hidden := make(map[string]bool)
+	visited := make(map[*Type]bool)
+	level := []embedding{{typ: typ}}
+
+	for len(level) > 0 {
+		found := make(map[string][]*MethodSetEntry)
+		fields := make(map[string]int)
+		var next []embedding
+
+		for _, e := range level {
+			methods := e.typ.Methods
+			if e.typ.Kind == "interface" {
+				methods = e.typ.InterfaceMethods()
+			}
+			for _, method := range methods {
+				if !method.IsEmbedded {
+					found[method.Name] = append(found[method.Name], &MethodSetEntry{Method: method, Via: e.via})
+				}
+			}
+
+			for _, field := range e.typ.Fields {
+				if !field.IsEmbedded {
+					fields[field.Name]++
+					continue
+				}
+				name := embeddedFieldName(field.Type)
+				fields[name]++
+				if !includePromoted {
+					continue
+				}
+				if embedded := m.resolveTypeName(e.typ, embeddedTypeName(field.Type)); embedded != nil && !visited[embedded] {
+					via := append(append([]string(nil), e.via...), name)
+					next = append(next, embedding{typ: embedded, via: via})
+				}
+			}
+		}
+		if e := level[0]; e.typ == typ && e.via == nil {
+			for _, embedded := range extra {
+				fields[embedded.Name]++
+				if includePromoted && !visited[embedded] {
+					next = append(next, embedding{typ: embedded, via: []string{embedded.Name}})
+				}
+			}
+		}
+		for _, e := range level {
+			visited[e.typ] = true
+		}
+
+		for name, candidates := range found {
+			if !hidden[name] && len(candidates) == 1 && fields[name] == 0 {
+				entries = append(entries, candidates[0])
+			}
+			hidden[name] = true
+		}
+		for name := range fields {
+			hidden[name] = true
+		}
+
+		level = next
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Method.Name < entries[j].Method.Name })
+	return entries
+}
+
+// embeddedTypeName returns the possibly qualified type name of an embedded
+// field type, without pointer and type arguments
+func embeddedTypeName(fieldType string) string {
+	name := strings.TrimPrefix(fieldType, "*")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// embeddedFieldName returns the implicit field name of an embedded field
+func embeddedFieldName(fieldType string) string {
+	name := embeddedTypeName(fieldType)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// EmbeddingPreview tells what embedding the type embedded in the struct type
+// outer would do to their exported method sets. Promoted are the methods of
+// embedded that would become methods of outer. Conflicts are the methods of
+// embedded that would be hidden by a method or field of outer or be
+// ambiguous with a method promoted from another embedded type, as well as
+// methods outer currently has that the embedding would hide or make
+// ambiguous. If outer already has a field named like embedded, the name of
+// embedded is reported as a conflict. Both lists are sorted.
+func (m *Module) EmbeddingPreview(outer, embedded *Type) (promoted, conflicts []string) {
+	// A second field of the same name does not compile
+	duplicate := false
+	for _, field := range outer.Fields {
+		name := field.Name
+		if field.IsEmbedded {
+			name = embeddedFieldName(field.Type)
+		}
+		duplicate = duplicate || name == embedded.Name
+	}
+
+	names := func(entries []*MethodSetEntry) map[string]*MethodSetEntry {
+		set := make(map[string]*MethodSetEntry)
+		for _, e := range entries {
+			if token.IsExported(e.Method.Name) {
+				set[e.Method.Name] = e
+			}
+		}
+		return set
+	}
+	before := names(m.methodSet(outer, true, nil))
+	after := names(m.methodSet(outer, true, []*Type{embedded}))
+	own := names(m.methodSet(embedded, true, nil))
+
+	conflicting := make(map[string]bool)
+	for name, e := range after {
+		if len(e.Via) > 0 && e.Via[0] == embedded.Name && !duplicate {
+			promoted = append(promoted, name)
+		}
+	}
+	for name := range own {
+		if e := after[name]; e == nil || len(e.Via) == 0 || e.Via[0] != embedded.Name {
+			conflicting[name] = true
+		}
+	}
+	for name, e := range before {
+		if a := after[name]; a == nil || a.Method != e.Method {
+			conflicting[name] = true
+		}
+	}
+	if duplicate {
+		conflicting[embedded.Name] = true
+	}
+	for name := range conflicting {
+		conflicts = append(conflicts, name)
+	}
+
+	sort.Strings(promoted)
+	sort.Strings(conflicts)
+	return promoted, conflicts
+}
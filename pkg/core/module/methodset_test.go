@@ -0,0 +1,44 @@
+package module
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbeddingPreview(t *testing.T) {
+	mod := NewModule("example.com/embed", "")
+	pkg := NewPackage("embed", "example.com/embed", "")
+	mod.AddPackage(pkg)
+
+	inner := NewType("Inner", "struct", true)
+	inner.AddMethod("Reset", "func()", false, "")
+	inner.AddMethod("Size", "func() int", false, "")
+	pkg.AddType(inner)
+
+	conn := NewType("Conn", "struct", true)
+	for _, name := range []string{"Close", "Reset", "Read", "Write", "dial"} {
+		conn.AddMethod(name, "func()", false, "")
+	}
+	pkg.AddType(conn)
+
+	service := NewType("Service", "struct", true)
+	service.AddField("Name", "string", "", false, "")
+	service.AddField("", "*Inner", "", true, "")
+	service.AddMethod("Close", "func() error", false, "")
+	pkg.AddType(service)
+
+	promoted, conflicts := mod.EmbeddingPreview(service, conn)
+	// Close is hidden by Service.Close and Reset becomes ambiguous with Inner.Reset
+	if got := strings.Join(promoted, ","); got != "Read,Write" {
+		t.Errorf("Expected promoted Read,Write, got %s", got)
+	}
+	if got := strings.Join(conflicts, ","); got != "Close,Reset" {
+		t.Errorf("Expected conflicts Close,Reset, got %s", got)
+	}
+
+	// Embedding a second field named Inner does not compile
+	promoted, conflicts = mod.EmbeddingPreview(service, inner)
+	if len(promoted) != 0 || strings.Join(conflicts, ",") != "Inner,Reset,Size" {
+		t.Errorf("Unexpected preview for a duplicate field: %v %v", promoted, conflicts)
+	}
+}
@@ -3,6 +3,7 @@ package module
 
 import (
 	"path/filepath"
+	"sync"
 )
 
 // Module represents a complete Go module
@@ -14,11 +15,13 @@ type Module struct {
 
 	// Content
 	Packages    map[string]*Package // Map of package import paths to packages
-	MainPackage *Package            // Main package if this is an executable module
+	MainPackage *Package            `json:"-"` // Main package if this is an executable module
 
 	// Module relationships
 	Dependencies []*ModuleDependency // Other modules this module depends on
 	Replace      []*ModuleReplace    // Module replacements
+	Exclude      []*ModuleDependency // Excluded module versions
+	Retract      []*ModuleRetract    // Versions of this module retracted by its author
 
 	// Build information
 	BuildFlags map[string]string // Build flags
@@ -27,6 +30,13 @@ type Module struct {
 	// Module metadata
 	Dir   string // Root directory path
 	GoMod string // Path to go.mod file
+
+	// Problems found while loading, for loads that allow errors
+	Diagnostics []Diagnostic
+
+	// Concurrency
+	locksMu   sync.Mutex             // Guards fileLocks
+	fileLocks map[string]*sync.Mutex // Per-file locks for concurrent modification
 }
 
 // ModuleDependency represents a dependency on another module
@@ -42,6 +52,14 @@ type ModuleReplace struct {
 	New *ModuleDependency // Replacement module
 }
 
+// ModuleRetract represents a retract directive, retracting a single version
+// (Low equals High) or a closed range of versions of the module
+type ModuleRetract struct {
+	Low       string // Lowest retracted version
+	High      string // Highest retracted version
+	Rationale string // Reason given in the directive's comment, if any
+}
+
 // NewModule creates a new empty module with the given path
 func NewModule(path, dir string) *Module {
 	return &Module{
@@ -108,3 +126,13 @@ func (m *Module) AddReplace(oldPath, oldVersion, newPath, newVersion string) {
 		},
 	})
 }
+
+// AddExclude adds an excluded module version
+func (m *Module) AddExclude(path, version string) {
+	m.Exclude = append(m.Exclude, &ModuleDependency{Path: path, Version: version})
+}
+
+// AddRetract adds a retracted version or range of versions
+func (m *Module) AddRetract(low, high, rationale string) {
+	m.Retract = append(m.Retract, &ModuleRetract{Low: low, High: high, Rationale: rationale})
+}
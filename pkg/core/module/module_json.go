@@ -0,0 +1,207 @@
+// Package module defines the JSON representation of modules for caching.
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/token"
+)
+
+// ModuleSchemaVersion is the version of the JSON representation of modules
+const ModuleSchemaVersion = 1
+
+// SourceHash returns the hex-encoded SHA-256 of a file's content, as stored
+// with each file in the JSON representation of a module
+func SourceHash(source []byte) string {
+	sum := sha256.Sum256(source)
+	return hex.EncodeToString(sum[:])
+}
+
+// MarshalJSON encodes the module with its packages, files and declarations,
+// including source code and positions. References back to containing
+// elements, ASTs and type information are left out.
+func (m *Module) MarshalJSON() ([]byte, error) {
+	type plain Module
+	out := struct {
+		Schema int
+		*plain
+		MainPackage string `json:",omitempty"`
+	}{Schema: ModuleSchemaVersion, plain: (*plain)(m)}
+	if m.MainPackage != nil {
+		out.MainPackage = m.MainPackage.ImportPath
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a module encoded by MarshalJSON and restores the
+// references between its elements. Positions refer to a file set per file,
+// set as File.FileSet. ASTs and type information are not restored. JSON
+// without a schema version is accepted too, for hand-written modules.
+func (m *Module) UnmarshalJSON(data []byte) error {
+	type plain Module
+	in := struct {
+		Schema int
+		*plain
+		MainPackage string `json:",omitempty"`
+	}{plain: (*plain)(m)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Schema != 0 && in.Schema != ModuleSchemaVersion {
+		return fmt.Errorf("unsupported module schema version %d", in.Schema)
+	}
+
+	if m.Packages == nil {
+		m.Packages = make(map[string]*Package)
+	}
+	for _, pkg := range m.Packages {
+		pkg.Module = m
+		pkg.relink()
+	}
+	m.MainPackage = m.Packages[in.MainPackage]
+	m.LinkEmbeddedInterfaces()
+	return nil
+}
+
+// MarshalJSON encodes the package without its declaration maps, which
+// hold the declarations of its files again
+func (p *Package) MarshalJSON() ([]byte, error) {
+	type plain Package
+	return json.Marshal(struct {
+		*plain
+		Types     map[string]*Type     `json:",omitempty"`
+		Functions map[string]*Function `json:",omitempty"`
+		Variables map[string]*Variable `json:",omitempty"`
+		Constants map[string]*Constant `json:",omitempty"`
+	}{plain: (*plain)(p)})
+}
+
+// relink restores the references from the package's files and declarations
+// to their containers, and adds the files' declarations to the package's
+// declaration maps
+func (p *Package) relink() {
+	if p.Files == nil {
+		p.Files = make(map[string]*File)
+	}
+	if p.Types == nil {
+		p.Types = make(map[string]*Type)
+	}
+	if p.Functions == nil {
+		p.Functions = make(map[string]*Function)
+	}
+	if p.Variables == nil {
+		p.Variables = make(map[string]*Variable)
+	}
+	if p.Constants == nil {
+		p.Constants = make(map[string]*Constant)
+	}
+	if p.Imports == nil {
+		p.Imports = make(map[string]*Import)
+	}
+
+	for _, file := range p.Files {
+		file.Package = p
+		for _, imp := range file.Imports {
+			imp.File = file
+		}
+		for _, t := range file.Types {
+			t.File, t.Package = file, p
+			for _, f := range t.Fields {
+				f.Parent = t
+			}
+			for _, method := range t.Methods {
+				method.Parent = t
+			}
+			for _, method := range t.Interfaces {
+				method.Parent = t
+			}
+			p.Types[t.Name] = t
+		}
+		for _, fn := range file.Functions {
+			fn.File, fn.Package = file, p
+			p.Functions[fn.Name] = fn
+		}
+		for _, v := range file.Variables {
+			v.File, v.Package = file, p
+			p.Variables[v.Name] = v
+		}
+		for _, c := range file.Constants {
+			c.File, c.Package = file, p
+			p.Constants[c.Name] = c
+		}
+	}
+}
+
+// MarshalJSON encodes the file along with the hash of its source code and
+// the base of its positions
+func (f *File) MarshalJSON() ([]byte, error) {
+	type plain File
+	out := struct {
+		*plain
+		Hash string
+		Base int `json:",omitempty"`
+	}{plain: (*plain)(f), Hash: SourceHash([]byte(f.SourceCode))}
+	if tf := f.positionFile(); tf != nil {
+		out.Base = tf.Base()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a file encoded by MarshalJSON. The file gets a file
+// set of its own in which its positions are valid again.
+func (f *File) UnmarshalJSON(data []byte) error {
+	type plain File
+	in := struct {
+		*plain
+		Hash string
+		Base int `json:",omitempty"`
+	}{plain: (*plain)(f)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Hash != "" && in.Hash != SourceHash([]byte(f.SourceCode)) {
+		return fmt.Errorf("source code of %s does not match its hash", f.Path)
+	}
+
+	if in.Base > 0 {
+		f.FileSet = token.NewFileSet()
+		f.TokenFile = f.FileSet.AddFile(f.Path, in.Base, len(f.SourceCode))
+		f.TokenFile.SetLinesForContent([]byte(f.SourceCode))
+	}
+	return nil
+}
+
+// positionFile returns the token file the positions of the file's
+// declarations refer to, or nil if there is none
+func (f *File) positionFile() *token.File {
+	if f.FileSet == nil {
+		return nil
+	}
+	if f.AST != nil {
+		return f.FileSet.File(f.AST.Pos())
+	}
+	var positions []token.Pos
+	for _, imp := range f.Imports {
+		positions = append(positions, imp.Pos)
+	}
+	for _, t := range f.Types {
+		positions = append(positions, t.Pos)
+	}
+	for _, fn := range f.Functions {
+		positions = append(positions, fn.Pos)
+	}
+	for _, v := range f.Variables {
+		positions = append(positions, v.Pos)
+	}
+	for _, c := range f.Constants {
+		positions = append(positions, c.Pos)
+	}
+	for _, pos := range positions {
+		if pos.IsValid() {
+			return f.FileSet.File(pos)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package module
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestModuleJSONRoundTrip(t *testing.T) {
+	source := `package shapes
+
+// Shape has an area
+type Shape interface {
+	Area() float64
+}
+
+// Square is a shape
+type Square struct {
+	Side float64
+}
+
+// Area returns the area of the square
+func (s Square) Area() float64 { return s.Side * s.Side }
+`
+	fset := token.NewFileSet()
+	// Offset the file's positions as if other files had been parsed before
+	fset.AddFile("other.go", -1, 100)
+	parsed, err := parser.ParseFile(fset, "/shapes/shapes.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := NewModule("example.com/shapes", "/shapes")
+	mod.GoVersion = "1.21"
+	mod.BuildTags = []string{"fast"}
+	pkg := NewPackage("shapes", "example.com/shapes", "/shapes")
+	mod.AddPackage(pkg)
+	file := NewFile("/shapes/shapes.go", "shapes.go", false)
+	file.SourceCode = source
+	file.FileSet = fset
+	pkg.AddFile(file)
+
+	for _, decl := range parsed.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			spec := d.Specs[0].(*ast.TypeSpec)
+			typ := &Type{Name: spec.Name.Name, IsExported: true, Doc: d.Doc.Text(), Pos: d.Pos(), End: d.End()}
+			if _, ok := spec.Type.(*ast.InterfaceType); ok {
+				typ.Kind = "interface"
+				typ.Interfaces = []*Method{{Name: "Area", Signature: "() float64", Parent: typ}}
+			} else {
+				typ.Kind = "struct"
+				typ.Fields = []*Field{{Name: "Side", Type: "float64", Parent: typ}}
+			}
+			file.AddType(typ)
+			pkg.AddType(typ)
+		case *ast.FuncDecl:
+			fn := &Function{Name: d.Name.Name, IsExported: true, IsMethod: true, Doc: d.Doc.Text(), Pos: d.Pos(), End: d.End()}
+			fn.Receiver = &Receiver{Name: "s", Type: "Square"}
+			file.AddFunction(fn)
+			pkg.AddFunction(fn)
+		}
+	}
+
+	data, err := json.Marshal(mod)
+	if err != nil {
+		t.Fatalf("Failed to encode module: %v", err)
+	}
+	if strings.Contains(string(data), `"Types":{`) {
+		t.Error("Expected declarations to be encoded only once, with their files")
+	}
+	decoded := &Module{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Failed to decode module: %v", err)
+	}
+
+	if decoded.Path != mod.Path || decoded.GoVersion != "1.21" || strings.Join(decoded.BuildTags, ",") != "fast" {
+		t.Errorf("Module metadata not preserved: %s %s %v", decoded.Path, decoded.GoVersion, decoded.BuildTags)
+	}
+	decodedPkg := decoded.Packages["example.com/shapes"]
+	if decodedPkg == nil || decodedPkg.Module != decoded {
+		t.Fatal("Package not restored with its module")
+	}
+	square := decodedPkg.Types["Square"]
+	if square == nil || square.Package != decodedPkg || square.Fields[0].Parent != square || square.File.Types[1] != square {
+		t.Fatal("Type not restored with its references")
+	}
+
+	// Symbols keep their IDs, hashes and positions
+	before, after := mod.Symbols(), decoded.Symbols()
+	if len(before) != len(after) {
+		t.Fatalf("Expected %d symbols, got %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i].ID != after[i].ID || before[i].Hash != after[i].Hash {
+			t.Errorf("Symbol %s changed to %s (%s, %s)", before[i].ID, after[i].ID, before[i].Hash, after[i].Hash)
+		}
+	}
+	area := decodedPkg.Functions["Area"]
+	if pos := area.File.GetPositionInfo(area.Pos, area.End); pos == nil || pos.LineStart != 14 {
+		t.Errorf("Expected Area on line 14, got %+v", pos)
+	}
+
+	// A corrupted source is detected
+	corrupted := strings.Replace(string(data), "Side float64", "Edge float64", 1)
+	if err := json.Unmarshal([]byte(corrupted), &Module{}); err == nil {
+		t.Error("Expected an error for source code that does not match its hash")
+	}
+	if err := json.Unmarshal([]byte(`{"Schema": 2}`), &Module{}); err == nil {
+		t.Error("Expected an error for an unknown schema version")
+	}
+}
@@ -2,7 +2,14 @@
 package module
 
 import (
+	"errors"
 	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 )
 
 // Package represents a Go package within a module
@@ -11,7 +18,7 @@ type Package struct {
 	Name       string  // Package name (final component of import path)
 	ImportPath string  // Full import path
 	Dir        string  // Directory containing the package
-	Module     *Module // Reference to parent module
+	Module     *Module `json:"-"` // Reference to parent module
 	IsTest     bool    // Whether this is a test package
 
 	// Package content
@@ -23,12 +30,20 @@ type Package struct {
 	Imports       map[string]*Import   // Packages imported by this package
 	Documentation string               // Package documentation
 
+	// Type information (only populated when loaded with IncludeAST)
+	TypesPackage *types.Package `json:"-"` // Type-checked package
+	TypesInfo    *types.Info    `json:"-"` // Type information keyed by the files' AST nodes
+
 	// Position information
 	Pos token.Pos // Start position in source
 	End token.Pos // End position in source
 
 	// Tracking
 	IsModified bool // Whether this package has been modified since loading
+
+	// Guards the maps above, which edits of different files of the package
+	// update concurrently while holding only their file's lock
+	mu sync.RWMutex
 }
 
 // Import represents a package import
@@ -37,7 +52,7 @@ type Import struct {
 	Name    string // Local name (if renamed, otherwise "")
 	IsBlank bool   // Whether it's a blank import (_)
 	Doc     string // Documentation comment
-	File    *File  // File that contains this import
+	File    *File  `json:"-"` // File that contains this import
 
 	// Position information
 	Pos token.Pos // Start position in source
@@ -64,6 +79,8 @@ func NewPackage(name, importPath, dir string) *Package {
 
 // AddFile adds a file to the package
 func (p *Package) AddFile(file *File) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.Files[file.Name] = file
 	file.Package = p
 	p.IsModified = true
@@ -71,6 +88,8 @@ func (p *Package) AddFile(file *File) {
 
 // AddType adds a type to the package
 func (p *Package) AddType(typ *Type) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.Types[typ.Name] = typ
 	typ.Package = p
 	p.IsModified = true
@@ -78,6 +97,8 @@ func (p *Package) AddType(typ *Type) {
 
 // AddFunction adds a function to the package
 func (p *Package) AddFunction(fn *Function) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.Functions[fn.Name] = fn
 	fn.Package = p
 	p.IsModified = true
@@ -85,6 +106,8 @@ func (p *Package) AddFunction(fn *Function) {
 
 // AddVariable adds a variable to the package
 func (p *Package) AddVariable(v *Variable) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.Variables[v.Name] = v
 	v.Package = p
 	p.IsModified = true
@@ -92,6 +115,8 @@ func (p *Package) AddVariable(v *Variable) {
 
 // AddConstant adds a constant to the package
 func (p *Package) AddConstant(c *Constant) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.Constants[c.Name] = c
 	c.Package = p
 	p.IsModified = true
@@ -99,30 +124,80 @@ func (p *Package) AddConstant(c *Constant) {
 
 // AddImport adds an import to the package
 func (p *Package) AddImport(i *Import) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.Imports[i.Path] = i
 	p.IsModified = true
 }
 
 // GetFunction gets a function by name
 func (p *Package) GetFunction(name string) *Function {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.Functions[name]
 }
 
 // GetType gets a type by name
 func (p *Package) GetType(name string) *Type {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.Types[name]
 }
 
 // GetVariable gets a variable by name
 func (p *Package) GetVariable(name string) *Variable {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.Variables[name]
 }
 
 // GetConstant gets a constant by name
 func (p *Package) GetConstant(name string) *Constant {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.Constants[name]
 }
 
+// TestDataDir is the name of the directory holding test fixtures, which the
+// go tool ignores when building but tests read relative to the package
+const TestDataDir = "testdata"
+
+// TestDataFiles returns the files below the package's testdata directory as
+// sorted paths relative to the package directory (e.g. "testdata/in.json").
+// It returns nil if the package has no directory on disk or no testdata.
+func (p *Package) TestDataFiles() ([]string, error) {
+	if p.Dir == "" {
+		return nil, nil
+	}
+
+	root := filepath.Join(p.Dir, TestDataDir)
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(p.Dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
 // SetPosition sets the position information for this package
 func (p *Package) SetPosition(pos, end token.Pos) {
 	p.Pos = pos
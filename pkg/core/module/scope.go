@@ -0,0 +1,55 @@
+// Package module defines scopes separating production code from test code.
+package module
+
+// Scope selects production code, test code or both
+type Scope int
+
+const (
+	// ScopeProduction includes only non-test files
+	ScopeProduction Scope = iota
+
+	// ScopeTests includes only _test.go files
+	ScopeTests
+
+	// ScopeAll includes production and test files
+	ScopeAll
+)
+
+// String returns the name of the scope
+func (s Scope) String() string {
+	switch s {
+	case ScopeProduction:
+		return "production"
+	case ScopeTests:
+		return "tests"
+	case ScopeAll:
+		return "all"
+	}
+	return "unknown"
+}
+
+// Includes reports whether a file belongs to the scope. Elements without a
+// file are treated as production code.
+func (s Scope) Includes(file *File) bool {
+	isTest := file != nil && file.IsTest
+	switch s {
+	case ScopeProduction:
+		return !isTest
+	case ScopeTests:
+		return isTest
+	default:
+		return true
+	}
+}
+
+// SymbolsIn returns the module's symbols declared in files of the given
+// scope, sorted by ID
+func (m *Module) SymbolsIn(scope Scope) []*Symbol {
+	var symbols []*Symbol
+	for _, sym := range m.Symbols() {
+		if scope.Includes(sym.File) {
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
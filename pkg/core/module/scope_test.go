@@ -0,0 +1,35 @@
+package module
+
+import "testing"
+
+func TestSymbolsIn(t *testing.T) {
+	mod := NewModule("example.com/scoped", "")
+	pkg := NewPackage("scoped", "example.com/scoped", "")
+	mod.AddPackage(pkg)
+
+	prod := NewFile("/scoped/scoped.go", "scoped.go", false)
+	pkg.AddFile(prod)
+	prod.AddFunction(NewFunction("Serve", true, false))
+
+	test := NewFile("/scoped/scoped_test.go", "scoped_test.go", true)
+	pkg.AddFile(test)
+	test.AddFunction(NewFunction("NewFixture", true, false))
+
+	ids := func(scope Scope) []string {
+		var ids []string
+		for _, sym := range mod.SymbolsIn(scope) {
+			ids = append(ids, sym.ID)
+		}
+		return ids
+	}
+
+	if got := ids(ScopeProduction); len(got) != 1 || got[0] != "example.com/scoped.Serve" {
+		t.Errorf("Unexpected production symbols: %v", got)
+	}
+	if got := ids(ScopeTests); len(got) != 1 || got[0] != "example.com/scoped.NewFixture[test]" {
+		t.Errorf("Unexpected test symbols: %v", got)
+	}
+	if got := ids(ScopeAll); len(got) != 2 {
+		t.Errorf("Expected both symbols, got %v", got)
+	}
+}
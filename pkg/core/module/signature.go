@@ -0,0 +1,195 @@
+// Package module defines structured signatures of function symbols.
+package module
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// FuncSignature is the structured signature of a function or method
+type FuncSignature struct {
+	Receiver *FuncParam  // Receiver of a method, nil for functions
+	Params   []FuncParam // Parameters in declaration order
+	Results  []FuncParam // Results in declaration order
+	Variadic bool        // Whether the last parameter is variadic
+}
+
+// FuncParam is a receiver, parameter or result of a function. Type renders
+// the type as declared, "...T" for a variadic parameter; Types is the type
+// as reported by go/types, []T for a variadic parameter, and is only set
+// when the package was loaded with type information.
+type FuncParam struct {
+	Name  string     // Name, empty if unnamed
+	Type  string     // Rendered type
+	Types types.Type // Type checked type, nil if not available
+}
+
+// Signature returns the structured signature of a function or method
+// symbol. Types are taken from the package's type information if it was
+// loaded, and otherwise from the declaration's AST or source code.
+func (s *Symbol) Signature() (*FuncSignature, error) {
+	if s.Kind != SymbolFunction && s.Kind != SymbolMethod {
+		return nil, fmt.Errorf("symbol %s is a %s, not a function", s.ID, s.Kind)
+	}
+	fn, ok := s.Element.(*Function)
+	if !ok {
+		return nil, fmt.Errorf("symbol %s has no function declaration", s.ID)
+	}
+
+	if obj := typesFunc(fn); obj != nil {
+		return typesSignature(obj), nil
+	}
+	if decl := funcDecl(fn); decl != nil {
+		return astSignature(decl), nil
+	}
+	if len(fn.Parameters) > 0 || len(fn.Results) > 0 {
+		return modelSignature(fn), nil
+	}
+	return nil, fmt.Errorf("no signature information for %s", s.ID)
+}
+
+// typesFunc returns the type-checked object of a function, or nil if its
+// package was not loaded with type information
+func typesFunc(fn *Function) *types.Func {
+	pkg := fn.Package
+	if pkg == nil && fn.File != nil {
+		pkg = fn.File.Package
+	}
+	if pkg == nil || pkg.TypesPackage == nil || (fn.File != nil && fn.File.IsTest) {
+		return nil
+	}
+	scope := pkg.TypesPackage.Scope()
+	if fn.Receiver == nil {
+		obj, _ := scope.Lookup(fn.Name).(*types.Func)
+		return obj
+	}
+
+	recv := strings.TrimPrefix(fn.Receiver.Type, "*")
+	if i := strings.IndexByte(recv, '['); i >= 0 {
+		recv = recv[:i]
+	}
+	typeName, ok := scope.Lookup(recv).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, ok := typeName.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if method := named.Method(i); method.Name() == fn.Name {
+			return method
+		}
+	}
+	return nil
+}
+
+// typesSignature builds a signature from a type-checked function
+func typesSignature(obj *types.Func) *FuncSignature {
+	sig := obj.Type().(*types.Signature)
+	qualifier := types.RelativeTo(obj.Pkg())
+	param := func(v *types.Var) FuncParam {
+		return FuncParam{Name: v.Name(), Type: types.TypeString(v.Type(), qualifier), Types: v.Type()}
+	}
+
+	out := &FuncSignature{Variadic: sig.Variadic()}
+	if recv := sig.Recv(); recv != nil {
+		p := param(recv)
+		out.Receiver = &p
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		p := param(sig.Params().At(i))
+		if out.Variadic && i == sig.Params().Len()-1 {
+			if slice, ok := p.Types.(*types.Slice); ok {
+				p.Type = "..." + types.TypeString(slice.Elem(), qualifier)
+			}
+		}
+		out.Params = append(out.Params, p)
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		out.Results = append(out.Results, param(sig.Results().At(i)))
+	}
+	return out
+}
+
+// funcDecl returns the declaration of a function from its AST or source
+func funcDecl(fn *Function) *ast.FuncDecl {
+	if fn.AST != nil {
+		return fn.AST
+	}
+	if fn.File == nil {
+		return nil
+	}
+	src := sourceRange(fn.File, fn.Pos, fn.End)
+	if src == "" {
+		return nil
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+src, parser.SkipObjectResolution)
+	if err != nil || len(file.Decls) != 1 {
+		return nil
+	}
+	decl, _ := file.Decls[0].(*ast.FuncDecl)
+	return decl
+}
+
+// astSignature builds a signature from a function declaration
+func astSignature(decl *ast.FuncDecl) *FuncSignature {
+	out := &FuncSignature{}
+	if decl.Recv != nil {
+		if recv := fieldParams(decl.Recv); len(recv) > 0 {
+			out.Receiver = &recv[0]
+		}
+	}
+	out.Params = fieldParams(decl.Type.Params)
+	out.Results = fieldParams(decl.Type.Results)
+	if n := len(out.Params); n > 0 {
+		out.Variadic = strings.HasPrefix(out.Params[n-1].Type, "...")
+	}
+	return out
+}
+
+// fieldParams expands a field list into one parameter per name
+func fieldParams(list *ast.FieldList) []FuncParam {
+	if list == nil {
+		return nil
+	}
+	var params []FuncParam
+	for _, field := range list.List {
+		typ := types.ExprString(field.Type)
+		if len(field.Names) == 0 {
+			params = append(params, FuncParam{Type: typ})
+			continue
+		}
+		for _, name := range field.Names {
+			params = append(params, FuncParam{Name: name.Name, Type: typ})
+		}
+	}
+	return params
+}
+
+// modelSignature builds a signature from the parameters of the model
+func modelSignature(fn *Function) *FuncSignature {
+	out := &FuncSignature{}
+	if fn.Receiver != nil {
+		out.Receiver = &FuncParam{Name: fn.Receiver.Name, Type: fn.Receiver.Type}
+		if fn.Receiver.IsPointer && !strings.HasPrefix(fn.Receiver.Type, "*") {
+			out.Receiver.Type = "*" + fn.Receiver.Type
+		}
+	}
+	for _, p := range fn.Parameters {
+		typ := p.Type
+		if p.IsVariadic && !strings.HasPrefix(typ, "...") {
+			typ = "..." + typ
+		}
+		out.Params = append(out.Params, FuncParam{Name: p.Name, Type: typ})
+		out.Variadic = p.IsVariadic
+	}
+	for _, r := range fn.Results {
+		out.Results = append(out.Results, FuncParam{Name: r.Name, Type: r.Type})
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package module
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestSymbolSignature(t *testing.T) {
+	source := `package calc
+
+type Acc struct{ total int }
+
+func (a *Acc) Add(values ...int) (sum int, err error) { return 0, nil }
+
+func Scale(x, y float64, _ string) float64 { return x * y }
+
+var Zero = 0
+`
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, "calc.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(withTypes bool) *Module {
+		mod := NewModule("example.com/calc", "")
+		pkg := NewPackage("calc", "example.com/calc", "")
+		mod.AddPackage(pkg)
+		file := NewFile("/calc/calc.go", "calc.go", false)
+		file.SourceCode = source
+		file.FileSet = fset
+		pkg.AddFile(file)
+		if withTypes {
+			if pkg.TypesPackage, err = (&types.Config{}).Check("example.com/calc", fset, []*ast.File{parsed}, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, decl := range parsed.Decls {
+			if d, ok := decl.(*ast.FuncDecl); ok {
+				fn := NewFunction(d.Name.Name, true, false)
+				fn.SetPosition(d.Pos(), d.End())
+				if d.Recv != nil {
+					fn.SetReceiver("a", "Acc", true)
+				}
+				file.AddFunction(fn)
+				pkg.AddFunction(fn)
+			}
+		}
+		file.AddVariable(&Variable{Name: "Zero", Value: "0", IsExported: true})
+		return mod
+	}
+
+	render := func(params []FuncParam) string {
+		var parts []string
+		for _, p := range params {
+			parts = append(parts, strings.TrimSpace(p.Name+" "+p.Type))
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	for _, withTypes := range []bool{false, true} {
+		symbols := make(map[string]*Symbol)
+		for _, sym := range build(withTypes).Symbols() {
+			symbols[sym.Name] = sym
+		}
+
+		add, err := symbols["Add"].Signature()
+		if err != nil {
+			t.Fatalf("Signature of Add failed: %v", err)
+		}
+		if add.Receiver == nil || add.Receiver.Name+" "+add.Receiver.Type != "a *Acc" {
+			t.Errorf("Unexpected receiver: %+v", add.Receiver)
+		}
+		if got := render(add.Params); got != "values ...int" || !add.Variadic {
+			t.Errorf("Unexpected parameters of Add: %s (variadic %v)", got, add.Variadic)
+		}
+		if got := render(add.Results); got != "sum int, err error" {
+			t.Errorf("Unexpected results of Add: %s", got)
+		}
+
+		scale, err := symbols["Scale"].Signature()
+		if err != nil {
+			t.Fatalf("Signature of Scale failed: %v", err)
+		}
+		if got := render(scale.Params); got != "x float64, y float64, _ string" || scale.Variadic {
+			t.Errorf("Unexpected parameters of Scale: %s", got)
+		}
+		if got := render(scale.Results); got != "float64" {
+			t.Errorf("Unexpected results of Scale: %s", got)
+		}
+		if withTypes != (scale.Params[0].Types != nil) {
+			t.Errorf("Expected types only with type information, got %v", scale.Params[0].Types)
+		}
+
+		if _, err := symbols["Zero"].Signature(); err == nil {
+			t.Error("Expected an error for a variable")
+		}
+	}
+}
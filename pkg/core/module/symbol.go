@@ -0,0 +1,225 @@
+// Package module defines stable symbol identities for comparing module versions.
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SymbolKind identifies the kind of declaration a symbol refers to
+type SymbolKind string
+
+const (
+	// SymbolFunction is a package-level function
+	SymbolFunction SymbolKind = "func"
+
+	// SymbolMethod is a method with a receiver
+	SymbolMethod SymbolKind = "method"
+
+	// SymbolType is a type declaration
+	SymbolType SymbolKind = "type"
+
+	// SymbolVariable is a package-level variable
+	SymbolVariable SymbolKind = "var"
+
+	// SymbolConstant is a package-level constant
+	SymbolConstant SymbolKind = "const"
+)
+
+// Symbol is a declaration with an identity that is stable across loads of
+// the same module and a hash of its content
+type Symbol struct {
+	ID      string      // Stable identifier, e.g. "example.com/pkg.User.Login"
+	Kind    SymbolKind  // Kind of declaration
+	Name    string      // Declared name
+	Package string      // Import path of the declaring package
+	File    *File       // File containing the declaration
+	Hash    string      // Hex-encoded hash of the declaration's source and docs
+	Element interface{} // The underlying *Function, *Type, *Variable or *Constant
+
+	// Build tags the module was loaded with, so symbols of loads with
+	// different tags can be told apart
+	BuildTags []string
+
+	decoded *SymbolJSON // Representation the symbol was decoded from, if any
+}
+
+// Symbols returns all top-level declarations of the module as symbols,
+// sorted by ID
+func (m *Module) Symbols() []*Symbol {
+	var symbols []*Symbol
+	for _, pkg := range m.Packages {
+		for _, file := range pkg.Files {
+			symbols = append(symbols, fileSymbols(pkg, file)...)
+		}
+	}
+
+	disambiguate(symbols)
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].ID < symbols[j].ID })
+	return symbols
+}
+
+// disambiguate qualifies symbols that share an ID, such as a function
+// declared once per build-constrained file, by their file name, e.g.
+// "example.com/pkg.Open[open_linux.go]"
+func disambiguate(symbols []*Symbol) {
+	byID := make(map[string][]*Symbol)
+	for _, sym := range symbols {
+		byID[sym.ID] = append(byID[sym.ID], sym)
+	}
+	for id, group := range byID {
+		if len(group) < 2 {
+			continue
+		}
+		seen := make(map[string]int)
+		for _, sym := range group {
+			qualified := id + "[" + sym.File.Name + "]"
+			seen[qualified]++
+			if n := seen[qualified]; n > 1 {
+				qualified += "#" + strconv.Itoa(n)
+			}
+			sym.ID = qualified
+		}
+	}
+}
+
+// DiffSymbols compares the symbols of two loads of the same module by ID and
+// content hash. Symbols only present in newMod are added, symbols only
+// present in oldMod are removed, and symbols whose hash differs are changed;
+// changed symbols are returned from newMod. Moving a declaration without editing it
+// does not change its hash. All results are sorted by ID.
+func DiffSymbols(oldMod, newMod *Module) (added, removed, changed []*Symbol) {
+	oldSymbols := symbolsByID(oldMod)
+	newSymbols := symbolsByID(newMod)
+
+	for id, sym := range newSymbols {
+		prev, ok := oldSymbols[id]
+		switch {
+		case !ok:
+			added = append(added, sym)
+		case prev.Hash != sym.Hash:
+			changed = append(changed, sym)
+		}
+	}
+	for id, sym := range oldSymbols {
+		if _, ok := newSymbols[id]; !ok {
+			removed = append(removed, sym)
+		}
+	}
+
+	for _, list := range [][]*Symbol{added, removed, changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	}
+	return added, removed, changed
+}
+
+// symbolsByID indexes a module's symbols by ID
+func symbolsByID(m *Module) map[string]*Symbol {
+	index := make(map[string]*Symbol)
+	if m == nil {
+		return index
+	}
+	for _, sym := range m.Symbols() {
+		index[sym.ID] = sym
+	}
+	return index
+}
+
+// fileSymbols returns the symbols declared in a file
+func fileSymbols(pkg *Package, file *File) []*Symbol {
+	var buildTags []string
+	if pkg.Module != nil {
+		buildTags = pkg.Module.BuildTags
+	}
+
+	var symbols []*Symbol
+	repeated := make(map[string]int)
+	add := func(kind SymbolKind, qualifier, name string, pos, end token.Pos, doc, details string, element interface{}) {
+		id := pkg.ImportPath + "." + name
+		if qualifier != "" {
+			id = pkg.ImportPath + "." + qualifier + "." + name
+		}
+		// init functions and blank declarations may occur several times; they
+		// are told apart by file and order, e.g. "example.com/pkg.init[a.go#2]"
+		if qualifier == "" && (name == "_" || name == "init" && kind == SymbolFunction) {
+			repeated[name]++
+			id += "[" + file.Name + "#" + strconv.Itoa(repeated[name]) + "]"
+		}
+		// Identical declarations in a test file and a regular file are distinct
+		if file.IsTest {
+			id += "[test]"
+		}
+
+		symbols = append(symbols, &Symbol{
+			ID:      id,
+			Kind:    kind,
+			Name:    name,
+			Package: pkg.ImportPath,
+			File:    file,
+			Hash:    hashContent(string(kind), sourceRange(file, pos, end), details, doc),
+			Element: element,
+
+			BuildTags: buildTags,
+		})
+	}
+
+	for _, fn := range file.Functions {
+		if fn.Receiver != nil {
+			recv := strings.TrimPrefix(fn.Receiver.Type, "*")
+			add(SymbolMethod, recv, fn.Name, fn.Pos, fn.End, fn.Doc, fn.Signature+fn.Body, fn)
+			continue
+		}
+		add(SymbolFunction, "", fn.Name, fn.Pos, fn.End, fn.Doc, fn.Signature+fn.Body, fn)
+	}
+	for _, t := range file.Types {
+		add(SymbolType, "", t.Name, t.Pos, t.End, t.Doc, typeDetails(t), t)
+	}
+	for _, v := range file.Variables {
+		add(SymbolVariable, "", v.Name, v.Pos, v.End, v.Doc, v.Type+"="+v.Value, v)
+	}
+	for _, c := range file.Constants {
+		add(SymbolConstant, "", c.Name, c.Pos, c.End, c.Doc, c.Type+"="+c.Value, c)
+	}
+
+	return symbols
+}
+
+// sourceRange returns the source text between two positions in the file
+func sourceRange(file *File, pos, end token.Pos) string {
+	if file.FileSet == nil || pos == token.NoPos || end == token.NoPos || file.SourceCode == "" {
+		return ""
+	}
+	start := file.FileSet.Position(pos).Offset
+	stop := file.FileSet.Position(end).Offset
+	if start < 0 || stop > len(file.SourceCode) || start >= stop {
+		return ""
+	}
+	return file.SourceCode[start:stop]
+}
+
+// typeDetails describes the modeled structure of a type
+func typeDetails(t *Type) string {
+	var b strings.Builder
+	b.WriteString(t.Kind + " " + t.Underlying)
+	for _, f := range t.Fields {
+		b.WriteString(";" + f.Name + " " + f.Type + " " + f.Tag)
+	}
+	for _, m := range t.Interfaces {
+		b.WriteString(";" + m.Name + m.Signature)
+	}
+	return b.String()
+}
+
+// hashContent returns the hex-encoded SHA-256 of the given parts
+func hashContent(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,183 @@
+// Package module defines the JSON representation of symbols for tooling.
+package module
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// SymbolSchemaVersion is the version of the JSON representation of symbols.
+// Within a version fields are only ever added; removing or changing the
+// meaning of a field increments it.
+const SymbolSchemaVersion = 1
+
+// SymbolJSON is the JSON representation of a Symbol, a stable wire format
+// for tools that are not written in Go:
+//
+//	{
+//	  "schema": 1,                          // SymbolSchemaVersion
+//	  "id": "example.com/pkg.User.Login",   // Symbol.ID
+//	  "name": "Login",
+//	  "kind": "method",                     // func, method, type, var or const
+//	  "exported": true,
+//	  "package": "example.com/pkg",         // Import path
+//	  "file": "/src/pkg/user.go",           // Omitted if unknown
+//	  "position": {"line": 12, "column": 1, "endLine": 14, "endColumn": 2},
+//	  "signature": "func (u *User) Login(password string) error",
+//	  "doc": "Login checks the password.\n",
+//	  "hash": "5f1c...",                    // Symbol.Hash
+//	  "buildTags": ["enterprise"]           // Symbol.BuildTags
+//	}
+//
+// Lines and columns are 1-based, columns count bytes. Signatures are the
+// declaration without body or doc comment: "func Name(...) ..." for
+// functions and methods, "type Name struct", "type Name interface",
+// "type Name = T" or "type Name T" for types, and "var Name T" or
+// "const Name T" for variables and constants, where the type may be absent.
+// Empty fields are omitted.
+type SymbolJSON struct {
+	Schema    int             `json:"schema"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Kind      SymbolKind      `json:"kind"`
+	Exported  bool            `json:"exported"`
+	Package   string          `json:"package"`
+	File      string          `json:"file,omitempty"`
+	Position  *SymbolPosition `json:"position,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	Doc       string          `json:"doc,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+	BuildTags []string        `json:"buildTags,omitempty"`
+}
+
+// SymbolPosition is the source range of a symbol in its JSON representation
+type SymbolPosition struct {
+	Line      int `json:"line"`
+	Column    int `json:"column"`
+	EndLine   int `json:"endLine"`
+	EndColumn int `json:"endColumn"`
+}
+
+// ToJSON returns the JSON representation of the symbol
+func (s *Symbol) ToJSON() *SymbolJSON {
+	if s.decoded != nil {
+		decoded := *s.decoded
+		return &decoded
+	}
+
+	out := &SymbolJSON{
+		Schema:   SymbolSchemaVersion,
+		ID:       s.ID,
+		Name:     s.Name,
+		Kind:     s.Kind,
+		Exported: token.IsExported(s.Name),
+		Package:  s.Package,
+		Hash:     s.Hash,
+	}
+	if len(s.BuildTags) > 0 {
+		out.BuildTags = append([]string(nil), s.BuildTags...)
+	}
+	if s.File != nil {
+		out.File = s.File.Path
+	}
+
+	var pos, end token.Pos
+	switch element := s.Element.(type) {
+	case *Function:
+		pos, end, out.Doc = element.Pos, element.End, element.Doc
+		out.Signature = functionSignature(element)
+	case *Type:
+		pos, end, out.Doc = element.Pos, element.End, element.Doc
+		switch element.Kind {
+		case "struct", "interface":
+			out.Signature = "type " + element.Name + " " + element.Kind
+		case "alias":
+			out.Signature = "type " + element.Name + " = " + element.Underlying
+		default:
+			out.Signature = strings.TrimSpace("type " + element.Name + " " + element.Underlying)
+		}
+	case *Variable:
+		pos, end, out.Doc = element.Pos, element.End, element.Doc
+		out.Signature = strings.TrimSpace("var " + element.Name + " " + element.Type)
+	case *Constant:
+		pos, end, out.Doc = element.Pos, element.End, element.Doc
+		out.Signature = strings.TrimSpace("const " + element.Name + " " + element.Type)
+	}
+	if s.File != nil {
+		if p := s.File.GetPositionInfo(pos, end); p != nil {
+			out.Position = &SymbolPosition{Line: p.LineStart, Column: p.ColStart, EndLine: p.LineEnd, EndColumn: p.ColEnd}
+		}
+	}
+	return out
+}
+
+// MarshalJSON encodes the symbol as a SymbolJSON
+func (s *Symbol) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToJSON())
+}
+
+// UnmarshalJSON decodes a SymbolJSON into a read-only symbol. The symbol
+// has no positions in a file set and its File and Element only carry what
+// the JSON describes: the file's path, and the element's name, doc comment
+// and exportedness, plus the signature for functions. Encoding the symbol
+// again yields the decoded representation.
+func (s *Symbol) UnmarshalJSON(data []byte) error {
+	var in SymbolJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Schema != SymbolSchemaVersion {
+		return fmt.Errorf("unsupported symbol schema version %d", in.Schema)
+	}
+
+	*s = Symbol{
+		ID:      in.ID,
+		Kind:    in.Kind,
+		Name:    in.Name,
+		Package: in.Package,
+		Hash:    in.Hash,
+		decoded: &in,
+
+		BuildTags: in.BuildTags,
+	}
+	if in.File != "" {
+		s.File = NewFile(in.File, filepath.Base(in.File), strings.HasSuffix(in.File, "_test.go"))
+	}
+
+	switch in.Kind {
+	case SymbolFunction, SymbolMethod:
+		fn := &Function{Name: in.Name, File: s.File, Signature: in.Signature, IsExported: in.Exported, Doc: in.Doc}
+		fn.IsMethod = in.Kind == SymbolMethod
+		s.Element = fn
+	case SymbolType:
+		s.Element = &Type{Name: in.Name, File: s.File, IsExported: in.Exported, Doc: in.Doc}
+	case SymbolVariable:
+		s.Element = &Variable{Name: in.Name, File: s.File, IsExported: in.Exported, Doc: in.Doc}
+	case SymbolConstant:
+		s.Element = &Constant{Name: in.Name, File: s.File, IsExported: in.Exported, Doc: in.Doc}
+	}
+	return nil
+}
+
+// functionSignature renders the declaration of a function without its body,
+// from its AST or else its source, falling back to Function.Signature
+func functionSignature(fn *Function) string {
+	decl := funcDecl(fn)
+	if decl == nil {
+		return fn.Signature
+	}
+
+	// A fresh file set prints the declaration on one line
+	var buf bytes.Buffer
+	header := &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type}
+	if err := printer.Fprint(&buf, token.NewFileSet(), header); err != nil {
+		return fn.Signature
+	}
+	return buf.String()
+}
@@ -0,0 +1,84 @@
+package module
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestSymbolJSON(t *testing.T) {
+	const source = `package users
+
+// User is an account
+type User struct{ Name string }
+
+// Login checks the password.
+func (u *User) Login(password string) (bool, error) {
+	return password != "", nil
+}
+`
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "/src/users/user.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	mod := NewModule("example.com/users", "")
+	pkg := NewPackage("users", "example.com/users", "")
+	mod.AddPackage(pkg)
+	file := NewFile("/src/users/user.go", "user.go", false)
+	file.FileSet = fset
+	file.SourceCode = source
+	pkg.AddFile(file)
+
+	typeSpec := astFile.Decls[0].(*ast.GenDecl).Specs[0]
+	typ := NewType("User", "struct", true)
+	typ.Doc = "User is an account\n"
+	typ.SetPosition(typeSpec.Pos(), typeSpec.End())
+	file.AddType(typ)
+
+	decl := astFile.Decls[1].(*ast.FuncDecl)
+	method := NewFunction("Login", true, false)
+	method.SetReceiver("u", "User", true)
+	method.Doc = "Login checks the password.\n"
+	method.SetPosition(decl.Pos(), decl.End())
+	file.AddFunction(method)
+
+	symbols := mod.Symbols()
+	data, err := json.Marshal(symbols[1])
+	if err != nil {
+		t.Fatalf("Failed to marshal symbol: %v", err)
+	}
+	expected := `{"schema":1,"id":"example.com/users.User.Login","name":"Login","kind":"method",` +
+		`"exported":true,"package":"example.com/users","file":"/src/users/user.go",` +
+		`"position":{"line":7,"column":1,"endLine":9,"endColumn":2},` +
+		`"signature":"func (u *User) Login(password string) (bool, error)",` +
+		`"doc":"Login checks the password.\n","hash":"` + symbols[1].Hash + `"}`
+	if string(data) != expected {
+		t.Errorf("Expected JSON:\n%s\ngot:\n%s", expected, data)
+	}
+
+	if got := symbols[0].ToJSON().Signature; got != "type User struct" {
+		t.Errorf("Unexpected type signature: %s", got)
+	}
+
+	// Decoded symbols are read-only reconstructions that encode identically
+	var decoded Symbol
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal symbol: %v", err)
+	}
+	fn, ok := decoded.Element.(*Function)
+	if decoded.ID != symbols[1].ID || decoded.File.Path != file.Path || !ok || !fn.IsMethod || fn.Doc != method.Doc {
+		t.Errorf("Unexpected decoded symbol: %+v", decoded)
+	}
+	again, err := json.Marshal(&decoded)
+	if err != nil || string(again) != string(data) {
+		t.Errorf("Expected re-encoding to match, got %s (%v)", again, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"schema":2,"id":"x"}`), &decoded); err == nil {
+		t.Error("Expected an error for an unknown schema version")
+	}
+}
@@ -0,0 +1,54 @@
+package module
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSymbolsRepeatedNames(t *testing.T) {
+	mod := NewModule("example.com/boot", "")
+	pkg := NewPackage("boot", "example.com/boot", "")
+	mod.AddPackage(pkg)
+	a := NewFile("/boot/a.go", "a.go", false)
+	pkg.AddFile(a)
+	a.AddFunction(&Function{Name: "init", Signature: "()", Body: "{ first() }"})
+	a.AddFunction(&Function{Name: "init", Signature: "()", Body: "{ second() }"})
+	a.AddVariable(&Variable{Name: "_", Type: "io.Reader", Value: "(*File)(nil)"})
+	a.AddConstant(&Constant{Name: "_", Value: "iota"})
+	b := NewFile("/boot/b.go", "b.go", false)
+	pkg.AddFile(b)
+	b.AddFunction(&Function{Name: "init", Signature: "()", Body: "{ third() }"})
+	b.AddFunction(&Function{Name: "Start", Signature: "()", Body: "{}"})
+
+	var ids []string
+	for _, sym := range mod.Symbols() {
+		ids = append(ids, strings.TrimPrefix(sym.ID, "example.com/boot."))
+	}
+	expected := "Start,_[a.go#1],_[a.go#2],init[a.go#1],init[a.go#2],init[b.go#1]"
+	if got := strings.Join(ids, ","); got != expected {
+		t.Errorf("Expected symbols %s, got %s", expected, got)
+	}
+}
+
+func TestSymbolsDuplicateIDs(t *testing.T) {
+	mod := NewModule("example.com/osfile", "")
+	pkg := NewPackage("osfile", "example.com/osfile", "")
+	mod.AddPackage(pkg)
+	for _, name := range []string{"open_windows.go", "open_linux.go"} {
+		file := NewFile("/osfile/"+name, name, false)
+		pkg.AddFile(file)
+		file.AddFunction(&Function{Name: "Open", Signature: "()", Body: "{}"})
+	}
+	common := NewFile("/osfile/common.go", "common.go", false)
+	pkg.AddFile(common)
+	common.AddFunction(&Function{Name: "Close", Signature: "()", Body: "{}"})
+
+	var ids []string
+	for _, sym := range mod.Symbols() {
+		ids = append(ids, strings.TrimPrefix(sym.ID, "example.com/osfile."))
+	}
+	expected := "Close,Open[open_linux.go],Open[open_windows.go]"
+	if got := strings.Join(ids, ","); got != expected {
+		t.Errorf("Expected symbols %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,134 @@
+// Package module defines the classification of test-support functions.
+package module
+
+import (
+	"go/ast"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestKind classifies functions declared in test files
+type TestKind string
+
+const (
+	// TestKindNone marks functions that play no special role in tests
+	TestKindNone TestKind = ""
+
+	// TestKindTest marks TestXxx(*testing.T) functions
+	TestKindTest TestKind = "test"
+
+	// TestKindBenchmark marks BenchmarkXxx(*testing.B) functions
+	TestKindBenchmark TestKind = "benchmark"
+
+	// TestKindFuzz marks FuzzXxx(*testing.F) functions
+	TestKindFuzz TestKind = "fuzz"
+
+	// TestKindExample marks ExampleXxx() functions
+	TestKindExample TestKind = "example"
+
+	// TestKindMain marks the TestMain(*testing.M) function
+	TestKindMain TestKind = "main"
+
+	// TestKindHelper marks functions that call Helper() on a testing.TB
+	TestKindHelper TestKind = "helper"
+)
+
+// IsTestHelper returns whether the function is a test helper
+func (f *Function) IsTestHelper() bool {
+	return f.TestKind == TestKindHelper
+}
+
+// ClassifyTestFunction determines the role of a function declared in a test
+// file, following the naming and signature rules of `go test`. Functions that
+// call t.Helper() (or b/f/tb.Helper()) are classified as helpers.
+func ClassifyTestFunction(decl *ast.FuncDecl) TestKind {
+	if decl == nil || decl.Name == nil {
+		return TestKindNone
+	}
+
+	name := decl.Name.Name
+	params := decl.Type.Params
+	hasResults := decl.Type.Results != nil && len(decl.Type.Results.List) > 0
+
+	if decl.Recv == nil && !hasResults {
+		switch {
+		case name == "TestMain" && hasSingleTestingParam(params, "M"):
+			return TestKindMain
+		case hasTestPrefix(name, "Test") && hasSingleTestingParam(params, "T"):
+			return TestKindTest
+		case hasTestPrefix(name, "Benchmark") && hasSingleTestingParam(params, "B"):
+			return TestKindBenchmark
+		case hasTestPrefix(name, "Fuzz") && hasSingleTestingParam(params, "F"):
+			return TestKindFuzz
+		case hasTestPrefix(name, "Example") && (params == nil || len(params.List) == 0):
+			return TestKindExample
+		}
+	}
+
+	if callsHelper(decl.Body) {
+		return TestKindHelper
+	}
+
+	return TestKindNone
+}
+
+// hasTestPrefix reports whether name is prefix followed by nothing or a
+// non-lowercase rune, as required by go test
+func hasTestPrefix(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// hasSingleTestingParam reports whether params is exactly one *testing.<typeName>
+func hasSingleTestingParam(params *ast.FieldList, typeName string) bool {
+	if params == nil || len(params.List) != 1 || len(params.List[0].Names) > 1 {
+		return false
+	}
+	star, ok := params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "testing" && sel.Sel.Name == typeName
+}
+
+// callsHelper reports whether body contains a call of the form x.Helper()
+func callsHelper(body *ast.BlockStmt) bool {
+	if body == nil {
+		return false
+	}
+
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		// Calls inside function literals belong to the literal
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 0 {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Helper" {
+			if _, ok := sel.X.(*ast.Ident); ok {
+				found = true
+			}
+		}
+		return true
+	})
+
+	return found
+}
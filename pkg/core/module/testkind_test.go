@@ -0,0 +1,81 @@
+package module
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestClassifyTestFunction(t *testing.T) {
+	src := `package sample_test
+
+import "testing"
+
+func TestMain(m *testing.M) {}
+func TestAdd(t *testing.T) {}
+func Test(t *testing.T) {}
+func Testify(t *testing.T) {}
+func BenchmarkAdd(b *testing.B) {}
+func FuzzParse(f *testing.F) {}
+func ExampleAdd() {}
+func ExampleAdd_bad(x int) {}
+func TestWithResult(t *testing.T) error { return nil }
+
+func assertEqual(t *testing.T, a, b int) {
+	t.Helper()
+	if a != b {
+		t.Fatal("not equal")
+	}
+}
+
+func setup(tb testing.TB) func() {
+	return func() { tb.Helper() }
+}
+
+type suite struct{}
+
+func (s *suite) check(t *testing.T) { t.Helper() }
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "sample_test.go", src, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+
+	expected := map[string]TestKind{
+		"TestMain":       TestKindMain,
+		"TestAdd":        TestKindTest,
+		"Test":           TestKindTest,
+		"Testify":        TestKindNone,
+		"BenchmarkAdd":   TestKindBenchmark,
+		"FuzzParse":      TestKindFuzz,
+		"ExampleAdd":     TestKindExample,
+		"ExampleAdd_bad": TestKindNone,
+		"TestWithResult": TestKindNone,
+		"assertEqual":    TestKindHelper,
+		"setup":          TestKindNone,
+		"check":          TestKindHelper,
+	}
+
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		want, ok := expected[funcDecl.Name.Name]
+		if !ok {
+			t.Fatalf("Unexpected function %s", funcDecl.Name.Name)
+		}
+		if got := ClassifyTestFunction(funcDecl); got != want {
+			t.Errorf("%s: expected kind %q, got %q", funcDecl.Name.Name, want, got)
+		}
+	}
+
+	fn := NewFunction("assertEqual", false, false)
+	fn.TestKind = TestKindHelper
+	if !fn.IsTestHelper() {
+		t.Error("Expected IsTestHelper to report true for helpers")
+	}
+}
@@ -9,8 +9,8 @@ import (
 type Type struct {
 	// Type identity
 	Name    string   // Type name
-	File    *File    // File where this type is defined
-	Package *Package // Package this type belongs to
+	File    *File    `json:"-"` // File where this type is defined
+	Package *Package `json:"-"` // Package this type belongs to
 
 	// Type information
 	Kind       string // "struct", "interface", "alias", etc.
@@ -21,6 +21,7 @@ type Type struct {
 	Fields     []*Field  // Fields for structs
 	Methods    []*Method // Methods for this type
 	Interfaces []*Method // Methods for interfaces
+	Embeds     []*Type   `json:"-"` // Embedded interfaces resolved within the module
 
 	// Position information
 	Pos token.Pos // Start position in source
@@ -37,7 +38,7 @@ type Field struct {
 	Tag        string // Struct tag string, if any
 	IsEmbedded bool   // Whether this is an embedded field
 	Doc        string // Documentation comment
-	Parent     *Type  // Parent type
+	Parent     *Type  `json:"-"` // Parent type
 
 	// Position information
 	Pos token.Pos // Start position in source
@@ -46,11 +47,12 @@ type Field struct {
 
 // Method represents a method in an interface or a struct type
 type Method struct {
-	Name       string // Method name
+	Name       string // Method name (the embedded type, e.g. "io.Reader", if embedded)
 	Signature  string // Method signature
 	IsEmbedded bool   // Whether this is an embedded interface
+	Embedded   *Type  `json:"-"` // Resolved embedded interface (nil if outside the module)
 	Doc        string // Documentation comment
-	Parent     *Type  // Parent type
+	Parent     *Type  `json:"-"` // Parent type
 
 	// Position information
 	Pos token.Pos // Start position in source
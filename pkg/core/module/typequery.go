@@ -0,0 +1,126 @@
+// Package module provides queries over the type information of symbols.
+package module
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// FindByUnderlyingType returns the symbols whose type has an underlying type
+// identical to that of t: named types declared with it, and functions,
+// methods, variables and constants of such a type. For example, passing
+// types.Typ[types.Int] finds the int-based named types as well as the
+// variables and typed constants of those types, such as enum values.
+// Untyped constants have untyped types and never match.
+//
+// Only packages loaded with IncludeAST carry the type information needed;
+// symbols of other packages are skipped. Results are sorted by ID.
+func (m *Module) FindByUnderlyingType(t types.Type) []*Symbol {
+	underlying := t.Underlying()
+
+	var matches []*Symbol
+	for _, sym := range m.Symbols() {
+		pkg := m.Packages[sym.Package]
+		if pkg == nil || pkg.TypesPackage == nil || sym.File != nil && sym.File.IsTest {
+			continue
+		}
+		obj := m.LookupObject(sym)
+		if obj == nil {
+			continue
+		}
+		if types.Identical(obj.Type().Underlying(), underlying) {
+			matches = append(matches, sym)
+		}
+	}
+	return matches
+}
+
+// LookupObject returns the type-checked object declaring a symbol, or nil
+// if its package was loaded without IncludeAST
+func (m *Module) LookupObject(sym *Symbol) types.Object {
+	p := m.Packages[sym.Package]
+	if p == nil || p.TypesPackage == nil {
+		return nil
+	}
+	pkg := p.TypesPackage
+	if sym.Kind != SymbolMethod {
+		return pkg.Scope().Lookup(sym.Name)
+	}
+
+	fn, ok := sym.Element.(*Function)
+	if !ok || fn.Receiver == nil {
+		return nil
+	}
+	recv := strings.TrimPrefix(fn.Receiver.Type, "*")
+	if i := strings.IndexByte(recv, '['); i >= 0 {
+		recv = recv[:i]
+	}
+	typeName, ok := pkg.Scope().Lookup(recv).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typeName.Type(), true, pkg, sym.Name)
+	if method, ok := obj.(*types.Func); ok {
+		return method
+	}
+	return nil
+}
+
+// Origin maps an object of an instantiated generic type or function to the
+// object it was declared as
+func Origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+// NamedTypes returns the package-level named types of the module that are
+// neither aliases nor generic, sorted by package path and name. Packages
+// loaded without IncludeAST are skipped.
+func (m *Module) NamedTypes() []*types.Named {
+	paths := make([]string, 0, len(m.Packages))
+	for path, pkg := range m.Packages {
+		if pkg.TypesPackage != nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var named []*types.Named
+	for _, path := range paths {
+		scope := m.Packages[path].TypesPackage.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			if n, ok := typeName.Type().(*types.Named); ok && n.TypeParams().Len() == 0 {
+				named = append(named, n)
+			}
+		}
+	}
+	return named
+}
+
+// Implementers returns the non-interface types of NamedTypes that implement
+// iface, in the same order
+func (m *Module) Implementers(iface *types.Interface) []*types.Named {
+	var implementers []*types.Named
+	for _, named := range m.NamedTypes() {
+		if !types.IsInterface(named) && Implements(named, iface) {
+			implementers = append(implementers, named)
+		}
+	}
+	return implementers
+}
+
+// Implements reports whether t implements iface through its value or
+// pointer method set
+func Implements(t types.Type, iface *types.Interface) bool {
+	return types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface)
+}
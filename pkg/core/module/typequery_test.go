@@ -0,0 +1,136 @@
+package module
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestFindByUnderlyingType(t *testing.T) {
+	const source = `package enums
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
+
+const Untyped = 1
+
+type Bytes []byte
+
+type Count = int
+
+var Total int
+
+var Name string
+
+func (c Color) String() string { return "" }
+`
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "enums.go", source, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	typesPkg, err := conf.Check("example.com/enums", fset, []*ast.File{astFile}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type-check: %v", err)
+	}
+
+	mod := NewModule("example.com/enums", "")
+	pkg := NewPackage("enums", "example.com/enums", "")
+	pkg.TypesPackage = typesPkg
+	mod.AddPackage(pkg)
+	file := NewFile("/enums/enums.go", "enums.go", false)
+	pkg.AddFile(file)
+	file.AddType(NewType("Color", "int", true))
+	file.AddType(NewType("Bytes", "slice", true))
+	file.AddType(NewType("Count", "alias", true))
+	file.AddConstant(NewConstant("Red", "Color", "iota", true))
+	file.AddConstant(NewConstant("Green", "", "", true))
+	file.AddConstant(NewConstant("Untyped", "", "1", true))
+	file.AddVariable(NewVariable("Total", "int", "", true))
+	file.AddVariable(NewVariable("Name", "string", "", true))
+	method := NewFunction("String", true, false)
+	method.SetReceiver("c", "Color", false)
+	file.AddFunction(method)
+
+	ids := func(t types.Type) string {
+		var names []string
+		for _, sym := range mod.FindByUnderlyingType(t) {
+			names = append(names, strings.TrimPrefix(sym.ID, "example.com/enums."))
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := ids(types.Typ[types.Int]); got != "Color,Count,Green,Red,Total" {
+		t.Errorf("Unexpected int-based symbols: %s", got)
+	}
+	if got := ids(types.NewSlice(types.Typ[types.Byte])); got != "Bytes" {
+		t.Errorf("Unexpected []byte-based symbols: %s", got)
+	}
+	// A named type is compared by its underlying type
+	if got := ids(typesPkg.Scope().Lookup("Color").Type()); got != "Color,Count,Green,Red,Total" {
+		t.Errorf("Unexpected symbols for Color: %s", got)
+	}
+	if got := ids(types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(types.NewParam(token.NoPos, nil, "", types.Typ[types.String])), false)); got != "Color.String" {
+		t.Errorf("Unexpected func() string symbols: %s", got)
+	}
+}
+
+func TestImplementers(t *testing.T) {
+	const source = `package shapes
+
+type Shape interface{ Area() float64 }
+
+type Square struct{}
+
+func (Square) Area() float64 { return 1 }
+
+type Circle struct{}
+
+func (*Circle) Area() float64 { return 3 }
+
+type Box[T any] struct{}
+
+func (Box[T]) Area() float64 { return 0 }
+
+type Alias = Square
+
+type Line struct{}
+`
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "shapes.go", source, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	typesPkg, err := (&types.Config{}).Check("example.com/shapes", fset, []*ast.File{astFile}, nil)
+	if err != nil {
+		t.Fatalf("Failed to type-check: %v", err)
+	}
+	mod := NewModule("example.com/shapes", "")
+	pkg := NewPackage("shapes", "example.com/shapes", "")
+	pkg.TypesPackage = typesPkg
+	mod.AddPackage(pkg)
+
+	names := func(named []*types.Named) string {
+		var result []string
+		for _, n := range named {
+			result = append(result, n.Obj().Name())
+		}
+		return strings.Join(result, ",")
+	}
+	if got := names(mod.NamedTypes()); got != "Circle,Line,Shape,Square" {
+		t.Errorf("Unexpected named types: %s", got)
+	}
+	shape := typesPkg.Scope().Lookup("Shape").Type().Underlying().(*types.Interface)
+	if got := names(mod.Implementers(shape)); got != "Circle,Square" {
+		t.Errorf("Unexpected implementers: %s", got)
+	}
+}
@@ -9,8 +9,8 @@ import (
 type Variable struct {
 	// Variable identity
 	Name    string   // Variable name
-	File    *File    // File where this variable is defined
-	Package *Package // Package this variable belongs to
+	File    *File    `json:"-"` // File where this variable is defined
+	Package *Package `json:"-"` // Package this variable belongs to
 
 	// Variable information
 	Type       string // Type of the variable
@@ -29,8 +29,8 @@ type Variable struct {
 type Constant struct {
 	// Constant identity
 	Name    string   // Constant name
-	File    *File    // File where this constant is defined
-	Package *Package // Package this constant belongs to
+	File    *File    `json:"-"` // File where this constant is defined
+	Package *Package `json:"-"` // Package this constant belongs to
 
 	// Constant information
 	Type       string // Type of the constant (may be inferred)
@@ -0,0 +1,87 @@
+package saver
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// sortedByName returns a copy of the declarations sorted by their key if
+// sorting is requested, and the declarations as they are otherwise
+func sortedByName[T any](decls []T, sorted bool, key func(T) string) []T {
+	if !sorted {
+		return decls
+	}
+	out := append([]T(nil), decls...)
+	sort.SliceStable(out, func(i, j int) bool { return key(out[i]) < key(out[j]) })
+	return out
+}
+
+// functionSortKey orders functions before methods, and methods by receiver
+// type and name
+func functionSortKey(fn *module.Function) string {
+	if fn.Receiver == nil {
+		return "0 " + fn.Name
+	}
+	recv := fn.Receiver.Type
+	if len(recv) > 0 && recv[0] == '*' {
+		recv = recv[1:]
+	}
+	return "1 " + recv + " " + fn.Name
+}
+
+// collapseBlankLines reduces runs of blank lines in Go source to a single
+// blank line and removes blank lines at the start and end. Lines inside raw
+// string literals and comments are kept as they are. Source that cannot be
+// scanned is returned unchanged.
+func collapseBlankLines(src []byte) []byte {
+	// Lines within multi-line tokens must not be touched
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	failed := false
+	s.Init(file, src, func(token.Position, string) { failed = true }, scanner.ScanComments)
+	protected := make(map[int]bool)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.STRING && tok != token.COMMENT {
+			continue
+		}
+		start := file.Line(pos)
+		end := file.Line(pos + token.Pos(len(lit)) - 1)
+		for line := start + 1; line <= end; line++ {
+			protected[line] = true
+		}
+	}
+	if failed {
+		return src
+	}
+
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	var out bytes.Buffer
+	blank := true // Drops leading blank lines
+	for i, line := range lines {
+		isBlank := len(bytes.TrimSpace(line)) == 0
+		if isBlank && blank && !protected[i+1] {
+			continue
+		}
+		if isBlank && !protected[i+1] {
+			out.WriteString("\n")
+		} else {
+			out.Write(line)
+		}
+		blank = isBlank && !protected[i+1]
+	}
+
+	result := bytes.TrimRight(out.Bytes(), " \t\n")
+	if len(result) == 0 {
+		return result
+	}
+	return append(result, '\n')
+}
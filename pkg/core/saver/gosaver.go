@@ -6,6 +6,7 @@ import (
 	"go/format"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"golang.org/x/tools/imports"
@@ -50,8 +51,8 @@ func (s *GoModuleSaver) SaveToWithOptions(module *module.Module, dir string, opt
 		return fmt.Errorf("failed to save go.mod: %w", err)
 	}
 
-	// Save packages
-	for _, pkg := range module.Packages {
+	// Save packages, dependencies first
+	for _, pkg := range packageOrder(module) {
 		if err := s.savePackage(pkg, dir, options); err != nil {
 			return fmt.Errorf("failed to save package %s: %w", pkg.ImportPath, err)
 		}
@@ -94,17 +95,18 @@ func (s *GoModuleSaver) saveGoMod(module *module.Module, dir string) error {
 	return os.WriteFile(goModPath, []byte(content), 0600)
 }
 
+// SavePackage writes a single package of a module below the module
+// directory dir, leaving the module's other packages and go.mod untouched
+func (s *GoModuleSaver) SavePackage(pkg *module.Package, dir string, options SaveOptions) error {
+	if pkg.Module == nil {
+		return fmt.Errorf("package %s does not belong to a module", pkg.ImportPath)
+	}
+	return s.savePackage(pkg, dir, options)
+}
+
 // savePackage saves a package to disk
 func (s *GoModuleSaver) savePackage(pkg *module.Package, baseDir string, options SaveOptions) error {
-	// Calculate package directory relative to module root
-	relDir := strings.TrimPrefix(pkg.ImportPath, pkg.Module.Path)
-	relDir = strings.TrimPrefix(relDir, "/")
-
-	// Create full package directory path
-	pkgDir := filepath.Join(baseDir, relDir)
-	if relDir == "" {
-		pkgDir = baseDir // Root package
-	}
+	pkgDir := filepath.Join(baseDir, packageRelDir(pkg))
 
 	// Create the directory if it doesn't exist
 	if err := os.MkdirAll(pkgDir, 0750); err != nil {
@@ -118,51 +120,75 @@ func (s *GoModuleSaver) savePackage(pkg *module.Package, baseDir string, options
 		}
 	}
 
+	// Bring along fixtures the package's tests read from testdata
+	if err := CopyTestData(pkg, pkgDir); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // saveFile saves a single file to disk
 func (s *GoModuleSaver) saveFile(file *module.File, dir string, options SaveOptions) error {
+	source, err := s.renderFile(file, options)
+	if err != nil {
+		return err
+	}
+
+	// Create the file path
+	filePath := filepath.Join(dir, file.Name)
+
+	// Check if the file exists and we need to create a backup
+	if options.CreateBackups {
+		if _, err := os.Stat(filePath); err == nil {
+			backupPath := filePath + ".bak"
+			if err := os.Rename(filePath, backupPath); err != nil {
+				return fmt.Errorf("failed to create backup of %s: %w", filePath, err)
+			}
+		}
+	}
+
+	// Write the file
+	return os.WriteFile(filePath, source, 0600)
+}
+
+// renderFile returns the content a file is saved with
+func (s *GoModuleSaver) renderFile(file *module.File, options SaveOptions) ([]byte, error) {
 	// Generate the Go source code for the file
 	source, err := s.generateFileSource(file, options)
 	if err != nil {
-		return fmt.Errorf("failed to generate source code: %w", err)
+		return nil, fmt.Errorf("failed to generate source code: %w", err)
+	}
+
+	// Mark the file as generated if requested
+	if options.GeneratedBy != "" && strings.HasSuffix(file.Name, ".go") {
+		source = addGeneratedHeader(source, options.GeneratedBy)
 	}
 
-	// Format the source code if requested
-	if options.Format {
+	// Format the source code if requested, other files are written verbatim
+	if options.Format && strings.HasSuffix(file.Name, ".go") {
 		if options.OrganizeImports {
 			// Use goimports to format and organize imports
 			formatted, err := imports.Process(file.Name, source, nil)
 			if err != nil {
-				return fmt.Errorf("failed to format source code with imports: %w", err)
+				return nil, fmt.Errorf("failed to format source code with imports: %w", err)
 			}
 			source = formatted
 		} else {
 			// Use standard go formatter
 			formatted, err := format.Source(source)
 			if err != nil {
-				return fmt.Errorf("failed to format source code: %w", err)
+				return nil, fmt.Errorf("failed to format source code: %w", err)
 			}
 			source = formatted
 		}
 	}
 
-	// Create the file path
-	filePath := filepath.Join(dir, file.Name)
-
-	// Check if the file exists and we need to create a backup
-	if options.CreateBackups {
-		if _, err := os.Stat(filePath); err == nil {
-			backupPath := filePath + ".bak"
-			if err := os.Rename(filePath, backupPath); err != nil {
-				return fmt.Errorf("failed to create backup of %s: %w", filePath, err)
-			}
-		}
+	if options.Deterministic && strings.HasSuffix(file.Name, ".go") {
+		source = collapseBlankLines(source)
 	}
 
-	// Write the file
-	return os.WriteFile(filePath, source, 0600)
+	return source, nil
 }
 
 // generateFileSource generates the Go source code for a file
@@ -170,8 +196,8 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 	// In a real implementation, this would be much more sophisticated
 	// For this example, we're just doing a basic reconstruction
 
-	// If we have the original source and AST, we would use that for reconstruction
-	if file.SourceCode != "" && !hasModifications(file) {
+	// Original or edited source is written as-is unless the model changed
+	if file.SourceCode != "" && !file.IsModified {
 		return []byte(file.SourceCode), nil
 	}
 
@@ -181,10 +207,17 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 	// Package declaration
 	builder.WriteString(fmt.Sprintf("package %s\n\n", file.Package.Name))
 
-	// Imports
-	if len(file.Imports) > 0 {
+	// Imports, without redundant aliases or name conflicts
+	imports := NormalizeImportAliases(file.Imports)
+	if options.Deterministic && len(imports) > 0 {
+		modulePath := ""
+		if file.Package.Module != nil {
+			modulePath = file.Package.Module.Path
+		}
+		builder.WriteString(importBlock(modulePath, imports) + "\n\n")
+	} else if len(imports) > 0 {
 		builder.WriteString("import (\n")
-		for _, imp := range file.Imports {
+		for _, imp := range imports {
 			if imp.IsBlank {
 				builder.WriteString(fmt.Sprintf("\t_ \"%s\"\n", imp.Path))
 			} else if imp.Name != "" {
@@ -197,9 +230,9 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 	}
 
 	// Constants
-	for _, c := range file.Constants {
+	for _, c := range sortedByName(file.Constants, options.Deterministic, func(c *module.Constant) string { return c.Name }) {
 		if c.Doc != "" {
-			builder.WriteString(fmt.Sprintf("// %s\n", c.Doc))
+			writeDoc(&builder, c.Doc)
 		}
 
 		if c.Type != "" {
@@ -210,9 +243,9 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 	}
 
 	// Variables
-	for _, v := range file.Variables {
+	for _, v := range sortedByName(file.Variables, options.Deterministic, func(v *module.Variable) string { return v.Name }) {
 		if v.Doc != "" {
-			builder.WriteString(fmt.Sprintf("// %s\n", v.Doc))
+			writeDoc(&builder, v.Doc)
 		}
 
 		if v.Type != "" && v.Value != "" {
@@ -224,10 +257,16 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 		}
 	}
 
-	// Types
-	for _, t := range file.Types {
+	// Types; structs the model did not change keep their original source
+	original := parseOriginalTypes(file)
+	for _, t := range sortedByName(file.Types, options.Deterministic, func(t *module.Type) string { return t.Name }) {
+		if text, ok := original.unchangedStruct(t); ok {
+			builder.WriteString(text + "\n\n")
+			continue
+		}
+
 		if t.Doc != "" {
-			builder.WriteString(fmt.Sprintf("// %s\n", t.Doc))
+			writeDoc(&builder, t.Doc)
 		}
 
 		switch t.Kind {
@@ -270,9 +309,9 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 	}
 
 	// Functions and methods
-	for _, fn := range file.Functions {
+	for _, fn := range sortedByName(file.Functions, options.Deterministic, functionSortKey) {
 		if fn.Doc != "" {
-			builder.WriteString(fmt.Sprintf("// %s\n", fn.Doc))
+			writeDoc(&builder, fn.Doc)
 		}
 
 		if fn.IsMethod {
@@ -294,6 +333,32 @@ func (s *GoModuleSaver) generateFileSource(file *module.File, options SaveOption
 	return []byte(builder.String()), nil
 }
 
+// packageRelDir returns the directory of a package relative to the module
+// root, "." for the root package
+func packageRelDir(pkg *module.Package) string {
+	relDir := strings.TrimPrefix(pkg.ImportPath, pkg.Module.Path)
+	relDir = strings.TrimPrefix(relDir, "/")
+	if relDir == "" {
+		return "."
+	}
+	return filepath.FromSlash(relDir)
+}
+
+// generatedHeaderRegexp matches the conventional header of generated Go files
+// as described in https://go.dev/s/generatedcode
+var generatedHeaderRegexp = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// addGeneratedHeader prepends a "Code generated ... DO NOT EDIT." header to the
+// source unless it already carries one
+func addGeneratedHeader(source []byte, generator string) []byte {
+	if generatedHeaderRegexp.Match(source) {
+		return source
+	}
+
+	header := fmt.Sprintf("// Code generated by %s; DO NOT EDIT.\n\n", generator)
+	return append([]byte(header), source...)
+}
+
 // formatReceiver formats a method receiver
 func formatReceiver(r *module.Receiver) string {
 	if r == nil {
@@ -313,9 +378,20 @@ func formatReceiver(r *module.Receiver) string {
 	return fmt.Sprintf("%s %s", r.Name, r.Type)
 }
 
-// hasModifications checks if a file has been modified since loading
-// This is a placeholder - a real implementation would track modifications
+// hasModifications checks if a file was changed since loading, either in
+// its model, which is regenerated, or in its source text, which is written
+// as-is
 func hasModifications(file *module.File) bool {
-	// For this example, we always generate new code
-	return true
+	return file.IsModified || file.IsSourceEdited
+}
+
+// writeDoc writes a doc comment with each line as a line comment
+func writeDoc(builder *strings.Builder, doc string) {
+	for _, line := range strings.Split(strings.TrimRight(doc, "\n"), "\n") {
+		if line == "" {
+			builder.WriteString("//\n")
+		} else {
+			builder.WriteString("// " + line + "\n")
+		}
+	}
 }
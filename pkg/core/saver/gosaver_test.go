@@ -274,3 +274,209 @@ func TestSaveWithModifiedModule(t *testing.T) {
 		t.Errorf("go.mod does not contain added replacement")
 	}
 }
+
+func TestSaveWithGeneratedHeader(t *testing.T) {
+	// Create a module with a single generated file
+	mod := module.NewModule("testmodule", "/test")
+	mod.GoVersion = "1.18"
+
+	pkg := module.NewPackage("gen", "testmodule/gen", "/test/gen")
+	mod.AddPackage(pkg)
+
+	file := module.NewFile("/test/gen/gen.go", "gen.go", false)
+	pkg.AddFile(file)
+
+	typ := module.NewType("Generated", "struct", true)
+	typ.AddField("Name", "string", "", false, "")
+	file.AddType(typ)
+	pkg.AddType(typ)
+
+	tempDir, err := os.MkdirTemp("", "gosaver-generated-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	options := DefaultSaveOptions()
+	options.GeneratedBy = "go-tree"
+
+	saver := NewGoModuleSaver()
+	if err := saver.SaveToWithOptions(mod, tempDir, options); err != nil {
+		t.Fatalf("Failed to save module: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "gen", "gen.go"))
+	if err != nil {
+		t.Fatalf("Failed to read gen.go: %v", err)
+	}
+
+	expected := "// Code generated by go-tree; DO NOT EDIT.\n\npackage gen"
+	if !strings.HasPrefix(string(content), expected) {
+		t.Errorf("Expected file to start with generated header, got:\n%s", content)
+	}
+
+	// Saving again must not duplicate an existing header
+	source := addGeneratedHeader(content, "go-tree")
+	if strings.Count(string(source), "DO NOT EDIT") != 1 {
+		t.Errorf("Expected exactly one generated header, got:\n%s", source)
+	}
+}
+
+func TestSaveDeterministic(t *testing.T) {
+	// Builds the same file with its declarations in the given order
+	build := func(reversed bool) *module.File {
+		mod := module.NewModule("example.com/det", "/det")
+		pkg := module.NewPackage("det", "example.com/det/det", "/det/det")
+		mod.AddPackage(pkg)
+		file := module.NewFile("/det/det/det.go", "det.go", false)
+		pkg.AddFile(file)
+		file.IsModified = true
+
+		imports := []*module.Import{
+			{Path: "example.com/det/util"},
+			{Path: "strings"},
+			{Path: "github.com/pkg/errors"},
+			{Path: "fmt"},
+		}
+		functions := []*module.Function{
+			{Name: "Zeta", Signature: "()", Body: "\tfmt.Println(`a\n\n\nb`)\n\n\n\tstrings.ToUpper(\"\")\n"},
+			{Name: "Alpha", Signature: "() error", Body: "\treturn errors.New(util.Name)\n"},
+			{Name: "Close", Signature: "()", IsMethod: true, Receiver: &module.Receiver{Name: "c", Type: "Conn", IsPointer: true}, Body: "\n\n"},
+		}
+		types := []*module.Type{module.NewType("Conn", "struct", true), module.NewType("Addr", "struct", true)}
+		if reversed {
+			for i, j := 0, len(imports)-1; i < j; i, j = i+1, j-1 {
+				imports[i], imports[j] = imports[j], imports[i]
+			}
+			functions[0], functions[2] = functions[2], functions[0]
+			types[0], types[1] = types[1], types[0]
+		}
+		file.Imports = imports
+		for _, typ := range types {
+			file.AddType(typ)
+		}
+		for _, fn := range functions {
+			file.AddFunction(fn)
+		}
+		return file
+	}
+
+	options := DefaultSaveOptions()
+	options.Format = false
+	options.Deterministic = true
+	saver := NewGoModuleSaver()
+
+	first, err := saver.renderFile(build(false), options)
+	if err != nil {
+		t.Fatalf("Failed to render file: %v", err)
+	}
+	second, err := saver.renderFile(build(true), options)
+	if err != nil {
+		t.Fatalf("Failed to render file: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("Expected the same output regardless of order, got:\n%s\nand:\n%s", first, second)
+	}
+
+	expected := "package det\n\n" +
+		"import (\n\t\"fmt\"\n\t\"strings\"\n\n\t\"github.com/pkg/errors\"\n\n\t\"example.com/det/util\"\n)\n\n" +
+		"type Addr struct {\n}\n\n" +
+		"type Conn struct {\n}\n\n" +
+		"func Alpha() error {\n\treturn errors.New(util.Name)\n}\n\n" +
+		"func Zeta() {\n\tfmt.Println(`a\n\n\nb`)\n\n\tstrings.ToUpper(\"\")\n}\n\n" +
+		"func (c *Conn) Close() {\n\n}\n"
+	if string(first) != expected {
+		t.Errorf("Unexpected output:\n%s", first)
+	}
+}
+
+func TestSaveKeepsUnchangedStructs(t *testing.T) {
+	source := "package user\n\n" +
+		"// User is a user\n" +
+		"type User struct {\n" +
+		"\t// ID identifies the user\n" +
+		"\tID   int    `json:\"id\"`\n" +
+		"\tName string `json:\"name,omitempty\"` // Display name\n" +
+		"}\n\n" +
+		"type Group struct {\n\tName string `json:\"name\"` // Group name\n}\n\n" +
+		"// Greet greets a user\n" +
+		"func Greet(u User) string {\n\treturn \"Hi\"\n}\n"
+
+	mod := module.NewModule("example.com/user", "/user")
+	pkg := module.NewPackage("user", "example.com/user", "/user")
+	mod.AddPackage(pkg)
+	file := module.NewFile("/user/user.go", "user.go", false)
+	file.SourceCode = source
+	pkg.AddFile(file)
+
+	user := module.NewType("User", "struct", true)
+	user.Doc = "User is a user\n"
+	user.AddField("ID", "int", "`json:\"id\"`", false, "ID identifies the user\n")
+	user.AddField("Name", "string", "`json:\"name,omitempty\"`", false, "")
+	file.AddType(user)
+	group := module.NewType("Group", "struct", true)
+	group.AddField("Name", "string", "`json:\"name\"`", false, "")
+	file.AddType(group)
+	file.AddFunction(&module.Function{Name: "Greet", Signature: "(u User) string", Doc: "Greet greets a user", Body: "\treturn \"Hi\"\n"})
+
+	// Change the function and one struct, but not the other
+	file.Functions[0].Body = "\treturn \"Hello, \" + u.Name\n"
+	group.Fields[0].Type = "[]byte"
+	file.IsModified = true
+
+	content, err := NewGoModuleSaver().renderFile(file, DefaultSaveOptions())
+	if err != nil {
+		t.Fatalf("Failed to render file: %v", err)
+	}
+	for _, want := range []string{
+		"// User is a user\ntype User struct {\n\t// ID identifies the user\n\tID   int    `json:\"id\"`\n\tName string `json:\"name,omitempty\"` // Display name\n}\n",
+		"Name []byte `json:\"name\"`\n",
+		"return \"Hello, \" + u.Name",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), "Group name") {
+		t.Errorf("Expected the changed struct to be regenerated, got:\n%s", content)
+	}
+}
+
+func TestSaveEditedSource(t *testing.T) {
+	source := "package doc\n\n// Run runs.\n//\n// Second paragraph.\nfunc Run() {}\n"
+
+	mod := module.NewModule("example.com/doc", "/doc")
+	pkg := module.NewPackage("doc", "example.com/doc", "/doc")
+	mod.AddPackage(pkg)
+	file := module.NewFile("/doc/doc.go", "doc.go", false)
+	pkg.AddFile(file)
+	file.AddFunction(&module.Function{Name: "Run", Signature: "()", Doc: "Run runs.\n\nSecond paragraph.\n", Body: "\n"})
+
+	// A changed model is regenerated, with multi-line docs kept valid
+	content, err := NewGoModuleSaver().renderFile(file, DefaultSaveOptions())
+	if err != nil {
+		t.Fatalf("Failed to render file: %v", err)
+	}
+	if !strings.Contains(string(content), "// Run runs.\n//\n// Second paragraph.\nfunc Run() {") {
+		t.Errorf("Expected a multi-line doc comment, got:\n%s", content)
+	}
+
+	// Edited source is written as-is
+	edited := strings.Replace(source, "Run()", "Start()", 1)
+	file.SetSource(edited)
+	if file.IsModified || !file.IsSourceEdited || !pkg.IsModified {
+		t.Errorf("Unexpected flags after SetSource: file modified %v, source edited %v, package modified %v",
+			file.IsModified, file.IsSourceEdited, pkg.IsModified)
+	}
+	content, err = NewGoModuleSaver().renderFile(file, DefaultSaveOptions())
+	if err != nil {
+		t.Fatalf("Failed to render file: %v", err)
+	}
+	if string(content) != edited {
+		t.Errorf("Expected the edited source, got:\n%s", content)
+	}
+}
@@ -0,0 +1,180 @@
+package saver
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// NormalizeImportAliases cleans up a file's imports before they are written:
+// duplicate imports are dropped, aliases equal to the package's name are
+// removed, and imports whose names collide get deterministic aliases (e.g.
+// "mathrand" for math/rand next to crypto/rand). Explicit aliases win over
+// default names, then standard library packages, and remaining ties are
+// broken by import path. Blank and dot imports are kept as they are.
+// Aliases are only removed if the package's name is known, from the type
+// information of the import's file or for the standard library; an alias
+// such as core for "k8s.io/api/core/v1" is kept otherwise.
+func NormalizeImportAliases(imports []*module.Import) []*module.Import {
+	// Drop redundant aliases and duplicates, keeping the original order
+	var result []*module.Import
+	seen := make(map[string]bool)
+	for _, imp := range imports {
+		normalized := *imp
+		if name, ok := packageName(imp); ok && normalized.Name == name {
+			normalized.Name = ""
+		}
+		key := normalized.Path + " " + normalized.Name
+		if normalized.IsBlank {
+			key = normalized.Path + " _"
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, &normalized)
+	}
+
+	// Resolve name conflicts, explicit aliases first, then by path
+	order := make([]*module.Import, 0, len(result))
+	for _, imp := range result {
+		if !imp.IsBlank && imp.Name != "." {
+			order = append(order, imp)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		ei, ej := order[i].Name != "", order[j].Name != ""
+		if ei != ej {
+			return ei
+		}
+		si, sj := isStandard(order[i].Path), isStandard(order[j].Path)
+		if si != sj {
+			return si
+		}
+		return order[i].Path < order[j].Path
+	})
+
+	used := make(map[string]string) // name to import path
+	for _, imp := range order {
+		name := importName(imp)
+		if owner, taken := used[name]; !taken || owner == imp.Path {
+			used[name] = imp.Path
+			continue
+		}
+		alias := conflictAlias(imp.Path, name, used)
+		imp.Name = alias
+		used[alias] = imp.Path
+	}
+
+	return result
+}
+
+// EnsureImport makes a file import the package at importPath and returns
+// the name the file refers to it by. An existing import of the path is
+// reused; otherwise one is added with File.AddImport, aliased like
+// NormalizeImportAliases would if its name is taken by another import of
+// the file. name is the package's name if known, and is set as the alias if
+// it differs from the name assumed from the path.
+func EnsureImport(file *module.File, importPath, name string) string {
+	used := make(map[string]string)
+	for _, imp := range file.Imports {
+		if imp.IsBlank || imp.Name == "." {
+			continue
+		}
+		if imp.Path == importPath {
+			return importName(imp)
+		}
+		used[importName(imp)] = imp.Path
+	}
+
+	imp := module.NewImport(importPath, "", false)
+	if name != "" && name != AssumedPackageName(importPath) {
+		imp.Name = name
+	}
+	imp.File = file
+	if name = importName(imp); used[name] != "" {
+		imp.Name = conflictAlias(importPath, name, used)
+		name = imp.Name
+	}
+	file.AddImport(imp)
+	return name
+}
+
+// AssumedPackageName returns the package name an import path conventionally
+// refers to: the last path element, skipping a major version suffix such as
+// "/v2" and dropping a "go-" prefix and anything after the first character
+// that cannot appear in an identifier (e.g. "yaml" for "gopkg.in/yaml.v3")
+func AssumedPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// packageName returns the name of an imported package and whether it is
+// known: from the type information of the import's package, or assumed from
+// the path for the standard library, whose names follow the convention
+func packageName(imp *module.Import) (string, bool) {
+	if imp.File != nil && imp.File.Package != nil && imp.File.Package.TypesPackage != nil {
+		for _, imported := range imp.File.Package.TypesPackage.Imports() {
+			if imported.Path() == imp.Path {
+				return imported.Name(), true
+			}
+		}
+	}
+	if isStandard(imp.Path) {
+		return AssumedPackageName(imp.Path), true
+	}
+	return "", false
+}
+
+// importName returns the name a file refers to an import by
+func importName(imp *module.Import) string {
+	if imp.Name != "" {
+		return imp.Name
+	}
+	if name, ok := packageName(imp); ok {
+		return name
+	}
+	return AssumedPackageName(imp.Path)
+}
+
+// conflictAlias derives an unused alias for an import whose name is taken,
+// prefixing the name with the parent path element and numbering if needed
+func conflictAlias(importPath, name string, used map[string]string) string {
+	candidate := name
+	if dir := path.Dir(importPath); dir != "." && dir != "/" {
+		candidate = AssumedPackageName(dir) + name
+	}
+	if _, taken := used[candidate]; !taken && candidate != name {
+		return candidate
+	}
+	for i := 2; ; i++ {
+		numbered := candidate + strconv.Itoa(i)
+		if _, taken := used[numbered]; !taken {
+			return numbered
+		}
+	}
+}
+
+// isStandard reports whether an import path looks like a standard library
+// package, whose first element has no dot
+func isStandard(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
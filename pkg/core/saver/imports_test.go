@@ -0,0 +1,221 @@
+package saver
+
+import (
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestAssumedPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"fmt":                         "fmt",
+		"math/rand":                   "rand",
+		"example.com/mod/v2":          "mod",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+	} {
+		if got := AssumedPackageName(path); got != want {
+			t.Errorf("AssumedPackageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestNormalizeImportAliases(t *testing.T) {
+	imports := []*module.Import{
+		module.NewImport("math/rand", "", false),
+		module.NewImport("fmt", "fmt", false),
+		module.NewImport("crypto/rand", "", false),
+		module.NewImport("example.com/a/log", "", false),
+		module.NewImport("log", "", false),
+		module.NewImport("example.com/b/log", "applog", false),
+		module.NewImport("math/rand", "", false),
+		module.NewImport("embed", "_", true),
+	}
+
+	normalized := NormalizeImportAliases(imports)
+
+	var got []string
+	for _, imp := range normalized {
+		got = append(got, strings.TrimSpace(imp.Name+" "+imp.Path))
+	}
+	expected := "mathrand math/rand,fmt,crypto/rand,alog example.com/a/log,log,applog example.com/b/log,_ embed"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected imports %s, got %s", expected, strings.Join(got, ","))
+	}
+
+	// The input is left untouched
+	if imports[1].Name != "fmt" || imports[0].Name != "" {
+		t.Error("Expected the original imports not to be modified")
+	}
+}
+func TestNormalizeImportAliasesPackageNames(t *testing.T) {
+	pkg := module.NewPackage("app", "example.com/app", "")
+	file := module.NewFile("app.go", "app.go", false)
+	pkg.AddFile(file)
+	for _, imp := range []*module.Import{
+		module.NewImport("k8s.io/api/core/v1", "core", false),
+		module.NewImport("k8s.io/api/apps/v1", "v1", false),
+		module.NewImport("gopkg.in/yaml.v3", "yaml", false),
+	} {
+		file.AddImport(imp)
+	}
+
+	// Without type information, the names of other packages are unknown
+	var got []string
+	for _, imp := range NormalizeImportAliases(file.Imports) {
+		got = append(got, strings.TrimSpace(imp.Name+" "+imp.Path))
+	}
+	expected := "core k8s.io/api/core/v1,v1 k8s.io/api/apps/v1,yaml gopkg.in/yaml.v3"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected imports %s, got %s", expected, strings.Join(got, ","))
+	}
+
+	pkg.TypesPackage = types.NewPackage("example.com/app", "app")
+	pkg.TypesPackage.SetImports([]*types.Package{
+		types.NewPackage("k8s.io/api/core/v1", "v1"),
+		types.NewPackage("k8s.io/api/apps/v1", "v1"),
+		types.NewPackage("gopkg.in/yaml.v3", "yaml"),
+	})
+	got = nil
+	for _, imp := range NormalizeImportAliases(file.Imports) {
+		got = append(got, strings.TrimSpace(imp.Name+" "+imp.Path))
+	}
+	expected = "core k8s.io/api/core/v1,k8s.io/api/apps/v1,gopkg.in/yaml.v3"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected imports %s, got %s", expected, strings.Join(got, ","))
+	}
+}
+
+func TestNormalizeImports(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+	pkg := module.NewPackage("app", "example.com/app", "")
+	mod.AddPackage(pkg)
+
+	messy := module.NewFile("app.go", "app.go", false)
+	messy.SourceCode = `package app
+
+import (
+	"example.com/app/internal/store"
+	"fmt"
+	"github.com/acme/log"
+	strings "strings"
+	"os"
+)
+
+func Run() { fmt.Println(strings.ToUpper(store.Name), log.Level) }
+`
+	tidy := module.NewFile("tidy.go", "tidy.go", false)
+	tidy.SourceCode = "package app\n\nimport \"fmt\"\n\nfunc Tidy() { fmt.Println() }\n"
+	pkg.AddFile(messy)
+	pkg.AddFile(tidy)
+	messy.IsModified, tidy.IsModified, pkg.IsModified = false, false, false
+
+	if err := NormalizeImports(mod); err != nil {
+		t.Fatalf("NormalizeImports failed: %v", err)
+	}
+
+	expected := `package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acme/log"
+
+	"example.com/app/internal/store"
+)
+
+func Run() { fmt.Println(strings.ToUpper(store.Name), log.Level) }
+`
+	if messy.SourceCode != expected {
+		t.Errorf("Expected normalized source:\n%s\ngot:\n%s", expected, messy.SourceCode)
+	}
+	if !messy.IsSourceEdited || messy.IsModified || !pkg.IsModified {
+		t.Error("Expected the changed file to keep its edited source and its package to be marked modified")
+	}
+	if len(messy.Imports) != 4 || messy.Imports[0].Path != "fmt" || messy.Imports[1].Name != "" {
+		t.Errorf("Expected the file's imports to be rebuilt, got %v", messy.Imports)
+	}
+	if tidy.IsSourceEdited {
+		t.Error("Expected an already normalized file not to be marked modified")
+	}
+}
+
+func TestNormalizeImportsSave(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+	mod.GoVersion = "1.21"
+	pkg := module.NewPackage("app", "example.com/app", "")
+	mod.AddPackage(pkg)
+
+	file := module.NewFile("app.go", "app.go", false)
+	file.SourceCode = `package app
+
+import (
+	"strings"
+	"fmt"
+)
+
+// Run prints a greeting.
+//
+// More text in a second paragraph.
+func Run() { fmt.Println(strings.ToUpper("hi")) }
+`
+	pkg.AddFile(file)
+	file.AddFunction(&module.Function{Name: "Run", Signature: "()", Doc: "Run prints a greeting.\n\nMore text in a second paragraph.\n"})
+	file.IsModified, pkg.IsModified = false, false
+
+	if err := NormalizeImports(mod); err != nil {
+		t.Fatalf("NormalizeImports failed: %v", err)
+	}
+	dir := t.TempDir()
+	if err := NewGoModuleSaver().SaveTo(mod, dir); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(dir, "app.go"))
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if string(saved) != file.SourceCode {
+		t.Errorf("Expected the normalized source to be saved, got:\n%s", saved)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "app.go", saved, parser.ParseComments); err != nil {
+		t.Errorf("Saved file does not parse: %v", err)
+	}
+}
+
+func TestEnsureImport(t *testing.T) {
+	file := module.NewFile("app.go", "app.go", false)
+	file.AddImport(module.NewImport("math/rand", "", false))
+	file.AddImport(module.NewImport("example.com/app/v2/api", "apiv2", false))
+
+	for _, tc := range []struct {
+		path, name, want, alias string
+	}{
+		{"math/rand", "", "rand", ""},                                           // existing import
+		{"example.com/app/v2/api", "api", "apiv2", "apiv2"},                     // existing alias
+		{"example.com/app/dto", "dto", "dto", ""},                               // new import
+		{"gopkg.in/yaml.v3", "yaml", "yaml", ""},                                // name assumed from the path
+		{"example.com/app/go-store", "kv", "kv", "kv"},                          // name differs from the path
+		{"crypto/rand", "rand", "cryptorand", "cryptorand"},                     // name taken
+		{"example.com/other/crypto/rand", "rand", "cryptorand2", "cryptorand2"}, // alias taken
+	} {
+		if got := EnsureImport(file, tc.path, tc.name); got != tc.want {
+			t.Errorf("EnsureImport(%q, %q) = %q, want %q", tc.path, tc.name, got, tc.want)
+		}
+		for _, imp := range file.Imports {
+			if imp.Path == tc.path && imp.Name != tc.alias {
+				t.Errorf("Expected %s to be imported as %q, got %q", tc.path, tc.alias, imp.Name)
+			}
+		}
+	}
+	if len(file.Imports) != 7 {
+		t.Errorf("Expected 7 imports, got %d", len(file.Imports))
+	}
+}
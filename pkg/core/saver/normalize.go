@@ -0,0 +1,186 @@
+package saver
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"golang.org/x/tools/imports"
+)
+
+// NormalizeImports organizes the imports of every Go file of the module the
+// way the saver does at save time: unused imports are removed, aliases
+// equal to the package's name are dropped, duplicates are merged,
+// and imports are sorted into standard library, third-party and module
+// groups. Files whose source changes get their Imports rebuilt and the new
+// source set with File.SetSource, so it is saved as-is.
+// Import blocks containing comments or cgo imports keep the grouping
+// goimports gives them.
+func NormalizeImports(mod *module.Module) error {
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			if !strings.HasSuffix(file.Name, ".go") || file.SourceCode == "" {
+				continue
+			}
+			source, err := normalizeFileImports(mod.Path, file)
+			if err != nil {
+				return fmt.Errorf("failed to normalize imports of %s: %w", file.Path, err)
+			}
+			if source == file.SourceCode {
+				continue
+			}
+			fileImports, err := parseImports(file, source)
+			if err != nil {
+				return fmt.Errorf("failed to normalize imports of %s: %w", file.Path, err)
+			}
+			file.Imports = fileImports
+			file.SetSource(source)
+		}
+	}
+	return nil
+}
+
+// normalizeFileImports returns the source of a file with its imports
+// organized
+func normalizeFileImports(modulePath string, file *module.File) (string, error) {
+	filename := file.Path
+	if filename == "" {
+		filename = file.Name
+	}
+	processed, err := imports.Process(filename, []byte(file.SourceCode), nil)
+	if err != nil {
+		return "", err
+	}
+
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, filename, processed, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	var decls []*ast.GenDecl
+	for _, decl := range astFile.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			decls = append(decls, gen)
+		}
+	}
+	if len(decls) == 0 {
+		return string(processed), nil
+	}
+
+	start := fset.Position(decls[0].Pos()).Offset
+	end := fset.Position(decls[len(decls)-1].End()).Offset
+	for _, group := range astFile.Comments {
+		if offset := fset.Position(group.Pos()).Offset; offset >= start && offset < end {
+			return string(processed), nil
+		}
+	}
+
+	var specs []*module.Import
+	for _, decl := range decls {
+		for _, spec := range decl.Specs {
+			imp := spec.(*ast.ImportSpec)
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				return "", err
+			}
+			if importPath == "C" {
+				return string(processed), nil
+			}
+			normalized := &module.Import{Path: importPath, File: file}
+			if imp.Name != nil {
+				normalized.Name = imp.Name.Name
+				normalized.IsBlank = imp.Name.Name == "_"
+			}
+			specs = append(specs, normalized)
+		}
+	}
+	specs = NormalizeImportAliases(specs)
+
+	block := importBlock(modulePath, specs)
+	formatted, err := format.Source([]byte(string(processed[:start]) + block + string(processed[end:])))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// importBlock renders imports as a single declaration, grouped into
+// standard library, third-party and module imports and sorted by path
+func importBlock(modulePath string, specs []*module.Import) string {
+	var groups [3][]*module.Import
+	for _, imp := range specs {
+		switch {
+		case isStandard(imp.Path):
+			groups[0] = append(groups[0], imp)
+		case imp.Path == modulePath || strings.HasPrefix(imp.Path, modulePath+"/"):
+			groups[2] = append(groups[2], imp)
+		default:
+			groups[1] = append(groups[1], imp)
+		}
+	}
+
+	spec := func(imp *module.Import) string {
+		if imp.Name != "" {
+			return imp.Name + " " + strconv.Quote(imp.Path)
+		}
+		return strconv.Quote(imp.Path)
+	}
+	if len(specs) == 1 {
+		return "import " + spec(specs[0])
+	}
+
+	var builder strings.Builder
+	builder.WriteString("import (\n")
+	first := true
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if !first {
+			builder.WriteString("\n")
+		}
+		first = false
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Path != group[j].Path {
+				return group[i].Path < group[j].Path
+			}
+			return group[i].Name < group[j].Name
+		})
+		for _, imp := range group {
+			builder.WriteString("\t" + spec(imp) + "\n")
+		}
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+// parseImports returns the imports of a file's new source
+func parseImports(file *module.File, source string) ([]*module.Import, error) {
+	astFile, err := parser.ParseFile(token.NewFileSet(), file.Name, source, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var result []*module.Import
+	for _, spec := range astFile.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		imp := &module.Import{Path: importPath, File: file}
+		if spec.Name != nil {
+			imp.Name = spec.Name.Name
+			imp.IsBlank = spec.Name.Name == "_"
+		}
+		if spec.Doc != nil {
+			imp.Doc = spec.Doc.Text()
+		}
+		result = append(result, imp)
+	}
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package saver
+
+import (
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// packageOrder returns the module's packages in topological import order,
+// with packages before the packages that import them. Packages whose order
+// is not constrained by imports are sorted by import path, and packages in
+// an import cycle follow all others in sorted order.
+func packageOrder(mod *module.Module) []*module.Package {
+	// Count intra-module dependencies and record reverse edges
+	pending := make(map[string]int, len(mod.Packages))
+	importers := make(map[string][]string, len(mod.Packages))
+	for path, pkg := range mod.Packages {
+		pending[path] = 0
+		for dep := range intraModuleImports(mod, pkg) {
+			pending[path]++
+			importers[dep] = append(importers[dep], path)
+		}
+	}
+
+	var ready []string
+	for path, count := range pending {
+		if count == 0 {
+			ready = append(ready, path)
+		}
+	}
+	sort.Strings(ready)
+
+	ordered := make([]*module.Package, 0, len(mod.Packages))
+	for len(ready) > 0 {
+		path := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, mod.Packages[path])
+		delete(pending, path)
+
+		released := false
+		for _, importer := range importers[path] {
+			pending[importer]--
+			if pending[importer] == 0 {
+				ready = append(ready, importer)
+				released = true
+			}
+		}
+		if released {
+			sort.Strings(ready)
+		}
+	}
+
+	// Whatever is left is part of, or depends on, an import cycle
+	remaining := make([]string, 0, len(pending))
+	for path := range pending {
+		remaining = append(remaining, path)
+	}
+	sort.Strings(remaining)
+	for _, path := range remaining {
+		ordered = append(ordered, mod.Packages[path])
+	}
+
+	return ordered
+}
+
+// intraModuleImports returns the set of module packages imported by pkg
+func intraModuleImports(mod *module.Module, pkg *module.Package) map[string]bool {
+	deps := make(map[string]bool)
+	add := func(path string) {
+		if path == pkg.ImportPath {
+			return
+		}
+		if _, ok := mod.Packages[path]; ok {
+			deps[path] = true
+		}
+	}
+
+	for path := range pkg.Imports {
+		add(path)
+	}
+	for _, file := range pkg.Files {
+		for _, imp := range file.Imports {
+			add(imp.Path)
+		}
+	}
+
+	return deps
+}
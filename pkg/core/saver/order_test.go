@@ -0,0 +1,42 @@
+package saver
+
+import (
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestPackageOrder(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+
+	add := func(name string, imports ...string) {
+		pkg := module.NewPackage(name, "example.com/app/"+name, "")
+		file := module.NewFile(name+".go", name+".go", false)
+		for _, imp := range imports {
+			file.AddImport(module.NewImport(imp, "", false))
+		}
+		pkg.AddFile(file)
+		mod.AddPackage(pkg)
+	}
+
+	// cmd -> service -> (store, model), store -> model, plus a cycle x <-> y
+	// and an independent package
+	add("cmd", "example.com/app/service", "fmt")
+	add("service", "example.com/app/store", "example.com/app/model")
+	add("store", "example.com/app/model")
+	add("model")
+	add("util")
+	add("x", "example.com/app/y")
+	add("y", "example.com/app/x")
+
+	var names []string
+	for _, pkg := range packageOrder(mod) {
+		names = append(names, pkg.Name)
+	}
+
+	expected := "model store service cmd util x y"
+	if got := strings.Join(names, " "); got != expected {
+		t.Errorf("Expected order %q, got %q", expected, got)
+	}
+}
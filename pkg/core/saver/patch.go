@@ -0,0 +1,238 @@
+package saver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// patchContext is the number of unchanged lines around each hunk
+const patchContext = 3
+
+// SavePatch writes the changes saving the module would make as a unified diff
+// instead of writing files, see SavePatchWithOptions
+func (s *GoModuleSaver) SavePatch(module *module.Module, w io.Writer) error {
+	return s.SavePatchWithOptions(module, w, DefaultSaveOptions())
+}
+
+// SavePatchWithOptions writes a single unified diff, with paths relative to
+// the module directory in the format of git diff, that turns the files on
+// disk into the files the module would be saved with. Only modified files and
+// files that do not exist yet, which appear as new files, are considered;
+// files whose content would not change are left out, and so is go.mod. The
+// patch applies with git apply or patch -p1 in the module directory.
+func (s *GoModuleSaver) SavePatchWithOptions(module *module.Module, w io.Writer, options SaveOptions) error {
+	if module == nil || module.Dir == "" {
+		return errors.New("module must be loaded from a directory")
+	}
+
+	type change struct {
+		path     string
+		old, new []byte
+		created  bool
+	}
+	var changes []change
+	for _, pkg := range module.Packages {
+		for _, file := range pkg.Files {
+			rel := filepath.Join(packageRelDir(pkg), file.Name)
+			old, err := os.ReadFile(filepath.Join(module.Dir, rel))
+			created := errors.Is(err, os.ErrNotExist)
+			if err != nil && !created {
+				return fmt.Errorf("failed to read %s: %w", rel, err)
+			}
+			if !created && !hasModifications(file) {
+				continue
+			}
+			source, err := s.renderFile(file, options)
+			if err != nil {
+				return fmt.Errorf("failed to render %s: %w", rel, err)
+			}
+			if !created && bytes.Equal(old, source) {
+				continue
+			}
+			changes = append(changes, change{filepath.ToSlash(rel), old, source, created})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+
+	var b strings.Builder
+	for _, c := range changes {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", c.path, c.path)
+		if c.created {
+			b.WriteString("new file mode 100644\n")
+			b.WriteString("--- /dev/null\n")
+		} else {
+			fmt.Fprintf(&b, "--- a/%s\n", c.path)
+		}
+		fmt.Fprintf(&b, "+++ b/%s\n", c.path)
+		b.WriteString(unifiedHunks(splitLines(c.old), splitLines(c.new), patchContext))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// diffOp is a line of an edit script: ' ' keeps line a of the old text as
+// line b of the new one, '-' deletes line a and '+' inserts line b
+type diffOp struct {
+	kind byte
+	a, b int
+}
+
+// splitLines splits text into lines that keep their newline
+func splitLines(text []byte) []string {
+	var lines []string
+	for len(text) > 0 {
+		i := bytes.IndexByte(text, '\n') + 1
+		if i == 0 {
+			i = len(text)
+		}
+		lines = append(lines, string(text[:i]))
+		text = text[i:]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script from a to b, using Myers'
+// algorithm
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace back from the end, collecting the script in reverse
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		if d == 0 {
+			prevX, prevY = 0, 0
+		}
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{'+', x, y})
+			} else {
+				x--
+				ops = append(ops, diffOp{'-', x, y})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedHunks renders the differences between the lines of a and b as
+// unified diff hunks with the given number of context lines
+func unifiedHunks(a, b []string, context int) string {
+	ops := diffLines(a, b)
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk over changes separated by at most twice the
+		// context, then add the trailing context
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		i = end
+		end += context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hunk := ops[start:end]
+		var oldCount, newCount int
+		for _, op := range hunk {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(hunk[0].a, oldCount), hunkRange(hunk[0].b, newCount))
+		for _, op := range hunk {
+			line := b[op.b]
+			if op.kind == '-' {
+				line = a[op.a]
+			}
+			out.WriteByte(op.kind)
+			out.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk's range in one file,
+// given the 0-based index of its first line
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
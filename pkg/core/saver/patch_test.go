@@ -0,0 +1,119 @@
+package saver
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestSavePatch(t *testing.T) {
+	dir := t.TempDir()
+	original := "package demo\n\nconst A = 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "demo.go"), []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other.go"), []byte("package demo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mod := module.NewModule("example.com/demo", dir)
+	pkg := module.NewPackage("demo", "example.com/demo", dir)
+	mod.AddPackage(pkg)
+
+	// A file extended through the model
+	file := module.NewFile(filepath.Join(dir, "demo.go"), "demo.go", false)
+	file.AddConstant(&module.Constant{Name: "A", Value: "1", IsExported: true})
+	file.AddConstant(&module.Constant{Name: "B", Value: "2", IsExported: true})
+	pkg.AddFile(file)
+
+	// An unmodified file
+	other := module.NewFile(filepath.Join(dir, "other.go"), "other.go", false)
+	other.SourceCode = "package demo\n"
+	pkg.AddFile(other)
+	other.IsModified = false
+
+	// A new file in a new package
+	sub := module.NewPackage("sub", "example.com/demo/sub", filepath.Join(dir, "sub"))
+	mod.AddPackage(sub)
+	created := module.NewFile(filepath.Join(dir, "sub", "sub.go"), "sub.go", false)
+	created.SourceCode = "package sub\n"
+	sub.AddFile(created)
+	created.IsModified = false
+
+	var patch strings.Builder
+	if err := NewGoModuleSaver().SavePatch(mod, &patch); err != nil {
+		t.Fatalf("SavePatch failed: %v", err)
+	}
+
+	want := `diff --git a/demo.go b/demo.go
+--- a/demo.go
++++ b/demo.go
+@@ -1,3 +1,5 @@
+ package demo
+` + " " + `
+ const A = 1
++
++const B = 2
+diff --git a/sub/sub.go b/sub/sub.go
+new file mode 100644
+--- /dev/null
++++ b/sub/sub.go
+@@ -0,0 +1 @@
++package sub
+`
+	if patch.String() != want {
+		t.Fatalf("unexpected patch:\n%s\nwant:\n%s", patch.String(), want)
+	}
+
+	// The files on disk are untouched
+	content, err := os.ReadFile(filepath.Join(dir, "demo.go"))
+	if err != nil || string(content) != original {
+		t.Fatalf("SavePatch modified demo.go: %q, %v", content, err)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	cmd := exec.Command("git", "apply", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed: %v\n%s", err, out)
+	}
+	content, err = os.ReadFile(filepath.Join(dir, "demo.go"))
+	if err != nil || string(content) != "package demo\n\nconst A = 1\n\nconst B = 2\n" {
+		t.Errorf("unexpected demo.go after applying the patch: %q, %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub", "sub.go")); err != nil {
+		t.Errorf("sub/sub.go was not created: %v", err)
+	}
+}
+
+func TestUnifiedHunks(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+	changed := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	got := unifiedHunks(splitLines([]byte(old)), splitLines([]byte(changed)), 3)
+	want := `@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,4 +8,4 @@
+ h
+ i
+ j
+-k
+\ No newline at end of file
++k
+`
+	if got != want {
+		t.Errorf("unexpected hunks:\n%s\nwant:\n%s", got, want)
+	}
+}
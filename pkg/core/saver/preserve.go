@@ -0,0 +1,120 @@
+package saver
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// originalTypes holds the type declarations of a file's original source,
+// so declarations the model did not change can be written as they were
+type originalTypes struct {
+	source string
+	fset   *token.FileSet
+	specs  map[string]originalType
+}
+
+// originalType is a type spec with the declaration containing it
+type originalType struct {
+	spec *ast.TypeSpec
+	decl *ast.GenDecl
+}
+
+// parseOriginalTypes parses the type declarations of a file's source; it
+// returns nil if the file has no source or the source does not parse
+func parseOriginalTypes(file *module.File) *originalTypes {
+	if file.SourceCode == "" {
+		return nil
+	}
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, file.Name, file.SourceCode, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	original := &originalTypes{source: file.SourceCode, fset: fset, specs: make(map[string]originalType)}
+	for _, decl := range astFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			original.specs[typeSpec.Name.Name] = originalType{spec: typeSpec, decl: genDecl}
+		}
+	}
+	return original
+}
+
+// unchangedStruct returns the original source of a struct type, with its
+// doc comment, field tags and comments, if the model's type still matches
+// it
+func (o *originalTypes) unchangedStruct(t *module.Type) (string, bool) {
+	if o == nil || t.Kind != "struct" {
+		return "", false
+	}
+	orig, ok := o.specs[t.Name]
+	if !ok || orig.spec.TypeParams != nil || orig.spec.Assign.IsValid() {
+		return "", false
+	}
+	structType, ok := orig.spec.Type.(*ast.StructType)
+	if !ok || !fieldsMatch(t.Fields, structType.Fields) {
+		return "", false
+	}
+
+	doc := orig.spec.Doc
+	if len(orig.decl.Specs) == 1 && orig.decl.Doc != nil {
+		doc = orig.decl.Doc
+	}
+	if t.Doc != "" && (doc == nil || doc.Text() != t.Doc) {
+		return "", false
+	}
+
+	// A spec of a grouped declaration is written as a declaration of its own
+	text := "type " + o.text(orig.spec.Pos(), orig.spec.End())
+	if doc != nil {
+		text = o.text(doc.Pos(), doc.End()) + "\n" + text
+	}
+	return text, true
+}
+
+// fieldsMatch reports whether the fields of the model are those of a
+// struct's field list
+func fieldsMatch(fields []*module.Field, list *ast.FieldList) bool {
+	i := 0
+	for _, field := range list.List {
+		names := []string{""}
+		if len(field.Names) > 0 {
+			names = names[:0]
+			for _, ident := range field.Names {
+				names = append(names, ident.Name)
+			}
+		}
+		tag := ""
+		if field.Tag != nil {
+			tag = field.Tag.Value
+		}
+		for _, name := range names {
+			if i >= len(fields) {
+				return false
+			}
+			f := fields[i]
+			i++
+			if f.Name != name || f.IsEmbedded != (name == "") || f.Type != types.ExprString(field.Type) || f.Tag != tag {
+				return false
+			}
+			if f.Doc != "" && (field.Doc == nil || field.Doc.Text() != f.Doc) {
+				return false
+			}
+		}
+	}
+	return i == len(fields)
+}
+
+// text returns the original source between two positions
+func (o *originalTypes) text(pos, end token.Pos) string {
+	return o.source[o.fset.Position(pos).Offset:o.fset.Position(end).Offset]
+}
@@ -2,6 +2,8 @@
 package saver
 
 import (
+	"io"
+
 	"bitspark.dev/go-tree/pkg/core/module"
 )
 
@@ -30,6 +32,15 @@ type SaveOptions struct {
 
 	// Save only modified files
 	OnlyModified bool
+
+	// Whether to generate output that depends only on the module's content:
+	// generated files get canonically sorted imports and declarations in
+	// name order, and runs of blank lines are collapsed in all Go files
+	Deterministic bool
+
+	// Name of the generator to mention in a "Code generated ... DO NOT EDIT."
+	// header prepended to each written file (empty means no header)
+	GeneratedBy string
 }
 
 // DefaultSaveOptions returns the default save options
@@ -59,4 +70,7 @@ type ModuleSaver interface {
 
 	// SaveToWithOptions writes a module to a new location with custom options
 	SaveToWithOptions(module *module.Module, dir string, options SaveOptions) error
+
+	// SavePatch writes the changes saving a module would make as a diff
+	SavePatch(module *module.Module, w io.Writer) error
 }
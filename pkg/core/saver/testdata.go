@@ -0,0 +1,76 @@
+package saver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// CopyTestData copies the package's testdata files from its original
+// directory into pkgDir, preserving their relative layout. Tests read these
+// files relative to the package directory, so they must travel with the
+// sources whenever a package is materialized elsewhere. Copying onto the
+// package's own directory is a no-op.
+func CopyTestData(pkg *module.Package, pkgDir string) error {
+	files, err := pkg.TestDataFiles()
+	if err != nil {
+		return fmt.Errorf("failed to list testdata of %s: %w", pkg.ImportPath, err)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	src, err := filepath.Abs(pkg.Dir)
+	if err != nil {
+		return err
+	}
+	dst, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return err
+	}
+	if src == dst {
+		return nil
+	}
+
+	for _, rel := range files {
+		if err := copyFile(filepath.Join(src, rel), filepath.Join(dst, rel)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", rel, err)
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies a single file, creating parent directories as needed
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -48,9 +48,13 @@ type ModuleWalker struct {
 	// IncludePrivate determines whether to visit unexported elements
 	IncludePrivate bool
 
-	// IncludeTests determines whether to visit test files
+	// IncludeTests determines whether to visit test files in addition to
+	// production code; it widens ScopeProduction to ScopeAll
 	IncludeTests bool
 
+	// Scope selects production code, test code or both
+	Scope module.Scope
+
 	// IncludeGenerated determines whether to visit generated files
 	IncludeGenerated bool
 }
@@ -83,7 +87,7 @@ func (w *ModuleWalker) Walk(mod *module.Module) error {
 // walkPackage traverses a package and its elements
 func (w *ModuleWalker) walkPackage(pkg *module.Package) error {
 	// Skip test packages if not included
-	if pkg.IsTest && !w.IncludeTests {
+	if pkg.IsTest && w.scope() == module.ScopeProduction {
 		return nil
 	}
 
@@ -103,6 +107,9 @@ func (w *ModuleWalker) walkPackage(pkg *module.Package) error {
 		if !w.IncludePrivate && !typ.IsExported {
 			continue
 		}
+		if !w.scope().Includes(typ.File) {
+			continue
+		}
 		if err := w.walkType(typ); err != nil {
 			return err
 		}
@@ -113,6 +120,9 @@ func (w *ModuleWalker) walkPackage(pkg *module.Package) error {
 		if !w.IncludePrivate && !fn.IsExported {
 			continue
 		}
+		if !w.scope().Includes(fn.File) {
+			continue
+		}
 		if err := w.Visitor.VisitFunction(fn); err != nil {
 			return err
 		}
@@ -123,6 +133,9 @@ func (w *ModuleWalker) walkPackage(pkg *module.Package) error {
 		if !w.IncludePrivate && !variable.IsExported {
 			continue
 		}
+		if !w.scope().Includes(variable.File) {
+			continue
+		}
 		if err := w.Visitor.VisitVariable(variable); err != nil {
 			return err
 		}
@@ -133,6 +146,9 @@ func (w *ModuleWalker) walkPackage(pkg *module.Package) error {
 		if !w.IncludePrivate && !constant.IsExported {
 			continue
 		}
+		if !w.scope().Includes(constant.File) {
+			continue
+		}
 		if err := w.Visitor.VisitConstant(constant); err != nil {
 			return err
 		}
@@ -143,8 +159,8 @@ func (w *ModuleWalker) walkPackage(pkg *module.Package) error {
 
 // walkFile traverses a file and its imports
 func (w *ModuleWalker) walkFile(file *module.File) error {
-	// Skip test files if not included
-	if file.IsTest && !w.IncludeTests {
+	// Skip files outside the walked scope
+	if !w.scope().Includes(file) {
 		return nil
 	}
 
@@ -199,6 +215,14 @@ func (w *ModuleWalker) walkType(typ *module.Type) error {
 	return nil
 }
 
+// scope returns the effective scope of the walk
+func (w *ModuleWalker) scope() module.Scope {
+	if w.Scope == module.ScopeProduction && w.IncludeTests {
+		return module.ScopeAll
+	}
+	return w.Scope
+}
+
 // isExported checks if a name is exported (starts with uppercase)
 func isExported(name string) bool {
 	if name == "" {
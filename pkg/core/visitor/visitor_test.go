@@ -0,0 +1,75 @@
+package visitor
+
+import (
+	"sort"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// functionCollector records the names of visited functions
+type functionCollector struct {
+	DefaultVisitor
+	names []string
+}
+
+func (c *functionCollector) VisitFunction(fn *module.Function) error {
+	c.names = append(c.names, fn.Name)
+	return nil
+}
+
+func TestModuleWalkerScope(t *testing.T) {
+	mod := module.NewModule("example.com/scoped", "")
+	pkg := module.NewPackage("scoped", "example.com/scoped", "")
+	mod.AddPackage(pkg)
+
+	for _, f := range []struct {
+		file   string
+		isTest bool
+		fn     string
+	}{
+		{"scoped.go", false, "Serve"},
+		{"scoped_test.go", true, "NewFixture"},
+	} {
+		file := module.NewFile("/scoped/"+f.file, f.file, f.isTest)
+		pkg.AddFile(file)
+		fn := module.NewFunction(f.fn, true, false)
+		file.AddFunction(fn)
+		pkg.AddFunction(fn)
+	}
+
+	walk := func(configure func(w *ModuleWalker)) []string {
+		collector := &functionCollector{}
+		walker := NewModuleWalker(collector)
+		configure(walker)
+		if err := walker.Walk(mod); err != nil {
+			t.Fatalf("Walk failed: %v", err)
+		}
+		sort.Strings(collector.names)
+		return collector.names
+	}
+
+	tests := []struct {
+		name      string
+		configure func(w *ModuleWalker)
+		want      []string
+	}{
+		{"default", func(w *ModuleWalker) {}, []string{"Serve"}},
+		{"include tests", func(w *ModuleWalker) { w.IncludeTests = true }, []string{"NewFixture", "Serve"}},
+		{"tests only", func(w *ModuleWalker) { w.Scope = module.ScopeTests }, []string{"NewFixture"}},
+		{"all", func(w *ModuleWalker) { w.Scope = module.ScopeAll }, []string{"NewFixture", "Serve"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := walk(tt.configure)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
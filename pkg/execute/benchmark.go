@@ -0,0 +1,171 @@
+package execute
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// BenchmarkOptions configures a benchmark run
+type BenchmarkOptions struct {
+	// Pattern selects the benchmarks to run, as for -bench ("." if empty)
+	Pattern string
+
+	// BenchTime is the time or iteration count per benchmark, as for
+	// -benchtime, e.g. "2s" or "100x" (the go test default if empty)
+	BenchTime string
+
+	// BenchMem reports memory allocations, as -benchmem
+	BenchMem bool
+
+	// Count runs each benchmark this many times (once if zero)
+	Count int
+
+	// Flags are additional flags passed to go test
+	Flags []string
+}
+
+// BenchmarkReading is a line of benchmark output: the measurements of one
+// run of a benchmark
+type BenchmarkReading struct {
+	// Package containing the benchmark
+	Package string
+
+	// Benchmark name, with sub-benchmarks as "BenchmarkParent/sub"
+	Name string
+
+	// GOMAXPROCS the benchmark ran with
+	Procs int
+
+	// Number of iterations measured
+	Iterations int64
+
+	// Time per iteration in nanoseconds
+	NsPerOp float64
+
+	// Bytes allocated per iteration (only with BenchMem)
+	BytesPerOp int64
+
+	// Allocations per iteration (only with BenchMem)
+	AllocsPerOp int64
+
+	// Other metrics reported with b.ReportMetric or -benchmem, by unit,
+	// e.g. "MB/s"
+	Metrics map[string]float64
+}
+
+// BenchmarkResult contains the result of running benchmarks
+type BenchmarkResult struct {
+	// Package pattern that was benchmarked
+	Package string
+
+	// Readings in the order they were reported
+	Readings []BenchmarkReading
+
+	// Benchmark output
+	Output string
+
+	// Error if any occurred during execution, e.g. a failed benchmark
+	Error error
+}
+
+// ExecuteBenchmarks runs the benchmarks of a package, without its tests,
+// and parses their results
+func (g *GoExecutor) ExecuteBenchmarks(mod *module.Module, pkgPath string, opts BenchmarkOptions) (*BenchmarkResult, error) {
+	if mod == nil {
+		return nil, errors.New("module cannot be nil")
+	}
+	targetPkg := pkgPath
+	if targetPkg == "" {
+		targetPkg = "./..."
+	}
+	pattern := opts.Pattern
+	if pattern == "" {
+		pattern = "."
+	}
+
+	args := []string{"test", "-run=^$", "-bench=" + pattern}
+	if opts.BenchTime != "" {
+		args = append(args, "-benchtime="+opts.BenchTime)
+	}
+	if opts.BenchMem {
+		args = append(args, "-benchmem")
+	}
+	if opts.Count > 0 {
+		args = append(args, "-count="+strconv.Itoa(opts.Count))
+	}
+	args = append(args, opts.Flags...)
+	args = append(args, targetPkg)
+
+	execResult, err := g.Execute(mod, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &BenchmarkResult{
+		Package:  targetPkg,
+		Readings: parseBenchmarkOutput(execResult.StdOut),
+		Output:   execResult.StdOut + execResult.StdErr,
+		Error:    execResult.Error,
+	}, nil
+}
+
+// parseBenchmarkOutput parses the result lines of go test -bench output.
+// The package of each reading is taken from the preceding "pkg:" line.
+func parseBenchmarkOutput(output string) []BenchmarkReading {
+	var readings []BenchmarkReading
+	pkg := ""
+	for _, line := range strings.Split(output, "\n") {
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		if reading, ok := parseBenchmarkLine(line); ok {
+			reading.Package = pkg
+			readings = append(readings, reading)
+		}
+	}
+	return readings
+}
+
+// parseBenchmarkLine parses a result line such as
+// "BenchmarkAdd-8   1000000   1052 ns/op   48 B/op   2 allocs/op"
+func parseBenchmarkLine(line string) (BenchmarkReading, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+		return BenchmarkReading{}, false
+	}
+	iterations, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return BenchmarkReading{}, false
+	}
+
+	reading := BenchmarkReading{Name: fields[0], Procs: 1, Iterations: iterations}
+	if i := strings.LastIndexByte(fields[0], '-'); i >= 0 {
+		if procs, err := strconv.Atoi(fields[0][i+1:]); err == nil {
+			reading.Name, reading.Procs = fields[0][:i], procs
+		}
+	}
+
+	for i := 2; i+1 < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return BenchmarkReading{}, false
+		}
+		switch unit := fields[i+1]; unit {
+		case "ns/op":
+			reading.NsPerOp = value
+		case "B/op":
+			reading.BytesPerOp = int64(value)
+		case "allocs/op":
+			reading.AllocsPerOp = int64(value)
+		default:
+			if reading.Metrics == nil {
+				reading.Metrics = make(map[string]float64)
+			}
+			reading.Metrics[unit] = value
+		}
+	}
+	return reading, true
+}
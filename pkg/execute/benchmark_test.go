@@ -0,0 +1,86 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestParseBenchmarkOutput(t *testing.T) {
+	output := `goos: linux
+goarch: amd64
+pkg: example.com/calc
+cpu: Some CPU @ 2.00GHz
+BenchmarkAdd-8          	1000000000	         0.2500 ns/op
+BenchmarkConcat/short-8 	  5000000	       240.5 ns/op	      48 B/op	       2 allocs/op
+BenchmarkCopy           	    10000	    105000 ns/op	 952.38 MB/s
+PASS
+ok  	example.com/calc	3.012s
+pkg: example.com/calc/sub
+BenchmarkSub-4          	      100	  12000000 ns/op
+--- FAIL: BenchmarkBroken
+`
+	readings := parseBenchmarkOutput(output)
+	want := []BenchmarkReading{
+		{Package: "example.com/calc", Name: "BenchmarkAdd", Procs: 8, Iterations: 1000000000, NsPerOp: 0.25},
+		{Package: "example.com/calc", Name: "BenchmarkConcat/short", Procs: 8, Iterations: 5000000, NsPerOp: 240.5, BytesPerOp: 48, AllocsPerOp: 2},
+		{Package: "example.com/calc", Name: "BenchmarkCopy", Procs: 1, Iterations: 10000, NsPerOp: 105000, Metrics: map[string]float64{"MB/s": 952.38}},
+		{Package: "example.com/calc/sub", Name: "BenchmarkSub", Procs: 4, Iterations: 100, NsPerOp: 12000000},
+	}
+	if len(readings) != len(want) {
+		t.Fatalf("Expected %d readings, got %+v", len(want), readings)
+	}
+	for i, r := range readings {
+		w := want[i]
+		if r.Package != w.Package || r.Name != w.Name || r.Procs != w.Procs || r.Iterations != w.Iterations ||
+			r.NsPerOp != w.NsPerOp || r.BytesPerOp != w.BytesPerOp || r.AllocsPerOp != w.AllocsPerOp ||
+			len(r.Metrics) != len(w.Metrics) || r.Metrics["MB/s"] != w.Metrics["MB/s"] {
+			t.Errorf("Reading %d: expected %+v, got %+v", i, w, r)
+		}
+	}
+}
+
+func TestExecuteBenchmarks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.21\n",
+		"calc_test.go": `package calc
+
+import "testing"
+
+var sink []byte
+
+func TestNotRun(t *testing.T) { t.Fatal("tests must not run") }
+
+func BenchmarkAlloc(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sink = make([]byte, 64)
+	}
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod := module.NewModule("example.com/calc", dir)
+
+	result, err := NewGoExecutor().ExecuteBenchmarks(mod, "./...", BenchmarkOptions{BenchTime: "10x", BenchMem: true, Count: 2})
+	if err != nil {
+		t.Fatalf("ExecuteBenchmarks failed: %v", err)
+	}
+	if result.Error != nil {
+		t.Fatalf("Benchmarks failed: %v\n%s", result.Error, result.Output)
+	}
+	if len(result.Readings) != 2 {
+		t.Fatalf("Expected 2 readings, got %+v", result.Readings)
+	}
+	for _, r := range result.Readings {
+		if r.Package != "example.com/calc" || r.Name != "BenchmarkAlloc" || r.Iterations != 10 || r.BytesPerOp != 64 || r.AllocsPerOp != 1 {
+			t.Errorf("Unexpected reading %+v", r)
+		}
+	}
+}
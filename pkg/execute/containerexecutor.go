@@ -0,0 +1,266 @@
+package execute
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+)
+
+// ContainerExecutor implements ModuleExecutor by running the Go toolchain
+// inside a container, isolating untrusted module code from the host. The
+// module directory is bind-mounted read-only into the container if the
+// module is unchanged since loading; modules with unsaved edits, and
+// in-memory modules without a directory on disk, are materialized to a
+// temporary directory first. The build cache lives in the container's /tmp.
+// Functions cannot be called in a container, ExecuteFunc returns
+// ErrFuncNotSupported.
+type ContainerExecutor struct {
+	// Runtime is the container CLI to invoke (defaults to "docker";
+	// any Docker-compatible CLI such as "podman" works)
+	Runtime string
+
+	// Image is the container image providing the Go toolchain
+	Image string
+
+	// Memory limits the container's memory, e.g. "512m" (empty means no limit)
+	Memory string
+
+	// CPUs limits the number of CPUs, e.g. "1.5" (empty means no limit)
+	CPUs string
+
+	// Network is the container network mode (defaults to "none")
+	Network string
+
+	// ModuleCacheDir is a host module cache to mount read-only at
+	// /go/pkg/mod so dependencies resolve without network access (empty
+	// means no mount)
+	ModuleCacheDir string
+
+	// AdditionalEnv contains additional environment variables for the container
+	AdditionalEnv []string
+
+	// AdditionalArgs are extra arguments passed to the runtime's run command
+	AdditionalArgs []string
+
+	// TempBaseDir is the base directory for materializing in-memory modules
+	TempBaseDir string
+
+	// JSONOutput runs tests with -json and parses the structured event stream
+	JSONOutput bool
+}
+
+// ErrFuncNotSupported is returned by ContainerExecutor.ExecuteFunc, as
+// calling a function needs its result back in the host process
+var ErrFuncNotSupported = errors.New("function execution is not supported in containers")
+
+// containerWorkDir is where the module is mounted inside the container
+const containerWorkDir = "/src"
+
+// containerBuildCache is the scratch GOCACHE inside the container, as the
+// mounted directories are read-only
+const containerBuildCache = "/tmp/go-build"
+
+// NewContainerExecutor creates a new container executor using the given image
+func NewContainerExecutor(image string) *ContainerExecutor {
+	return &ContainerExecutor{
+		Runtime: "docker",
+		Image:   image,
+		Network: "none",
+	}
+}
+
+// Execute runs a go command on the module inside a container
+func (c *ContainerExecutor) Execute(mod *module.Module, args ...string) (ExecutionResult, error) {
+	if mod == nil {
+		return ExecutionResult{}, errors.New("module cannot be nil")
+	}
+	if c.Image == "" {
+		return ExecutionResult{}, errors.New("container image must be set")
+	}
+
+	dir, cleanup, err := c.moduleDir(mod)
+	if err != nil {
+		return ExecutionResult{}, err
+	}
+	defer cleanup()
+
+	runtime := c.Runtime
+	if runtime == "" {
+		runtime = "docker"
+	}
+
+	cmd := exec.Command(runtime, c.runArgs(dir, args)...)
+
+	// Capture output
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	result := ExecutionResult{
+		Command: "go " + strings.Join(args, " "),
+		StdOut:  stdout.String(),
+		StdErr:  stderr.String(),
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			// The runtime itself could not be started
+			return result, fmt.Errorf("failed to run container runtime %s: %w", runtime, err)
+		}
+		result.Error = err
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	return result, nil
+}
+
+// ExecuteTest runs tests for a package in the module inside a container
+func (c *ContainerExecutor) ExecuteTest(mod *module.Module, pkgPath string, testFlags ...string) (TestResult, error) {
+	targetPkg := pkgPath
+	if targetPkg == "" {
+		targetPkg = "./..."
+	}
+
+	if c.JSONOutput && !containsFlag(testFlags, "-json") {
+		testFlags = append([]string{"-json"}, testFlags...)
+	}
+	args := append([]string{"test"}, testFlags...)
+	args = append(args, targetPkg)
+
+	execResult, err := c.Execute(mod, args...)
+	if err != nil {
+		return TestResult{}, err
+	}
+
+	return newTestResult(targetPkg, execResult, nil, testFlags), nil
+}
+
+// ExecuteFunc always fails with ErrFuncNotSupported
+func (c *ContainerExecutor) ExecuteFunc(mod *module.Module, funcPath string, args ...interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("%w: %s", ErrFuncNotSupported, funcPath)
+}
+
+// runArgs builds the runtime arguments for running go with args on dir
+func (c *ContainerExecutor) runArgs(dir string, args []string) []string {
+	network := c.Network
+	if network == "" {
+		network = "none"
+	}
+
+	runArgs := []string{
+		"run", "--rm",
+		"--network", network,
+		"-v", dir + ":" + containerWorkDir + ":ro",
+		"-w", containerWorkDir,
+		"-e", "GOCACHE=" + containerBuildCache,
+	}
+	if c.Memory != "" {
+		runArgs = append(runArgs, "--memory", c.Memory)
+	}
+	if c.CPUs != "" {
+		runArgs = append(runArgs, "--cpus", c.CPUs)
+	}
+	if c.ModuleCacheDir != "" {
+		runArgs = append(runArgs, "-v", c.ModuleCacheDir+":/go/pkg/mod:ro")
+	}
+	for _, env := range c.AdditionalEnv {
+		runArgs = append(runArgs, "-e", env)
+	}
+	runArgs = append(runArgs, c.AdditionalArgs...)
+	runArgs = append(runArgs, c.Image, "go")
+
+	return append(runArgs, args...)
+}
+
+// moduleDir returns an absolute host directory containing the module: its
+// directory on disk if it has no unsaved edits, or a temporary directory it
+// is materialized to otherwise
+func (c *ContainerExecutor) moduleDir(mod *module.Module) (string, func(), error) {
+	noop := func() {}
+
+	onDisk := false
+	if mod.Dir != "" {
+		_, err := os.Stat(filepath.Join(mod.Dir, "go.mod"))
+		onDisk = err == nil
+	}
+	if onDisk && !hasUnsavedEdits(mod) {
+		dir, err := filepath.Abs(mod.Dir)
+		if err != nil {
+			return "", noop, fmt.Errorf("failed to resolve module directory: %w", err)
+		}
+		return dir, noop, nil
+	}
+
+	tmp := &TmpExecutor{TempBaseDir: c.TempBaseDir}
+	tempDir, err := tmp.createTempDir(mod)
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove temp directory %s: %v\n", tempDir, err)
+		}
+	}
+
+	if onDisk {
+		err = materializeEdited(mod, tempDir)
+	} else {
+		_, err = tmp.saveToTemp(mod, tempDir)
+	}
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("failed to save module to temp directory: %w", err)
+	}
+
+	dir, err := filepath.Abs(tempDir)
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("failed to resolve module directory: %w", err)
+	}
+	return dir, cleanup, nil
+}
+
+// hasUnsavedEdits reports whether a package or file of the module changed
+// since it was loaded
+func hasUnsavedEdits(mod *module.Module) bool {
+	for _, pkg := range mod.Packages {
+		if pkg.IsModified {
+			return true
+		}
+		for _, file := range pkg.Files {
+			if file.IsModified || file.IsSourceEdited {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// materializeEdited writes a module loaded from disk, including its unsaved
+// edits, to dir with the module saver, keeping the go.sum of the module's
+// directory so its dependencies still verify. Files the model does not
+// hold, such as embedded assets, are not written.
+func materializeEdited(mod *module.Module, dir string) error {
+	if err := saver.NewGoModuleSaver().SaveTo(mod, dir); err != nil {
+		return err
+	}
+	sum, err := os.ReadFile(filepath.Join(mod.Dir, "go.sum"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read go.sum: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "go.sum"), sum, 0600)
+}
@@ -0,0 +1,150 @@
+package execute
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestContainerExecutor_RunArgs(t *testing.T) {
+	executor := NewContainerExecutor("golang:1.23")
+	executor.Memory = "512m"
+	executor.CPUs = "2"
+	executor.ModuleCacheDir = "/home/user/go/pkg/mod"
+	executor.AdditionalEnv = []string{"CGO_ENABLED=0"}
+
+	args := executor.runArgs("/work/mod", []string{"test", "-v", "./..."})
+	got := strings.Join(args, " ")
+
+	expected := "run --rm --network none -v /work/mod:/src:ro -w /src -e GOCACHE=/tmp/go-build " +
+		"--memory 512m --cpus 2 -v /home/user/go/pkg/mod:/go/pkg/mod:ro -e CGO_ENABLED=0 golang:1.23 go test -v ./..."
+	if got != expected {
+		t.Errorf("Unexpected run arguments:\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+func TestContainerExecutor_MaterializesInMemoryModule(t *testing.T) {
+	mod := module.NewModule("example.com/inmem", "")
+	mod.GoVersion = "1.21"
+	pkg := module.NewPackage("util", "example.com/inmem/util", "")
+	file := module.NewFile("util.go", "util.go", false)
+	file.SourceCode = "package util\n"
+	pkg.AddFile(file)
+	mod.AddPackage(pkg)
+
+	executor := NewContainerExecutor("golang:1.23")
+	executor.TempBaseDir = t.TempDir()
+
+	dir, cleanup, err := executor.moduleDir(mod)
+	if err != nil {
+		t.Fatalf("moduleDir failed: %v", err)
+	}
+
+	if !filepath.IsAbs(dir) {
+		t.Errorf("Expected absolute directory, got %s", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "util", "util.go")); err != nil {
+		t.Errorf("Expected materialized source file: %v", err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary directory to be removed")
+	}
+}
+
+func TestContainerExecutor_MaterializesUnsavedEdits(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/edited\n\ngo 1.21\n",
+		"go.sum":       "",
+		"calc/calc.go": "package calc\n\nfunc Double(n int) int { return n * 2 }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	executor := NewContainerExecutor("golang:1.23")
+	executor.TempBaseDir = t.TempDir()
+
+	// An unchanged module is mounted where it is
+	mounted, cleanup, err := executor.moduleDir(mod)
+	if err != nil {
+		t.Fatalf("moduleDir failed: %v", err)
+	}
+	cleanup()
+	if abs, _ := filepath.Abs(dir); mounted != abs {
+		t.Errorf("Expected the module directory %s to be mounted, got %s", abs, mounted)
+	}
+
+	edited := "package calc\n\nfunc Double(n int) int { return n + n }\n"
+	mod.Packages["example.com/edited/calc"].Files["calc.go"].SetSource(edited)
+
+	materialized, cleanup, err := executor.moduleDir(mod)
+	if err != nil {
+		t.Fatalf("moduleDir failed: %v", err)
+	}
+	defer cleanup()
+	if strings.HasPrefix(materialized, dir) {
+		t.Fatalf("Expected an edited module to be materialized, got %s", materialized)
+	}
+	content, err := os.ReadFile(filepath.Join(materialized, "calc", "calc.go"))
+	if err != nil || string(content) != edited {
+		t.Errorf("Expected the edited source in the mounted directory, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(materialized, "go.sum")); err != nil {
+		t.Errorf("Expected go.sum to be kept: %v", err)
+	}
+}
+
+func TestContainerExecutor_ExecuteFunc(t *testing.T) {
+	_, err := NewContainerExecutor("golang:1.23").ExecuteFunc(module.NewModule("example.com/m", ""), "example.com/m.F")
+	if !errors.Is(err, ErrFuncNotSupported) {
+		t.Errorf("Expected ErrFuncNotSupported, got %v", err)
+	}
+}
+
+func TestContainerExecutor_ExecuteTest(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker not available")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skip("docker daemon not reachable")
+	}
+
+	testDir, err := createTestModule(t)
+	if err != nil {
+		t.Fatalf("Failed to create test module: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(testDir); err != nil {
+			t.Logf("Warning: failed to remove test directory %s: %v", testDir, err)
+		}
+	}()
+
+	mod := &module.Module{Path: "example.com/testmod", Dir: testDir}
+
+	result, err := NewContainerExecutor("golang:1.23").ExecuteTest(mod, "./...", "-v")
+	if err != nil {
+		t.Fatalf("ExecuteTest failed: %v", err)
+	}
+	if result.Failed > 0 || result.Passed == 0 {
+		t.Errorf("Expected passing tests, got %d passed and %d failed:\n%s", result.Passed, result.Failed, result.Output)
+	}
+}
@@ -0,0 +1,274 @@
+package execute
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// CoveringTest is a test function that exercises a symbol
+type CoveringTest struct {
+	Package string // Import path of the package to pass to go test
+	Name    string // Test function name
+	File    string // Path of the test file
+}
+
+// FindTestsCovering returns the Test functions of the module that
+// transitively reference the symbol, sorted by package and name. The
+// module's packages are type-checked together with their test files, and a
+// test covers the symbol if the symbol can be reached from it through
+// references between functions, methods, types and package variables.
+// Referencing a type counts as referencing its methods, so calls through
+// interfaces are covered as long as the concrete type is referenced.
+func FindTestsCovering(mod *module.Module, sym *module.Symbol) ([]CoveringTest, error) {
+	if mod == nil || mod.Dir == "" {
+		return nil, errors.New("module must be loaded from a directory")
+	}
+	if sym == nil {
+		return nil, errors.New("symbol cannot be nil")
+	}
+
+	config := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps | packages.NeedForTest,
+		Dir:        mod.Dir,
+		Tests:      true,
+		BuildFlags: []string{"-tags=" + strings.Join(mod.BuildTags, ",")},
+	}
+	pkgs, err := packages.Load(config, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages with tests: %w", err)
+	}
+
+	// Edges from each declaration to the declarations it refers to, keyed
+	// like symbol IDs so the variants of a package under test coincide
+	refs := make(map[string]map[string]bool)
+	addRef := func(from, to string) {
+		if refs[from] == nil {
+			refs[from] = make(map[string]bool)
+		}
+		refs[from][to] = true
+	}
+	tests := make(map[string]CoveringTest)
+
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("failed to load package %s: %v", pkg.ID, pkg.Errors[0])
+		}
+		if pkg.TypesInfo == nil || strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		info := pkg.TypesInfo
+		for _, file := range pkg.Syntax {
+			isTest := strings.HasSuffix(pkg.Fset.Position(file.Pos()).Filename, "_test.go")
+			for _, decl := range file.Decls {
+				for _, from := range declKeys(info, decl) {
+					for _, to := range referencedKeys(info, decl) {
+						if from != "" && to != from {
+							addRef(from, to)
+						}
+					}
+				}
+
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				if fn.Recv != nil {
+					if method, ok := info.Defs[fn.Name].(*types.Func); ok {
+						if recv := receiverKey(method); recv != "" {
+							addRef(recv, objectKey(method))
+						}
+					}
+					continue
+				}
+				if isTest && module.ClassifyTestFunction(fn) == module.TestKindTest {
+					testPkg := pkg.PkgPath
+					if pkg.ForTest != "" {
+						testPkg = pkg.ForTest
+					}
+					key := objectKey(info.Defs[fn.Name])
+					tests[key] = CoveringTest{
+						Package: testPkg,
+						Name:    fn.Name.Name,
+						File:    pkg.Fset.Position(fn.Pos()).Filename,
+					}
+				}
+			}
+		}
+	}
+
+	// Walk the references backwards from the symbol
+	reverse := make(map[string][]string)
+	for from, tos := range refs {
+		for to := range tos {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+	target := strings.TrimSuffix(sym.ID, "[test]")
+	seen := map[string]bool{target: true}
+	queue := []string{target}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, from := range reverse[key] {
+			if !seen[from] {
+				seen[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+
+	var covering []CoveringTest
+	for key, test := range tests {
+		if seen[key] {
+			covering = append(covering, test)
+		}
+	}
+	sort.Slice(covering, func(i, j int) bool {
+		if covering[i].Package != covering[j].Package {
+			return covering[i].Package < covering[j].Package
+		}
+		return covering[i].Name < covering[j].Name
+	})
+	return covering, nil
+}
+
+// RunTestsCovering runs only the tests that cover the symbol, as found by
+// FindTestsCovering, with one go test invocation per package whose -run
+// pattern names only that package's covering tests, so a test of another
+// package with the same name is not run. The results are combined into
+// one, whose error joins the errors of the invocations. If no test covers
+// the symbol, nothing is run and the result is empty.
+func (g *GoExecutor) RunTestsCovering(mod *module.Module, sym *module.Symbol) (TestResult, error) {
+	covering, err := FindTestsCovering(mod, sym)
+	if err != nil {
+		return TestResult{}, err
+	}
+	if len(covering) == 0 {
+		return TestResult{}, nil
+	}
+
+	var pkgs []string
+	names := make(map[string][]string)
+	for _, test := range covering {
+		if _, seen := names[test.Package]; !seen {
+			pkgs = append(pkgs, test.Package)
+		}
+		names[test.Package] = append(names[test.Package], regexp.QuoteMeta(test.Name))
+	}
+
+	combined := TestResult{Package: strings.Join(pkgs, " ")}
+	var errs []error
+	for _, pkg := range pkgs {
+		testFlags := []string{"-run", "^(" + strings.Join(names[pkg], "|") + ")$"}
+		if g.JSONOutput {
+			testFlags = append([]string{"-json"}, testFlags...)
+		}
+		execResult, err := g.Execute(mod, append(append([]string{"test"}, testFlags...), pkg)...)
+		result := newTestResult(pkg, execResult, err, testFlags)
+
+		combined.Tests = append(combined.Tests, result.Tests...)
+		combined.Passed += result.Passed
+		combined.Failed += result.Failed
+		combined.Skipped += result.Skipped
+		combined.TimedOut += result.TimedOut
+		combined.Results = append(combined.Results, result.Results...)
+		combined.Output += result.Output
+		combined.Races = append(combined.Races, result.Races...)
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pkg, result.Error))
+		}
+	}
+	combined.Error = errors.Join(errs...)
+	return combined, nil
+}
+
+// declKeys returns the keys of the package-level objects a declaration
+// declares
+func declKeys(info *types.Info, decl ast.Decl) []string {
+	var keys []string
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Name.Name != "init" {
+			keys = append(keys, objectKey(info.Defs[d.Name]))
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				keys = append(keys, objectKey(info.Defs[s.Name]))
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					keys = append(keys, objectKey(info.Defs[name]))
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// referencedKeys returns the keys of the package-level objects and methods
+// a declaration refers to
+func referencedKeys(info *types.Info, decl ast.Decl) []string {
+	var keys []string
+	ast.Inspect(decl, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if key := objectKey(info.Uses[ident]); key != "" {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
+
+// objectKey returns the symbol ID of a package-level object or method, or
+// an empty string for other objects
+func objectKey(obj types.Object) string {
+	if obj == nil || obj.Pkg() == nil {
+		return ""
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		fn = fn.Origin()
+		if recv := receiverKey(fn); recv != "" {
+			return recv + "." + fn.Name()
+		}
+		if fn.Type().(*types.Signature).Recv() != nil {
+			return ""
+		}
+		obj = fn
+	}
+	if obj.Parent() != obj.Pkg().Scope() {
+		return ""
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// receiverKey returns the symbol ID of a method's named receiver type, or
+// an empty string for functions and interface methods
+func receiverKey(fn *types.Func) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || types.IsInterface(named) {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
@@ -0,0 +1,114 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestRunTestsCovering(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/shapes\n\ngo 1.21\n",
+		"shapes.go": `package shapes
+
+func Scale(n int) int { return n * 2 }
+
+type Square struct{ Side int }
+
+func (s Square) Area() int { return Scale(s.Side) * s.Side }
+
+func Perimeter(side int) int { return 4 * side }
+`,
+		"shapes_test.go": `package shapes
+
+import "testing"
+
+func TestScale(t *testing.T) {
+	if Scale(2) != 4 {
+		t.Fail()
+	}
+}
+
+func TestArea(t *testing.T) {
+	if (Square{Side: 1}).Area() != 2 {
+		t.Fail()
+	}
+}
+
+func TestPerimeter(t *testing.T) {
+	if Perimeter(1) != 4 {
+		t.Fail()
+	}
+}
+
+func TestTotal(t *testing.T) {
+	t.Fatal("shares its name with a covering test of another package")
+}
+`,
+		"report/report.go":      "package report\n\nimport \"example.com/shapes\"\n\nfunc Total(side int) int { return shapes.Square{Side: side}.Area() }\n",
+		"report/export_test.go": "package report_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/shapes/report\"\n)\n\nfunc TestTotal(t *testing.T) {\n\tif report.Total(1) != 2 {\n\t\tt.Fail()\n\t}\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	var scale, perimeter *module.Symbol
+	for _, sym := range mod.Symbols() {
+		switch sym.ID {
+		case "example.com/shapes.Scale":
+			scale = sym
+		case "example.com/shapes.Perimeter":
+			perimeter = sym
+		}
+	}
+	if scale == nil || perimeter == nil {
+		t.Fatal("Expected Scale and Perimeter symbols")
+	}
+
+	covering, err := FindTestsCovering(mod, scale)
+	if err != nil {
+		t.Fatalf("FindTestsCovering failed: %v", err)
+	}
+	var names []string
+	for _, test := range covering {
+		names = append(names, test.Package+"."+test.Name)
+	}
+	expected := "example.com/shapes.TestArea,example.com/shapes.TestScale,example.com/shapes/report.TestTotal"
+	if got := strings.Join(names, ","); got != expected {
+		t.Errorf("Expected covering tests %s, got %s", expected, got)
+	}
+
+	executor := NewGoExecutor()
+	executor.JSONOutput = true
+	result, err := executor.RunTestsCovering(mod, perimeter)
+	if err != nil {
+		t.Fatalf("RunTestsCovering failed: %v", err)
+	}
+	if result.Error != nil || result.Passed != 1 || strings.Join(result.Tests, ",") != "TestPerimeter" {
+		t.Errorf("Expected only TestPerimeter to run, got %+v", result)
+	}
+
+	// Each package runs only its own covering tests
+	result, err = executor.RunTestsCovering(mod, scale)
+	if err != nil {
+		t.Fatalf("RunTestsCovering failed: %v", err)
+	}
+	if result.Error != nil || result.Passed != 3 || result.Failed != 0 {
+		t.Errorf("Expected the 3 covering tests to pass, got %+v", result)
+	}
+}
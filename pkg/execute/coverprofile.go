@@ -0,0 +1,256 @@
+package execute
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// CoverageBlock is a block of statements of a cover profile
+type CoverageBlock struct {
+	File      string // File as import path and name, e.g. "example.com/m/pkg/file.go"
+	StartLine int    // Line of the block start
+	StartCol  int    // Column of the block start
+	EndLine   int    // Line of the block end
+	EndCol    int    // Column of the block end
+	NumStmt   int    // Number of statements in the block
+	Count     int    // Times the block ran; 0 or 1 in set mode
+}
+
+// CoverageProfile is a cover profile as written by go test -coverprofile
+type CoverageProfile struct {
+	Mode   string          // Cover mode: "set", "count" or "atomic"
+	Blocks []CoverageBlock // Blocks sorted by file and position
+}
+
+// ExecuteCoverage runs the tests of a package with a cover profile and
+// parses the profile. The test result is returned along with it, as tests
+// that fail still produce a profile. The cover mode is "set" unless
+// testFlags choose another.
+func (g *GoExecutor) ExecuteCoverage(mod *module.Module, pkgPath string, testFlags ...string) (*CoverageProfile, TestResult, error) {
+	if mod == nil {
+		return nil, TestResult{}, errors.New("module cannot be nil")
+	}
+
+	tempDir, err := os.MkdirTemp("", "gotree-cover-")
+	if err != nil {
+		return nil, TestResult{}, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	profilePath := filepath.Join(tempDir, "coverage.out")
+	flags := []string{"-coverprofile=" + profilePath}
+	if !hasFlagPrefix(testFlags, "-covermode") && !containsFlag(testFlags, "-race") {
+		flags = append(flags, "-covermode=set")
+	}
+	result, err := g.ExecuteTest(mod, pkgPath, append(flags, testFlags...)...)
+	if err != nil {
+		return nil, result, err
+	}
+
+	file, err := os.Open(profilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, result, fmt.Errorf("no cover profile written: %v\n%s", result.Error, result.Output)
+		}
+		return nil, result, fmt.Errorf("failed to open cover profile: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	profile, err := ParseCoverageProfile(file)
+	if err != nil {
+		return nil, result, err
+	}
+	return profile, result, nil
+}
+
+// ParseCoverageProfile parses a cover profile. Blocks listed more than once,
+// as for packages covered by several test binaries, are merged.
+func ParseCoverageProfile(r io.Reader) (*CoverageProfile, error) {
+	profile := &CoverageProfile{}
+	index := make(map[CoverageBlock]int)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if line == 1 {
+			mode, ok := strings.CutPrefix(text, "mode: ")
+			if !ok {
+				return nil, fmt.Errorf("line 1: missing mode line")
+			}
+			profile.Mode = mode
+			continue
+		}
+
+		block, err := parseCoverageBlock(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		key := block
+		key.Count = 0
+		if i, ok := index[key]; ok {
+			if profile.Mode == "set" {
+				profile.Blocks[i].Count = max(profile.Blocks[i].Count, block.Count)
+			} else {
+				profile.Blocks[i].Count += block.Count
+			}
+			continue
+		}
+		index[key] = len(profile.Blocks)
+		profile.Blocks = append(profile.Blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cover profile: %w", err)
+	}
+	if line == 0 {
+		return nil, errors.New("empty cover profile")
+	}
+
+	sort.SliceStable(profile.Blocks, func(i, j int) bool {
+		a, b := profile.Blocks[i], profile.Blocks[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.StartCol < b.StartCol
+	})
+	return profile, nil
+}
+
+// parseCoverageBlock parses a block line of the form
+// "file.go:startLine.startCol,endLine.endCol numStmt count"
+func parseCoverageBlock(text string) (CoverageBlock, error) {
+	colon := strings.LastIndexByte(text, ':')
+	if colon < 0 {
+		return CoverageBlock{}, fmt.Errorf("invalid block %q", text)
+	}
+	fields := strings.Fields(text[colon+1:])
+	if len(fields) != 3 {
+		return CoverageBlock{}, fmt.Errorf("invalid block %q", text)
+	}
+	start, end, ok := strings.Cut(fields[0], ",")
+	if !ok {
+		return CoverageBlock{}, fmt.Errorf("invalid block range %q", fields[0])
+	}
+
+	block := CoverageBlock{File: text[:colon]}
+	var err error
+	if block.StartLine, block.StartCol, err = parseLineCol(start); err != nil {
+		return CoverageBlock{}, err
+	}
+	if block.EndLine, block.EndCol, err = parseLineCol(end); err != nil {
+		return CoverageBlock{}, err
+	}
+	if block.NumStmt, err = strconv.Atoi(fields[1]); err != nil {
+		return CoverageBlock{}, fmt.Errorf("invalid statement count %q", fields[1])
+	}
+	if block.Count, err = strconv.Atoi(fields[2]); err != nil {
+		return CoverageBlock{}, fmt.Errorf("invalid hit count %q", fields[2])
+	}
+	return block, nil
+}
+
+// parseLineCol parses a "line.col" position
+func parseLineCol(s string) (int, int, error) {
+	lineText, colText, ok := strings.Cut(s, ".")
+	line, err1 := strconv.Atoi(lineText)
+	col, err2 := strconv.Atoi(colText)
+	if !ok || err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid position %q", s)
+	}
+	return line, col, nil
+}
+
+// WriteTo writes the profile in the format of go test -coverprofile, as
+// read by go tool cover and coverage services
+func (p *CoverageProfile) WriteTo(w io.Writer) (int64, error) {
+	mode := p.Mode
+	if mode == "" {
+		mode = "set"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "mode: %s\n", mode)
+	for _, block := range p.Blocks {
+		fmt.Fprintf(&b, "%s:%d.%d,%d.%d %d %d\n", block.File,
+			block.StartLine, block.StartCol, block.EndLine, block.EndCol, block.NumStmt, block.Count)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Percent returns the percentage of statements run at least once
+func (p *CoverageProfile) Percent() float64 {
+	total, covered := 0, 0
+	for _, block := range p.Blocks {
+		total += block.NumStmt
+		if block.Count > 0 {
+			covered += block.NumStmt
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+// UncoveredFunctions returns the functions and methods of the module whose
+// statements in the profile were never run, sorted by ID. Blocks are matched
+// to declarations by file and position; functions without statements or
+// outside the profile are not reported.
+func (p *CoverageProfile) UncoveredFunctions(mod *module.Module) []*module.Symbol {
+	byFile := make(map[string][]CoverageBlock)
+	for _, block := range p.Blocks {
+		byFile[block.File] = append(byFile[block.File], block)
+	}
+
+	var uncovered []*module.Symbol
+	for _, sym := range mod.Symbols() {
+		fn, ok := sym.Element.(*module.Function)
+		if !ok || sym.File == nil || sym.File.FileSet == nil || !fn.Pos.IsValid() {
+			continue
+		}
+		start, end := sym.File.FileSet.Position(fn.Pos), sym.File.FileSet.Position(fn.End)
+		statements, covered := 0, false
+		for _, block := range byFile[sym.Package+"/"+sym.File.Name] {
+			if before(block.StartLine, block.StartCol, start.Line, start.Column) ||
+				before(end.Line, end.Column, block.EndLine, block.EndCol) {
+				continue
+			}
+			statements += block.NumStmt
+			covered = covered || block.Count > 0
+		}
+		if statements > 0 && !covered {
+			uncovered = append(uncovered, sym)
+		}
+	}
+	return uncovered
+}
+
+// before reports whether the position line1.col1 precedes line2.col2
+func before(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || line1 == line2 && col1 < col2
+}
+
+// hasFlagPrefix reports whether a flag starting with prefix is present
+func hasFlagPrefix(args []string, prefix string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, prefix) {
+			return true
+		}
+	}
+	return false
+}
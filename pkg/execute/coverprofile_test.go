@@ -0,0 +1,122 @@
+package execute
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestExecuteCoverage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.21\n",
+		"calc.go": `package calc
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Div(a, b int) int {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+type Acc struct{ n int }
+
+func (a *Acc) Reset() {
+	a.n = 0
+}
+
+func Noop() {}
+`,
+		"calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+	if Div(4, 2) != 2 {
+		t.Fatal("wrong quotient")
+	}
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	profile, result, err := NewGoExecutor().ExecuteCoverage(mod, "./...")
+	if err != nil {
+		t.Fatalf("ExecuteCoverage failed: %v\n%s", err, result.Output)
+	}
+	if profile.Mode != "set" || len(profile.Blocks) == 0 {
+		t.Fatalf("Unexpected profile %+v", profile)
+	}
+	for _, block := range profile.Blocks {
+		if block.File != "example.com/calc/calc.go" {
+			t.Errorf("Unexpected block file %s", block.File)
+		}
+	}
+	if percent := profile.Percent(); percent <= 0 || percent >= 100 {
+		t.Errorf("Expected partial coverage, got %.1f%%", percent)
+	}
+
+	var ids []string
+	for _, sym := range profile.UncoveredFunctions(mod) {
+		ids = append(ids, sym.ID)
+	}
+	// Div is partially covered and Noop has no statements
+	if got := strings.Join(ids, ","); got != "example.com/calc.Acc.Reset" {
+		t.Errorf("Unexpected uncovered functions %s", got)
+	}
+
+	// The written profile reads back the same
+	var buf bytes.Buffer
+	if _, err := profile.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "mode: set\nexample.com/calc/calc.go:") {
+		t.Errorf("Unexpected profile output:\n%s", buf.String())
+	}
+	reread, err := ParseCoverageProfile(&buf)
+	if err != nil || len(reread.Blocks) != len(profile.Blocks) {
+		t.Errorf("Failed to read back the profile: %v", err)
+	}
+}
+
+func TestParseCoverageProfile(t *testing.T) {
+	profile, err := ParseCoverageProfile(strings.NewReader("mode: count\n" +
+		"example.com/m/b.go:1.10,3.2 2 1\n" +
+		"example.com/m/a.go:5.1,6.2 1 0\n" +
+		"example.com/m/b.go:1.10,3.2 2 3\n"))
+	if err != nil {
+		t.Fatalf("ParseCoverageProfile failed: %v", err)
+	}
+	want := []CoverageBlock{
+		{File: "example.com/m/a.go", StartLine: 5, StartCol: 1, EndLine: 6, EndCol: 2, NumStmt: 1, Count: 0},
+		{File: "example.com/m/b.go", StartLine: 1, StartCol: 10, EndLine: 3, EndCol: 2, NumStmt: 2, Count: 4},
+	}
+	if profile.Mode != "count" || len(profile.Blocks) != 2 || profile.Blocks[0] != want[0] || profile.Blocks[1] != want[1] {
+		t.Errorf("Unexpected profile %+v", profile)
+	}
+
+	for _, invalid := range []string{"", "example.com/m/a.go:1.1,2.2 1 1\n", "mode: set\na.go:1.1 1 1\n", "mode: set\na.go:1.x,2.2 1 1\n"} {
+		if _, err := ParseCoverageProfile(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package execute
+
+import (
+	"errors"
+	"fmt"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// Example function outcomes
+const (
+	// ExamplePassed marks an example whose output matched
+	ExamplePassed = "pass"
+
+	// ExampleFailed marks an example whose output differed, that panicked,
+	// or whose package did not compile
+	ExampleFailed = "fail"
+
+	// ExampleCompiled marks an example without an output comment, which
+	// go test compiles but does not run
+	ExampleCompiled = "compiled"
+)
+
+// ExampleFunc is an Example function of a test file
+type ExampleFunc struct {
+	Package   string // Import path of the package to pass to go test
+	Name      string // Function name, e.g. "ExampleUser_Login"
+	File      string // Path of the test file
+	Output    string // Expected output, from the "// Output:" comment
+	Unordered bool   // Whether the comment was "// Unordered output:"
+	HasOutput bool   // Whether there is an output comment, possibly empty
+}
+
+// ExampleFuncResult is the outcome of running an example
+type ExampleFuncResult struct {
+	ExampleFunc
+
+	// One of ExamplePassed, ExampleFailed or ExampleCompiled
+	Status string
+
+	// Output the example printed, if it failed
+	Got string
+
+	// Output of go test for the example, or for its package if it did not
+	// compile
+	Output string
+}
+
+// FindExampleFuncs returns the Example functions in the test files of the
+// module, sorted by package and name, with their expected output as go test
+// reads it. Test files excluded by the module's build tags are skipped.
+func FindExampleFuncs(mod *module.Module) ([]ExampleFunc, error) {
+	if mod == nil || mod.Dir == "" {
+		return nil, errors.New("module must be loaded from a directory")
+	}
+
+	config := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedForTest,
+		Dir:        mod.Dir,
+		Tests:      true,
+		BuildFlags: []string{"-tags=" + strings.Join(mod.BuildTags, ",")},
+	}
+	pkgs, err := packages.Load(config, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages with tests: %w", err)
+	}
+
+	// Test files are listed by both variants of a package under test
+	seen := make(map[string]bool)
+	var examples []ExampleFunc
+	fset := token.NewFileSet()
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue
+		}
+		testPkg := pkg.PkgPath
+		if pkg.ForTest != "" {
+			testPkg = pkg.ForTest
+		}
+		for _, path := range pkg.GoFiles {
+			if !strings.HasSuffix(path, "_test.go") || seen[path] {
+				continue
+			}
+			seen[path] = true
+			file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			for _, ex := range doc.Examples(file) {
+				examples = append(examples, ExampleFunc{
+					Package:   testPkg,
+					Name:      "Example" + ex.Name,
+					File:      path,
+					Output:    ex.Output,
+					Unordered: ex.Unordered,
+					HasOutput: ex.Output != "" || ex.EmptyOutput,
+				})
+			}
+		}
+	}
+
+	sort.Slice(examples, func(i, j int) bool {
+		if examples[i].Package != examples[j].Package {
+			return examples[i].Package < examples[j].Package
+		}
+		return examples[i].Name < examples[j].Name
+	})
+	return examples, nil
+}
+
+// ExecuteExampleFuncs runs examples with go test, one run per package, and
+// reports the outcome of each. go test compares the output; examples
+// without an output comment are only compiled.
+func (g *GoExecutor) ExecuteExampleFuncs(mod *module.Module, examples []ExampleFunc) ([]ExampleFuncResult, error) {
+	if mod == nil {
+		return nil, errors.New("module cannot be nil")
+	}
+
+	var pkgPaths []string
+	byPkg := make(map[string][]ExampleFunc)
+	for _, ex := range examples {
+		if byPkg[ex.Package] == nil {
+			pkgPaths = append(pkgPaths, ex.Package)
+		}
+		byPkg[ex.Package] = append(byPkg[ex.Package], ex)
+	}
+
+	var results []ExampleFuncResult
+	for _, pkgPath := range pkgPaths {
+		var names []string
+		for _, ex := range byPkg[pkgPath] {
+			if ex.HasOutput {
+				names = append(names, regexp.QuoteMeta(ex.Name))
+			}
+		}
+		run := "^$"
+		if len(names) > 0 {
+			run = "^(" + strings.Join(names, "|") + ")$"
+		}
+
+		testResult, err := g.ExecuteTest(mod, pkgPath, "-json", "-run="+run)
+		if err != nil {
+			return nil, err
+		}
+		outcomes := make(map[string]TestCaseResult, len(testResult.Results))
+		for _, r := range testResult.Results {
+			outcomes[r.Name] = r
+		}
+		buildFailed := buildFailed(testResult.Output)
+
+		for _, ex := range byPkg[pkgPath] {
+			result := ExampleFuncResult{ExampleFunc: ex, Status: ExampleFailed}
+			outcome, ran := outcomes[ex.Name]
+			switch {
+			case ran:
+				result.Output = outcome.Output
+				if outcome.Action == "pass" {
+					result.Status = ExamplePassed
+				} else {
+					result.Got = exampleGot(outcome.Output)
+				}
+			case buildFailed || ex.HasOutput:
+				result.Output = testResult.Output
+			default:
+				result.Status = ExampleCompiled
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// buildFailed reports whether go test output says a package could not be
+// built or set up
+func buildFailed(output string) bool {
+	return strings.Contains(output, "[build failed]") || strings.Contains(output, "[setup failed]")
+}
+
+// exampleGot extracts the output a failed example printed from the "got:"
+// section go test reports
+func exampleGot(output string) string {
+	_, got, ok := strings.Cut(output, "got:\n")
+	if !ok {
+		return ""
+	}
+	got, _, _ = strings.Cut(got, "want:\n")
+	return strings.TrimSuffix(got, "\n")
+}
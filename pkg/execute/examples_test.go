@@ -0,0 +1,103 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestExecuteExampleFuncs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":      "module example.com/greet\n\ngo 1.21\n",
+		"greet.go":    "package greet\n\nfunc Hello(name string) string { return \"Hello, \" + name }\n",
+		"broken/b.go": "package broken\n\nfunc Value() int { return 1 }\n",
+		"broken/b_test.go": `package broken
+
+func ExampleValue() {
+	Value("one")
+}
+`,
+		"example_test.go": `package greet_test
+
+import (
+	"fmt"
+
+	"example.com/greet"
+)
+
+func ExampleHello() {
+	fmt.Println(greet.Hello("Gopher"))
+	// Output: Hello, Gopher
+}
+
+func ExampleHello_wrong() {
+	fmt.Println(greet.Hello("World"))
+	// Output: Hello, Gopher
+}
+
+func ExampleHello_unordered() {
+	fmt.Println(greet.Hello("b"))
+	fmt.Println(greet.Hello("a"))
+	// Unordered output:
+	// Hello, a
+	// Hello, b
+}
+
+func ExampleHello_compileOnly() {
+	greet.Hello("nobody")
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod := module.NewModule("example.com/greet", dir)
+
+	examples, err := FindExampleFuncs(mod)
+	if err != nil {
+		t.Fatalf("FindExampleFuncs failed: %v", err)
+	}
+	if len(examples) != 5 {
+		t.Fatalf("Expected 5 examples, got %+v", examples)
+	}
+	byName := make(map[string]ExampleFunc)
+	for _, ex := range examples {
+		byName[ex.Name] = ex
+	}
+	if ex := byName["ExampleHello_unordered"]; !ex.Unordered || !ex.HasOutput || ex.Package != "example.com/greet" {
+		t.Errorf("Unexpected unordered example: %+v", ex)
+	}
+	if ex := byName["ExampleHello_compileOnly"]; ex.HasOutput {
+		t.Errorf("Expected no output comment: %+v", ex)
+	}
+
+	results, err := NewGoExecutor().ExecuteExampleFuncs(mod, examples)
+	if err != nil {
+		t.Fatalf("ExecuteExampleFuncs failed: %v", err)
+	}
+	expected := map[string]string{
+		"ExampleHello":             ExamplePassed,
+		"ExampleHello_wrong":       ExampleFailed,
+		"ExampleHello_unordered":   ExamplePassed,
+		"ExampleHello_compileOnly": ExampleCompiled,
+		"ExampleValue":             ExampleFailed, // broken does not compile
+	}
+	for _, r := range results {
+		if r.Status != expected[r.Name] {
+			t.Errorf("Expected %s to %s, got %s\n%s", r.Name, expected[r.Name], r.Status, r.Output)
+		}
+		if r.Name == "ExampleHello_wrong" && r.Got != "Hello, World" {
+			t.Errorf("Expected the printed output to be reported, got %q", r.Got)
+		}
+	}
+
+}
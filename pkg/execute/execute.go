@@ -37,9 +37,22 @@ type TestResult struct {
 	// Tests that failed
 	Failed int
 
+	// Tests that were skipped (only known for -json output)
+	Skipped int
+
+	// Tests that were stopped for exceeding their timeout, also counted as
+	// failed (only known for supervised runs)
+	TimedOut int
+
+	// Per-test outcomes, including subtests (only populated for -json output)
+	Results []TestCaseResult
+
 	// Test output
 	Output string
 
+	// Data races detected in all tests (only with -race)
+	Races []RaceReport
+
 	// Error if any occurred during execution
 	Error error
 }
@@ -0,0 +1,422 @@
+package execute
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/types"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// funcDir is the module-relative directory function calls are compiled in;
+// it only exists in the build overlay
+const funcDir = "gotree_func"
+
+// ExecuteFunc calls a package-level function of the module, identified by
+// its symbol ID such as "example.com/calc.Add". Arguments are converted to
+// the declared parameter types: strings are parsed for numeric and boolean
+// parameters and decoded as JSON for structs, slices, arrays and maps, and
+// other values are passed through their JSON encoding. The function's
+// package is loaded again with type information if the module has none.
+// The function is
+// called from a generated program built with an overlay in the module
+// directory; its results are returned decoded from JSON, a single value for
+// one result and a slice for several, with a non-nil error result returned
+// as the error.
+func (g *GoExecutor) ExecuteFunc(module *module.Module, funcPath string, args ...interface{}) (interface{}, error) {
+	if module == nil || module.Dir == "" {
+		return nil, errors.New("module must be loaded from a directory")
+	}
+	sym, pkg, err := loadFuncSymbol(module, funcPath)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sym.Signature()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := convertArgs(funcPath, sig, args)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "gotree-func-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	target := filepath.Join(module.Dir, funcDir, "main.go")
+	sourcePath := filepath.Join(workDir, "main.go")
+	if err := os.WriteFile(sourcePath, funcProgram(pkg.ImportPath, sym.Name, encoded), 0600); err != nil {
+		return nil, err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {target: sourcePath}})
+	if err != nil {
+		return nil, err
+	}
+	overlayPath := filepath.Join(workDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0600); err != nil {
+		return nil, err
+	}
+
+	binary := filepath.Join(workDir, "call")
+	build, err := g.Execute(module, "build", "-overlay", overlayPath, "-o", binary, "./"+funcDir)
+	if err != nil {
+		return nil, err
+	}
+	if build.Error != nil {
+		return nil, fmt.Errorf("failed to build call of %s: %s", funcPath, strings.TrimSpace(build.StdErr))
+	}
+
+	resultPath := filepath.Join(workDir, "result.json")
+	cmd := exec.Command(binary, resultPath)
+	cmd.Dir = module.Dir
+	if g.WorkingDir != "" {
+		cmd.Dir = g.WorkingDir
+	}
+	cmd.Env = append(os.Environ(), g.AdditionalEnv...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", funcPath, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", funcPath, err)
+	}
+
+	data, err := os.ReadFile(resultPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results of %s: %w", funcPath, err)
+	}
+	var results []interface{}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode results of %s: %w", funcPath, err)
+	}
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
+
+// loadFuncSymbol returns the symbol of a callable package-level function
+// and its package, loading the package again with type information if the
+// module has none
+func loadFuncSymbol(module *module.Module, funcPath string) (*module.Symbol, *module.Package, error) {
+	sym, pkg, err := findFuncSymbol(module, funcPath)
+	if err != nil || pkg.TypesPackage != nil {
+		return sym, pkg, err
+	}
+	// Parameter types are needed to convert the arguments
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	options.BuildTags = module.BuildTags
+	options.PackagePaths = []string{pkg.ImportPath}
+	typed, err := loader.NewGoModuleLoader().LoadWithOptions(module.Dir, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load types of %s: %w", pkg.ImportPath, err)
+	}
+	return findFuncSymbol(typed, funcPath)
+}
+
+// findFuncSymbol returns the symbol of a callable package-level function
+// and its package
+func findFuncSymbol(mod *module.Module, funcPath string) (*module.Symbol, *module.Package, error) {
+	for _, sym := range mod.Symbols() {
+		if sym.ID != funcPath {
+			continue
+		}
+		if sym.Kind != module.SymbolFunction {
+			return nil, nil, fmt.Errorf("%s is a %s, only package-level functions can be called", funcPath, sym.Kind)
+		}
+		pkg := mod.Packages[sym.Package]
+		if pkg == nil || pkg.Name == "main" {
+			return nil, nil, fmt.Errorf("%s is not in an importable package", funcPath)
+		}
+		if fn, ok := sym.Element.(*module.Function); ok && !fn.IsExported {
+			return nil, nil, fmt.Errorf("%s is not exported", funcPath)
+		}
+		if pkg.TypesPackage != nil {
+			if obj, ok := pkg.TypesPackage.Scope().Lookup(sym.Name).(*types.Func); ok {
+				if sig, ok := obj.Type().(*types.Signature); ok && sig.TypeParams().Len() > 0 {
+					return nil, nil, fmt.Errorf("%s is generic and cannot be called", funcPath)
+				}
+			}
+		}
+		return sym, pkg, nil
+	}
+	return nil, nil, fmt.Errorf("function %s not found", funcPath)
+}
+
+// convertArgs converts the arguments of a call to the JSON encoding of the
+// declared parameter types
+func convertArgs(funcPath string, sig *module.FuncSignature, args []interface{}) ([]string, error) {
+	n := len(sig.Params)
+	if (!sig.Variadic && len(args) != n) || (sig.Variadic && len(args) < n-1) {
+		want := strconv.Itoa(n)
+		if sig.Variadic {
+			want = "at least " + strconv.Itoa(n-1)
+		}
+		return nil, fmt.Errorf("%s takes %s arguments, got %d", funcPath, want, len(args))
+	}
+
+	encoded := make([]string, len(args))
+	for i, arg := range args {
+		param := sig.Params[min(i, n-1)]
+		typ, rendered := paramType(param)
+		data, err := convertArg(arg, typ)
+		if err != nil {
+			name := param.Name
+			if name == "" {
+				name = "#" + strconv.Itoa(i+1)
+			}
+			return nil, fmt.Errorf("argument %d of %s (%s %s): %w", i+1, funcPath, name, rendered, err)
+		}
+		encoded[i] = string(data)
+	}
+	return encoded, nil
+}
+
+// paramType returns the type a single argument of a parameter must have,
+// the element type for a variadic parameter, and its rendering. The type is
+// nil if the signature has no type information and the type is not
+// predeclared.
+func paramType(param module.FuncParam) (types.Type, string) {
+	rendered := param.Type
+	variadic := strings.HasPrefix(rendered, "...")
+	rendered = strings.TrimPrefix(rendered, "...")
+
+	if param.Types != nil {
+		if slice, ok := param.Types.(*types.Slice); ok && variadic {
+			return slice.Elem(), rendered
+		}
+		return param.Types, rendered
+	}
+	if name, ok := types.Universe.Lookup(rendered).(*types.TypeName); ok {
+		return name.Type(), rendered
+	}
+	return nil, rendered
+}
+
+// convertArg converts an argument to the JSON encoding of a value of the
+// type; a nil type accepts any value, and strings holding a JSON object or
+// array are passed as such
+func convertArg(arg interface{}, typ types.Type) ([]byte, error) {
+	s, isString := arg.(string)
+	if typ == nil {
+		if isString && isJSONComposite(s) {
+			return []byte(s), nil
+		}
+		return json.Marshal(arg)
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		return convertBasic(arg, t)
+	case *types.Struct, *types.Slice, *types.Array, *types.Map, *types.Pointer:
+		if isString {
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("cannot convert %q to %s: invalid JSON", s, typ)
+			}
+			return []byte(s), nil
+		}
+		return json.Marshal(arg)
+	case *types.Interface:
+		return json.Marshal(arg)
+	default:
+		return nil, fmt.Errorf("cannot pass a value of type %s", typ)
+	}
+}
+
+// convertBasic converts an argument to the JSON encoding of a value of a
+// basic type
+func convertBasic(arg interface{}, t *types.Basic) ([]byte, error) {
+	info := t.Info()
+	s, isString := arg.(string)
+	switch {
+	case info&types.IsString != 0:
+		if !isString {
+			return nil, fmt.Errorf("cannot use %T as %s", arg, t)
+		}
+		return json.Marshal(s)
+
+	case info&types.IsBoolean != 0:
+		if b, ok := arg.(bool); ok {
+			return json.Marshal(b)
+		}
+		if !isString {
+			return nil, fmt.Errorf("cannot use %T as %s", arg, t)
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to %s", s, t)
+		}
+		return json.Marshal(b)
+
+	case info&types.IsInteger != 0:
+		bits := basicBits(t)
+		if isString {
+			if info&types.IsUnsigned != 0 {
+				u, err := strconv.ParseUint(s, 10, bits)
+				if err != nil {
+					return nil, fmt.Errorf("cannot convert %q to %s", s, t)
+				}
+				return json.Marshal(u)
+			}
+			i, err := strconv.ParseInt(s, 10, bits)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to %s", s, t)
+			}
+			return json.Marshal(i)
+		}
+		f, ok := toFloat(arg)
+		if !ok || f != math.Trunc(f) || (info&types.IsUnsigned != 0 && f < 0) {
+			return nil, fmt.Errorf("cannot use %v (%T) as %s", arg, arg, t)
+		}
+		return json.Marshal(arg)
+
+	case info&types.IsFloat != 0:
+		if isString {
+			f, err := strconv.ParseFloat(s, basicBits(t))
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %q to %s", s, t)
+			}
+			return json.Marshal(f)
+		}
+		if _, ok := toFloat(arg); !ok {
+			return nil, fmt.Errorf("cannot use %T as %s", arg, t)
+		}
+		return json.Marshal(arg)
+	}
+	return nil, fmt.Errorf("cannot pass a value of type %s", t)
+}
+
+// basicBits returns the size in bits of a numeric type, 64 for the
+// platform-dependent ones
+func basicBits(t *types.Basic) int {
+	switch t.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	}
+	return 64
+}
+
+// toFloat returns the value of a numeric argument
+func toFloat(arg interface{}) (float64, bool) {
+	switch v := arg.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// isJSONComposite reports whether a string holds a JSON object or array
+func isJSONComposite(s string) bool {
+	s = strings.TrimSpace(s)
+	return (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) && json.Valid([]byte(s))
+}
+
+// funcProgram returns the source of a program calling a function with
+// JSON-encoded arguments. It writes the JSON-encoded results, without a
+// trailing error, to the file named by its first argument.
+func funcProgram(importPath, name string, args []string) []byte {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	return []byte(fmt.Sprintf(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	target %q
+)
+
+func main() {
+	fn := reflect.ValueOf(target.%s)
+	ft := fn.Type()
+	args := []string{%s}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var t reflect.Type
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			t = ft.In(ft.NumIn() - 1).Elem()
+		} else {
+			t = ft.In(i)
+		}
+		v := reflect.New(t)
+		if err := json.Unmarshal([]byte(arg), v.Interface()); err != nil {
+			fmt.Fprintf(os.Stderr, "argument %%d: %%v\n", i+1, err)
+			os.Exit(2)
+		}
+		in[i] = v.Elem()
+	}
+
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	results := []interface{}{}
+	for i, out := range fn.Call(in) {
+		if i == ft.NumOut()-1 && ft.Out(i) == errorType {
+			if !out.IsNil() {
+				fmt.Fprintln(os.Stderr, out.Interface())
+				os.Exit(1)
+			}
+			continue
+		}
+		results = append(results, out.Interface())
+	}
+
+	data, err := json.Marshal(results)
+	if err == nil {
+		err = os.WriteFile(os.Args[1], data, 0600)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+`, importPath, name, strings.Join(quoted, ", ")))
+}
@@ -0,0 +1,135 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestGoExecutor_ExecuteFunc(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.21\n",
+		"calc/calc.go": `package calc
+
+import (
+	"errors"
+	"strings"
+)
+
+// Point is a point in the plane
+type Point struct {
+	X, Y int
+}
+
+// Add adds two integers
+func Add(a, b int) int { return a + b }
+
+// Greet greets a name
+func Greet(name string) string { return "Hello, " + name }
+
+// Sum adds integers
+func Sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// Move moves a point
+func Move(p Point, dx int) Point { return Point{p.X + dx, p.Y} }
+
+// Join joins strings
+func Join(sep string, parts ...string) string { return strings.Join(parts, sep) }
+
+// Divide divides two numbers
+func Divide(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a / b, nil
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	executor := NewGoExecutor()
+
+	// Without type information the package is loaded again to get it
+	untyped, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	if _, err := executor.ExecuteFunc(untyped, "example.com/calc/calc.Move", "{X: 1}", "3"); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("Expected the argument to be checked against the struct type, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		funcPath string
+		args     []interface{}
+		want     interface{}
+	}{
+		{"int from strings", "example.com/calc/calc.Add", []interface{}{"2", "3"}, 5.0},
+		{"int", "example.com/calc/calc.Add", []interface{}{2, 3}, 5.0},
+		{"string", "example.com/calc/calc.Greet", []interface{}{"tree"}, "Hello, tree"},
+		{"slice from JSON", "example.com/calc/calc.Sum", []interface{}{"[1, 2, 3]"}, 6.0},
+		{"slice", "example.com/calc/calc.Sum", []interface{}{[]int{4, 5}}, 9.0},
+		{"struct", "example.com/calc/calc.Move", []interface{}{`{"X": 1, "Y": 2}`, "3"}, map[string]interface{}{"X": 4.0, "Y": 2.0}},
+		{"variadic", "example.com/calc/calc.Join", []interface{}{"-", "a", "b"}, "a-b"},
+		{"error result", "example.com/calc/calc.Divide", []interface{}{"1", "4"}, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executor.ExecuteFunc(mod, tt.funcPath, tt.args...)
+			if err != nil {
+				t.Fatalf("ExecuteFunc failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+
+	failures := []struct {
+		name     string
+		funcPath string
+		args     []interface{}
+		want     string
+	}{
+		{"not an int", "example.com/calc/calc.Add", []interface{}{"two", "3"}, `argument 1 of example.com/calc/calc.Add (a int): cannot convert "two" to int`},
+		{"fractional int", "example.com/calc/calc.Add", []interface{}{1.5, 3}, "cannot use 1.5 (float64) as int"},
+		{"invalid JSON", "example.com/calc/calc.Move", []interface{}{"{X: 1}", "3"}, "invalid JSON"},
+		{"argument count", "example.com/calc/calc.Add", []interface{}{"1"}, "takes 2 arguments, got 1"},
+		{"not a function", "example.com/calc/calc.Point", nil, "only package-level functions can be called"},
+		{"unknown", "example.com/calc/calc.Missing", nil, "not found"},
+		{"error result", "example.com/calc/calc.Divide", []interface{}{"1", "0"}, "division by zero"},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executor.ExecuteFunc(mod, tt.funcPath, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
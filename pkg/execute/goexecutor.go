@@ -3,7 +3,6 @@ package execute
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
@@ -22,6 +21,9 @@ type GoExecutor struct {
 
 	// WorkingDir specifies a custom working directory (defaults to module directory)
 	WorkingDir string
+
+	// JSONOutput runs tests with -json and parses the structured event stream
+	JSONOutput bool
 }
 
 // NewGoExecutor creates a new Go executor
@@ -96,24 +98,54 @@ func (g *GoExecutor) ExecuteTest(module *module.Module, pkgPath string, testFlag
 	}
 
 	// Prepare test command
+	if g.JSONOutput && !containsFlag(testFlags, "-json") {
+		testFlags = append([]string{"-json"}, testFlags...)
+	}
 	args := append([]string{"test"}, testFlags...)
 	args = append(args, targetPkg)
 
 	// Run the test command
 	execResult, err := g.Execute(module, args...)
 
-	// Parse test results
+	return newTestResult(targetPkg, execResult, err, testFlags), nil
+}
+
+// Helper functions
+
+// newTestResult builds a test result from the output of a go test command
+func newTestResult(targetPkg string, execResult ExecutionResult, err error, testFlags []string) TestResult {
 	result := TestResult{
 		Package: targetPkg,
 		Output:  execResult.StdOut + execResult.StdErr,
 		Error:   err,
 	}
 
+	// Structured output reports every test, the text format is the fallback
+	if containsFlag(testFlags, "-json") {
+		if results, output, ok := parseTestEvents(execResult.StdOut); ok {
+			result.Results = results
+			result.Output = output + execResult.StdErr
+			for _, r := range results {
+				result.Tests = append(result.Tests, r.Name)
+				switch r.Action {
+				case "pass":
+					result.Passed++
+				case "fail":
+					result.Failed++
+				case "skip":
+					result.Skipped++
+				}
+				result.Races = append(result.Races, r.Races...)
+			}
+			return result
+		}
+	}
+
 	// Count passed/failed tests
 	result.Tests = parseTestNames(execResult.StdOut)
 
 	// If we have verbose output, count passed/failed from output
-	if containsFlag(testFlags, "-v") || containsFlag(testFlags, "-json") {
+	if containsFlag(testFlags, "-v") {
 		passed, failed := countTestResults(execResult.StdOut)
 		result.Passed = passed
 		result.Failed = failed
@@ -128,25 +160,11 @@ func (g *GoExecutor) ExecuteTest(module *module.Module, pkgPath string, testFlag
 			result.Passed = len(result.Tests) - result.Failed
 		}
 	}
+	result.Races = parseRaceReports(result.Output)
 
-	return result, nil
+	return result
 }
 
-// ExecuteFunc calls a specific function in the module
-func (g *GoExecutor) ExecuteFunc(module *module.Module, funcPath string, args ...interface{}) (interface{}, error) {
-	// NOTE: This is a placeholder implementation, as properly executing a function
-	// from a module requires runtime reflection or code generation techniques
-	// that are beyond this initial implementation. In a full implementation,
-	// this would:
-	// 1. Generate a small program that imports the module
-	// 2. Call the function with the provided arguments
-	// 3. Serialize and return the results
-
-	return nil, fmt.Errorf("function execution not implemented: %s", funcPath)
-}
-
-// Helper functions
-
 // parseTestNames extracts test names from go test output
 func parseTestNames(output string) []string {
 	// Simple regex to match "--- PASS: TestName" or "--- FAIL: TestName"
@@ -0,0 +1,379 @@
+package execute
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+	"golang.org/x/tools/imports"
+)
+
+// SkipExampleMarker opts a fenced code block out of execution when it
+// follows the language in the info string, e.g. "```go norun"
+const SkipExampleMarker = "norun"
+
+// exampleTimeout bounds how long the program of a code block may run
+var exampleTimeout = time.Minute
+
+// exampleDir is the module-relative directory examples are compiled in; it
+// only exists in the build overlay
+const exampleDir = "gotree_example"
+
+// CodeBlock is a fenced code block of a markdown document
+type CodeBlock struct {
+	Line int    // Line of the opening fence
+	Info string // Info string after the opening fence, e.g. "go norun"
+	Code string // Content between the fences
+}
+
+// Language returns the first word of the info string
+func (b CodeBlock) Language() string {
+	if fields := strings.Fields(b.Info); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// Skipped reports whether the info string contains the skip marker
+func (b CodeBlock) Skipped() bool {
+	for i, field := range strings.Fields(b.Info) {
+		if i > 0 && field == SkipExampleMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// ExampleResult is the outcome of running one markdown code block
+type ExampleResult struct {
+	Block    CodeBlock // The code block
+	Source   string    // Generated program
+	Skipped  bool      // Block opted out of execution
+	Compiled bool      // Program compiled against the module
+	Passed   bool      // Program compiled and exited with status 0
+	Output   string    // Compiler output on failure, otherwise program output
+}
+
+// ExtractCodeBlocks returns the fenced code blocks of a markdown document.
+// Both backtick and tilde fences are recognized; an unterminated block
+// extends to the end of the document.
+func ExtractCodeBlocks(markdown string) []CodeBlock {
+	var blocks []CodeBlock
+	var current *CodeBlock
+	var fence, indent string
+	var body strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(markdown))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimLeft(text, " ")
+
+		if current == nil {
+			if marker := fenceMarker(trimmed); marker != "" && len(text)-len(trimmed) < 4 {
+				current = &CodeBlock{Line: line, Info: strings.TrimSpace(trimmed[len(marker):])}
+				fence, indent = marker, text[:len(text)-len(trimmed)]
+				body.Reset()
+			}
+			continue
+		}
+
+		// A closing fence is at least as long as the opening one
+		if marker := fenceMarker(trimmed); marker != "" && marker[0] == fence[0] &&
+			len(marker) >= len(fence) && strings.TrimSpace(trimmed[len(marker):]) == "" {
+			current.Code = body.String()
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+		body.WriteString(strings.TrimPrefix(text, indent))
+		body.WriteByte('\n')
+	}
+
+	if current != nil {
+		current.Code = body.String()
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// fenceMarker returns the run of three or more backticks or tildes a line
+// starts with, or an empty string
+func fenceMarker(line string) string {
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 || (line[0] == '`' && strings.Contains(line[n:], "`")) {
+		return ""
+	}
+	return line[:n]
+}
+
+// RunMarkdownExamples extracts the ```go code blocks of a markdown file and
+// runs each as a program against the module, so documented examples keep
+// compiling and working. A block that is a complete file must be package
+// main; a block with a main function but no package clause is given one;
+// any other block becomes the body of main, with leading import
+// declarations kept at file level. Missing imports, including packages of
+// the module, are added automatically.
+//
+// Programs are built with a build overlay in the module directory, which
+// therefore must exist on disk; the directory itself is not modified. A
+// program running longer than a minute is killed and fails.
+func RunMarkdownExamples(mdPath string, mod *module.Module) ([]ExampleResult, error) {
+	if mod == nil || mod.Dir == "" {
+		return nil, errors.New("module must be loaded from a directory")
+	}
+	data, err := os.ReadFile(mdPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", mdPath, err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "gotree-examples-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	executor := NewGoExecutor()
+	executor.WorkingDir = mod.Dir
+
+	var results []ExampleResult
+	for i, block := range ExtractCodeBlocks(string(data)) {
+		if block.Language() != "go" {
+			continue
+		}
+		result := ExampleResult{Block: block, Skipped: block.Skipped()}
+		if !result.Skipped {
+			if err := runExample(executor, mod, &result, filepath.Join(tempDir, fmt.Sprintf("example%d", i))); err != nil {
+				return results, fmt.Errorf("example at %s:%d: %w", mdPath, block.Line, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runExample builds the program of a code block in the module and runs it
+func runExample(executor *GoExecutor, mod *module.Module, result *ExampleResult, workDir string) error {
+	if err := os.MkdirAll(workDir, 0750); err != nil {
+		return err
+	}
+
+	target := filepath.Join(mod.Dir, exampleDir, "main.go")
+	source, err := exampleProgram(mod, result.Block.Code, target)
+	if err != nil {
+		// Not valid Go; let the compiler report it
+		source = []byte(result.Block.Code)
+	}
+	result.Source = string(source)
+
+	sourcePath := filepath.Join(workDir, "main.go")
+	if err := os.WriteFile(sourcePath, source, 0600); err != nil {
+		return err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {target: sourcePath}})
+	if err != nil {
+		return err
+	}
+	overlayPath := filepath.Join(workDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0600); err != nil {
+		return err
+	}
+
+	binary := filepath.Join(workDir, "example")
+	build, err := executor.Execute(mod, "build", "-overlay", overlayPath, "-o", binary, "./"+exampleDir)
+	if err != nil {
+		return err
+	}
+	if build.ExitCode != 0 || build.Error != nil {
+		result.Output = strings.ReplaceAll(build.StdErr, target, "main.go")
+		return nil
+	}
+	result.Compiled = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), exampleTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binary)
+	cmd.Dir = mod.Dir
+	cmd.WaitDelay = time.Second
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	result.Passed = cmd.Run() == nil
+	result.Output = output.String()
+	if ctx.Err() != nil {
+		result.Output += fmt.Sprintf("example timed out after %s\n", exampleTimeout)
+	}
+	return nil
+}
+
+// exampleProgram wraps the code of a block into a main package with its
+// imports resolved; filename is where the program will be compiled
+func exampleProgram(mod *module.Module, code, filename string) ([]byte, error) {
+	fset := token.NewFileSet()
+	var source string
+	switch file, err := parser.ParseFile(fset, "", code, parser.PackageClauseOnly); {
+	case err == nil:
+		if file.Name.Name != "main" {
+			return nil, fmt.Errorf("example is package %s, not main", file.Name.Name)
+		}
+		source = code
+	case strings.Contains(code, "func main()"):
+		source = "package main\n\n" + code
+	default:
+		decls, body := splitImports(code)
+		source = "package main\n\n" + decls + "\nfunc main() {\n" + body + "}\n"
+	}
+	source, err := addModuleImports(mod, source)
+	if err != nil {
+		return nil, err
+	}
+	return imports.Process(filename, []byte(source), nil)
+}
+
+// addModuleImports imports the packages of the module that the source
+// refers to by an unresolved qualifier. goimports resolves packages relative
+// to the working directory rather than the module, so module packages are
+// added here; imports that turn out unused are removed by goimports. If
+// several packages have the name, the first by path declaring every
+// selected name is imported. Qualifiers bound by an import of the source
+// are left to it, as are those of standard library packages if no module
+// package declares the names.
+func addModuleImports(mod *module.Module, source string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", source, 0)
+	if err != nil {
+		return "", err
+	}
+	imported := make(map[string]bool)
+	bound := make(map[string]bool)
+	for _, spec := range file.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		imported[importPath] = true
+		if spec.Name != nil {
+			bound[spec.Name.Name] = true
+		} else {
+			bound[saver.AssumedPackageName(importPath)] = true
+		}
+	}
+
+	// Selected names by unresolved qualifier
+	selected := make(map[string]map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil && !bound[ident.Name] {
+				if selected[ident.Name] == nil {
+					selected[ident.Name] = make(map[string]bool)
+				}
+				selected[ident.Name][sel.Sel.Name] = true
+			}
+		}
+		return true
+	})
+
+	candidates := make(map[string][]string)
+	for importPath, pkg := range mod.Packages {
+		if pkg.Name == "" || pkg.Name == "main" || imported[importPath] || selected[pkg.Name] == nil {
+			continue
+		}
+		candidates[pkg.Name] = append(candidates[pkg.Name], importPath)
+	}
+	var paths []string
+	for name, pkgPaths := range candidates {
+		sort.Strings(pkgPaths)
+		choice := ""
+		for _, importPath := range pkgPaths {
+			if declaresAll(mod.Packages[importPath], selected[name]) {
+				choice = importPath
+				break
+			}
+		}
+		// Let the compiler report missing names, unless goimports may
+		// find a standard library package
+		if choice == "" && !standardPackageNames()[name] {
+			choice = pkgPaths[0]
+		}
+		if choice != "" {
+			paths = append(paths, choice)
+		}
+	}
+	if len(paths) == 0 {
+		return source, nil
+	}
+	sort.Strings(paths)
+
+	var decl strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&decl, "import %q\n", path)
+	}
+	// Imports go right after the package clause
+	offset := fset.Position(file.Name.End()).Offset
+	return source[:offset] + "\n\n" + decl.String() + source[offset:], nil
+}
+
+// standardPackageNames returns the names of the standard library packages,
+// as listed by the go command once
+var standardPackageNames = sync.OnceValue(func() map[string]bool {
+	names := make(map[string]bool)
+	out, err := exec.Command("go", "list", "std").Output()
+	if err != nil {
+		return names
+	}
+	for _, importPath := range strings.Fields(string(out)) {
+		if !strings.Contains(importPath, "internal") && !strings.HasPrefix(importPath, "vendor/") {
+			names[saver.AssumedPackageName(importPath)] = true
+		}
+	}
+	return names
+})
+
+// declaresAll reports whether a package declares all of the names at
+// package level
+func declaresAll(pkg *module.Package, names map[string]bool) bool {
+	for name := range names {
+		if pkg.Functions[name] == nil && pkg.Types[name] == nil && pkg.Variables[name] == nil && pkg.Constants[name] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// splitImports separates the leading import declarations of a snippet from
+// its statements
+func splitImports(code string) (decls, body string) {
+	lines := strings.SplitAfter(code, "\n")
+	i, inBlock := 0, false
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case inBlock:
+			inBlock = line != ")"
+		case line == "" || strings.HasPrefix(line, "//"):
+		case strings.HasPrefix(line, "import"):
+			inBlock = strings.HasSuffix(line, "(")
+		default:
+			return strings.Join(lines[:i], ""), strings.Join(lines[i:], "")
+		}
+	}
+	return code, ""
+}
@@ -0,0 +1,98 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+)
+
+func TestExtractCodeBlocks(t *testing.T) {
+	blocks := ExtractCodeBlocks("# Title\n\n```go norun\nx := 1\n```\n\n  ~~~~sh\n  echo ```\n  ~~~~\n\n```go\nunterminated\n")
+
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d: %+v", len(blocks), blocks)
+	}
+	if blocks[0].Line != 3 || blocks[0].Language() != "go" || !blocks[0].Skipped() || blocks[0].Code != "x := 1\n" {
+		t.Errorf("Unexpected first block: %+v", blocks[0])
+	}
+	if blocks[1].Language() != "sh" || blocks[1].Code != "echo ```\n" {
+		t.Errorf("Unexpected second block: %+v", blocks[1])
+	}
+	if blocks[2].Code != "unterminated\n" || blocks[2].Skipped() {
+		t.Errorf("Unexpected third block: %+v", blocks[2])
+	}
+}
+
+func TestRunMarkdownExamples(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/docs\n\ngo 1.21\n",
+		"greet/greet.go": "package greet\n\n// Hello greets a name\nfunc Hello(name string) string { return \"Hello, \" + name }\n",
+		// Packages sharing a name, with a standard library package too
+		"legacy/greet/greet.go": "package greet\n\n// Wave waves\nfunc Wave() string { return \"*waves*\" }\n",
+		"internal/log/log.go":   "package log\n\n// Debug logs nothing\nfunc Debug() {}\n",
+		"README.md": "# Docs\n\n" +
+			"```go\nfmt.Println(greet.Hello(\"docs\"))\n```\n\n" +
+			"```go\nimport \"os\"\n\nos.Exit(3)\n```\n\n" +
+			"```go\nfunc main() {\n\tgreet.Goodbye()\n}\n```\n\n" +
+			"```go norun\nclient := connect()\n```\n\n" +
+			"```sh\ngo get example.com/docs\n```\n\n" +
+			"```go\nfmt.Println(greet.Wave())\n```\n\n" +
+			"```go\nlog.SetFlags(0)\nlog.Println(\"standard\")\n```\n\n" +
+			"```go\nfor {\n}\n```\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	defer func(timeout time.Duration) { exampleTimeout = timeout }(exampleTimeout)
+	exampleTimeout = 2 * time.Second
+	results, err := RunMarkdownExamples(filepath.Join(dir, "README.md"), mod)
+	if err != nil {
+		t.Fatalf("RunMarkdownExamples failed: %v", err)
+	}
+	if len(results) != 7 {
+		t.Fatalf("Expected 7 go examples, got %d", len(results))
+	}
+
+	if !results[0].Passed || results[0].Output != "Hello, docs\n" {
+		t.Errorf("Expected first example to pass, got %+v", results[0])
+	}
+	if !results[1].Compiled || results[1].Passed {
+		t.Errorf("Expected second example to compile and fail, got %+v", results[1])
+	}
+	if results[2].Compiled || !strings.Contains(results[2].Output, "Goodbye") {
+		t.Errorf("Expected third example not to compile, got %+v", results[2])
+	}
+	if !results[3].Skipped || results[3].Compiled {
+		t.Errorf("Expected fourth example to be skipped, got %+v", results[3])
+	}
+	if !results[4].Passed || results[4].Output != "*waves*\n" || strings.Count(results[4].Source, "/greet\"") != 1 {
+		t.Errorf("Expected fifth example to import the greet package declaring Wave, got %+v", results[4])
+	}
+	if !results[5].Passed || results[5].Output != "standard\n" {
+		t.Errorf("Expected sixth example to use the standard log package, got %+v", results[5])
+	}
+	if results[6].Passed || !strings.Contains(results[6].Output, "timed out") {
+		t.Errorf("Expected seventh example to time out, got %+v", results[6])
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, exampleDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected the module directory to be left untouched")
+	}
+}
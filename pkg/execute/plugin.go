@@ -0,0 +1,384 @@
+package execute
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// pluginDir is the module-relative directory plugins are compiled in; it
+// only exists in the build overlay
+const pluginDir = "gotree_plugin"
+
+// PluginExecutor implements ModuleExecutor by calling functions in-process.
+// The package of a function is compiled once into a plugin exposing its
+// exported functions, which is loaded into the running program; further
+// calls into the package need no build or process. Commands and tests, and
+// calls that cannot be made in-process, are run by the fallback executor.
+//
+// Called functions share the process: they should be pure, as a function
+// that exits, never returns or corrupts memory affects the caller. Loaded
+// plugins cannot be unloaded. A package whose source, or the source of a
+// package of the module it imports, changed is compiled again, but a plugin
+// for it can only be loaded if no other package it shares with an earlier
+// plugin changed; otherwise the call falls back.
+type PluginExecutor struct {
+	// Fallback runs commands and tests and calls functions when plugins
+	// are not supported, a parameter or result has an unexported type, or
+	// the plugin cannot be built or loaded
+	Fallback ModuleExecutor
+
+	// Builder compiles plugins; the fallback is used if it is a
+	// *GoExecutor and this is nil
+	Builder *GoExecutor
+
+	mu      sync.Mutex
+	plugins map[string]map[string]interface{} // Functions by plugin key, nil if not loadable
+}
+
+// NewPluginExecutor creates a new plugin executor falling back to a Go executor
+func NewPluginExecutor() *PluginExecutor {
+	return &PluginExecutor{
+		Fallback: NewGoExecutor(),
+		plugins:  make(map[string]map[string]interface{}),
+	}
+}
+
+// Execute runs a go command on the module with the fallback executor
+func (p *PluginExecutor) Execute(mod *module.Module, args ...string) (ExecutionResult, error) {
+	return p.Fallback.Execute(mod, args...)
+}
+
+// ExecuteTest runs tests in the module with the fallback executor
+func (p *PluginExecutor) ExecuteTest(mod *module.Module, pkgPath string, testFlags ...string) (TestResult, error) {
+	return p.Fallback.ExecuteTest(mod, pkgPath, testFlags...)
+}
+
+// ExecuteFunc calls a package-level function of the module in-process.
+// Arguments are converted and results returned as by GoExecutor.ExecuteFunc.
+func (p *PluginExecutor) ExecuteFunc(mod *module.Module, funcPath string, args ...interface{}) (interface{}, error) {
+	if mod == nil || mod.Dir == "" {
+		return nil, errors.New("module must be loaded from a directory")
+	}
+	if !pluginsSupported {
+		return p.Fallback.ExecuteFunc(mod, funcPath, args...)
+	}
+	sym, pkg, err := loadFuncSymbol(mod, funcPath)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := pkg.TypesPackage.Scope().Lookup(sym.Name).(*types.Func)
+	if !ok || hasUnexportedType(fn.Type()) {
+		return p.Fallback.ExecuteFunc(mod, funcPath, args...)
+	}
+	sig, err := sym.Signature()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := convertArgs(funcPath, sig, args)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := p.loadPlugin(mod, pkg)
+	target, ok := funcs[sym.Name]
+	if !ok {
+		return p.Fallback.ExecuteFunc(mod, funcPath, args...)
+	}
+	return callFunc(funcPath, reflect.ValueOf(target), encoded)
+}
+
+// loadPlugin returns the functions of the plugin for a package, building
+// and loading it on first use; nil if it cannot be built or loaded
+func (p *PluginExecutor) loadPlugin(mod *module.Module, pkg *module.Package) map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, err := pluginKey(mod, pkg)
+	if err != nil {
+		return nil
+	}
+	if funcs, ok := p.plugins[key]; ok {
+		return funcs
+	}
+	funcs, err := p.buildPlugin(mod, pkg)
+	if err != nil {
+		funcs = nil
+	}
+	if p.plugins == nil {
+		p.plugins = make(map[string]map[string]interface{})
+	}
+	p.plugins[key] = funcs
+	return funcs
+}
+
+// buildPlugin compiles the plugin of a package and loads it
+func (p *PluginExecutor) buildPlugin(mod *module.Module, pkg *module.Package) (map[string]interface{}, error) {
+	builder := p.Builder
+	if builder == nil {
+		if goExec, ok := p.Fallback.(*GoExecutor); ok {
+			builder = goExec
+		} else {
+			builder = NewGoExecutor()
+		}
+	}
+
+	workDir, err := os.MkdirTemp("", "gotree-plugin-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	// A loaded plugin stays mapped after its file is removed
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	target := filepath.Join(mod.Dir, pluginDir, "main.go")
+	sourcePath := filepath.Join(workDir, "main.go")
+	if err := os.WriteFile(sourcePath, pluginProgram(pkg), 0600); err != nil {
+		return nil, err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {target: sourcePath}})
+	if err != nil {
+		return nil, err
+	}
+	overlayPath := filepath.Join(workDir, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0600); err != nil {
+		return nil, err
+	}
+
+	library := filepath.Join(workDir, "funcs.so")
+	args := append([]string{"build", "-buildmode=plugin"}, hostBuildFlags()...)
+	args = append(args, "-overlay", overlayPath, "-o", library, "./"+pluginDir)
+	build, err := builder.Execute(mod, args...)
+	if err != nil {
+		return nil, err
+	}
+	if build.Error != nil {
+		return nil, fmt.Errorf("failed to build plugin of %s: %s", pkg.ImportPath, strings.TrimSpace(build.StdErr))
+	}
+	return openPluginFuncs(library)
+}
+
+// hostBuildFlags returns the flags the running binary was built with that
+// a plugin has to share to be loadable into it, such as -race
+func hostBuildFlags() []string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	var flags []string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "-race", "-msan", "-asan", "-trimpath":
+			if setting.Value == "true" {
+				flags = append(flags, setting.Key)
+			}
+		case "-tags", "-gcflags", "-asmflags":
+			flags = append(flags, setting.Key+"="+setting.Value)
+		}
+	}
+	return flags
+}
+
+// pluginKey identifies the plugin of a package by its module directory,
+// import path and the source of the package and of the packages of the
+// module it imports, directly or indirectly
+func pluginKey(mod *module.Module, pkg *module.Package) (string, error) {
+	deps, err := moduleDeps(mod, pkg)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, dep := range deps {
+		names := make([]string, 0, len(dep.Files))
+		for name, file := range dep.Files {
+			if !file.IsTest {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(h, "package %s\n", dep.ImportPath)
+		for _, name := range names {
+			data, err := os.ReadFile(dep.Files[name].Path)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s %d\n", name, len(data))
+			h.Write(data)
+		}
+	}
+	return mod.Dir + " " + pkg.ImportPath + " " + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// moduleDeps returns a package and the packages of the module it imports,
+// directly or indirectly, sorted by import path. Imports of packages of the
+// module that are not loaded are an error, as their source is unknown.
+func moduleDeps(mod *module.Module, pkg *module.Package) ([]*module.Package, error) {
+	seen := map[string]*module.Package{pkg.ImportPath: pkg}
+	queue := []*module.Package{pkg}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, file := range current.Files {
+			if file.IsTest {
+				continue
+			}
+			for _, imp := range file.Imports {
+				if _, ok := seen[imp.Path]; ok || !inModule(mod, imp.Path) {
+					continue
+				}
+				dep, ok := mod.Packages[imp.Path]
+				if !ok {
+					return nil, fmt.Errorf("package %s imported by %s is not loaded", imp.Path, current.ImportPath)
+				}
+				seen[imp.Path] = dep
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	deps := make([]*module.Package, 0, len(seen))
+	for _, dep := range seen {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].ImportPath < deps[j].ImportPath })
+	return deps, nil
+}
+
+// inModule reports whether an import path belongs to the module
+func inModule(mod *module.Module, importPath string) bool {
+	return importPath == mod.Path || strings.HasPrefix(importPath, mod.Path+"/")
+}
+
+// pluginProgram returns the source of a plugin exposing the exported,
+// non-generic functions of a package by name in its Funcs variable
+func pluginProgram(pkg *module.Package) []byte {
+	var entries strings.Builder
+	scope := pkg.TypesPackage.Scope()
+	for _, name := range scope.Names() {
+		fn, ok := scope.Lookup(name).(*types.Func)
+		if !ok || !fn.Exported() || fn.Type().(*types.Signature).TypeParams().Len() > 0 {
+			continue
+		}
+		fmt.Fprintf(&entries, "\t%s: target.%s,\n", strconv.Quote(name), name)
+	}
+	return []byte(fmt.Sprintf(`package main
+
+import target %q
+
+// Funcs are the callable functions of the package by name
+var Funcs = map[string]interface{}{
+%s}
+`, pkg.ImportPath, entries.String()))
+}
+
+// hasUnexportedType reports whether a type refers to a named type that is
+// not exported, which cannot be passed across the plugin boundary
+func hasUnexportedType(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.Named:
+		if obj := t.Obj(); obj.Pkg() != nil && !obj.Exported() {
+			return true
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if hasUnexportedType(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return hasUnexportedType(types.Unalias(t))
+	case *types.Pointer:
+		return hasUnexportedType(t.Elem())
+	case *types.Slice:
+		return hasUnexportedType(t.Elem())
+	case *types.Array:
+		return hasUnexportedType(t.Elem())
+	case *types.Chan:
+		return hasUnexportedType(t.Elem())
+	case *types.Map:
+		return hasUnexportedType(t.Key()) || hasUnexportedType(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if hasUnexportedType(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if hasUnexportedType(t.At(i).Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return hasUnexportedType(t.Params()) || hasUnexportedType(t.Results())
+	}
+	return false
+}
+
+// callFunc calls a function with JSON-encoded arguments and returns its
+// results as decoded from JSON, like the program of GoExecutor.ExecuteFunc
+func callFunc(funcPath string, fn reflect.Value, args []string) (result interface{}, err error) {
+	ft := fn.Type()
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var t reflect.Type
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			t = ft.In(ft.NumIn() - 1).Elem()
+		} else {
+			t = ft.In(i)
+		}
+		v := reflect.New(t)
+		if err := json.Unmarshal([]byte(arg), v.Interface()); err != nil {
+			return nil, fmt.Errorf("%s failed: argument %d: %v", funcPath, i+1, err)
+		}
+		in[i] = v.Elem()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("%s failed: panic: %v", funcPath, r)
+		}
+	}()
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	results := []interface{}{}
+	for i, out := range fn.Call(in) {
+		if i == ft.NumOut()-1 && ft.Out(i) == errorType {
+			if !out.IsNil() {
+				return nil, fmt.Errorf("%s failed: %v", funcPath, out.Interface())
+			}
+			continue
+		}
+		results = append(results, out.Interface())
+	}
+
+	// Round-trip through JSON for the same results as a subprocess call
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode results of %s: %w", funcPath, err)
+	}
+	var decoded []interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode results of %s: %w", funcPath, err)
+	}
+	switch len(decoded) {
+	case 0:
+		return nil, nil
+	case 1:
+		return decoded[0], nil
+	default:
+		return decoded, nil
+	}
+}
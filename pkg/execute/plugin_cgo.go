@@ -0,0 +1,28 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package execute
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// pluginsSupported reports whether plugins can be loaded on this platform
+const pluginsSupported = true
+
+// openPluginFuncs loads a plugin and returns its Funcs variable
+func openPluginFuncs(path string) (map[string]interface{}, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin: %w", err)
+	}
+	sym, err := p.Lookup("Funcs")
+	if err != nil {
+		return nil, err
+	}
+	funcs, ok := sym.(*map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plugin Funcs has unexpected type %T", sym)
+	}
+	return *funcs, nil
+}
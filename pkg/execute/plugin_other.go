@@ -0,0 +1,13 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package execute
+
+import "errors"
+
+// pluginsSupported reports that plugins cannot be loaded on this platform
+const pluginsSupported = false
+
+// openPluginFuncs fails, plugins are not supported on this platform
+func openPluginFuncs(path string) (map[string]interface{}, error) {
+	return nil, errors.New("plugins are not supported on this platform")
+}
@@ -0,0 +1,187 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// countingExecutor records the functions called through it
+type countingExecutor struct {
+	*GoExecutor
+	calls []string
+}
+
+func (c *countingExecutor) ExecuteFunc(mod *module.Module, funcPath string, args ...interface{}) (interface{}, error) {
+	c.calls = append(c.calls, funcPath)
+	return c.GoExecutor.ExecuteFunc(mod, funcPath, args...)
+}
+
+func TestPluginExecutor_ExecuteFunc(t *testing.T) {
+	if !pluginsSupported {
+		t.Skip("Plugins are not supported on this platform")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.21\n",
+		"calc/calc.go": `package calc
+
+import "errors"
+
+// Point is a point in the plane
+type Point struct {
+	X, Y int
+}
+
+type factor int
+
+// Add adds two integers
+func Add(a, b int) int { return a + b }
+
+// Move moves a point
+func Move(p Point, dx int) Point { return Point{p.X + dx, p.Y} }
+
+// Divide divides two numbers
+func Divide(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a / b, nil
+}
+
+// Scale scales by an unexported factor
+func Scale(v int, f factor) int { return v * int(f) }
+
+// Index returns an element
+func Index(values []int, i int) int { return values[i] }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	fallback := &countingExecutor{GoExecutor: NewGoExecutor()}
+	executor := NewPluginExecutor()
+	executor.Fallback = fallback
+
+	tests := []struct {
+		name     string
+		funcPath string
+		args     []interface{}
+		want     interface{}
+	}{
+		{"int", "example.com/calc/calc.Add", []interface{}{"2", "3"}, 5.0},
+		{"struct", "example.com/calc/calc.Move", []interface{}{`{"X": 1, "Y": 2}`, "3"}, map[string]interface{}{"X": 4.0, "Y": 2.0}},
+		{"error result", "example.com/calc/calc.Divide", []interface{}{"1", "4"}, 0.25},
+		{"unexported parameter type", "example.com/calc/calc.Scale", []interface{}{"2", "3"}, 6.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executor.ExecuteFunc(mod, tt.funcPath, tt.args...)
+			if err != nil {
+				t.Fatalf("ExecuteFunc failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+
+	failures := []struct {
+		name     string
+		funcPath string
+		args     []interface{}
+		want     string
+	}{
+		{"error result", "example.com/calc/calc.Divide", []interface{}{"1", "0"}, "division by zero"},
+		{"panic", "example.com/calc/calc.Index", []interface{}{"[1]", "2"}, "index out of range"},
+		{"argument count", "example.com/calc/calc.Add", []interface{}{"1"}, "takes 2 arguments, got 1"},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executor.ExecuteFunc(mod, tt.funcPath, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Only the call with an unexported parameter type falls back, and the
+	// package is compiled once
+	if want := []string{"example.com/calc/calc.Scale"}; !reflect.DeepEqual(fallback.calls, want) {
+		t.Errorf("Expected fallback calls %v, got %v", want, fallback.calls)
+	}
+	if len(executor.plugins) != 1 {
+		t.Errorf("Expected one plugin, got %d", len(executor.plugins))
+	}
+}
+
+func TestPluginExecutor_DependencyChange(t *testing.T) {
+	if !pluginsSupported {
+		t.Skip("Plugins are not supported on this platform")
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("go.mod", "module example.com/rates\n\ngo 1.21\n")
+	write("price/price.go", `package price
+
+import "example.com/rates/tax"
+
+// Gross adds tax to a net price
+func Gross(net int) int { return net + tax.Of(net) }
+`)
+	write("tax/tax.go", "package tax\n\n// Of returns the tax on an amount\nfunc Of(amount int) int { return amount / 10 }\n")
+
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	fallback := &countingExecutor{GoExecutor: NewGoExecutor()}
+	executor := NewPluginExecutor()
+	executor.Fallback = fallback
+
+	got, err := executor.ExecuteFunc(mod, "example.com/rates/price.Gross", "100")
+	if err != nil || got != 110.0 {
+		t.Fatalf("Expected 110, got %v (%v)", got, err)
+	}
+
+	// Editing only the imported package must not reuse the first plugin
+	write("tax/tax.go", "package tax\n\n// Of returns the tax on an amount\nfunc Of(amount int) int { return amount / 5 }\n")
+	got, err = executor.ExecuteFunc(mod, "example.com/rates/price.Gross", "100")
+	if err != nil || got != 120.0 {
+		t.Errorf("Expected 120 after editing tax, got %v (%v)", got, err)
+	}
+	if len(executor.plugins) != 2 {
+		t.Errorf("Expected a second plugin key after editing tax, got %d", len(executor.plugins))
+	}
+}
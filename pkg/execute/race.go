@@ -0,0 +1,118 @@
+package execute
+
+import (
+	"strconv"
+	"strings"
+)
+
+// raceSeparator delimits the reports of the race detector
+const raceSeparator = "=================="
+
+// RaceReport is a data race found by the race detector, as reported in the
+// output of a program or test built with -race
+type RaceReport struct {
+	// Current is the access that revealed the race
+	Current RaceAccess
+
+	// Previous is the earlier conflicting access
+	Previous RaceAccess
+
+	// Text is the report as printed, without its separator lines
+	Text string
+}
+
+// RaceAccess is one of the conflicting memory accesses of a data race
+type RaceAccess struct {
+	// Op is "read" or "write", prefixed with "atomic " for atomic accesses
+	Op string
+
+	// Goroutine is the ID of the goroutine making the access
+	Goroutine int
+
+	// Stack is the call stack of the access, innermost call first
+	Stack []StackFrame
+}
+
+// StackFrame is a call of a stack trace
+type StackFrame struct {
+	Function string // Fully qualified function, e.g. "example.com/m.(*T).Inc"
+	File     string // Path of the source file
+	Line     int    // Line of the call
+}
+
+// parseRaceReports extracts the data race reports from the output of a
+// program or test run with the race detector, in order
+func parseRaceReports(output string) []RaceReport {
+	var reports []RaceReport
+	lines := strings.Split(output, "\n")
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "WARNING: DATA RACE" {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && strings.TrimSpace(lines[end]) != raceSeparator {
+			end++
+		}
+		reports = append(reports, parseRaceReport(lines[i:end]))
+		i = end
+	}
+	return reports
+}
+
+// parseRaceReport parses the lines of a report, starting with its warning
+func parseRaceReport(lines []string) RaceReport {
+	report := RaceReport{Text: strings.Join(lines, "\n")}
+	var access *RaceAccess
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			access = nil
+		case !strings.HasPrefix(line, " "):
+			// A section header, e.g. "Previous write at 0x00c0000a0 by goroutine 7:"
+			access = nil
+			op, goroutine, ok := parseAccessHeader(trimmed)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(trimmed, "Previous ") {
+				access = &report.Previous
+			} else if report.Current.Op == "" {
+				access = &report.Current
+			} else {
+				continue
+			}
+			access.Op, access.Goroutine = op, goroutine
+		case access != nil && !strings.HasPrefix(line, "      "):
+			access.Stack = append(access.Stack, StackFrame{Function: trimmed})
+		case access != nil && len(access.Stack) > 0:
+			// The location of the preceding call, e.g. "/src/m.go:12 +0x44"
+			location, _, _ := strings.Cut(trimmed, " ")
+			frame := &access.Stack[len(access.Stack)-1]
+			if i := strings.LastIndexByte(location, ':'); i >= 0 {
+				frame.File = location[:i]
+				frame.Line, _ = strconv.Atoi(location[i+1:])
+			}
+		}
+	}
+	return report
+}
+
+// parseAccessHeader parses the header of an access section, such as
+// "Read at 0x00c000018100 by goroutine 8:"
+func parseAccessHeader(header string) (op string, goroutine int, ok bool) {
+	header = strings.TrimSuffix(strings.TrimPrefix(header, "Previous "), ":")
+	verb, rest, found := strings.Cut(header, " at ")
+	if !found {
+		return "", 0, false
+	}
+	op = strings.ToLower(verb)
+	if !strings.HasSuffix(op, "read") && !strings.HasSuffix(op, "write") {
+		return "", 0, false
+	}
+	if _, id, found := strings.Cut(rest, " by goroutine "); found {
+		goroutine, _ = strconv.Atoi(id)
+	}
+	// The main goroutine is reported as "by main goroutine"
+	return op, goroutine, true
+}
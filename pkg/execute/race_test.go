@@ -0,0 +1,133 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestParseRaceReports(t *testing.T) {
+	output := `=== RUN   TestRace
+==================
+WARNING: DATA RACE
+Read at 0x000000834528 by goroutine 9:
+  example.com/racy.inc()
+      /src/racy/racy_test.go:10 +0x75
+  example.com/racy.TestRace.func1()
+      /src/racy/racy_test.go:18 +0x69
+
+Previous write at 0x000000834528 by main goroutine:
+  example.com/racy.inc()
+      /src/racy/racy_test.go:10 +0x8d
+
+Goroutine 9 (running) created at:
+  example.com/racy.TestRace()
+      /src/racy/racy_test.go:16 +0x56
+==================
+==================
+WARNING: DATA RACE
+Atomic write at 0x00c000012340 by goroutine 7:
+  sync/atomic.AddInt64()
+      /go/src/sync/atomic/asm.s:56 +0x10
+
+Previous read at 0x00c000012340 by goroutine 6:
+  example.com/racy.load()
+      /src/racy/racy.go:5 +0x30
+==================
+--- FAIL: TestRace (0.00s)
+`
+	reports := parseRaceReports(output)
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reports))
+	}
+
+	first := reports[0]
+	if first.Current.Op != "read" || first.Current.Goroutine != 9 || len(first.Current.Stack) != 2 {
+		t.Errorf("Unexpected current access %+v", first.Current)
+	}
+	if frame := first.Current.Stack[1]; frame.Function != "example.com/racy.TestRace.func1()" || frame.File != "/src/racy/racy_test.go" || frame.Line != 18 {
+		t.Errorf("Unexpected stack frame %+v", frame)
+	}
+	if first.Previous.Op != "write" || first.Previous.Goroutine != 0 || len(first.Previous.Stack) != 1 {
+		t.Errorf("Unexpected previous access %+v", first.Previous)
+	}
+	if !strings.HasPrefix(first.Text, "WARNING: DATA RACE\n") || strings.Contains(first.Text, "==") {
+		t.Errorf("Unexpected report text:\n%s", first.Text)
+	}
+
+	second := reports[1]
+	if second.Current.Op != "atomic write" || second.Previous.Op != "read" || second.Previous.Goroutine != 6 {
+		t.Errorf("Unexpected accesses %+v %+v", second.Current, second.Previous)
+	}
+
+	if reports := parseRaceReports("--- PASS: TestFine (0.00s)\n"); len(reports) != 0 {
+		t.Errorf("Expected no reports, got %d", len(reports))
+	}
+}
+
+func TestExecuteTestRace(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/racy\n\ngo 1.21\n",
+		"racy_test.go": `package racy
+
+import (
+	"sync"
+	"testing"
+)
+
+var counter int
+
+func inc() { counter++ }
+
+func TestRace(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inc()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFine(t *testing.T) {}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod := module.NewModule("example.com/racy", dir)
+
+	executor := NewGoExecutor()
+	executor.JSONOutput = true
+	result, err := executor.ExecuteTest(mod, "./...", "-race")
+	if err != nil {
+		t.Fatalf("ExecuteTest failed: %v", err)
+	}
+	if strings.Contains(result.Output, "-race requires cgo") {
+		t.Skip("Race detector not available")
+	}
+
+	if len(result.Races) == 0 {
+		t.Fatalf("Expected a race report, got output:\n%s", result.Output)
+	}
+	for _, r := range result.Results {
+		switch r.Name {
+		case "TestRace":
+			if r.Action != "fail" || len(r.Races) == 0 || r.Races[0].Current.Stack[0].Function != "example.com/racy.inc()" {
+				t.Errorf("Expected TestRace to fail with a race in inc, got %s %+v", r.Action, r.Races)
+			}
+		case "TestFine":
+			if len(r.Races) != 0 {
+				t.Errorf("Expected no races in TestFine, got %d", len(r.Races))
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package execute
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// memoryPollInterval is how often the memory use of a sandboxed process is
+// checked
+const memoryPollInterval = 20 * time.Millisecond
+
+// ResourceLimit names a limit of a sandbox
+type ResourceLimit string
+
+const (
+	// LimitMemory is the limit on resident memory
+	LimitMemory ResourceLimit = "memory"
+
+	// LimitCPUTime is the limit on CPU time
+	LimitCPUTime ResourceLimit = "cpu time"
+
+	// LimitWallClock is the limit on elapsed real time
+	LimitWallClock ResourceLimit = "wall clock"
+)
+
+// ResourceLimits bounds the resources a sandboxed program may use; zero
+// values mean no limit
+type ResourceLimits struct {
+	// MaxMemoryBytes is the resident memory the program may use; only
+	// enforced on Linux
+	MaxMemoryBytes uint64
+
+	// MaxCPUTime is the CPU time the program may use, rounded up to whole
+	// seconds; only enforced on Unix
+	MaxCPUTime time.Duration
+
+	// MaxWallClock is how long the program may run
+	MaxWallClock time.Duration
+}
+
+// DefaultResourceLimits returns the limits of a new sandbox
+func DefaultResourceLimits() ResourceLimits {
+	return ResourceLimits{
+		MaxMemoryBytes: 512 << 20,
+		MaxCPUTime:     10 * time.Second,
+		MaxWallClock:   30 * time.Second,
+	}
+}
+
+// ErrResourceExceeded is returned when a sandboxed program was stopped for
+// exceeding one of its limits
+type ErrResourceExceeded struct {
+	Limit ResourceLimit // Limit that was exceeded
+	Value string        // The limit, e.g. "2s" or "67108864 bytes"
+}
+
+// Error implements the error interface
+func (e *ErrResourceExceeded) Error() string {
+	return fmt.Sprintf("%s limit of %s exceeded", e.Limit, e.Value)
+}
+
+// Sandbox runs the main packages of a module as separate processes with
+// bounded resources, for code that cannot be trusted not to hang or
+// exhaust the machine. Programs are built with the Go executor and run in
+// a process group of their own, which is killed when a limit is exceeded.
+// The wall clock limit is enforced everywhere, the CPU time limit through
+// an rlimit on Unix, and the memory limit by watching the resident memory
+// of the process on Linux.
+type Sandbox struct {
+	// Limits bounds the resources of the programs run
+	Limits ResourceLimits
+
+	// Executor builds the programs
+	Executor *GoExecutor
+}
+
+// NewSandbox creates a sandbox with the default limits
+func NewSandbox() *Sandbox {
+	return &Sandbox{
+		Limits:   DefaultResourceLimits(),
+		Executor: NewGoExecutor(),
+	}
+}
+
+// Execute builds the main package at pkgPath, relative to the module
+// directory, and runs it with the arguments under the sandbox's limits.
+// Building is not limited. If the program exceeds a limit, the result holds
+// its output so far and the error is an *ErrResourceExceeded; other
+// failures of the program are reported in the result as by
+// GoExecutor.Execute.
+func (s *Sandbox) Execute(mod *module.Module, pkgPath string, args ...string) (ExecutionResult, error) {
+	if mod == nil {
+		return ExecutionResult{}, errors.New("module cannot be nil")
+	}
+	executor := s.Executor
+	if executor == nil {
+		executor = NewGoExecutor()
+	}
+
+	tempDir, err := os.MkdirTemp("", "gotree-sandbox-")
+	if err != nil {
+		return ExecutionResult{}, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	binary := filepath.Join(tempDir, "program")
+	build, err := executor.Execute(mod, "build", "-o", binary, pkgPath)
+	if err != nil {
+		return build, err
+	}
+	if build.Error != nil {
+		return build, nil
+	}
+
+	cmd := limitCommand(binary, args, s.Limits.MaxCPUTime)
+	cmd.Dir = executor.WorkingDir
+	if cmd.Dir == "" {
+		cmd.Dir = mod.Dir
+	}
+	cmd.Env = append(os.Environ(), executor.AdditionalEnv...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+
+	result := ExecutionResult{Command: strings.Join(append([]string{pkgPath}, args...), " ")}
+	if err := cmd.Start(); err != nil {
+		return result, fmt.Errorf("failed to start %s: %w", pkgPath, err)
+	}
+
+	var (
+		mu       sync.Mutex
+		exceeded *ErrResourceExceeded
+		finished bool
+	)
+	stop := func(e *ErrResourceExceeded) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The process group must not be signaled once the process is reaped
+		if exceeded == nil && !finished {
+			exceeded = e
+			killProcessGroup(cmd)
+		}
+	}
+
+	done := make(chan struct{})
+	if s.Limits.MaxWallClock > 0 {
+		timer := time.AfterFunc(s.Limits.MaxWallClock, func() {
+			stop(&ErrResourceExceeded{Limit: LimitWallClock, Value: s.Limits.MaxWallClock.String()})
+		})
+		defer timer.Stop()
+	}
+	if s.Limits.MaxMemoryBytes > 0 {
+		go watchMemory(cmd.Process.Pid, s.Limits.MaxMemoryBytes, done, stop)
+	}
+
+	err = cmd.Wait()
+	mu.Lock()
+	finished = true
+	mu.Unlock()
+	close(done)
+
+	result.StdOut, result.StdErr = stdout.String(), stderr.String()
+	if err != nil {
+		result.Error = err
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	if exceeded == nil && err != nil && s.Limits.MaxCPUTime > 0 && cpuLimitKilled(cmd.ProcessState, s.Limits.MaxCPUTime) {
+		exceeded = &ErrResourceExceeded{Limit: LimitCPUTime, Value: s.Limits.MaxCPUTime.String()}
+	}
+	if exceeded != nil {
+		result.Error = exceeded
+		return result, exceeded
+	}
+	return result, nil
+}
+
+// watchMemory stops a process when its resident memory exceeds the limit,
+// until done is closed
+func watchMemory(pid int, limit uint64, done <-chan struct{}, stop func(*ErrResourceExceeded)) {
+	ticker := time.NewTicker(memoryPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			used, ok := processMemory(pid)
+			if !ok {
+				return
+			}
+			if used > limit {
+				stop(&ErrResourceExceeded{Limit: LimitMemory, Value: fmt.Sprintf("%d bytes", limit)})
+				return
+			}
+		}
+	}
+}
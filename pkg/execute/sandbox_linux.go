@@ -0,0 +1,27 @@
+package execute
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processMemory returns the resident memory of a process in bytes
+func processMemory(pid int) (uint64, bool) {
+	f, err := os.Open("/proc/" + strconv.Itoa(pid) + "/status")
+	if err != nil {
+		return 0, false
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			return kb * 1024, err == nil
+		}
+	}
+	return 0, false
+}
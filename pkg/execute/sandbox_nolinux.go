@@ -0,0 +1,8 @@
+//go:build !linux
+
+package execute
+
+// processMemory reports that memory use is not known on this platform
+func processMemory(pid int) (uint64, bool) {
+	return 0, false
+}
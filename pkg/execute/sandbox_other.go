@@ -0,0 +1,20 @@
+//go:build !unix
+
+package execute
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// limitCommand returns the command running a program; CPU time cannot be
+// limited on this platform
+func limitCommand(binary string, args []string, cpuTime time.Duration) *exec.Cmd {
+	return exec.Command(binary, args...)
+}
+
+// cpuLimitKilled reports false, CPU time is not limited on this platform
+func cpuLimitKilled(state *os.ProcessState, cpuTime time.Duration) bool {
+	return false
+}
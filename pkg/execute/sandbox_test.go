@@ -0,0 +1,78 @@
+package execute
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestSandbox_Execute(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/student\n\ngo 1.21\n",
+		"hello/main.go":    "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc main() { fmt.Println(\"hello\", os.Args[1]) }\n",
+		"sleep/main.go":    "package main\n\nimport \"time\"\n\nfunc main() { time.Sleep(time.Minute) }\n",
+		"spin/main.go":     "package main\n\nfunc main() {\n\tfor i := 0; ; i++ {\n\t}\n}\n",
+		"allocate/main.go": "package main\n\nimport \"time\"\n\nvar keep [][]byte\n\nfunc main() {\n\tfor {\n\t\tb := make([]byte, 1<<20)\n\t\tfor i := range b {\n\t\t\tb[i] = 1\n\t\t}\n\t\tkeep = append(keep, b)\n\t\ttime.Sleep(time.Millisecond)\n\t}\n}\n",
+		"broken/main.go":   "package main\n\nfunc main() { undefined() }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	mod := module.NewModule("example.com/student", dir)
+
+	sandbox := NewSandbox()
+	sandbox.Limits = ResourceLimits{MaxWallClock: 10 * time.Second}
+	result, err := sandbox.Execute(mod, "./hello", "sandbox")
+	if err != nil || result.Error != nil {
+		t.Fatalf("Execute failed: %v %v\n%s", err, result.Error, result.StdErr)
+	}
+	if result.StdOut != "hello sandbox\n" {
+		t.Errorf("Unexpected output %q", result.StdOut)
+	}
+
+	result, err = sandbox.Execute(mod, "./broken")
+	if err != nil || result.Error == nil {
+		t.Errorf("Expected a build failure in the result, got %v %v", err, result.Error)
+	}
+
+	tests := []struct {
+		name   string
+		pkg    string
+		limits ResourceLimits
+		want   ResourceLimit
+		goos   string
+	}{
+		{"wall clock", "./sleep", ResourceLimits{MaxWallClock: time.Second}, LimitWallClock, ""},
+		{"cpu time", "./spin", ResourceLimits{MaxCPUTime: time.Second, MaxWallClock: 20 * time.Second}, LimitCPUTime, "unix"},
+		{"memory", "./allocate", ResourceLimits{MaxMemoryBytes: 64 << 20, MaxWallClock: 20 * time.Second}, LimitMemory, "linux"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.goos == "linux" && runtime.GOOS != "linux" || tt.goos == "unix" && runtime.GOOS == "windows" {
+				t.Skipf("%s limit not enforced on %s", tt.want, runtime.GOOS)
+			}
+			sandbox.Limits = tt.limits
+			start := time.Now()
+			_, err := sandbox.Execute(mod, tt.pkg)
+			var exceeded *ErrResourceExceeded
+			if !errors.As(err, &exceeded) || exceeded.Limit != tt.want {
+				t.Fatalf("Expected the %s limit to be exceeded, got %v", tt.want, err)
+			}
+			if elapsed := time.Since(start); elapsed > 15*time.Second {
+				t.Errorf("Program was stopped only after %s", elapsed)
+			}
+		})
+	}
+}
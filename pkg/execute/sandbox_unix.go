@@ -0,0 +1,33 @@
+//go:build unix
+
+package execute
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// limitCommand returns the command running a program with its CPU time
+// limited by the shell's ulimit, which sets the rlimit of the program
+func limitCommand(binary string, args []string, cpuTime time.Duration) *exec.Cmd {
+	if cpuTime <= 0 {
+		return exec.Command(binary, args...)
+	}
+	seconds := int64((cpuTime + time.Second - 1) / time.Second)
+	script := "ulimit -t " + strconv.FormatInt(seconds, 10) + ` && exec "$0" "$@"`
+	return exec.Command("/bin/sh", append([]string{"-c", script, binary}, args...)...)
+}
+
+// cpuLimitKilled reports whether a process was killed by the system for
+// exceeding its CPU time rlimit. The kernel's accounting may report a
+// little less than the limit, so 90% of it counts.
+func cpuLimitKilled(state *os.ProcessState, cpuTime time.Duration) bool {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || (status.Signal() != syscall.SIGXCPU && status.Signal() != syscall.SIGKILL) {
+		return false
+	}
+	return state.UserTime()+state.SystemTime() >= cpuTime*9/10
+}
@@ -0,0 +1,182 @@
+package execute
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// dumpGracePeriod is how long a timed out test may take to print its
+// goroutine dump before its process group is killed
+const dumpGracePeriod = 5 * time.Second
+
+// ExecuteTestSupervised runs each top-level test of a package in its own
+// process, so a test that hangs cannot block the others. The package's test
+// binary is built once; every test then runs with -test.run selecting only
+// it. When a test exceeds the timeout, its process group receives SIGQUIT,
+// which makes the Go runtime print a goroutine dump into the test's output,
+// and is killed if it has not exited after a grace period; the test is
+// recorded as failed with TimedOut set. On platforms without process groups
+// and SIGQUIT the process is killed without a dump.
+//
+// pkgPath must name a single package. testFlags are passed to the test
+// binary and must use the -test. prefix, e.g. "-test.count=1".
+func (g *GoExecutor) ExecuteTestSupervised(mod *module.Module, pkgPath string, timeout time.Duration, testFlags ...string) (TestResult, error) {
+	if mod == nil {
+		return TestResult{}, errors.New("module cannot be nil")
+	}
+	if timeout <= 0 {
+		return TestResult{}, errors.New("timeout must be positive")
+	}
+
+	tempDir, err := os.MkdirTemp("", "gotree-supervised-")
+	if err != nil {
+		return TestResult{}, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	result := TestResult{Package: pkgPath}
+
+	// Tests run in their package directory, like under go test
+	list, err := g.Execute(mod, "list", "-f", "{{.Dir}}", pkgPath)
+	if err != nil {
+		return result, err
+	}
+	if list.Error != nil {
+		result.Output, result.Error = list.StdErr, list.Error
+		return result, nil
+	}
+	pkgDir := strings.TrimSpace(list.StdOut)
+	if strings.Contains(pkgDir, "\n") {
+		return result, fmt.Errorf("%s matches more than one package", pkgPath)
+	}
+
+	binary := filepath.Join(tempDir, "pkg.test")
+	build, err := g.Execute(mod, "test", "-c", "-o", binary, pkgPath)
+	if err != nil {
+		return result, err
+	}
+	if build.Error != nil {
+		result.Output, result.Error = build.StdOut+build.StdErr, build.Error
+		return result, nil
+	}
+	if _, err := os.Stat(binary); err != nil {
+		// The package has no test files
+		result.Output = build.StdOut + build.StdErr
+		return result, nil
+	}
+
+	names, err := g.listTests(binary, pkgDir)
+	if err != nil {
+		return result, err
+	}
+
+	var output strings.Builder
+	for _, name := range names {
+		run := g.runTestProcess(binary, pkgDir, name, timeout, testFlags)
+		run.Package = pkgPath
+		result.Tests = append(result.Tests, name)
+		result.Results = append(result.Results, run)
+		output.WriteString(run.Output)
+		switch run.Action {
+		case "pass":
+			result.Passed++
+		case "fail":
+			result.Failed++
+		case "skip":
+			result.Skipped++
+		}
+		if run.TimedOut {
+			result.TimedOut++
+		}
+	}
+	result.Output = output.String()
+	if result.Failed > 0 {
+		result.Error = fmt.Errorf("%d of %d tests failed", result.Failed, len(names))
+	}
+	return result, nil
+}
+
+// listTests returns the names of the top-level tests of a test binary
+func (g *GoExecutor) listTests(binary, dir string) ([]string, error) {
+	cmd := exec.Command(binary, "-test.list", ".")
+	cmd.Dir = dir
+	cmd.Env = g.testEnv()
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tests: %w", err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "Test") {
+			names = append(names, strings.TrimSpace(line))
+		}
+	}
+	return names, nil
+}
+
+// runTestProcess runs a single test of a test binary under supervision
+func (g *GoExecutor) runTestProcess(binary, dir, name string, timeout time.Duration, testFlags []string) TestCaseResult {
+	args := append([]string{"-test.run", "^" + regexp.QuoteMeta(name) + "$", "-test.v"}, testFlags...)
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	cmd.Env = g.testEnv()
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = dumpGracePeriod
+	setProcessGroup(cmd)
+
+	run := TestCaseResult{Name: name}
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		run.Action = "fail"
+		run.Output = err.Error() + "\n"
+		return run
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		run.TimedOut = true
+		interruptProcessGroup(cmd)
+		select {
+		case err = <-done:
+		case <-time.After(dumpGracePeriod):
+			killProcessGroup(cmd)
+			err = <-done
+		}
+	}
+	run.Elapsed = time.Since(start)
+	run.Output = output.String()
+
+	switch {
+	case run.TimedOut:
+		run.Action = "fail"
+		run.Output += fmt.Sprintf("--- FAIL: %s (timed out after %s)\n", name, timeout)
+	case err != nil:
+		run.Action = "fail"
+	case strings.Contains(run.Output, "--- SKIP: "+name+" "):
+		run.Action = "skip"
+	default:
+		run.Action = "pass"
+	}
+	return run
+}
+
+// testEnv returns the environment test binaries run with
+func (g *GoExecutor) testEnv() []string {
+	return append(os.Environ(), g.AdditionalEnv...)
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package execute
+
+import "os/exec"
+
+// setProcessGroup is a no-op on platforms without process groups
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptProcessGroup kills the test; there is no SIGQUIT to request a
+// goroutine dump with
+func interruptProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+// killProcessGroup kills the test process
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,66 @@
+package execute
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestExecuteTestSupervised(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("goroutine dumps need SIGQUIT")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/hang\n\ngo 1.21\n",
+		"hang.go": "package hang\n",
+		"hang_test.go": `package hang
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuick(t *testing.T) {}
+
+func TestHang(t *testing.T) { waitForever() }
+
+func waitForever() { time.Sleep(time.Hour) }
+
+func TestSkipped(t *testing.T) { t.Skip("not today") }
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod := module.NewModule("example.com/hang", dir)
+	result, err := NewGoExecutor().ExecuteTestSupervised(mod, ".", time.Second)
+	if err != nil {
+		t.Fatalf("ExecuteTestSupervised failed: %v", err)
+	}
+
+	if result.Passed != 1 || result.Failed != 1 || result.Skipped != 1 || result.TimedOut != 1 {
+		t.Errorf("Unexpected counts: passed %d, failed %d, skipped %d, timed out %d",
+			result.Passed, result.Failed, result.Skipped, result.TimedOut)
+	}
+	if result.Error == nil {
+		t.Error("Expected an error for the hung test")
+	}
+	for _, r := range result.Results {
+		if r.TimedOut != (r.Name == "TestHang") {
+			t.Errorf("%s: unexpected TimedOut %v", r.Name, r.TimedOut)
+		}
+		if r.Name == "TestHang" && !strings.Contains(r.Output, "waitForever") {
+			t.Errorf("Expected a goroutine dump in the output, got:\n%s", r.Output)
+		}
+	}
+}
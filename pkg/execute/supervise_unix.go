@@ -0,0 +1,24 @@
+//go:build unix
+
+package execute
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a new process group, so the test
+// and any processes it spawns can be signaled together
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptProcessGroup asks the Go runtime of the test for a goroutine dump
+func interruptProcessGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGQUIT)
+}
+
+// killProcessGroup kills the test and the processes it spawned
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
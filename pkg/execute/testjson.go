@@ -0,0 +1,95 @@
+package execute
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TestCaseResult is the outcome of a single test or subtest
+type TestCaseResult struct {
+	// Package containing the test
+	Package string
+
+	// Test name, with subtests as "TestParent/sub"
+	Name string
+
+	// Outcome: "pass", "fail" or "skip" (empty if the test did not finish)
+	Action string
+
+	// Time the test took
+	Elapsed time.Duration
+
+	// Output printed by the test
+	Output string
+
+	// Whether the test was stopped for exceeding its timeout (only set by
+	// ExecuteTestSupervised)
+	TimedOut bool
+
+	// Data races detected while the test ran (only with -race)
+	Races []RaceReport
+}
+
+// testEvent is a line of the go test -json (test2json) event stream
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// parseTestEvents parses the test2json event stream printed by go test -json.
+// It returns the results of each test in the order the tests started and the
+// plain text output reconstructed from the events; lines that are not events,
+// such as build errors, are kept in the output. ok is false if the stream
+// contains no events, so the caller can fall back to the text format.
+func parseTestEvents(stream string) (results []TestCaseResult, output string, ok bool) {
+	var text strings.Builder
+	index := make(map[string]int)
+	outputs := make(map[string]*strings.Builder)
+
+	scanner := bufio.NewScanner(strings.NewReader(stream))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var event testEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil || event.Action == "" {
+			text.WriteString(line + "\n")
+			continue
+		}
+		ok = true
+
+		if event.Action == "output" {
+			text.WriteString(event.Output)
+		}
+		if event.Test == "" {
+			continue
+		}
+
+		key := event.Package + "\x00" + event.Test
+		i, seen := index[key]
+		if !seen {
+			i = len(results)
+			index[key] = i
+			outputs[key] = &strings.Builder{}
+			results = append(results, TestCaseResult{Package: event.Package, Name: event.Test})
+		}
+
+		switch event.Action {
+		case "output":
+			outputs[key].WriteString(event.Output)
+		case "pass", "fail", "skip":
+			results[i].Action = event.Action
+			results[i].Elapsed = time.Duration(event.Elapsed * float64(time.Second))
+		}
+	}
+
+	for key, i := range index {
+		results[i].Output = outputs[key].String()
+		results[i].Races = parseRaceReports(results[i].Output)
+	}
+	return results, text.String(), ok
+}
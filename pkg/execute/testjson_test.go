@@ -0,0 +1,91 @@
+package execute
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestParseTestEvents(t *testing.T) {
+	stream := `# example.com/m [example.com/m.test]
+{"Action":"start","Package":"example.com/m"}
+{"Action":"run","Package":"example.com/m","Test":"TestTable"}
+{"Action":"output","Package":"example.com/m","Test":"TestTable","Output":"=== RUN   TestTable\n"}
+{"Action":"run","Package":"example.com/m","Test":"TestTable/empty"}
+{"Action":"output","Package":"example.com/m","Test":"TestTable/empty","Output":"    table_test.go:9: got 1\n"}
+{"Action":"fail","Package":"example.com/m","Test":"TestTable/empty","Elapsed":0.25}
+{"Action":"run","Package":"example.com/m","Test":"TestSlow"}
+{"Action":"skip","Package":"example.com/m","Test":"TestSlow","Elapsed":0}
+{"Action":"fail","Package":"example.com/m","Test":"TestTable","Elapsed":0.5}
+{"Action":"run","Package":"example.com/m","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/m","Test":"TestOK","Elapsed":0.01}
+{"Action":"output","Package":"example.com/m","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/m","Elapsed":0.6}
+`
+
+	results, output, ok := parseTestEvents(stream)
+	if !ok {
+		t.Fatal("Expected events to be recognized")
+	}
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Name+"="+r.Action)
+	}
+	expected := "TestTable=fail,TestTable/empty=fail,TestSlow=skip,TestOK=pass"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, ","))
+	}
+
+	if results[1].Elapsed != 250*time.Millisecond {
+		t.Errorf("Expected elapsed 250ms, got %v", results[1].Elapsed)
+	}
+	if results[1].Output != "    table_test.go:9: got 1\n" {
+		t.Errorf("Unexpected subtest output %q", results[1].Output)
+	}
+	if !strings.HasPrefix(output, "# example.com/m [example.com/m.test]\n=== RUN   TestTable\n") || !strings.HasSuffix(output, "FAIL\n") {
+		t.Errorf("Unexpected reconstructed output:\n%s", output)
+	}
+
+	if _, _, ok := parseTestEvents("--- PASS: TestAdd (0.00s)\nok\n"); ok {
+		t.Error("Expected text output not to be recognized as events")
+	}
+
+	result := newTestResult("./...", ExecutionResult{StdOut: stream}, nil, []string{"-json"})
+	if result.Passed != 1 || result.Failed != 2 || result.Skipped != 1 || len(result.Tests) != 4 {
+		t.Errorf("Unexpected counts: %d passed, %d failed, %d skipped, tests %v",
+			result.Passed, result.Failed, result.Skipped, result.Tests)
+	}
+}
+
+func TestGoExecutor_ExecuteTestJSON(t *testing.T) {
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping in CI environment")
+	}
+
+	testDir, err := createTestModule(t)
+	if err != nil {
+		t.Fatalf("Failed to create test module: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(testDir) }()
+
+	executor := NewGoExecutor()
+	executor.JSONOutput = true
+
+	result, err := executor.ExecuteTest(&module.Module{Path: "example.com/testmod", Dir: testDir}, "./...")
+	if err != nil {
+		t.Fatalf("ExecuteTest failed: %v", err)
+	}
+	if len(result.Results) != 1 || result.Results[0].Name != "TestAdd" || result.Results[0].Action != "pass" {
+		t.Fatalf("Expected TestAdd to pass, got %+v\n%s", result.Results, result.Output)
+	}
+	if result.Passed != 1 || result.Failed != 0 {
+		t.Errorf("Expected 1 passed test, got %d passed and %d failed", result.Passed, result.Failed)
+	}
+	if strings.Contains(result.Output, `"Action"`) {
+		t.Errorf("Expected plain text output, got:\n%s", result.Output)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
 )
 
 // TmpExecutor is an executor that saves in-memory modules to a temporary
@@ -21,6 +22,11 @@ type TmpExecutor struct {
 
 	// KeepTempFiles determines whether temporary files are kept after execution
 	KeepTempFiles bool
+
+	// BuildCacheDir, if set, is used as GOCACHE by the underlying Go
+	// executor, so compiled packages are reused across executions while
+	// the module source is still written to a new temporary directory
+	BuildCacheDir string
 }
 
 // NewTmpExecutor creates a new temporary directory executor
@@ -54,10 +60,8 @@ func (e *TmpExecutor) Execute(mod *module.Module, args ...string) (ExecutionResu
 		return ExecutionResult{}, fmt.Errorf("failed to save module to temp directory: %w", err)
 	}
 
-	// Set working directory explicitly
-	if goExec, ok := e.executor.(*GoExecutor); ok {
-		goExec.WorkingDir = tempDir
-	}
+	// Run in the temporary directory with the shared build cache
+	e.configureExecutor(tempDir)
 
 	// Execute using the underlying executor
 	return e.executor.Execute(tmpModule, args...)
@@ -86,10 +90,8 @@ func (e *TmpExecutor) ExecuteTest(mod *module.Module, pkgPath string, testFlags
 		return TestResult{}, fmt.Errorf("failed to save module to temp directory: %w", err)
 	}
 
-	// Explicitly set working directory in the executor
-	if goExec, ok := e.executor.(*GoExecutor); ok {
-		goExec.WorkingDir = tempDir
-	}
+	// Run in the temporary directory with the shared build cache
+	e.configureExecutor(tempDir)
 
 	// Execute test using the underlying executor
 	return e.executor.ExecuteTest(tmpModule, pkgPath, testFlags...)
@@ -118,10 +120,8 @@ func (e *TmpExecutor) ExecuteFunc(mod *module.Module, funcPath string, args ...i
 		return nil, fmt.Errorf("failed to save module to temp directory: %w", err)
 	}
 
-	// Explicitly set working directory in the executor
-	if goExec, ok := e.executor.(*GoExecutor); ok {
-		goExec.WorkingDir = tempDir
-	}
+	// Run in the temporary directory with the shared build cache
+	e.configureExecutor(tempDir)
 
 	// Execute function using the underlying executor
 	return e.executor.ExecuteFunc(tmpModule, funcPath, args...)
@@ -129,6 +129,31 @@ func (e *TmpExecutor) ExecuteFunc(mod *module.Module, funcPath string, args ...i
 
 // Helper methods
 
+// configureExecutor points the underlying Go executor at the temporary
+// directory and the build cache
+func (e *TmpExecutor) configureExecutor(tempDir string) {
+	goExec, ok := e.executor.(*GoExecutor)
+	if !ok {
+		return
+	}
+	goExec.WorkingDir = tempDir
+	if e.BuildCacheDir == "" {
+		return
+	}
+	env := make([]string, 0, len(goExec.AdditionalEnv)+1)
+	for _, v := range goExec.AdditionalEnv {
+		if !strings.HasPrefix(v, "GOCACHE=") {
+			env = append(env, v)
+		}
+	}
+	// GOCACHE must be absolute
+	cacheDir := e.BuildCacheDir
+	if abs, err := filepath.Abs(cacheDir); err == nil {
+		cacheDir = abs
+	}
+	goExec.AdditionalEnv = append(env, "GOCACHE="+cacheDir)
+}
+
 // createTempDir creates a temporary directory for the module
 func (e *TmpExecutor) createTempDir(mod *module.Module) (string, error) {
 	baseDir := e.TempBaseDir
@@ -181,6 +206,11 @@ func (e *TmpExecutor) saveToTemp(mod *module.Module, tempDir string) (*module.Mo
 				return nil, fmt.Errorf("failed to write file %s: %w", filePath, err)
 			}
 		}
+
+		// Tests read testdata relative to the package directory
+		if err := saver.CopyTestData(pkg, pkgDir); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create a new module instance with updated paths
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"bitspark.dev/go-tree/pkg/core/module"
 	"bitspark.dev/go-tree/pkg/core/saver"
@@ -837,3 +838,127 @@ func TestAdd(t *testing.T) {
 		t.Logf("TmpExecutor found %d tests: %v", len(tmpResult.Tests), tmpResult.Tests)
 	}
 }
+
+func TestTmpExecutor_CopiesTestData(t *testing.T) {
+	// Skip this test in CI environments
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping in CI environment")
+	}
+
+	// The package's original directory holds only its testdata
+	origDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(origDir, "testdata", "nested"), 0750); err != nil {
+		t.Fatalf("Failed to create testdata: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(origDir, "testdata", "nested", "input.txt"), []byte("hello"), 0600); err != nil {
+		t.Fatalf("Failed to write testdata file: %v", err)
+	}
+
+	mod := module.NewModule("example.com/fixtures", "")
+	mod.GoVersion = "1.18"
+
+	pkg := module.NewPackage("reader", "example.com/fixtures/reader", origDir)
+	mod.AddPackage(pkg)
+
+	testFile := module.NewFile("", "reader_test.go", true)
+	testFile.SourceCode = `package reader
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/nested/input.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("unexpected fixture content %q", data)
+	}
+}
+`
+	pkg.AddFile(testFile)
+
+	files, err := pkg.TestDataFiles()
+	if err != nil {
+		t.Fatalf("TestDataFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join("testdata", "nested", "input.txt") {
+		t.Errorf("Unexpected testdata files: %v", files)
+	}
+
+	executor := NewTmpExecutor()
+	executor.TempBaseDir = t.TempDir()
+
+	result, err := executor.ExecuteTest(mod, "./...", "-v")
+	if err != nil {
+		t.Fatalf("ExecuteTest failed: %v", err)
+	}
+	if result.Failed > 0 || result.Passed != 1 {
+		t.Errorf("Expected the fixture test to pass, got %d passed and %d failed:\n%s",
+			result.Passed, result.Failed, result.Output)
+	}
+}
+
+func TestTmpExecutor_BuildCacheDir(t *testing.T) {
+	// Skip this test in CI environments
+	if os.Getenv("CI") != "" {
+		t.Skip("Skipping in CI environment")
+	}
+
+	mod := module.NewModule("example.com/cached", "")
+	mod.GoVersion = "1.18"
+
+	pkg := module.NewPackage("main", "example.com/cached/app", "")
+	mod.AddPackage(pkg)
+
+	mainFile := module.NewFile("", "main.go", false)
+	mainFile.SourceCode = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("first")
+}
+`
+	pkg.AddFile(mainFile)
+
+	cacheDir := t.TempDir()
+	executor := NewTmpExecutor()
+	executor.TempBaseDir = t.TempDir()
+	executor.BuildCacheDir = cacheDir
+
+	run := func() (string, time.Duration) {
+		t.Helper()
+		start := time.Now()
+		result, err := executor.Execute(mod, "run", "./app")
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result.ExitCode != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", result.ExitCode, result.StdErr)
+		}
+		return strings.TrimSpace(result.StdOut), time.Since(start)
+	}
+
+	// The first run fills the empty cache, the second reuses it
+	out, cold := run()
+	if out != "first" {
+		t.Errorf("Expected output %q, got %q", "first", out)
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("Expected the build cache to be filled, got %d entries (%v)", len(entries), err)
+	}
+
+	mainFile.SourceCode = strings.Replace(mainFile.SourceCode, `"first"`, `"second"`, 1)
+	out, warm := run()
+	if out != "second" {
+		t.Errorf("Expected the changed source to be built, got output %q", out)
+	}
+	if warm >= cold {
+		t.Errorf("Expected the cached run to be faster: cold %v, warm %v", cold, warm)
+	}
+	t.Logf("Cold run %v, cached run %v", cold, warm)
+}
@@ -0,0 +1,435 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// mockHelpers are the methods every mock gets from MockRecorder, which the
+// methods of a mocked interface must not shadow
+var mockHelpers = map[string]bool{"On": true, "Calls": true, "CallCount": true, "AssertCalled": true, "Record": true}
+
+// mockMethod is a method of a mocked interface
+type mockMethod struct {
+	name     string
+	params   []mockParam
+	results  []string
+	variadic bool
+}
+
+// mockParam is a named parameter of a mocked method
+type mockParam struct {
+	name string
+	typ  string // Declared type, "...T" for a variadic parameter
+}
+
+// GenerateMock generates a mock implementation of an interface type, named
+// "Mock" followed by the interface name, for use in the interface's
+// package. The mock embeds a MockRecorder that records the arguments of
+// every call and serves the values configured with On(...).Return(...);
+// a function field per method, such as GetFunc for Get, takes precedence
+// over stubs when set. Methods of embedded interfaces of the module are
+// included. The generated code needs the support code of GenerateMockFile.
+func (g *Generator) GenerateMock(iface *module.Type) (string, error) {
+	methods, err := mockMethods(iface, make(map[*module.Type]bool))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	mockName := "Mock" + iface.Name
+	fmt.Fprintf(&b, "// %s is a mock implementation of %s\n", mockName, iface.Name)
+	fmt.Fprintf(&b, "type %s struct {\n\tMockRecorder\n\n", mockName)
+	for _, m := range methods {
+		fmt.Fprintf(&b, "\t// %sFunc, if set, implements %s in place of stubbed values\n", m.name, m.name)
+		fmt.Fprintf(&b, "\t%sFunc func%s\n", m.name, m.signature())
+	}
+	b.WriteString("}\n")
+
+	for _, m := range methods {
+		var names, forwarded []string
+		for _, p := range m.params {
+			names = append(names, p.name)
+			forwarded = append(forwarded, p.name)
+		}
+		if m.variadic {
+			forwarded[len(forwarded)-1] += "..."
+		}
+
+		fmt.Fprintf(&b, "\n// %s records the call and returns the stubbed values\n", m.name)
+		fmt.Fprintf(&b, "func (m *%s) %s%s {\n", mockName, m.name, m.signature())
+		recordArgs := ""
+		if len(names) > 0 {
+			recordArgs = ", " + strings.Join(names, ", ")
+		}
+		if len(m.results) == 0 {
+			fmt.Fprintf(&b, "\tm.Record(%q%s)\n", m.name, recordArgs)
+			fmt.Fprintf(&b, "\tif m.%sFunc != nil {\n\t\tm.%sFunc(%s)\n\t}\n}\n", m.name, m.name, strings.Join(forwarded, ", "))
+			continue
+		}
+		fmt.Fprintf(&b, "\tvalues := m.Record(%q%s)\n", m.name, recordArgs)
+		fmt.Fprintf(&b, "\tif m.%sFunc != nil {\n\t\treturn m.%sFunc(%s)\n\t}\n", m.name, m.name, strings.Join(forwarded, ", "))
+		var results []string
+		for i, typ := range m.results {
+			result := fmt.Sprintf("r%d", i)
+			results = append(results, result)
+			fmt.Fprintf(&b, "\tvar %s %s\n", result, typ)
+			fmt.Fprintf(&b, "\tif len(values) > %d && values[%d] != nil {\n\t\t%s = values[%d].(%s)\n\t}\n", i, i, result, i, typ)
+		}
+		fmt.Fprintf(&b, "\treturn %s\n}\n", strings.Join(results, ", "))
+	}
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return b.String(), fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// GenerateMockFile generates a complete source file for the package
+// pkgName with mocks of the interfaces and the MockRecorder support code
+// they share. The generated code only depends on the standard library and
+// the packages the interfaces' signatures use.
+func (g *Generator) GenerateMockFile(pkgName string, ifaces ...*module.Type) (string, error) {
+	imports := map[string]string{"reflect": "", "sync": ""}
+	var mocks []string
+	for _, iface := range ifaces {
+		mock, err := g.GenerateMock(iface)
+		if err != nil {
+			return "", err
+		}
+		mocks = append(mocks, mock)
+		if err := addMockImports(imports, iface, make(map[*module.Type]bool)); err != nil {
+			return "", err
+		}
+	}
+
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", pkgName)
+	for _, p := range paths {
+		if name := imports[p]; name != "" {
+			fmt.Fprintf(&b, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(&b, "\t%q\n", p)
+		}
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(mockSupportCode)
+	for _, mock := range mocks {
+		b.WriteString("\n" + mock)
+	}
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return b.String(), fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// mockMethods returns the methods of an interface, including those of
+// embedded interfaces, in declaration order
+func mockMethods(iface *module.Type, visited map[*module.Type]bool) ([]mockMethod, error) {
+	if iface.Kind != "interface" {
+		return nil, fmt.Errorf("type %s is not an interface", iface.Name)
+	}
+	visited[iface] = true
+
+	var methods []mockMethod
+	seen := make(map[string]bool)
+	add := func(m mockMethod) {
+		if !seen[m.name] {
+			seen[m.name] = true
+			methods = append(methods, m)
+		}
+	}
+	for _, m := range iface.Interfaces {
+		if m.IsEmbedded {
+			if m.Embedded == nil {
+				return nil, fmt.Errorf("embedded interface %s of %s is outside the module and cannot be mocked", m.Name, iface.Name)
+			}
+			if visited[m.Embedded] {
+				continue
+			}
+			embedded, err := mockMethods(m.Embedded, visited)
+			if err != nil {
+				return nil, err
+			}
+			for _, em := range embedded {
+				add(em)
+			}
+			continue
+		}
+		if mockHelpers[m.Name] {
+			return nil, fmt.Errorf("method %s of %s conflicts with a mock helper", m.Name, iface.Name)
+		}
+		method, err := parseMockMethod(m)
+		if err != nil {
+			return nil, fmt.Errorf("method %s of %s: %w", m.Name, iface.Name, err)
+		}
+		add(method)
+	}
+	return methods, nil
+}
+
+// parseMockMethod parses the signature of an interface method, naming
+// unnamed parameters and renaming parameters that would collide with the
+// receiver, the locals of the generated method or identifiers of the
+// signature's types
+func parseMockMethod(m *module.Method) (mockMethod, error) {
+	funcType, err := parseFuncType(m.Signature)
+	if err != nil {
+		return mockMethod{}, err
+	}
+	method := mockMethod{name: m.Name}
+	reserved := map[string]bool{"m": true, "values": true}
+	reserveIdents := func(expr ast.Expr) {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				reserved[ident.Name] = true
+			}
+			return true
+		})
+	}
+	if funcType.Params != nil {
+		for _, field := range funcType.Params.List {
+			reserveIdents(field.Type)
+			typ := types.ExprString(field.Type)
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				method.variadic = true
+			}
+			if len(field.Names) == 0 {
+				method.params = append(method.params, mockParam{typ: typ})
+			}
+			for _, name := range field.Names {
+				method.params = append(method.params, mockParam{name: name.Name, typ: typ})
+			}
+		}
+	}
+	if funcType.Results != nil {
+		for _, field := range funcType.Results.List {
+			reserveIdents(field.Type)
+			typ := types.ExprString(field.Type)
+			for n := max(len(field.Names), 1); n > 0; n-- {
+				method.results = append(method.results, typ)
+			}
+		}
+	}
+	for i := range method.results {
+		reserved[fmt.Sprintf("r%d", i)] = true
+	}
+
+	// Keep the parameters' names where possible, generated names must not
+	// take a name kept by a later parameter
+	taken := make(map[string]bool)
+	for _, p := range method.params {
+		taken[p.name] = true
+	}
+	used := make(map[string]bool)
+	for i, p := range method.params {
+		name := p.name
+		if name == "" || name == "_" || reserved[name] || used[name] {
+			name = fmt.Sprintf("a%d", i)
+			for n := 0; reserved[name] || used[name] || taken[name]; n++ {
+				name = fmt.Sprintf("a%d_%d", i, n)
+			}
+		}
+		used[name] = true
+		method.params[i].name = name
+	}
+	return method, nil
+}
+
+// signature returns the parameter and result lists of a mocked method
+func (m mockMethod) signature() string {
+	params := make([]string, len(m.params))
+	for i, p := range m.params {
+		params[i] = p.name + " " + p.typ
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	switch len(m.results) {
+	case 0:
+	case 1:
+		sig += " " + m.results[0]
+	default:
+		sig += " (" + strings.Join(m.results, ", ") + ")"
+	}
+	return sig
+}
+
+// addMockImports adds the imports of the interface's file that its
+// signatures use, by import path with their explicit name
+func addMockImports(imports map[string]string, iface *module.Type, visited map[*module.Type]bool) error {
+	visited[iface] = true
+	used := make(map[string]bool)
+	for _, m := range iface.Interfaces {
+		if m.IsEmbedded {
+			if m.Embedded != nil && !visited[m.Embedded] {
+				if err := addMockImports(imports, m.Embedded, visited); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		funcType, err := parseFuncType(m.Signature)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(funcType, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if ident, ok := sel.X.(*ast.Ident); ok {
+					used[ident.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	if iface.File == nil {
+		return nil
+	}
+	for _, imp := range iface.File.Imports {
+		name := imp.Name
+		if name == "" {
+			name = importName(imp.Path)
+		}
+		if used[name] {
+			explicit := imp.Name
+			if explicit == importName(imp.Path) {
+				explicit = ""
+			}
+			imports[imp.Path] = explicit
+		}
+	}
+	return nil
+}
+
+// importName returns the default name of an imported package, the last
+// path element without a major version suffix
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" && name != importPath {
+		name = path.Base(path.Dir(importPath))
+	}
+	return name
+}
+
+// parseFuncType parses a signature as stored in the model, without the
+// func keyword
+func parseFuncType(signature string) (*ast.FuncType, error) {
+	expr, err := parser.ParseExpr("func" + signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %w", signature, err)
+	}
+	funcType, ok := expr.(*ast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf("invalid signature %q", signature)
+	}
+	return funcType, nil
+}
+
+// mockSupportCode is shared by all generated mocks of a file
+const mockSupportCode = `// CallRecord is a call made on a mock
+type CallRecord struct {
+	Method string        // Name of the called method
+	Args   []interface{} // Arguments, with variadic arguments as one slice
+}
+
+// MockStub configures the values a mocked method returns
+type MockStub struct {
+	method string
+	args   []interface{}
+	values []interface{}
+}
+
+// Return sets the values the method returns, which must have the method's
+// result types; missing or nil values return zero values
+func (s *MockStub) Return(values ...interface{}) *MockStub {
+	s.values = values
+	return s
+}
+
+// MockRecorder records the calls made on a mock and the stubs configured
+// for it
+type MockRecorder struct {
+	mu    sync.Mutex
+	calls []CallRecord
+	stubs []*MockStub
+}
+
+// On configures the values returned by calls of the method. Without
+// arguments the stub applies to every call, otherwise only to calls with
+// equal arguments. Later stubs take precedence.
+func (r *MockRecorder) On(method string, args ...interface{}) *MockStub {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stub := &MockStub{method: method, args: args}
+	r.stubs = append(r.stubs, stub)
+	return stub
+}
+
+// Record records a call and returns the values stubbed for it
+func (r *MockRecorder) Record(method string, args ...interface{}) []interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, CallRecord{Method: method, Args: args})
+	for i := len(r.stubs) - 1; i >= 0; i-- {
+		stub := r.stubs[i]
+		if stub.method == method && (len(stub.args) == 0 || reflect.DeepEqual(stub.args, args)) {
+			return stub.values
+		}
+	}
+	return nil
+}
+
+// Calls returns the calls of the method in the order they were made
+func (r *MockRecorder) Calls(method string) []CallRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []CallRecord
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// CallCount returns how often the method was called
+func (r *MockRecorder) CallCount(method string) int {
+	return len(r.Calls(method))
+}
+
+// AssertCalled reports an error through t, usually a *testing.T, unless
+// the method was called with the arguments, or at all if none are given
+func (r *MockRecorder) AssertCalled(t interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}, method string, args ...interface{}) bool {
+	t.Helper()
+	calls := r.Calls(method)
+	for _, call := range calls {
+		if len(args) == 0 || reflect.DeepEqual(call.Args, args) {
+			return true
+		}
+	}
+	if len(args) == 0 {
+		t.Errorf("expected %s to be called", method)
+	} else {
+		t.Errorf("expected %s to be called with %v, got %d other call(s)", method, args, len(calls))
+	}
+	return false
+}
+`
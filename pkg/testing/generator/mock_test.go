@@ -0,0 +1,200 @@
+package generator
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// createStoreInterfaces builds a Store interface embedding Closer, with a
+// variadic method and a signature using an import
+func createStoreInterfaces() (*module.Type, *module.Type) {
+	file := module.NewFile("/src/store/store.go", "store.go", false)
+	file.AddImport(&module.Import{Path: "context"})
+
+	closer := module.NewType("Closer", "interface", true)
+	closer.AddInterfaceMethod("Close", "() error", false, "")
+
+	store := module.NewType("Store", "interface", true)
+	store.File = file
+	store.AddInterfaceMethod("Closer", "", true, "").Embedded = closer
+	store.AddInterfaceMethod("Get", "(ctx context.Context, key string) (string, error)", false, "")
+	store.AddInterfaceMethod("Put", "(string, []byte)", false, "")
+	store.AddInterfaceMethod("Logf", "(format string, args ...interface{}) (n int)", false, "")
+	file.AddType(closer)
+	file.AddType(store)
+	return store, closer
+}
+
+func TestGenerateMock(t *testing.T) {
+	store, _ := createStoreInterfaces()
+	g := NewGenerator()
+
+	mock, err := g.GenerateMock(store)
+	if err != nil {
+		t.Fatalf("GenerateMock failed: %v", err)
+	}
+	for _, want := range []string{
+		"type MockStore struct {",
+		"CloseFunc func() error",
+		"func (m *MockStore) Get(ctx context.Context, key string) (string, error) {",
+		"func (m *MockStore) Put(a0 string, a1 []byte) {",
+		`values := m.Record("Logf", format, args)`,
+		"return m.LogfFunc(format, args...)",
+	} {
+		if !strings.Contains(mock, want) {
+			t.Errorf("Expected mock to contain %q:\n%s", want, mock)
+		}
+	}
+
+	onMethod := module.NewType("Handler", "interface", true)
+	onMethod.AddInterfaceMethod("On", "(event string)", false, "")
+	if _, err := g.GenerateMock(onMethod); err == nil {
+		t.Error("Expected an error for a method shadowing a mock helper")
+	}
+	external := module.NewType("ReadCloser", "interface", true)
+	external.AddInterfaceMethod("io.Reader", "", true, "")
+	if _, err := g.GenerateMock(external); err == nil {
+		t.Error("Expected an error for an embedded interface outside the module")
+	}
+}
+
+func TestGeneratedMockBehavior(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	store, closer := createStoreInterfaces()
+	code, err := NewGenerator().GenerateMockFile("store", store, closer)
+	if err != nil {
+		t.Fatalf("GenerateMockFile failed: %v", err)
+	}
+	if strings.Contains(code, "testify") || !strings.Contains(code, `"context"`) {
+		t.Errorf("Unexpected imports:\n%s", code)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/store\n\ngo 1.21\n",
+		"mock_test.go": code,
+		"store.go": `package store
+
+import "context"
+
+type Closer interface{ Close() error }
+
+type Store interface {
+	Closer
+	Get(ctx context.Context, key string) (string, error)
+	Put(string, []byte)
+	Logf(format string, args ...interface{}) (n int)
+}
+`,
+		"store_test.go": `package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var _ Store = &MockStore{}
+
+func TestMock(t *testing.T) {
+	m := &MockStore{}
+	m.On("Get").Return("default", nil)
+	m.On("Get", context.Background(), "missing").Return("", errors.New("not found"))
+	m.On("Logf").Return(3)
+
+	if v, err := m.Get(context.Background(), "a"); v != "default" || err != nil {
+		t.Errorf("unexpected stub result %q %v", v, err)
+	}
+	if _, err := m.Get(context.Background(), "missing"); err == nil {
+		t.Error("expected the argument-specific stub")
+	}
+	if n := m.Logf("%d-%s", 1, "x"); n != 3 {
+		t.Errorf("unexpected Logf result %d", n)
+	}
+	m.Put("k", []byte("v"))
+	if err := m.Close(); err != nil {
+		t.Errorf("unexpected Close error %v", err)
+	}
+
+	if m.CallCount("Get") != 2 || m.CallCount("Close") != 1 {
+		t.Errorf("unexpected call counts %d %d", m.CallCount("Get"), m.CallCount("Close"))
+	}
+	if !m.AssertCalled(t, "Put", "k", []byte("v")) || !m.AssertCalled(t, "Logf", "%d-%s", []interface{}{1, "x"}) {
+		t.Error("expected recorded arguments")
+	}
+	var failed fakeT
+	if m.AssertCalled(&failed, "Put", "other", []byte("v")) || failed.errors != 1 {
+		t.Error("expected AssertCalled to fail for other arguments")
+	}
+
+	m.GetFunc = func(ctx context.Context, key string) (string, error) { return "func " + key, nil }
+	if v, _ := m.Get(context.Background(), "b"); v != "func b" {
+		t.Errorf("expected the function field to take precedence, got %q", v)
+	}
+}
+
+type fakeT struct{ errors int }
+
+func (f *fakeT) Helper()                             {}
+func (f *fakeT) Errorf(string, ...interface{})      { f.errors++ }
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated mock does not work: %v\n%s\n%s", err, output, code)
+	}
+}
+
+func TestGenerateMockParameterNames(t *testing.T) {
+	file := module.NewFile("/src/kv/kv.go", "kv.go", false)
+	file.AddImport(&module.Import{Path: "fmt"})
+	kv := module.NewType("KV", "interface", true)
+	kv.File = file
+	kv.AddInterfaceMethod("Set", "(m map[string]int)", false, "")
+	kv.AddInterfaceMethod("Get", "(values string, r0 int) (int, error)", false, "")
+	kv.AddInterfaceMethod("Name", "(string string) string", false, "")
+	kv.AddInterfaceMethod("Pair", "(a1 int, _ string, a0 int)", false, "")
+	kv.AddInterfaceMethod("Format", "(fmt fmt.Stringer) string", false, "")
+	file.AddType(kv)
+
+	source, err := NewGenerator().GenerateMockFile("kv", kv)
+	if err != nil {
+		t.Fatalf("GenerateMockFile failed: %v\n%s", err, source)
+	}
+
+	// The interface and its mock type-check together
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, 2)
+	for name, src := range map[string]string{
+		"kv.go":      "package kv\n\nimport \"fmt\"\n\ntype KV interface {\n\tSet(m map[string]int)\n\tGet(values string, r0 int) (int, error)\n\tName(string string) string\n\tPair(a1 int, _ string, a0 int)\n\tFormat(fmt fmt.Stringer) string\n}\n\nvar _ KV = (*MockKV)(nil)\n",
+		"kv_mock.go": source,
+	} {
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v\n%s", name, err, src)
+		}
+		files = append(files, f)
+	}
+	config := types.Config{Importer: importer.Default()}
+	if _, err := config.Check("kv", fset, files, nil); err != nil {
+		t.Errorf("Generated mock does not type-check: %v\n%s", err, source)
+	}
+}
@@ -0,0 +1,380 @@
+// Package convert generates conversion functions between similar struct types.
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/ast/astutil"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+)
+
+// numericTypes are the types that can be converted into each other with a
+// plain conversion expression
+var numericTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "byte": true, "rune": true,
+}
+
+// GenerateConverter generates a function converting a value of the struct
+// type from into the struct type to. Fields are matched by name: identical
+// types are copied, numeric types and pointer/value pairs are converted, and
+// target fields without a compatible source are left as TODO comments.
+// Field types are compared with the type information of the packages if
+// both have it, and by their package-qualified names otherwise, so types
+// of the same name from different packages do not match.
+//
+// The function is meant to be placed in the package of to; from is
+// qualified with its package name if it lives elsewhere. AddConverter
+// places it in a file along with the imports it needs.
+func GenerateConverter(from, to *module.Type) (string, error) {
+	if err := checkTypes(from, to); err != nil {
+		return "", err
+	}
+	qualifier := ""
+	if !samePackage(from, to) && from.Package != nil {
+		qualifier = from.Package.Name
+	}
+	return generate(from, to, qualifier)
+}
+
+// AddConverter generates the converter of GenerateConverter and appends it
+// to a file of the package of to. The imports it needs, the package of from
+// and packages named in conversions, are added to the file with
+// saver.EnsureImport, aliased if their names are taken by other imports of
+// the file. The file's source is edited with File.SetSource and the
+// generated code is returned.
+func AddConverter(file *module.File, from, to *module.Type) (string, error) {
+	if err := checkTypes(from, to); err != nil {
+		return "", err
+	}
+	if file == nil || file.Package == nil || to.Package == nil || file.Package.ImportPath != to.Package.ImportPath {
+		return "", fmt.Errorf("the converter must be added to a file of the package of %s", to.Name)
+	}
+
+	qualifier := ""
+	if !samePackage(from, to) && from.Package != nil {
+		qualifier = saver.EnsureImport(file, from.Package.ImportPath, from.Package.Name)
+	}
+	code, err := generate(from, to, qualifier)
+	if err != nil {
+		return "", err
+	}
+
+	// Packages that target field types refer to, imported where to is declared
+	for _, name := range packageQualifiers(code) {
+		if name == qualifier || to.File == nil {
+			continue
+		}
+		for _, imp := range to.File.Imports {
+			// The file of to imports the path already, so this only looks up its name
+			if imp.IsBlank || imp.Name == "." || saver.EnsureImport(to.File, imp.Path, "") != name {
+				continue
+			}
+			if got := saver.EnsureImport(file, imp.Path, name); got != name {
+				return "", fmt.Errorf("%s is imported as %s in %s, the converter refers to it as %s", imp.Path, got, file.Name, name)
+			}
+		}
+	}
+
+	source, err := withImports(file, file.Imports)
+	if err != nil {
+		return "", fmt.Errorf("failed to add imports to %s: %w", file.Name, err)
+	}
+	file.SetSource(strings.TrimRight(source, "\n") + "\n\n" + code)
+	return code, nil
+}
+
+// checkTypes reports whether a converter can be generated between the types
+func checkTypes(from, to *module.Type) error {
+	if from == nil || to == nil {
+		return fmt.Errorf("both types must be given")
+	}
+	if from.Kind != "struct" || to.Kind != "struct" {
+		return fmt.Errorf("cannot convert %s (%s) to %s (%s): both must be structs",
+			from.Name, from.Kind, to.Name, to.Kind)
+	}
+	return nil
+}
+
+// samePackage reports whether two types are declared in the same package
+func samePackage(from, to *module.Type) bool {
+	return from.Package == to.Package ||
+		(from.Package != nil && to.Package != nil && from.Package.ImportPath == to.Package.ImportPath)
+}
+
+// generate returns the converter between the types, referring to the
+// package of from by qualifier, or to from unqualified if it is empty
+func generate(from, to *module.Type, qualifier string) (string, error) {
+	samePackage := qualifier == ""
+
+	fromName := from.Name
+	if !samePackage {
+		fromName = qualifier + "." + from.Name
+	}
+
+	sourceFields := make(map[string]*module.Field, len(from.Fields))
+	for _, f := range from.Fields {
+		sourceFields[fieldName(f)] = f
+	}
+	fromTypes, toTypes := structFields(from), structFields(to)
+
+	funcName := "Convert" + from.Name + "To" + to.Name
+	if from.Name == to.Name && from.Package != nil {
+		funcName = "Convert" + upperFirst(from.Package.Name) + from.Name + "To" + to.Name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s converts a %s into a %s.\n", funcName, fromName, to.Name)
+	fmt.Fprintf(&b, "func %s(in %s) %s {\n", funcName, fromName, to.Name)
+	fmt.Fprintf(&b, "var out %s\n", to.Name)
+
+	for _, target := range to.Fields {
+		name := fieldName(target)
+		source, ok := sourceFields[name]
+		if !ok {
+			fmt.Fprintf(&b, "// TODO: set %s (%s), no matching field in %s\n", name, target.Type, fromName)
+			continue
+		}
+		if !samePackage && !token.IsExported(name) {
+			fmt.Fprintf(&b, "// TODO: set %s, unexported field cannot be read from %s\n", name, fromName)
+			continue
+		}
+		fromType := source.Type
+		if !samePackage {
+			fromType = qualify(fromType, qualifier)
+		}
+		var kind conversion
+		if fromTypes != nil && toTypes != nil && fromTypes[name] != nil && toTypes[name] != nil {
+			kind = classifyTypes(fromTypes[name].Type(), toTypes[name].Type())
+		} else {
+			kind = classifyNames(qualify(source.Type, packagePath(from)), qualify(target.Type, packagePath(to)))
+		}
+		b.WriteString(assignment(kind, name, fromType, target.Type))
+	}
+
+	b.WriteString("return out\n}\n")
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format converter: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// packageQualifiers returns the package names the code of a function
+// refers to, such as "time" in time.Duration(in.Timeout)
+func packageQualifiers(code string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+code, 0)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			// Unresolved identifiers are not declared in the function
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && !seen[x.Name] {
+				seen[x.Name] = true
+				names = append(names, x.Name)
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// withImports returns the source of a file, starting a new file if it has
+// none, with the given imports added unless it has them already
+func withImports(file *module.File, imports []*module.Import) (string, error) {
+	source := file.SourceCode
+	if source == "" {
+		source = "package " + file.Package.Name + "\n"
+	}
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file.Name, source, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	added := false
+	for _, imp := range imports {
+		if imp.IsBlank {
+			continue
+		}
+		added = astutil.AddNamedImport(fset, parsed, imp.Name, imp.Path) || added
+	}
+	if !added {
+		return source, nil
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, parsed); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// conversion is the way a field value is converted to the target field's type
+type conversion int
+
+const (
+	convertUnsupported conversion = iota // No conversion, left as a TODO
+	convertCopy                          // Identical types
+	convertNumeric                       // Numeric conversion expression
+	convertDeref                         // Pointer to value
+	convertAddress                       // Value to pointer
+)
+
+// classifyTypes determines the conversion between type-checked types
+func classifyTypes(from, to types.Type) conversion {
+	isNumeric := func(t types.Type) bool {
+		basic, ok := t.Underlying().(*types.Basic)
+		return ok && basic.Info()&types.IsNumeric != 0 && basic.Info()&types.IsComplex == 0
+	}
+	switch {
+	case types.Identical(from, to):
+		return convertCopy
+	case isNumeric(from) && isNumeric(to):
+		return convertNumeric
+	}
+	if ptr, ok := from.(*types.Pointer); ok && types.Identical(ptr.Elem(), to) {
+		return convertDeref
+	}
+	if ptr, ok := to.(*types.Pointer); ok && types.Identical(from, ptr.Elem()) {
+		return convertAddress
+	}
+	return convertUnsupported
+}
+
+// classifyNames determines the conversion between package-qualified type
+// expressions
+func classifyNames(from, to string) conversion {
+	switch {
+	case from == to:
+		return convertCopy
+	case numericTypes[from] && numericTypes[to]:
+		return convertNumeric
+	case "*"+to == from:
+		return convertDeref
+	case "*"+from == to:
+		return convertAddress
+	}
+	return convertUnsupported
+}
+
+// assignment returns the statements copying field name from in to out;
+// the types are as written in the generated function
+func assignment(kind conversion, name, fromType, toType string) string {
+	switch kind {
+	case convertCopy:
+		return fmt.Sprintf("out.%s = in.%s\n", name, name)
+
+	case convertNumeric:
+		return fmt.Sprintf("out.%s = %s(in.%s) // converted from %s, check for overflow or precision loss\n",
+			name, toType, name, fromType)
+
+	case convertDeref:
+		return fmt.Sprintf("if in.%s != nil {\nout.%s = *in.%s\n}\n", name, name, name)
+
+	case convertAddress:
+		return fmt.Sprintf("%s := in.%s\nout.%s = &%s\n", lowerFirst(name)+"Value", name, name, lowerFirst(name)+"Value")
+
+	default:
+		return fmt.Sprintf("// TODO: convert %s from %s to %s\n", name, fromType, toType)
+	}
+}
+
+// structFields returns the type-checked fields of a struct type by name, or
+// nil if its package has no type information
+func structFields(t *module.Type) map[string]*types.Var {
+	if t.Package == nil || t.Package.TypesPackage == nil {
+		return nil
+	}
+	typeName, ok := t.Package.TypesPackage.Scope().Lookup(t.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	st, ok := typeName.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]*types.Var, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		fields[st.Field(i).Name()] = st.Field(i)
+	}
+	return fields
+}
+
+// packagePath returns the import path of a type's package, or an empty
+// string if it has none
+func packagePath(t *module.Type) string {
+	if t.Package == nil {
+		return ""
+	}
+	return t.Package.ImportPath
+}
+
+// qualify prefixes the type names a type expression declares in its own
+// package with qualifier, e.g. "[]Tag" becomes "[]dto.Tag"; predeclared
+// and already qualified names are kept. Expressions that do not parse are
+// returned as they are.
+func qualify(expr, qualifier string) string {
+	if qualifier == "" {
+		return expr
+	}
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return expr
+	}
+	selected := make(map[*ast.Ident]bool)
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				selected[x] = true
+			}
+			selected[sel.Sel] = true
+		}
+		return true
+	})
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && !selected[ident] && types.Universe.Lookup(ident.Name) == nil {
+			ident.Name = qualifier + "." + ident.Name
+		}
+		return true
+	})
+	return types.ExprString(parsed)
+}
+
+// fieldName returns the name of a field; embedded fields are named by their type
+func fieldName(f *module.Field) string {
+	if !f.IsEmbedded {
+		return f.Name
+	}
+	name := strings.TrimPrefix(f.Type, "*")
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// upperFirst upper-cases the first letter of s
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// lowerFirst lower-cases the first letter of s
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
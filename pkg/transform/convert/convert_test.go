@@ -0,0 +1,202 @@
+package convert
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+)
+
+func TestGenerateConverter(t *testing.T) {
+	dto := module.NewPackage("dto", "example.com/app/dto", "")
+	model := module.NewPackage("model", "example.com/app/model", "")
+
+	from := module.NewType("UserDTO", "struct", true)
+	from.AddField("ID", "int32", "", false, "")
+	from.AddField("Name", "string", "", false, "")
+	from.AddField("Email", "*string", "", false, "")
+	from.AddField("Age", "int", "", false, "")
+	from.AddField("Tags", "string", "", false, "")
+	from.AddField("", "Metadata", "", true, "")
+	from.AddField("secret", "string", "", false, "")
+	dto.AddType(from)
+
+	to := module.NewType("User", "struct", true)
+	to.AddField("ID", "int64", "", false, "")
+	to.AddField("Name", "string", "", false, "")
+	to.AddField("Email", "string", "", false, "")
+	to.AddField("Age", "*int", "", false, "")
+	to.AddField("Tags", "[]string", "", false, "")
+	to.AddField("Metadata", "Metadata", "", false, "")
+	to.AddField("secret", "string", "", false, "")
+	to.AddField("Created", "time.Time", "", false, "")
+	model.AddType(to)
+
+	code, err := GenerateConverter(from, to)
+	if err != nil {
+		t.Fatalf("GenerateConverter failed: %v", err)
+	}
+
+	expected := `// ConvertUserDTOToUser converts a dto.UserDTO into a User.
+func ConvertUserDTOToUser(in dto.UserDTO) User {
+	var out User
+	out.ID = int64(in.ID) // converted from int32, check for overflow or precision loss
+	out.Name = in.Name
+	if in.Email != nil {
+		out.Email = *in.Email
+	}
+	ageValue := in.Age
+	out.Age = &ageValue
+	// TODO: convert Tags from string to []string
+	// TODO: convert Metadata from dto.Metadata to Metadata
+	// TODO: set secret, unexported field cannot be read from dto.UserDTO
+	// TODO: set Created (time.Time), no matching field in dto.UserDTO
+	return out
+}
+`
+	if code != expected {
+		t.Errorf("Unexpected converter:\n--- expected\n%s\n--- got\n%s", expected, code)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "", "package model\n"+code, 0); err != nil {
+		t.Errorf("Generated code does not parse: %v", err)
+	}
+}
+
+func TestGenerateConverter_TypeInfo(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.21\n",
+		"shared/shared.go": "package shared\n\ntype Meta struct{}\n",
+		"dto/dto.go":       "package dto\n\nimport \"example.com/app/shared\"\n\ntype Status int\n\ntype Level = int\n\ntype User struct {\n\tMeta   shared.Meta\n\tStatus Status\n\tLevel  Level\n}\n",
+		"model/model.go":   "package model\n\nimport \"example.com/app/shared\"\n\ntype Status string\n\ntype User struct {\n\tMeta   shared.Meta\n\tStatus Status\n\tLevel  int\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	code, err := GenerateConverter(mod.Packages["example.com/app/dto"].Types["User"], mod.Packages["example.com/app/model"].Types["User"])
+	if err != nil {
+		t.Fatalf("GenerateConverter failed: %v", err)
+	}
+	// Same-named types of different packages differ, aliases do not
+	for _, want := range []string{
+		"out.Meta = in.Meta\n",
+		"// TODO: convert Status from dto.Status to Status\n",
+		"out.Level = in.Level\n",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Expected %q in converter:\n%s", want, code)
+		}
+	}
+}
+
+func TestGenerateConverter_SameName(t *testing.T) {
+	api := module.NewPackage("api", "example.com/app/api", "")
+	store := module.NewPackage("store", "example.com/app/store", "")
+
+	from := module.NewType("Item", "struct", true)
+	from.AddField("ID", "string", "", false, "")
+	api.AddType(from)
+
+	to := module.NewType("Item", "struct", true)
+	to.AddField("ID", "string", "", false, "")
+	store.AddType(to)
+
+	code, err := GenerateConverter(from, to)
+	if err != nil {
+		t.Fatalf("GenerateConverter failed: %v", err)
+	}
+	if !strings.Contains(code, "func ConvertApiItemToItem(in api.Item) Item {") {
+		t.Errorf("Expected package-qualified function name, got:\n%s", code)
+	}
+}
+
+func TestGenerateConverter_RequiresStructs(t *testing.T) {
+	from := module.NewType("Reader", "interface", true)
+	to := module.NewType("User", "struct", true)
+	if _, err := GenerateConverter(from, to); err == nil {
+		t.Error("Expected an error for non-struct types")
+	}
+}
+
+func TestAddConverter_CrossPackage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.21\n",
+		"units/units.go":    "package units\n\ntype Meters float64\n",
+		"dto/dto.go":        "package dto\n\ntype User struct {\n\tID       int32\n\tName     string\n\tDistance float64\n}\n",
+		"legacy/dto/dto.go": "package dto\n\nconst Version = 1\n",
+		"model/model.go":    "package model\n\nimport \"example.com/app/units\"\n\ntype User struct {\n\tID       int64\n\tName     string\n\tDistance units.Meters\n}\n",
+		"model/convert.go":  "package model\n\nimport \"example.com/app/legacy/dto\"\n\n// LegacyVersion is the version of the legacy types\nconst LegacyVersion = dto.Version\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+
+	model := mod.Packages["example.com/app/model"]
+	file := model.Files["convert.go"]
+	code, err := AddConverter(file, mod.Packages["example.com/app/dto"].Types["User"], model.Types["User"])
+	if err != nil {
+		t.Fatalf("AddConverter failed: %v", err)
+	}
+	// dto is taken by the legacy import of the file
+	for _, want := range []string{
+		"func ConvertDtoUserToUser(in appdto.User) User {",
+		"out.Distance = units.Meters(in.Distance)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Expected %q in converter:\n%s", want, code)
+		}
+	}
+	for _, want := range []string{`appdto "example.com/app/dto"`, `"example.com/app/units"`, `"example.com/app/legacy/dto"`} {
+		if !strings.Contains(file.SourceCode, want) {
+			t.Errorf("Expected import %s in file:\n%s", want, file.SourceCode)
+		}
+	}
+
+	if _, err := AddConverter(mod.Packages["example.com/app/dto"].Files["dto.go"], mod.Packages["example.com/app/dto"].Types["User"], model.Types["User"]); err == nil {
+		t.Error("Expected an error for a file of another package")
+	}
+
+	if err := saver.NewGoModuleSaver().Save(mod); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Module with the converter does not build: %v\n%s", err, output)
+	}
+}
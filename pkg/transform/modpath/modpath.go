@@ -0,0 +1,241 @@
+// Package modpath provides a transformer for changing a module's path.
+package modpath
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	gomodule "golang.org/x/mod/module"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/transform"
+)
+
+// ModulePathRewriter changes the path of a module and rewrites every import
+// of its packages accordingly
+type ModulePathRewriter struct {
+	OldPath string // Current module path
+	NewPath string // New module path, e.g. with a "/v2" suffix
+	DryRun  bool   // Whether to perform a dry run
+}
+
+// NewModulePathRewriter creates a new module path rewriter
+func NewModulePathRewriter(oldPath, newPath string, dryRun bool) *ModulePathRewriter {
+	return &ModulePathRewriter{
+		OldPath: oldPath,
+		NewPath: newPath,
+		DryRun:  dryRun,
+	}
+}
+
+// RewriteModulePath changes the module's path from oldPath to newPath,
+// updating the module line, package import paths and all imports under the
+// old prefix. Rewritten files keep their edited source for the saver.
+func RewriteModulePath(mod *module.Module, oldPath, newPath string) error {
+	result := NewModulePathRewriter(oldPath, newPath, false).Transform(mod)
+	return result.Error
+}
+
+// Transform implements the ModuleTransformer interface
+func (r *ModulePathRewriter) Transform(mod *module.Module) *transform.TransformationResult {
+	result := &transform.TransformationResult{
+		Summary:       fmt.Sprintf("Rewrite module path '%s' to '%s'", r.OldPath, r.NewPath),
+		IsDryRun:      r.DryRun,
+		AffectedFiles: []string{},
+		Changes:       []transform.ChangePreview{},
+	}
+
+	if err := r.validate(mod); err != nil {
+		result.Error = err
+		result.Details = "Module path was not changed"
+		return result
+	}
+
+	affected := make(map[string]bool)
+
+	// Collect import rewrites across all files first, so a dry run sees them all
+	for _, pkg := range sortedPackages(mod) {
+		for _, file := range sortedFiles(pkg) {
+			changed := false
+			for _, imp := range file.Imports {
+				newImport, ok := r.rewrite(imp.Path)
+				if !ok {
+					continue
+				}
+
+				line := 0
+				if pos := imp.GetPosition(); pos != nil {
+					line = pos.LineStart
+				}
+				result.Changes = append(result.Changes, transform.ChangePreview{
+					FilePath:   file.Path,
+					LineNumber: line,
+					Original:   strconv.Quote(imp.Path),
+					New:        strconv.Quote(newImport),
+				})
+
+				if !r.DryRun {
+					imp.Path = newImport
+				}
+				changed = true
+			}
+
+			if !changed {
+				continue
+			}
+			affected[file.Path] = true
+			if r.DryRun {
+				continue
+			}
+
+			source, err := rewriteSourceImports(file.SourceCode, r.rewrite)
+			if err != nil {
+				result.Error = fmt.Errorf("failed to rewrite imports in %s: %w", file.Path, err)
+				return result
+			}
+			file.SetSource(source)
+		}
+	}
+
+	if !r.DryRun {
+		r.applyModulePath(mod)
+	}
+
+	for path := range affected {
+		result.AffectedFiles = append(result.AffectedFiles, path)
+	}
+	sort.Strings(result.AffectedFiles)
+
+	result.Success = true
+	result.FilesAffected = len(result.AffectedFiles)
+	result.Details = fmt.Sprintf("Rewrote %d import(s) in %d file(s)", len(result.Changes), result.FilesAffected)
+
+	return result
+}
+
+// Name returns the name of the transformer
+func (r *ModulePathRewriter) Name() string {
+	return "ModulePathRewriter"
+}
+
+// Description returns a description of what the transformer does
+func (r *ModulePathRewriter) Description() string {
+	return fmt.Sprintf("Changes the module path from '%s' to '%s'", r.OldPath, r.NewPath)
+}
+
+// validate checks that the rewrite applies to the module and yields a valid path
+func (r *ModulePathRewriter) validate(mod *module.Module) error {
+	if mod == nil {
+		return fmt.Errorf("module is nil")
+	}
+	if mod.Path != r.OldPath {
+		return fmt.Errorf("module path is %q, not %q", mod.Path, r.OldPath)
+	}
+	if r.OldPath == r.NewPath {
+		return fmt.Errorf("new module path is the same as the old one")
+	}
+	if err := gomodule.CheckPath(r.NewPath); err != nil {
+		return fmt.Errorf("invalid module path: %w", err)
+	}
+	return nil
+}
+
+// rewrite maps an import path under the old module path to the new one.
+// When the new path extends the old one (as with a "/v2" major version
+// suffix), imports that already use the new path are left unchanged.
+func (r *ModulePathRewriter) rewrite(path string) (string, bool) {
+	if hasPathPrefix(path, r.NewPath) && hasPathPrefix(r.NewPath, r.OldPath) {
+		return path, false
+	}
+	if !hasPathPrefix(path, r.OldPath) {
+		return path, false
+	}
+	return r.NewPath + strings.TrimPrefix(path, r.OldPath), true
+}
+
+// applyModulePath updates the module and package import paths
+func (r *ModulePathRewriter) applyModulePath(mod *module.Module) {
+	mod.Path = r.NewPath
+
+	packages := make(map[string]*module.Package, len(mod.Packages))
+	for path, pkg := range mod.Packages {
+		if newPath, ok := r.rewrite(path); ok {
+			pkg.ImportPath = newPath
+			pkg.IsModified = true
+			path = newPath
+		}
+		for oldImport, imp := range pkg.Imports {
+			if newImport, ok := r.rewrite(oldImport); ok {
+				delete(pkg.Imports, oldImport)
+				imp.Path = newImport
+				pkg.Imports[newImport] = imp
+			}
+		}
+		packages[path] = pkg
+	}
+	mod.Packages = packages
+}
+
+// rewriteSourceImports rewrites the import paths in Go source code, leaving
+// everything else byte-for-byte unchanged
+func rewriteSourceImports(source string, rewrite func(string) (string, bool)) (string, error) {
+	if source == "" {
+		return source, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		newPath, ok := rewrite(path)
+		if !ok {
+			continue
+		}
+		start := fset.Position(spec.Path.Pos()).Offset
+		end := fset.Position(spec.Path.End()).Offset
+		b.WriteString(source[last:start])
+		b.WriteString(strconv.Quote(newPath))
+		last = end
+	}
+	b.WriteString(source[last:])
+
+	return b.String(), nil
+}
+
+// hasPathPrefix reports whether path equals prefix or is below it
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// sortedPackages returns the module's packages sorted by import path
+func sortedPackages(mod *module.Module) []*module.Package {
+	pkgs := make([]*module.Package, 0, len(mod.Packages))
+	for _, pkg := range mod.Packages {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ImportPath < pkgs[j].ImportPath })
+	return pkgs
+}
+
+// sortedFiles returns the package's files sorted by name
+func sortedFiles(pkg *module.Package) []*module.File {
+	files := make([]*module.File, 0, len(pkg.Files))
+	for _, file := range pkg.Files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
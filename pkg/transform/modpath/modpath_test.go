@@ -0,0 +1,183 @@
+package modpath
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+)
+
+// createTestModule creates a module whose packages import each other
+func createTestModule() *module.Module {
+	mod := module.NewModule("example.com/app", "")
+
+	api := module.NewPackage("api", "example.com/app/api", "")
+	apiFile := module.NewFile("api/api.go", "api.go", false)
+	apiFile.SourceCode = "package api\n\nfunc Version() string { return \"1\" }\n"
+	api.AddFile(apiFile)
+	mod.AddPackage(api)
+
+	cmd := module.NewPackage("main", "example.com/app/cmd", "")
+	cmdFile := module.NewFile("cmd/main.go", "main.go", false)
+	cmdFile.SourceCode = `package main
+
+import (
+	"fmt"
+
+	"example.com/app/api"
+	other "example.com/application/lib"
+)
+
+func main() { fmt.Println(api.Version(), other.X) }
+`
+	for _, path := range []string{"fmt", "example.com/app/api", "example.com/application/lib"} {
+		cmdFile.AddImport(module.NewImport(path, "", false))
+	}
+	cmd.AddFile(cmdFile)
+	mod.AddPackage(cmd)
+
+	// Start from a freshly loaded state
+	apiFile.IsModified = false
+	cmdFile.IsModified = false
+
+	return mod
+}
+
+func TestRewriteModulePath_MajorVersion(t *testing.T) {
+	mod := createTestModule()
+
+	if err := RewriteModulePath(mod, "example.com/app", "example.com/app/v2"); err != nil {
+		t.Fatalf("RewriteModulePath failed: %v", err)
+	}
+
+	if mod.Path != "example.com/app/v2" {
+		t.Errorf("Expected module path to be updated, got %q", mod.Path)
+	}
+
+	cmd, ok := mod.Packages["example.com/app/v2/cmd"]
+	if !ok {
+		t.Fatalf("Expected package to be re-keyed under the new path")
+	}
+	if _, ok := mod.Packages["example.com/app/v2/api"]; !ok {
+		t.Errorf("Expected api package under the new path")
+	}
+
+	file := cmd.Files["main.go"]
+	if !file.IsSourceEdited || file.IsModified {
+		t.Error("Expected rewritten file to keep its edited source")
+	}
+	if file.Imports[1].Path != "example.com/app/v2/api" {
+		t.Errorf("Expected import to be rewritten, got %q", file.Imports[1].Path)
+	}
+	if file.Imports[2].Path != "example.com/application/lib" {
+		t.Errorf("Import with a shared string prefix must not change, got %q", file.Imports[2].Path)
+	}
+	if !strings.Contains(file.SourceCode, "\t\"example.com/app/v2/api\"\n\tother \"example.com/application/lib\"") {
+		t.Errorf("Expected source imports to be rewritten, got:\n%s", file.SourceCode)
+	}
+
+	// Running again from v1 paths must not double the suffix
+	again := NewModulePathRewriter("example.com/app/v2", "example.com/app/v3", false).Transform(mod)
+	if !again.Success {
+		t.Fatalf("Second rewrite failed: %v", again.Error)
+	}
+	if file.Imports[1].Path != "example.com/app/v3/api" {
+		t.Errorf("Expected import to move to v3, got %q", file.Imports[1].Path)
+	}
+}
+
+func TestRewriteModulePath_DryRun(t *testing.T) {
+	mod := createTestModule()
+
+	result := NewModulePathRewriter("example.com/app", "github.com/org/app", true).Transform(mod)
+	if !result.Success {
+		t.Fatalf("Dry run failed: %v", result.Error)
+	}
+	if len(result.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %+v", result.Changes)
+	}
+	if result.Changes[0].New != `"github.com/org/app/api"` {
+		t.Errorf("Unexpected new import %s", result.Changes[0].New)
+	}
+	if mod.Path != "example.com/app" || mod.Packages["example.com/app/cmd"].Files["main.go"].IsSourceEdited {
+		t.Error("Dry run must not modify the module")
+	}
+}
+
+func TestRewriteModulePath_Invalid(t *testing.T) {
+	mod := createTestModule()
+
+	if err := RewriteModulePath(mod, "example.com/other", "example.com/new"); err == nil {
+		t.Error("Expected an error when the old path does not match")
+	}
+	if err := RewriteModulePath(mod, "example.com/app", "not a path"); err == nil {
+		t.Error("Expected an error for an invalid module path")
+	}
+}
+
+func TestRewriteModulePath_SaveBuilds(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"api/api.go": `package api
+
+// Version returns the version.
+//
+// Second paragraph of the doc.
+func Version() string { return "1" }
+`,
+		"cmd/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/api"
+)
+
+// main prints the version.
+// It has a multi-line doc.
+func main() { fmt.Println(api.Version()) }
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	mod, err := loader.NewGoModuleLoader().Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	if err := RewriteModulePath(mod, "example.com/app", "example.com/app/v2"); err != nil {
+		t.Fatalf("RewriteModulePath failed: %v", err)
+	}
+
+	out := t.TempDir()
+	if err := saver.NewGoModuleSaver().SaveTo(mod, out); err != nil {
+		t.Fatalf("SaveTo failed: %v", err)
+	}
+	saved, err := os.ReadFile(filepath.Join(out, "cmd", "main.go"))
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if want := strings.Replace(files["cmd/main.go"], "example.com/app/api", "example.com/app/v2/api", 1); string(saved) != want {
+		t.Errorf("Expected only the import to change, got:\n%s", saved)
+	}
+
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = out
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Saved module does not build: %v\n%s", err, output)
+	}
+}
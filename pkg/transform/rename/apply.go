@@ -0,0 +1,87 @@
+package rename
+
+import (
+	"fmt"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// RenameResult describes a rename applied to a module's sources
+type RenameResult struct {
+	Edits    map[string][]Edit // Applied edits by file path, sorted by offset
+	Files    []string          // Sorted paths of the changed files
+	Warnings []string          // Notes on what the rename left unchanged
+}
+
+// RenameSymbol renames a package-level symbol or method of the module and
+// every reference to it, checked as by BatchRename, and rewrites the
+// source of the changed files (see ApplyToModule). The module must be
+// loaded with IncludeAST. References from outside the module, which only
+// exist for exported symbols, cannot be found and are not updated.
+func RenameSymbol(mod *module.Module, sym *module.Symbol, newName string) (*RenameResult, error) {
+	edits, err := BatchRename(mod, map[*module.Symbol]string{sym: newName})
+	if err != nil {
+		return nil, err
+	}
+	files, err := ApplyToModule(mod, edits)
+	if err != nil {
+		return nil, err
+	}
+	return &RenameResult{Edits: edits, Files: files}, nil
+}
+
+// RenameStructField renames a field of a struct type of the module as by
+// RenameField and rewrites the source of the changed files
+func RenameStructField(mod *module.Module, typ *module.Type, fieldName, newName string, opts FieldRenameOptions) (*RenameResult, error) {
+	edits, warnings, err := RenameField(mod, typ, fieldName, newName, opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ApplyToModule(mod, edits)
+	if err != nil {
+		return nil, err
+	}
+	return &RenameResult{Edits: edits, Files: files, Warnings: warnings}, nil
+}
+
+// ApplyToModule applies edits to the source of the module's files and
+// returns the sorted paths of the files changed. Nothing is changed if an
+// edit does not apply. The edited source is set with File.SetSource, so it
+// is saved as-is rather than regenerated from the model, which still has
+// the old names, and changed files show up in patches. Positions
+// of the model no longer match the edited source, so the module must be
+// saved and reloaded before computing further edits; stale edits are
+// rejected.
+func ApplyToModule(mod *module.Module, edits map[string][]Edit) ([]string, error) {
+	files := make(map[string]*module.File)
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			files[file.Path] = file
+		}
+	}
+
+	updated := make(map[string]string, len(edits))
+	for path, fileEdits := range edits {
+		if len(fileEdits) == 0 {
+			continue
+		}
+		file := files[path]
+		if file == nil {
+			return nil, fmt.Errorf("edited file %s is not part of the module", path)
+		}
+		source, err := ApplyEdits(file.SourceCode, fileEdits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to edit %s: %w", path, err)
+		}
+		updated[path] = source
+	}
+
+	changed := make([]string, 0, len(updated))
+	for path, source := range updated {
+		files[path].SetSource(source)
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
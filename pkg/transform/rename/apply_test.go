@@ -0,0 +1,166 @@
+package rename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+)
+
+func TestRenameSymbolAndSave(t *testing.T) {
+	tests := []struct {
+		name   string
+		rename func(t *testing.T, mod *module.Module) (*RenameResult, error)
+		files  []string
+		want   []string
+	}{
+		{
+			name: "function",
+			rename: func(t *testing.T, mod *module.Module) (*RenameResult, error) {
+				return RenameSymbol(mod, symbolByID(t, mod, "example.com/batch/store.New"), "Open")
+			},
+			files: []string{"app/app.go", "store/store.go"},
+			want:  []string{"func Open()", "store.Open()"},
+		},
+		{
+			name: "method",
+			rename: func(t *testing.T, mod *module.Module) (*RenameResult, error) {
+				return RenameSymbol(mod, symbolByID(t, mod, "example.com/batch/store.Store.Len"), "Size")
+			},
+			files: []string{"app/app.go", "store/store.go"},
+			want:  []string{"func (s *Store) Size()", "return s.Size()"},
+		},
+		{
+			name: "struct field",
+			rename: func(t *testing.T, mod *module.Module) (*RenameResult, error) {
+				typ := mod.Packages["example.com/batch/store"].Types["Store"]
+				return RenameStructField(mod, typ, "items", "entries", FieldRenameOptions{})
+			},
+			files: []string{"store/store.go"},
+			want:  []string{"type Store struct{ entries []string }", "s.entries = append(s.entries, item)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod := loadBatchModule(t)
+			result, err := tt.rename(t, mod)
+			if err != nil {
+				t.Fatalf("Rename failed: %v", err)
+			}
+			var want []string
+			for _, name := range tt.files {
+				want = append(want, filepath.Join(mod.Dir, name))
+			}
+			if strings.Join(result.Files, ",") != strings.Join(want, ",") {
+				t.Errorf("Expected changed files %v, got %v", want, result.Files)
+			}
+
+			// A patch shows the rename in every changed file
+			var patch strings.Builder
+			if err := saver.NewGoModuleSaver().SavePatch(mod, &patch); err != nil {
+				t.Fatalf("SavePatch failed: %v", err)
+			}
+			for _, name := range tt.files {
+				if !strings.Contains(patch.String(), "+++ b/"+name+"\n") {
+					t.Errorf("Expected the patch to change %s:\n%s", name, patch.String())
+				}
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(patch.String(), s) {
+					t.Errorf("Expected the patch to contain %q:\n%s", s, patch.String())
+				}
+			}
+
+			// The saved module type-checks with the new names
+			dir := t.TempDir()
+			if err := saver.NewGoModuleSaver().SaveTo(mod, dir); err != nil {
+				t.Fatalf("Failed to save module: %v", err)
+			}
+			options := loader.DefaultLoadOptions()
+			options.IncludeAST = true
+			saved, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+			if err != nil {
+				t.Fatalf("Failed to load saved module: %v", err)
+			}
+			var sources strings.Builder
+			for _, pkg := range saved.Packages {
+				for _, file := range pkg.Files {
+					sources.WriteString(file.SourceCode)
+				}
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(sources.String(), s) {
+					t.Errorf("Expected the saved module to contain %q:\n%s", s, sources.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenameSymbolConflict(t *testing.T) {
+	mod := loadBatchModule(t)
+	store := mod.Packages["example.com/batch/store"].Files["store.go"]
+	before := store.SourceCode
+
+	_, err := RenameSymbol(mod, symbolByID(t, mod, "example.com/batch/store.helper"), "Limit")
+	var conflicts *ConflictError
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("Expected a conflict, got %v", err)
+	}
+	if store.SourceCode != before {
+		t.Error("Expected a conflicting rename to leave the source unchanged")
+	}
+
+	// Edits computed before a rename no longer apply afterwards
+	stale, err := BatchRename(mod, map[*module.Symbol]string{symbolByID(t, mod, "example.com/batch/store.Store.Len"): "Size"})
+	if err != nil {
+		t.Fatalf("BatchRename failed: %v", err)
+	}
+	if _, err := RenameSymbol(mod, symbolByID(t, mod, "example.com/batch/store.New"), "Open"); err != nil {
+		t.Fatalf("RenameSymbol failed: %v", err)
+	}
+	if _, err := ApplyToModule(mod, stale); err == nil {
+		t.Error("Expected stale edits to be rejected")
+	}
+}
+
+func TestRenameSymbolAfterReload(t *testing.T) {
+	mod := loadFiles(t, map[string]string{
+		"go.mod": "module example.com/reload\n\ngo 1.21\n",
+		"a/a.go": "package a\n\nfunc F() int { return 1 }\n",
+		"b/b.go": "package b\n\nimport \"example.com/reload/a\"\n\nfunc G() int { return a.F() }\n",
+		"c/c.go": "package c\n\nfunc C() {}\n",
+	})
+	c := mod.Packages["example.com/reload/c"]
+
+	// Change a/a.go on disk and reload it
+	path := filepath.Join(mod.Dir, "a", "a.go")
+	if err := os.WriteFile(path, []byte("package a\n\nfunc F() int { return 2 }\n\nfunc Unused() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write a.go: %v", err)
+	}
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	if err := loader.NewGoModuleLoader().ReloadFile(mod, path, options); err != nil {
+		t.Fatalf("ReloadFile failed: %v", err)
+	}
+	if mod.Packages["example.com/reload/c"] != c {
+		t.Error("Expected the unconnected package to be kept")
+	}
+
+	result, err := RenameSymbol(mod, symbolByID(t, mod, "example.com/reload/a.F"), "H")
+	if err != nil {
+		t.Fatalf("RenameSymbol failed: %v", err)
+	}
+	want := []string{path, filepath.Join(mod.Dir, "b", "b.go")}
+	if strings.Join(result.Files, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected the rename to change %v, got %v", want, result.Files)
+	}
+	if b := mod.Packages["example.com/reload/b"].Files["b.go"].SourceCode; !strings.Contains(b, "return a.H()") {
+		t.Errorf("Expected the caller in b to be renamed:\n%s", b)
+	}
+}
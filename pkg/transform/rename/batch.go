@@ -0,0 +1,305 @@
+// Package rename provides batch renaming of module symbols with collision checks.
+package rename
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// Edit replaces a byte range of a file's source
+type Edit struct {
+	Start   int    // Byte offset of the first replaced byte
+	End     int    // Byte offset just after the last replaced byte
+	OldText string // Text being replaced
+	NewText string // Replacement text
+	Line    int    // Line of the edit, for display
+}
+
+// Conflict explains why a rename cannot be applied
+type Conflict struct {
+	Symbol  *module.Symbol // Symbol being renamed
+	NewName string         // Requested name
+	Reason  string         // Why the rename is invalid
+}
+
+// ConflictError lists every conflict found in a batch of renames
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+// Error implements the error interface
+func (e *ConflictError) Error() string {
+	lines := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		lines = append(lines, fmt.Sprintf("%s -> %s: %s", c.Symbol.ID, c.NewName, c.Reason))
+	}
+	return fmt.Sprintf("%d rename conflict(s):\n%s", len(e.Conflicts), strings.Join(lines, "\n"))
+}
+
+// target is a symbol being renamed together with its type-checked object
+type target struct {
+	symbol  *module.Symbol
+	newName string
+	obj     types.Object
+	pkg     *module.Package
+}
+
+// BatchRename computes the edits for renaming many symbols at once. The
+// whole set is checked before any edit is produced: new names must be valid
+// identifiers, must not collide with existing declarations or with each
+// other, must not be shadowed at any reference, and exported symbols used by
+// other packages must stay exported. If any rename is invalid, no edits are
+// returned and the error is a *ConflictError listing all conflicts.
+//
+// Edits are keyed by file path and sorted by offset. The module must be
+// loaded with IncludeAST so references can be resolved; the module itself
+// is not modified (see ApplyEdits).
+func BatchRename(mod *module.Module, renames map[*module.Symbol]string) (map[string][]Edit, error) {
+	var conflicts []Conflict
+	var targets []*target
+
+	for sym, newName := range renames {
+		pkg := mod.Packages[sym.Package]
+		if pkg == nil || pkg.TypesPackage == nil || pkg.TypesInfo == nil {
+			return nil, fmt.Errorf("no type information for package %s, load with IncludeAST", sym.Package)
+		}
+		obj := mod.LookupObject(sym)
+		if obj == nil {
+			return nil, fmt.Errorf("symbol %s not found in type information", sym.ID)
+		}
+		targets = append(targets, &target{symbol: sym, newName: newName, obj: obj, pkg: pkg})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].symbol.ID < targets[j].symbol.ID })
+
+	renamed := make(map[types.Object]*target, len(targets))
+	for _, t := range targets {
+		renamed[t.obj] = t
+	}
+
+	refs := collectReferences(mod, renamed)
+
+	for _, t := range targets {
+		conflict := func(format string, args ...interface{}) {
+			conflicts = append(conflicts, Conflict{Symbol: t.symbol, NewName: t.newName, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		if !token.IsIdentifier(t.newName) {
+			conflict("not a valid identifier")
+			continue
+		}
+		if t.newName == t.obj.Name() {
+			continue
+		}
+
+		// Exported symbols referenced from other packages must stay exported
+		if t.obj.Exported() && !token.IsExported(t.newName) {
+			for _, ref := range refs[t.obj] {
+				if ref.pkg != t.pkg {
+					conflict("unexporting symbol used by package %s", ref.pkg.ImportPath)
+					break
+				}
+			}
+		}
+
+		// Collisions with declarations in the same scope, including other renames
+		for _, other := range targets {
+			if other != t && other.newName == t.newName && sameScope(other.obj, t.obj) {
+				conflict("collides with rename of %s", other.symbol.ID)
+			}
+		}
+		if existing := declaredIn(t.obj, t.newName); existing != nil {
+			if r, ok := renamed[existing]; !ok || r.newName == t.newName {
+				conflict("collides with existing %s at %s", existing.Name(), objectPosition(mod, existing))
+			}
+		}
+
+		// References must not be captured by a declaration in an inner scope
+		if t.symbol.Kind != module.SymbolMethod {
+			for _, ref := range refs[t.obj] {
+				if shadow := shadowing(ref, t.newName, t.obj); shadow != nil {
+					if _, ok := renamed[shadow]; !ok {
+						conflict("would be shadowed by %s at %s", shadow.Name(), objectPosition(mod, shadow))
+						break
+					}
+				}
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	edits := make(map[string][]Edit)
+	for obj, idents := range refs {
+		t := renamed[obj]
+		if t.newName == obj.Name() {
+			continue
+		}
+		addIdentEdits(edits, idents, t.newName)
+	}
+	sortEdits(edits)
+	return edits, nil
+}
+
+// addIdentEdits adds an edit replacing each referencing identifier
+func addIdentEdits(edits map[string][]Edit, refs []reference, newName string) {
+	for _, ref := range refs {
+		position := ref.file.FileSet.Position(ref.ident.Pos())
+		edits[position.Filename] = append(edits[position.Filename], Edit{
+			Start:   position.Offset,
+			End:     position.Offset + len(ref.ident.Name),
+			OldText: ref.ident.Name,
+			NewText: newName,
+			Line:    position.Line,
+		})
+	}
+}
+
+// sortEdits orders the edits of each file by offset
+func sortEdits(edits map[string][]Edit) {
+	for _, fileEdits := range edits {
+		sort.Slice(fileEdits, func(i, j int) bool { return fileEdits[i].Start < fileEdits[j].Start })
+	}
+}
+
+// ApplyEdits returns the source with the edits applied. The edits must be
+// sorted by offset and must not overlap.
+func ApplyEdits(source string, edits []Edit) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End > len(source) || source[e.Start:e.End] != e.OldText {
+			return "", fmt.Errorf("edit at offset %d does not match the source", e.Start)
+		}
+		b.WriteString(source[last:e.Start])
+		b.WriteString(e.NewText)
+		last = e.End
+	}
+	b.WriteString(source[last:])
+	return b.String(), nil
+}
+
+// reference is an identifier that defines or uses a renamed object
+type reference struct {
+	ident *ast.Ident
+	file  *module.File
+	pkg   *module.Package
+}
+
+// collectReferences finds every identifier of the module that refers to one
+// of the renamed objects
+func collectReferences(mod *module.Module, renamed map[types.Object]*target) map[types.Object][]reference {
+	refs := make(map[types.Object][]reference)
+	for _, pkg := range mod.Packages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Files {
+			if file.AST == nil || file.FileSet == nil {
+				continue
+			}
+			ast.Inspect(file.AST, func(n ast.Node) bool {
+				ident, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				obj := pkg.TypesInfo.Defs[ident]
+				if obj == nil {
+					obj = pkg.TypesInfo.Uses[ident]
+				}
+				if obj != nil {
+					obj = module.Origin(obj)
+					if _, ok := renamed[obj]; ok {
+						refs[obj] = append(refs[obj], reference{ident: ident, file: file, pkg: pkg})
+					}
+				}
+				return true
+			})
+		}
+	}
+	return refs
+}
+
+// sameScope reports whether two objects are declared in the same namespace
+func sameScope(a, b types.Object) bool {
+	ra, rb := receiverNamed(a), receiverNamed(b)
+	if ra != nil || rb != nil {
+		return ra == rb
+	}
+	return a.Pkg() == b.Pkg()
+}
+
+// declaredIn returns an existing declaration named name that a rename of
+// obj would collide with: a package-level object, or a field or method of
+// the receiver type for methods
+func declaredIn(obj types.Object, name string) types.Object {
+	if named := receiverNamed(obj); named != nil {
+		existing, _, _ := types.LookupFieldOrMethod(named, true, obj.Pkg(), name)
+		return existing
+	}
+	return obj.Pkg().Scope().Lookup(name)
+}
+
+// shadowing returns the object a reference would resolve to after renaming
+// if that is not the renamed object itself
+func shadowing(ref reference, name string, obj types.Object) types.Object {
+	if ref.pkg.TypesInfo.Defs[ref.ident] != nil {
+		return nil
+	}
+	scope := ref.pkg.TypesPackage.Scope().Innermost(ref.ident.Pos())
+	if scope == nil {
+		return nil
+	}
+	_, found := scope.LookupParent(name, ref.ident.Pos())
+	if found == nil || found == obj || found.Parent() == obj.Pkg().Scope() || found.Parent() == types.Universe {
+		// Package-level names are covered by declaredIn; a universe name is
+		// simply hidden by the renamed declaration
+		return nil
+	}
+	if ref.pkg.TypesPackage != obj.Pkg() {
+		// Other packages refer to the symbol qualified
+		return nil
+	}
+	return found
+}
+
+// receiverNamed returns the receiver type of a method, or nil
+func receiverNamed(obj types.Object) *types.Named {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return nil
+	}
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	named, _ := recv.(*types.Named)
+	if named != nil {
+		named = named.Origin()
+	}
+	return named
+}
+
+// objectPosition formats the declaration position of an object using the
+// file set the module's files share
+func objectPosition(mod *module.Module, obj types.Object) string {
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			if file.FileSet != nil {
+				return file.FileSet.Position(obj.Pos()).String()
+			}
+		}
+	}
+	return obj.Name()
+}
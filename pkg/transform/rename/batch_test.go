@@ -0,0 +1,159 @@
+package rename
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// loadBatchModule writes a two-package module and loads it with type information
+func loadBatchModule(t *testing.T) *module.Module {
+	t.Helper()
+
+	return loadFiles(t, map[string]string{
+		"go.mod": "module example.com/batch\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+const Limit = 10
+
+type Store struct{ items []string }
+
+func (s *Store) Add(item string) { s.items = append(s.items, item) }
+
+func (s *Store) Len() int { return len(s.items) }
+
+func New() *Store { return &Store{} }
+
+func helper(count int) int { return count + Limit }
+`,
+		"app/app.go": `package app
+
+import "example.com/batch/store"
+
+func Run() int {
+	var s *store.Store = store.New()
+	s.Add("x")
+	return s.Len()
+}
+`,
+	})
+}
+
+// loadFiles writes the files of a module and loads it with type information
+func loadFiles(t *testing.T, files map[string]string) *module.Module {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	return mod
+}
+
+// symbolByID finds a symbol of the module by its ID
+func symbolByID(t *testing.T, mod *module.Module, id string) *module.Symbol {
+	t.Helper()
+	for _, sym := range mod.Symbols() {
+		if sym.ID == id {
+			return sym
+		}
+	}
+	t.Fatalf("Symbol %s not found", id)
+	return nil
+}
+
+func TestBatchRename(t *testing.T) {
+	mod := loadBatchModule(t)
+
+	edits, err := BatchRename(mod, map[*module.Symbol]string{
+		symbolByID(t, mod, "example.com/batch/store.New"):       "Open",
+		symbolByID(t, mod, "example.com/batch/store.Store.Add"): "Put",
+		symbolByID(t, mod, "example.com/batch/store.Limit"):     "MaxItems",
+	})
+	if err != nil {
+		t.Fatalf("BatchRename failed: %v", err)
+	}
+
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			updated, err := ApplyEdits(file.SourceCode, edits[file.Path])
+			if err != nil {
+				t.Fatalf("ApplyEdits failed: %v", err)
+			}
+			switch file.Name {
+			case "store.go":
+				for _, want := range []string{"const MaxItems = 10", "func (s *Store) Put(", "func Open()", "count + MaxItems"} {
+					if !strings.Contains(updated, want) {
+						t.Errorf("Expected store.go to contain %q:\n%s", want, updated)
+					}
+				}
+			case "app.go":
+				if !strings.Contains(updated, "store.Open()") || !strings.Contains(updated, `s.Put("x")`) {
+					t.Errorf("Expected references in app.go to be renamed:\n%s", updated)
+				}
+			}
+		}
+	}
+}
+
+func TestBatchRenameConflicts(t *testing.T) {
+	mod := loadBatchModule(t)
+
+	_, err := BatchRename(mod, map[*module.Symbol]string{
+		// Two renames to the same name
+		symbolByID(t, mod, "example.com/batch/store.New"):   "Make",
+		symbolByID(t, mod, "example.com/batch/store.Limit"): "Make",
+		// Method colliding with an existing method
+		symbolByID(t, mod, "example.com/batch/store.Store.Add"): "Len",
+		// Unexporting a symbol used by another package
+		symbolByID(t, mod, "example.com/batch/store.Store"): "store",
+	})
+
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected a ConflictError, got %v", err)
+	}
+
+	reasons := make(map[string]bool)
+	for _, c := range conflictErr.Conflicts {
+		reasons[c.Symbol.Name+": "+c.Reason] = true
+	}
+	for _, want := range []string{
+		"New: collides with rename of example.com/batch/store.Limit",
+		"Limit: collides with rename of example.com/batch/store.New",
+		"Store: unexporting symbol used by package example.com/batch/app",
+	} {
+		if !reasons[want] {
+			t.Errorf("Expected conflict %q, got %v", want, reasons)
+		}
+	}
+	if len(conflictErr.Conflicts) != 4 {
+		t.Errorf("Expected 4 conflicts, got %d:\n%v", len(conflictErr.Conflicts), err)
+	}
+
+	// A constant renamed to a parameter name it is used next to is shadowed
+	_, err = BatchRename(mod, map[*module.Symbol]string{
+		symbolByID(t, mod, "example.com/batch/store.Limit"): "count",
+	})
+	if err == nil || !strings.Contains(err.Error(), "shadowed by count") {
+		t.Errorf("Expected shadowing conflict, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package rename
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// FieldRenameOptions configures RenameField
+type FieldRenameOptions struct {
+	// Tag keys (e.g. "json", "yaml") whose name is renamed along with the
+	// field when it is derived from the old field name: equal to it, in
+	// lower case, or with a lower-case first letter
+	UpdateTags []string
+}
+
+// RenameField computes the edits for renaming a field of a struct type:
+// its declaration, selector references and keys of keyed composite
+// literals. Struct tags keep the serialized name unless their key is listed
+// in opts.UpdateTags and the tag name is derived from the old field name;
+// every tag left pointing at a name is reported as a warning, since
+// serialization is driven by the tag and not affected by the rename.
+//
+// The new name must not collide with a field or method of the type, and an
+// exported field used by other packages must stay exported. Embedded fields
+// cannot be renamed. The module must be loaded with IncludeAST.
+func RenameField(mod *module.Module, typ *module.Type, fieldName, newName string, opts FieldRenameOptions) (map[string][]Edit, []string, error) {
+	if typ.Package == nil || typ.Package.TypesPackage == nil || typ.Package.TypesInfo == nil {
+		return nil, nil, fmt.Errorf("no type information for type %s, load with IncludeAST", typ.Name)
+	}
+	if !token.IsIdentifier(newName) {
+		return nil, nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+
+	typeName, ok := typ.Package.TypesPackage.Scope().Lookup(typ.Name).(*types.TypeName)
+	if !ok {
+		return nil, nil, fmt.Errorf("type %s not found in type information", typ.Name)
+	}
+	st, ok := typeName.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("type %s is not a struct", typ.Name)
+	}
+	var field *types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == fieldName {
+			field = st.Field(i)
+		}
+	}
+	if field == nil {
+		return nil, nil, fmt.Errorf("type %s has no field %s", typ.Name, fieldName)
+	}
+	if field.Embedded() {
+		return nil, nil, fmt.Errorf("field %s of %s is embedded, rename its type instead", fieldName, typ.Name)
+	}
+	if newName == fieldName {
+		return map[string][]Edit{}, nil, nil
+	}
+
+	if existing, _, _ := types.LookupFieldOrMethod(types.NewPointer(typeName.Type()), true, typeName.Pkg(), newName); existing != nil {
+		return nil, nil, fmt.Errorf("%s.%s collides with existing %s at %s", typ.Name, newName, existing.Name(), objectPosition(mod, existing))
+	}
+
+	refs := collectReferences(mod, map[types.Object]*target{field: {newName: newName, obj: field}})[field]
+	if field.Exported() && !token.IsExported(newName) {
+		for _, ref := range refs {
+			if ref.pkg != typ.Package {
+				return nil, nil, fmt.Errorf("cannot unexport field %s used by package %s", fieldName, ref.pkg.ImportPath)
+			}
+		}
+	}
+
+	edits := make(map[string][]Edit)
+	addIdentEdits(edits, refs, newName)
+
+	// Struct tags
+	var warnings []string
+	if lit, file := fieldTag(typ.Package, field); lit != nil {
+		tag, err := strconv.Unquote(lit.Value)
+		if err == nil {
+			start := file.FileSet.Position(lit.Pos())
+			for _, key := range tagKeys(tag) {
+				value := reflect.StructTag(tag).Get(key)
+				name, _, _ := strings.Cut(value, ",")
+				if name == "" || name == "-" {
+					continue
+				}
+				newTagName := derivedName(name, fieldName, newName)
+				if newTagName == "" || !contains(opts.UpdateTags, key) {
+					warnings = append(warnings, fmt.Sprintf("%s tag of %s.%s keeps the serialized name %q",
+						key, typ.Name, fieldName, name))
+					continue
+				}
+				// Replace the name inside the literal, which holds the tag verbatim
+				// for raw strings; interpreted strings are rewritten as a whole
+				if offset := strings.Index(lit.Value, key+`:"`+name); lit.Value[0] == '`' && offset >= 0 {
+					offset += len(key) + 2
+					edits[start.Filename] = append(edits[start.Filename], Edit{
+						Start:   start.Offset + offset,
+						End:     start.Offset + offset + len(name),
+						OldText: name,
+						NewText: newTagName,
+						Line:    start.Line,
+					})
+				} else {
+					warnings = append(warnings, fmt.Sprintf("%s tag of %s.%s could not be updated", key, typ.Name, fieldName))
+				}
+			}
+		}
+	}
+
+	sortEdits(edits)
+	return edits, warnings, nil
+}
+
+// derivedName applies the rename to a tag name that is derived from the
+// field name, returning an empty string for unrelated names
+func derivedName(tagName, oldName, newName string) string {
+	switch tagName {
+	case oldName:
+		return newName
+	case strings.ToLower(oldName):
+		return strings.ToLower(newName)
+	case lowerFirst(oldName):
+		return lowerFirst(newName)
+	}
+	return ""
+}
+
+// lowerFirst returns s with a lower-case first letter
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// fieldTag returns the tag literal of a field's declaration and its file
+func fieldTag(pkg *module.Package, field *types.Var) (*ast.BasicLit, *module.File) {
+	for _, file := range pkg.Files {
+		if file.AST == nil || file.FileSet == nil {
+			continue
+		}
+		var tag *ast.BasicLit
+		ast.Inspect(file.AST, func(n ast.Node) bool {
+			f, ok := n.(*ast.Field)
+			if !ok || tag != nil {
+				return tag == nil
+			}
+			for _, name := range f.Names {
+				if pkg.TypesInfo.Defs[name] == field {
+					tag = f.Tag
+				}
+			}
+			return true
+		})
+		if tag != nil {
+			return tag, file
+		}
+	}
+	return nil, nil
+}
+
+// tagKeys returns the keys of a struct tag in order of appearance
+func tagKeys(tag string) []string {
+	var keys []string
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		colon := strings.Index(tag, `:"`)
+		if colon <= 0 {
+			break
+		}
+		keys = append(keys, tag[:colon])
+		// Skip the quoted value, honoring escaped quotes
+		rest := tag[colon+2:]
+		i := 0
+		for i < len(rest) && rest[i] != '"' {
+			if rest[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(rest) {
+			break
+		}
+		tag = rest[i+1:]
+	}
+	return keys
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
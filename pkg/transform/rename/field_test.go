@@ -0,0 +1,99 @@
+package rename
+
+import (
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// configModule declares a tagged struct that another package initializes
+func configModule(t *testing.T) *module.Module {
+	return loadFiles(t, map[string]string{
+		"go.mod": "module example.com/cfg\n\ngo 1.21\n",
+		"config/config.go": "package config\n\n" +
+			"type Config struct {\n" +
+			"\tTimeout int    `json:\"timeout,omitempty\" yaml:\"timeout\"`\n" +
+			"\tName    string `json:\"display_name\"`\n" +
+			"}\n\n" +
+			"func (c Config) Valid() bool { return c.Timeout > 0 }\n",
+		"app/app.go": `package app
+
+import "example.com/cfg/config"
+
+func Default() config.Config {
+	c := config.Config{Timeout: 5, Name: "app"}
+	c.Timeout++
+	return c
+}
+`,
+	})
+}
+
+// applyAll applies the edits to every file of the module, keyed by file name
+func applyAll(t *testing.T, mod *module.Module, edits map[string][]Edit) map[string]string {
+	t.Helper()
+	updated := make(map[string]string)
+	for _, pkg := range mod.Packages {
+		for _, file := range pkg.Files {
+			source, err := ApplyEdits(file.SourceCode, edits[file.Path])
+			if err != nil {
+				t.Fatalf("ApplyEdits failed: %v", err)
+			}
+			updated[file.Name] = source
+		}
+	}
+	return updated
+}
+
+func TestRenameField(t *testing.T) {
+	mod := configModule(t)
+	config := mod.Packages["example.com/cfg/config"].Types["Config"]
+
+	edits, warnings, err := RenameField(mod, config, "Timeout", "Deadline", FieldRenameOptions{UpdateTags: []string{"json"}})
+	if err != nil {
+		t.Fatalf("RenameField failed: %v", err)
+	}
+	updated := applyAll(t, mod, edits)
+
+	for file, want := range map[string]string{
+		"config.go": "Deadline int    `json:\"deadline,omitempty\" yaml:\"timeout\"`",
+		"app.go":    "config.Config{Deadline: 5, Name: \"app\"}",
+	} {
+		if !strings.Contains(updated[file], want) {
+			t.Errorf("Expected %s to contain %q:\n%s", file, want, updated[file])
+		}
+	}
+	if !strings.Contains(updated["config.go"], "c.Deadline > 0") || !strings.Contains(updated["app.go"], "c.Deadline++") {
+		t.Errorf("Expected selector references to be renamed:\n%s\n%s", updated["config.go"], updated["app.go"])
+	}
+
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `yaml tag of Config.Timeout keeps the serialized name "timeout"`) {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	// A tag-driven name is never changed
+	_, warnings, err = RenameField(mod, config, "Name", "Title", FieldRenameOptions{UpdateTags: []string{"json"}})
+	if err != nil {
+		t.Fatalf("RenameField failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"display_name"`) {
+		t.Errorf("Expected a warning about the json name, got %v", warnings)
+	}
+}
+
+func TestRenameFieldErrors(t *testing.T) {
+	mod := configModule(t)
+	config := mod.Packages["example.com/cfg/config"].Types["Config"]
+
+	for newName, want := range map[string]string{
+		"Name":    "collides with existing Name",
+		"Valid":   "collides with existing Valid",
+		"timeout": "cannot unexport field Timeout used by package example.com/cfg/app",
+		"1x":      "not a valid identifier",
+	} {
+		if _, _, err := RenameField(mod, config, "Timeout", newName, FieldRenameOptions{}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Renaming to %s: expected error containing %q, got %v", newName, want, err)
+		}
+	}
+}
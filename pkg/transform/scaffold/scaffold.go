@@ -0,0 +1,215 @@
+// Package scaffold provides a transformer that adds a new package with
+// boilerplate files to a module.
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/transform"
+)
+
+// DocTemplate is the default template of a package's doc.go
+const DocTemplate = `// Package {{.Name}} TODO: describe the package.
+package {{.Name}}
+`
+
+// TestTemplate is the default template of a package's test file
+const TestTemplate = `package {{.Name}}
+
+import "testing"
+
+func Test{{.Title}}(t *testing.T) {
+	t.Skip("TODO: add tests")
+}
+`
+
+// TemplateData is passed to the file templates
+type TemplateData struct {
+	Name       string // Package name, e.g. "billing"
+	Title      string // Package name with an upper-case first letter, e.g. "Billing"
+	ImportPath string // Full import path of the package
+	ModulePath string // Path of the module the package belongs to
+}
+
+// PackageScaffolder adds a new package to a module, rendering each of its
+// files from a template. The files keep the rendered source, so the saver
+// writes them as generated. Files that already exist on disk are never
+// overwritten.
+type PackageScaffolder struct {
+	ImportPath string            // Import path of the new package
+	Package    string            // Package name (defaults to the last path element)
+	Templates  map[string]string // File name to text/template source
+	WithTest   bool              // Whether to add <name>_test.go from TestTemplate unless Templates has it
+	DryRun     bool              // Whether to perform a dry run
+}
+
+// NewPackageScaffolder creates a scaffolder producing a doc.go and, if
+// withTest is set, a test file named after the package
+func NewPackageScaffolder(importPath string, withTest, dryRun bool) *PackageScaffolder {
+	return &PackageScaffolder{
+		ImportPath: importPath,
+		Templates:  map[string]string{"doc.go": DocTemplate},
+		WithTest:   withTest,
+		DryRun:     dryRun,
+	}
+}
+
+// Transform implements the ModuleTransformer interface
+func (s *PackageScaffolder) Transform(mod *module.Module) *transform.TransformationResult {
+	result := &transform.TransformationResult{
+		Summary:       fmt.Sprintf("Scaffold package '%s'", s.ImportPath),
+		IsDryRun:      s.DryRun,
+		AffectedFiles: []string{},
+		Changes:       []transform.ChangePreview{},
+	}
+
+	pkg, err := s.build(mod)
+	if err != nil {
+		result.Error = err
+		result.Details = "No package was created"
+		return result
+	}
+
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := pkg.Files[name]
+		result.AffectedFiles = append(result.AffectedFiles, file.Path)
+		result.Changes = append(result.Changes, transform.ChangePreview{
+			FilePath: file.Path,
+			New:      file.SourceCode,
+		})
+	}
+
+	if !s.DryRun {
+		mod.AddPackage(pkg)
+	}
+
+	result.Success = true
+	result.FilesAffected = len(result.AffectedFiles)
+	result.Details = fmt.Sprintf("Created package '%s' with %d file(s)", s.ImportPath, result.FilesAffected)
+	return result
+}
+
+// Name returns the name of the transformer
+func (s *PackageScaffolder) Name() string {
+	return "PackageScaffolder"
+}
+
+// Description returns a description of what the transformer does
+func (s *PackageScaffolder) Description() string {
+	return fmt.Sprintf("Creates package '%s' with boilerplate files", s.ImportPath)
+}
+
+// build validates the request and renders the new package
+func (s *PackageScaffolder) build(mod *module.Module) (*module.Package, error) {
+	rel, ok := relativePath(mod.Path, s.ImportPath)
+	if !ok {
+		return nil, fmt.Errorf("import path %s is not inside module %s", s.ImportPath, mod.Path)
+	}
+	if _, exists := mod.Packages[s.ImportPath]; exists {
+		return nil, fmt.Errorf("package %s already exists", s.ImportPath)
+	}
+
+	name := s.Package
+	if name == "" {
+		name = defaultName(s.ImportPath)
+	}
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("invalid package name %q, set one explicitly", name)
+	}
+	templates := make(map[string]string, len(s.Templates)+1)
+	for fileName, text := range s.Templates {
+		templates[fileName] = text
+	}
+	if testFile := name + "_test.go"; s.WithTest && templates[testFile] == "" {
+		templates[testFile] = TestTemplate
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no templates to render")
+	}
+
+	dir := filepath.Join(mod.Dir, filepath.FromSlash(rel))
+	pkg := module.NewPackage(name, s.ImportPath, dir)
+	data := TemplateData{
+		Name:       name,
+		Title:      strings.ToUpper(name[:1]) + name[1:],
+		ImportPath: s.ImportPath,
+		ModulePath: mod.Path,
+	}
+
+	for fileName, text := range templates {
+		filePath := filepath.Join(dir, fileName)
+		if _, err := os.Stat(filePath); err == nil {
+			return nil, fmt.Errorf("file %s already exists", filePath)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to check %s: %w", filePath, err)
+		}
+		source, err := render(fileName, text, data)
+		if err != nil {
+			return nil, err
+		}
+		file := module.NewFile(filePath, fileName, strings.HasSuffix(fileName, "_test.go"))
+		file.SourceCode = source
+		pkg.AddFile(file)
+	}
+
+	return pkg, nil
+}
+
+// render executes a file template, formatting the result if it is Go source
+func render(fileName, text string, data TemplateData) (string, error) {
+	tmpl, err := template.New(fileName).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template for %s: %w", fileName, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", fileName, err)
+	}
+	if !strings.HasSuffix(fileName, ".go") {
+		return buf.String(), nil
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("template for %s does not produce valid Go: %w", fileName, err)
+	}
+	return string(formatted), nil
+}
+
+// relativePath returns the import path relative to the module path
+func relativePath(modulePath, importPath string) (string, bool) {
+	if importPath == modulePath {
+		return "", true
+	}
+	rel := strings.TrimPrefix(importPath, modulePath+"/")
+	return rel, rel != importPath && rel != ""
+}
+
+// defaultName derives a package name from the last element of an import path
+func defaultName(importPath string) string {
+	name := strings.ToLower(path.Base(importPath))
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, name)
+}
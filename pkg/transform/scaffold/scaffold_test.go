@@ -0,0 +1,118 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/core/saver"
+)
+
+func TestPackageScaffolder(t *testing.T) {
+	dir := t.TempDir()
+	mod := module.NewModule("example.com/shop", dir)
+
+	scaffolder := NewPackageScaffolder("example.com/shop/internal/order-book", true, false)
+	scaffolder.Templates["README.md"] = "# {{.ImportPath}}\n"
+
+	result := scaffolder.Transform(mod)
+	if !result.Success {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+
+	pkg := mod.Packages["example.com/shop/internal/order-book"]
+	if pkg == nil || pkg.Name != "orderbook" || pkg.Module != mod {
+		t.Fatalf("Expected package to be added to the module, got %+v", pkg)
+	}
+	if len(result.AffectedFiles) != 3 {
+		t.Errorf("Expected 3 files, got %v", result.AffectedFiles)
+	}
+
+	if err := saver.NewGoModuleSaver().SavePackage(pkg, dir, saver.DefaultSaveOptions()); err != nil {
+		t.Fatalf("SavePackage failed: %v", err)
+	}
+
+	pkgDir := filepath.Join(dir, "internal", "order-book")
+	for file, want := range map[string]string{
+		"doc.go":            "// Package orderbook TODO: describe the package.\npackage orderbook\n",
+		"orderbook_test.go": "func TestOrderbook(t *testing.T) {",
+		"README.md":         "# example.com/shop/internal/order-book\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(pkgDir, file))
+		if err != nil {
+			t.Fatalf("Expected %s to be written: %v", file, err)
+		}
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", file, want, content)
+		}
+	}
+
+	// Only the new package is written
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		t.Error("Expected go.mod to be left alone")
+	}
+}
+
+func TestPackageScaffolderNamedTest(t *testing.T) {
+	mod := module.NewModule("example.com/shop", t.TempDir())
+
+	scaffolder := NewPackageScaffolder("example.com/shop/order-book", true, false)
+	scaffolder.Package = "orders"
+	if result := scaffolder.Transform(mod); !result.Success {
+		t.Fatalf("Transform failed: %v", result.Error)
+	}
+
+	pkg := mod.Packages["example.com/shop/order-book"]
+	file := pkg.Files["orders_test.go"]
+	if file == nil || !strings.Contains(file.SourceCode, "package orders\n") || !strings.Contains(file.SourceCode, "func TestOrders(") {
+		t.Errorf("Expected orders_test.go for the package name, got files %v", pkg.Files)
+	}
+}
+
+func TestPackageScaffolderErrors(t *testing.T) {
+	dir := t.TempDir()
+	mod := module.NewModule("example.com/shop", dir)
+	mod.AddPackage(module.NewPackage("api", "example.com/shop/api", ""))
+
+	// A directory with files that is not loaded into the module
+	if err := os.MkdirAll(filepath.Join(dir, "legacy"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "legacy", "doc.go"), []byte("// Package legacy is old\npackage legacy\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]*PackageScaffolder{
+		"outside module": NewPackageScaffolder("example.com/other/api", false, false),
+		"exists":         NewPackageScaffolder("example.com/shop/api", false, false),
+		"bad template":   {ImportPath: "example.com/shop/bad", Templates: map[string]string{"doc.go": "package {{.Name"}},
+		"invalid go":     {ImportPath: "example.com/shop/bad", Templates: map[string]string{"doc.go": "packag {{.Name}}"}},
+		"bad name":       {ImportPath: "example.com/shop/bad", Package: "1st", Templates: map[string]string{"doc.go": DocTemplate}},
+		"file on disk":   NewPackageScaffolder("example.com/shop/legacy", false, false),
+	}
+	for name, scaffolder := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := scaffolder.Transform(mod); result.Success || result.Error == nil {
+				t.Error("Expected the scaffold to fail")
+			}
+			if _, ok := mod.Packages["example.com/shop/bad"]; ok {
+				t.Error("Expected no package to be added")
+			}
+		})
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "legacy", "doc.go")); err != nil || string(content) != "// Package legacy is old\npackage legacy\n" {
+		t.Errorf("Expected the existing doc.go to be left alone, got %q (%v)", content, err)
+	}
+
+	// A dry run leaves the module unchanged
+	dryRun := NewPackageScaffolder("example.com/shop/dry", false, true)
+	if result := dryRun.Transform(mod); !result.Success || len(result.Changes) != 1 {
+		t.Fatalf("Expected a successful dry run, got %+v", result)
+	}
+	if _, ok := mod.Packages["example.com/shop/dry"]; ok {
+		t.Error("Expected dry run not to add the package")
+	}
+}
@@ -0,0 +1,102 @@
+package formatter
+
+import (
+	"go/doc/comment"
+	"strings"
+	"unicode"
+)
+
+// headingLevel is the level of headings within doc comments, below the
+// headings of the symbols they document
+const headingLevel = 5
+
+// docLinkBaseURL is where doc links such as [io.Reader] point to
+const docLinkBaseURL = "https://pkg.go.dev"
+
+// DocHTML renders a doc comment as HTML following the godoc conventions:
+// paragraphs, headings, lists, links and indented code blocks, with the
+// first sentence in bold as the summary
+func DocHTML(doc string) string {
+	p := &comment.Printer{HeadingLevel: headingLevel, DocLinkBaseURL: docLinkBaseURL}
+	inner := func(text []comment.Text) string {
+		out := string(p.HTML(&comment.Doc{Content: []comment.Block{&comment.Paragraph{Text: text}}}))
+		return strings.TrimSpace(strings.TrimPrefix(out, "<p>"))
+	}
+
+	d := new(comment.Parser).Parse(doc)
+	summary, rest, ok := splitSummary(d)
+	if !ok {
+		return string(p.HTML(d))
+	}
+	var b strings.Builder
+	b.WriteString("<p><strong>" + inner(summary) + "</strong>")
+	if len(rest) > 0 {
+		b.WriteString(" " + inner(rest))
+	}
+	b.WriteString("\n")
+	b.Write(p.HTML(&comment.Doc{Content: d.Content[1:], Links: d.Links}))
+	return b.String()
+}
+
+// DocMarkdown renders a doc comment as Markdown following the godoc
+// conventions, with the first sentence in bold as the summary
+func DocMarkdown(doc string) string {
+	p := &comment.Printer{HeadingLevel: headingLevel, DocLinkBaseURL: docLinkBaseURL}
+	inner := func(text []comment.Text) string {
+		return strings.TrimSpace(string(p.Markdown(&comment.Doc{Content: []comment.Block{&comment.Paragraph{Text: text}}})))
+	}
+
+	d := new(comment.Parser).Parse(doc)
+	summary, rest, ok := splitSummary(d)
+	if !ok {
+		return string(p.Markdown(d))
+	}
+	var b strings.Builder
+	b.WriteString("**" + inner(summary) + "**")
+	if len(rest) > 0 {
+		b.WriteString(" " + inner(rest))
+	}
+	b.WriteString("\n")
+	if len(d.Content) > 1 {
+		b.WriteString("\n")
+		b.Write(p.Markdown(&comment.Doc{Content: d.Content[1:], Links: d.Links}))
+	}
+	return b.String()
+}
+
+// splitSummary splits the leading paragraph of a doc comment after its
+// first sentence, which ends at a period followed by a space or the end of
+// the paragraph. It reports false if the comment does not start with a
+// paragraph.
+func splitSummary(d *comment.Doc) (summary, rest []comment.Text, ok bool) {
+	if len(d.Content) == 0 {
+		return nil, nil, false
+	}
+	para, ok := d.Content[0].(*comment.Paragraph)
+	if !ok {
+		return nil, nil, false
+	}
+	for i, text := range para.Text {
+		plain, isPlain := text.(comment.Plain)
+		if !isPlain {
+			continue
+		}
+		s := string(plain)
+		for j := 0; j < len(s); j++ {
+			if s[j] != '.' {
+				continue
+			}
+			// Only a period followed by a space or ending the paragraph ends a sentence
+			if j+1 < len(s) && !unicode.IsSpace(rune(s[j+1])) || j+1 == len(s) && i < len(para.Text)-1 {
+				continue
+			}
+			summary = append(append(summary, para.Text[:i]...), comment.Plain(s[:j+1]))
+			if tail := strings.TrimLeftFunc(s[j+1:], unicode.IsSpace); tail != "" {
+				rest = append(rest, comment.Plain(tail))
+			}
+			rest = append(rest, para.Text[i+1:]...)
+			return summary, rest, true
+		}
+	}
+	return para.Text, nil, true
+}
@@ -0,0 +1,60 @@
+package formatter
+
+import "testing"
+
+func TestDocRendering(t *testing.T) {
+	tests := []struct {
+		name         string
+		doc          string
+		wantHTML     string
+		wantMarkdown string
+	}{
+		{
+			name:         "single sentence",
+			doc:          "Reset clears the buffer",
+			wantHTML:     "<p><strong>Reset clears the buffer</strong>\n",
+			wantMarkdown: "**Reset clears the buffer**\n",
+		},
+		{
+			name:         "summary across lines",
+			doc:          "Open opens a file. The file is\nclosed by Close.\n",
+			wantHTML:     "<p><strong>Open opens a file.</strong> The file is\nclosed by Close.\n",
+			wantMarkdown: "**Open opens a file.** The file is closed by Close.\n",
+		},
+		{
+			name:         "periods within words",
+			doc:          "Version 1.2 reads go.mod files. Others are ignored.",
+			wantHTML:     "<p><strong>Version 1.2 reads go.mod files.</strong> Others are ignored.\n",
+			wantMarkdown: "**Version 1.2 reads go.mod files.** Others are ignored.\n",
+		},
+		{
+			name:         "paragraphs and code",
+			doc:          "Parse parses x.\n\nFor example:\n\n\tv := Parse(\"a < b\")\n",
+			wantHTML:     "<p><strong>Parse parses x.</strong>\n<p>For example:\n<pre>v := Parse(&quot;a &lt; b&quot;)\n</pre>\n",
+			wantMarkdown: "**Parse parses x.**\n\nFor example:\n\n\tv := Parse(\"a < b\")\n",
+		},
+		{
+			name:         "doc link",
+			doc:          "Wrap wraps an [io.Reader]. It buffers reads.",
+			wantHTML:     "<p><strong>Wrap wraps an <a href=\"https://pkg.go.dev/io#Reader\">io.Reader</a>.</strong> It buffers reads.\n",
+			wantMarkdown: "**Wrap wraps an [io.Reader](https://pkg.go.dev/io#Reader).** It buffers reads.\n",
+		},
+		{
+			name:         "leading heading",
+			doc:          "# Usage\n\nCall Run.",
+			wantHTML:     "<h5 id=\"hdr-Usage\">Usage</h5>\n<p>Call Run.\n",
+			wantMarkdown: "##### Usage {#hdr-Usage}\n\nCall Run.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DocHTML(tt.doc); got != tt.wantHTML {
+				t.Errorf("DocHTML() = %q, want %q", got, tt.wantHTML)
+			}
+			if got := DocMarkdown(tt.doc); got != tt.wantMarkdown {
+				t.Errorf("DocMarkdown() = %q, want %q", got, tt.wantMarkdown)
+			}
+		})
+	}
+}
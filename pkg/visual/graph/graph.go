@@ -0,0 +1,354 @@
+// Package graph builds the package, type and call relationship graph of a
+// module that graph-based visualizers render.
+package graph
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual"
+)
+
+// Node kinds
+const (
+	NodePackage  = "package"
+	NodeType     = "type"
+	NodeFunction = "function"
+	NodeMethod   = "method"
+)
+
+// Edge kinds
+const (
+	EdgeImports  = "imports"  // package -> imported package
+	EdgeContains = "contains" // package -> type or function
+	EdgeMethod   = "method"   // type -> method
+	EdgeEmbeds   = "embeds"   // type -> embedded type
+	EdgeUses     = "uses"     // type -> type referenced by a field
+	EdgeCalls    = "calls"    // function -> called function
+)
+
+// Node is an element of the module
+type Node struct {
+	ID       string // Unique identifier, e.g. "type:example.com/pkg.User"
+	Label    string // Display name, e.g. "User" or "User.Login"
+	Kind     string // One of the Node* kinds
+	Package  string // Import path of the declaring package
+	Exported bool   // Whether the element is exported
+}
+
+// Edge is a directed relationship between two nodes
+type Edge struct {
+	From string // ID of the source node
+	To   string // ID of the target node
+	Kind string // One of the Edge* kinds
+}
+
+// Graph is the relationship graph of a module
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	nodes map[string]*Node
+	edges map[Edge]bool
+}
+
+// Options configures which elements are included in the graph
+type Options struct {
+	// Embed the common base options
+	visual.BaseVisualizerOptions
+
+	// Include type nodes and their relationships
+	IncludeTypes bool
+
+	// Include function and method nodes
+	IncludeFunctions bool
+
+	// Include call edges (requires a module loaded with IncludeAST)
+	IncludeCalls bool
+}
+
+// DefaultOptions returns options including packages, types and functions
+func DefaultOptions() Options {
+	return Options{
+		IncludeTypes:     true,
+		IncludeFunctions: true,
+		IncludeCalls:     true,
+	}
+}
+
+// Build creates the relationship graph of a module. Nodes and edges are
+// sorted, so the same module always produces the same graph.
+func Build(mod *module.Module, options Options) *Graph {
+	g := &Graph{nodes: make(map[string]*Node), edges: make(map[Edge]bool)}
+
+	pkgs := make([]*module.Package, 0, len(mod.Packages))
+	for _, pkg := range mod.Packages {
+		if pkg.IsTest && !options.IncludeTests {
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+		g.addNode(&Node{ID: PackageID(pkg.ImportPath), Label: pkg.ImportPath, Kind: NodePackage,
+			Package: pkg.ImportPath, Exported: true})
+	}
+
+	// Declarations first, so relationships can refer to any of them
+	for _, pkg := range pkgs {
+		for _, file := range includedFiles(pkg, options) {
+			if options.IncludeTypes {
+				for _, t := range file.Types {
+					if t.IsExported || options.IncludePrivate {
+						g.addNode(&Node{ID: TypeID(pkg.ImportPath, t.Name), Label: t.Name, Kind: NodeType,
+							Package: pkg.ImportPath, Exported: t.IsExported})
+						g.addEdge(PackageID(pkg.ImportPath), TypeID(pkg.ImportPath, t.Name), EdgeContains)
+					}
+				}
+			}
+			if options.IncludeFunctions {
+				for _, fn := range file.Functions {
+					if !fn.IsExported && !options.IncludePrivate {
+						continue
+					}
+					id, label, kind := FunctionID(pkg.ImportPath, fn), fn.Name, NodeFunction
+					if fn.Receiver != nil {
+						label, kind = receiverName(fn.Receiver)+"."+fn.Name, NodeMethod
+					}
+					g.addNode(&Node{ID: id, Label: label, Kind: kind, Package: pkg.ImportPath, Exported: fn.IsExported})
+					g.addEdge(PackageID(pkg.ImportPath), id, EdgeContains)
+				}
+			}
+		}
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range includedFiles(pkg, options) {
+			for _, imp := range file.Imports {
+				g.addEdge(PackageID(pkg.ImportPath), PackageID(imp.Path), EdgeImports)
+			}
+			for _, t := range file.Types {
+				g.addTypeEdges(mod, pkg, file, t)
+			}
+			for _, fn := range file.Functions {
+				if fn.Receiver != nil {
+					g.addEdge(TypeID(pkg.ImportPath, receiverName(fn.Receiver)), FunctionID(pkg.ImportPath, fn), EdgeMethod)
+				}
+			}
+			if options.IncludeCalls && options.IncludeFunctions {
+				g.addCallEdges(pkg, file)
+			}
+		}
+	}
+
+	g.sort()
+	return g
+}
+
+// Node returns the node with the given ID, or nil
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// PackageID returns the node ID of a package
+func PackageID(importPath string) string {
+	return "pkg:" + importPath
+}
+
+// TypeID returns the node ID of a type
+func TypeID(importPath, name string) string {
+	return "type:" + importPath + "." + name
+}
+
+// FunctionID returns the node ID of a function or method
+func FunctionID(importPath string, fn *module.Function) string {
+	if fn.Receiver != nil {
+		return "func:" + importPath + "." + receiverName(fn.Receiver) + "." + fn.Name
+	}
+	return "func:" + importPath + "." + fn.Name
+}
+
+// addNode adds a node unless one with the same ID exists
+func (g *Graph) addNode(n *Node) {
+	if _, ok := g.nodes[n.ID]; ok {
+		return
+	}
+	g.nodes[n.ID] = n
+	g.Nodes = append(g.Nodes, n)
+}
+
+// addEdge adds an edge between two existing nodes, ignoring duplicates
+func (g *Graph) addEdge(from, to, kind string) {
+	if from == to || g.nodes[from] == nil || g.nodes[to] == nil {
+		return
+	}
+	key := Edge{From: from, To: to, Kind: kind}
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+	g.Edges = append(g.Edges, &key)
+}
+
+// addTypeEdges adds embedding and field usage edges of a type
+func (g *Graph) addTypeEdges(mod *module.Module, pkg *module.Package, file *module.File, t *module.Type) {
+	from := TypeID(pkg.ImportPath, t.Name)
+
+	for _, embedded := range t.Embeds {
+		if embedded.Package != nil {
+			g.addEdge(from, TypeID(embedded.Package.ImportPath, embedded.Name), EdgeEmbeds)
+		}
+	}
+
+	for _, field := range t.Fields {
+		kind := EdgeUses
+		if field.IsEmbedded {
+			kind = EdgeEmbeds
+		}
+		for _, ref := range typeRefs(mod, pkg, file, field.Type) {
+			g.addEdge(from, ref, kind)
+		}
+	}
+}
+
+// addCallEdges adds an edge for every call between functions of the graph
+func (g *Graph) addCallEdges(pkg *module.Package, file *module.File) {
+	if pkg.TypesInfo == nil || file.AST == nil {
+		return
+	}
+
+	for _, decl := range file.AST.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+		caller, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+		if !ok {
+			continue
+		}
+		from := objectID(caller)
+
+		ast.Inspect(fd.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var ident *ast.Ident
+			switch fun := ast.Unparen(call.Fun).(type) {
+			case *ast.Ident:
+				ident = fun
+			case *ast.SelectorExpr:
+				ident = fun.Sel
+			}
+			if ident == nil {
+				return true
+			}
+			if callee, ok := pkg.TypesInfo.Uses[ident].(*types.Func); ok {
+				g.addEdge(from, objectID(callee), EdgeCalls)
+			}
+			return true
+		})
+	}
+}
+
+// objectID returns the node ID of a type-checked function or method
+func objectID(fn *types.Func) string {
+	if fn.Pkg() == nil {
+		return ""
+	}
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		recv := sig.Recv().Type()
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		if named, ok := recv.(*types.Named); ok {
+			return "func:" + fn.Pkg().Path() + "." + named.Obj().Name() + "." + fn.Name()
+		}
+	}
+	return "func:" + fn.Pkg().Path() + "." + fn.Name()
+}
+
+// typeRefs returns the IDs of module types referenced by a type expression
+func typeRefs(mod *module.Module, pkg *module.Package, file *module.File, expr string) []string {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil
+	}
+
+	var refs []string
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			if qualifier, ok := node.X.(*ast.Ident); ok {
+				if importPath := resolveImport(file, qualifier.Name); importPath != "" {
+					if target, ok := mod.Packages[importPath]; ok && target.Types[node.Sel.Name] != nil {
+						refs = append(refs, TypeID(importPath, node.Sel.Name))
+					}
+				}
+			}
+			return false
+		case *ast.Ident:
+			if pkg.Types[node.Name] != nil {
+				refs = append(refs, TypeID(pkg.ImportPath, node.Name))
+			}
+		}
+		return true
+	})
+	return refs
+}
+
+// resolveImport returns the import path a file refers to by name
+func resolveImport(file *module.File, name string) string {
+	for _, imp := range file.Imports {
+		localName := imp.Name
+		if localName == "" {
+			localName = path.Base(imp.Path)
+		}
+		if localName == name {
+			return imp.Path
+		}
+	}
+	return ""
+}
+
+// includedFiles returns the package's files selected by the options, sorted by name
+func includedFiles(pkg *module.Package, options Options) []*module.File {
+	files := make([]*module.File, 0, len(pkg.Files))
+	for _, file := range pkg.Files {
+		if file.IsTest && !options.IncludeTests {
+			continue
+		}
+		if file.IsGenerated && !options.IncludeGenerated {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// receiverName returns the receiver's type name without pointer or type parameters
+func receiverName(r *module.Receiver) string {
+	name := strings.TrimPrefix(r.Type, "*")
+	if idx := strings.IndexByte(name, '['); idx >= 0 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// sort orders nodes by ID and edges by endpoints and kind
+func (g *Graph) sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+}
@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/loader"
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+// loadModule writes a small two-package module and loads it with type information
+func loadModule(t *testing.T) *module.Module {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.21\n",
+		"model/model.go": `package model
+
+type Item struct {
+	Name  string
+	Price int
+}
+
+type Order struct {
+	Item
+	Items []*Item
+}
+
+func (o *Order) Total() int {
+	total := 0
+	for _, item := range o.Items {
+		total += item.Price
+	}
+	return total
+}
+`,
+		"service/service.go": `package service
+
+import "example.com/shop/model"
+
+type Service struct {
+	Last *model.Order
+}
+
+func (s *Service) Checkout(o *model.Order) int {
+	s.Last = o
+	return o.Total()
+}
+
+func helper() {}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	options := loader.DefaultLoadOptions()
+	options.IncludeAST = true
+	mod, err := loader.NewGoModuleLoader().LoadWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("Failed to load module: %v", err)
+	}
+	return mod
+}
+
+func TestBuild(t *testing.T) {
+	g := Build(loadModule(t), DefaultOptions())
+
+	wantEdges := []Edge{
+		{From: "pkg:example.com/shop/service", To: "pkg:example.com/shop/model", Kind: EdgeImports},
+		{From: "pkg:example.com/shop/model", To: "type:example.com/shop/model.Order", Kind: EdgeContains},
+		{From: "type:example.com/shop/model.Order", To: "type:example.com/shop/model.Item", Kind: EdgeEmbeds},
+		{From: "type:example.com/shop/model.Order", To: "type:example.com/shop/model.Item", Kind: EdgeUses},
+		{From: "type:example.com/shop/model.Order", To: "func:example.com/shop/model.Order.Total", Kind: EdgeMethod},
+		{From: "type:example.com/shop/service.Service", To: "type:example.com/shop/model.Order", Kind: EdgeUses},
+		{From: "func:example.com/shop/service.Service.Checkout", To: "func:example.com/shop/model.Order.Total", Kind: EdgeCalls},
+	}
+	for _, want := range wantEdges {
+		if !hasEdge(g, want) {
+			t.Errorf("Missing edge %s -[%s]-> %s", want.From, want.Kind, want.To)
+		}
+	}
+
+	total := g.Node("func:example.com/shop/model.Order.Total")
+	if total == nil || total.Kind != NodeMethod || total.Label != "Order.Total" || !total.Exported {
+		t.Errorf("Unexpected method node: %+v", total)
+	}
+
+	// Unexported functions are left out unless requested
+	if g.Node("func:example.com/shop/service.helper") != nil {
+		t.Error("Expected unexported function to be excluded")
+	}
+	options := DefaultOptions()
+	options.IncludePrivate = true
+	if Build(loadModule(t), options).Node("func:example.com/shop/service.helper") == nil {
+		t.Error("Expected unexported function with IncludePrivate")
+	}
+}
+
+func TestBuildIsSorted(t *testing.T) {
+	g := Build(loadModule(t), DefaultOptions())
+
+	for i := 1; i < len(g.Nodes); i++ {
+		if g.Nodes[i-1].ID >= g.Nodes[i].ID {
+			t.Fatalf("Nodes not sorted: %s before %s", g.Nodes[i-1].ID, g.Nodes[i].ID)
+		}
+	}
+	for i := 1; i < len(g.Edges); i++ {
+		if g.Edges[i-1].From > g.Edges[i].From {
+			t.Fatalf("Edges not sorted: %s before %s", g.Edges[i-1].From, g.Edges[i].From)
+		}
+	}
+}
+
+func hasEdge(g *Graph, want Edge) bool {
+	for _, e := range g.Edges {
+		if *e == want {
+			return true
+		}
+	}
+	return false
+}
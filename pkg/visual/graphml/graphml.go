@@ -0,0 +1,145 @@
+// Package graphml exports the relationship graph of a module as GraphML for
+// graph-analysis tools such as Gephi or yEd.
+package graphml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual/graph"
+)
+
+// Namespace is the GraphML XML namespace
+const Namespace = "http://graphml.graphdrawing.org/xmlns"
+
+// GraphMLVisualizer renders a module's relationship graph as GraphML
+type GraphMLVisualizer struct {
+	options graph.Options
+}
+
+// NewGraphMLVisualizer creates a new GraphML visualizer
+func NewGraphMLVisualizer(options graph.Options) *GraphMLVisualizer {
+	return &GraphMLVisualizer{options: options}
+}
+
+// Visualize implements the ModuleVisualizer interface
+func (v *GraphMLVisualizer) Visualize(mod *module.Module) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, v.options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Name returns the name of the visualizer
+func (v *GraphMLVisualizer) Name() string {
+	return "GraphML"
+}
+
+// Description returns a description of what the visualizer produces
+func (v *GraphMLVisualizer) Description() string {
+	return "Exports package, type and call relationships as GraphML"
+}
+
+// Export writes the relationship graph of a module as a GraphML document.
+// Nodes carry the kind, package and exported attributes, edges their kind.
+func Export(w io.Writer, mod *module.Module, options graph.Options) error {
+	return Write(w, graph.Build(mod, options), options.Title)
+}
+
+// Write writes an already built graph as a GraphML document
+func Write(w io.Writer, g *graph.Graph, title string) error {
+	doc := document{
+		Xmlns: Namespace,
+		Keys: []key{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "package", For: "node", Name: "package", Type: "string"},
+			{ID: "exported", For: "node", Name: "exported", Type: "boolean"},
+			{ID: "edgekind", For: "edge", Name: "kind", Type: "string"},
+		},
+		Graph: graphElement{ID: "G", EdgeDefault: "directed"},
+	}
+	if title != "" {
+		doc.Graph.Desc = title
+	}
+
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{
+			ID: n.ID,
+			Data: []data{
+				{Key: "label", Value: n.Label},
+				{Key: "kind", Value: n.Kind},
+				{Key: "package", Value: n.Package},
+				{Key: "exported", Value: strconv.FormatBool(n.Exported)},
+			},
+		})
+	}
+	for i, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, edge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: e.From,
+			Target: e.To,
+			Data:   []data{{Key: "edgekind", Value: e.Kind}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode GraphML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// document is the GraphML root element
+type document struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []key        `xml:"key"`
+	Graph   graphElement `xml:"graph"`
+}
+
+// key declares a data attribute of nodes or edges
+type key struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphElement struct {
+	ID          string `xml:"id,attr"`
+	EdgeDefault string `xml:"edgedefault,attr"`
+	Desc        string `xml:"desc,omitempty"`
+	Nodes       []node `xml:"node"`
+	Edges       []edge `xml:"edge"`
+}
+
+type node struct {
+	ID   string `xml:"id,attr"`
+	Data []data `xml:"data"`
+}
+
+type edge struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Data   []data `xml:"data"`
+}
+
+type data struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
@@ -0,0 +1,70 @@
+package graphml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual/graph"
+)
+
+func TestExport(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+	pkg := module.NewPackage("app", "example.com/app", "")
+	mod.AddPackage(pkg)
+
+	file := module.NewFile("/app/app.go", "app.go", false)
+	pkg.AddFile(file)
+
+	user := module.NewType("User", "struct", true)
+	file.AddType(user)
+	pkg.AddType(user)
+	account := module.NewType("account", "struct", false)
+	account.AddField("Owner", "*User", "", false, "")
+	file.AddType(account)
+	pkg.AddType(account)
+
+	options := graph.DefaultOptions()
+	options.IncludePrivate = true
+	options.Title = "App & friends"
+
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, options); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	out := buf.String()
+
+	// The output must be well-formed XML
+	var doc document
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid XML: %v\n%s", err, out)
+	}
+	if doc.Graph.EdgeDefault != "directed" || len(doc.Keys) != 5 {
+		t.Errorf("Unexpected graph header: %+v", doc)
+	}
+
+	for _, want := range []string{
+		`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`,
+		`<key id="exported" for="node" attr.name="exported" attr.type="boolean"></key>`,
+		`<desc>App &amp; friends</desc>`,
+		`<node id="type:example.com/app.account">`,
+		`<data key="exported">false</data>`,
+		`<edge id="e2" source="type:example.com/app.account" target="type:example.com/app.User">`,
+		`<data key="edgekind">uses</data>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q\n%s", want, out)
+		}
+	}
+
+	// Exporting twice gives identical documents
+	again, err := NewGraphMLVisualizer(options).Visualize(mod)
+	if err != nil {
+		t.Fatalf("Visualize failed: %v", err)
+	}
+	if string(again) != out {
+		t.Error("Expected deterministic output")
+	}
+}
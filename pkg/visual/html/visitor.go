@@ -10,6 +10,7 @@ import (
 
 	"bitspark.dev/go-tree/pkg/core/module"
 	"bitspark.dev/go-tree/pkg/core/visitor"
+	"bitspark.dev/go-tree/pkg/visual/formatter"
 )
 
 // HTMLVisitor implements visitor.ModuleVisitor to generate HTML documentation
@@ -27,6 +28,10 @@ type HTMLVisitor struct {
 	IncludeTests     bool
 	IncludeGenerated bool
 	Title            string
+
+	// Render doc comments as godoc does, with paragraphs, code blocks and
+	// the first sentence in bold, instead of as plain text
+	IncludeDocs bool
 }
 
 // NewHTMLVisitor creates a new HTML visitor
@@ -74,6 +79,14 @@ func formatDocComment(doc string) string {
 	return doc
 }
 
+// formatDoc formats a documentation comment in the configured style
+func (v *HTMLVisitor) formatDoc(doc string) string {
+	if v.IncludeDocs {
+		return formatter.DocHTML(doc)
+	}
+	return formatDocComment(doc)
+}
+
 // typeKindClass returns a CSS class based on the type kind
 func typeKindClass(kind string) string {
 	switch kind {
@@ -214,6 +227,16 @@ body {
   border-radius: 3px;
 }
 
+.doc-comment p {
+  margin: 0 0 8px 0;
+}
+
+.doc-comment pre {
+  font-family: "SFMono-Regular", Consolas, "Liberation Mono", Menlo, monospace;
+  margin: 0 0 8px 0;
+  overflow-x: auto;
+}
+
 .code {
   font-family: "SFMono-Regular", Consolas, "Liberation Mono", Menlo, monospace;
   background-color: #f5f5f5;
@@ -352,7 +375,7 @@ func (v *HTMLVisitor) VisitPackage(pkg *module.Package) error {
 
 	// Package documentation
 	if pkg.Documentation != "" {
-		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", formatDocComment(pkg.Documentation)))
+		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", v.formatDoc(pkg.Documentation)))
 	}
 
 	// Create section for types if any
@@ -441,7 +464,7 @@ func (v *HTMLVisitor) VisitType(typ *module.Type) error {
 
 	// Type documentation
 	if typ.Doc != "" {
-		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", formatDocComment(typ.Doc)))
+		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", v.formatDoc(typ.Doc)))
 	}
 
 	// Type definition
@@ -473,7 +496,7 @@ func (v *HTMLVisitor) VisitFunction(fn *module.Function) error {
 
 	// Function documentation
 	if fn.Doc != "" {
-		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", formatDocComment(fn.Doc)))
+		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", v.formatDoc(fn.Doc)))
 	}
 
 	// Function signature
@@ -509,7 +532,7 @@ func (v *HTMLVisitor) VisitMethod(method *module.Method) error {
 
 	// Method documentation
 	if method.Doc != "" {
-		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", formatDocComment(method.Doc)))
+		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", v.formatDoc(method.Doc)))
 	}
 
 	// Method signature
@@ -545,7 +568,7 @@ func (v *HTMLVisitor) VisitVariable(variable *module.Variable) error {
 
 	// Variable documentation
 	if variable.Doc != "" {
-		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", formatDocComment(variable.Doc)))
+		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", v.formatDoc(variable.Doc)))
 	}
 
 	// Variable definition
@@ -580,7 +603,7 @@ func (v *HTMLVisitor) VisitConstant(constant *module.Constant) error {
 
 	// Constant documentation
 	if constant.Doc != "" {
-		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", formatDocComment(constant.Doc)))
+		v.writeString(fmt.Sprintf("<div class=\"doc-comment\">%s</div>\n", v.formatDoc(constant.Doc)))
 	}
 
 	// Constant definition
@@ -12,8 +12,9 @@ type Options struct {
 	visual.BaseVisualizerOptions
 
 	// Additional HTML-specific options could be added here
-	IncludeCSS bool   // Whether to include CSS in the HTML output
-	CustomCSS  string // Custom CSS to include
+	IncludeCSS  bool   // Whether to include CSS in the HTML output
+	CustomCSS   string // Custom CSS to include
+	IncludeDocs bool   // Whether to render doc comments godoc-style
 }
 
 // HTMLVisualizer implements the ModuleVisualizer interface for generating
@@ -63,6 +64,7 @@ func (v *HTMLVisualizer) Visualize(mod *module.Module) ([]byte, error) {
 	htmlVisitor.IncludeTests = v.options.IncludeTests
 	htmlVisitor.IncludeGenerated = v.options.IncludeGenerated
 	htmlVisitor.Title = v.options.Title
+	htmlVisitor.IncludeDocs = v.options.IncludeDocs
 
 	// Create a module walker with the HTML visitor
 	walker := visitor.NewModuleWalker(htmlVisitor)
@@ -0,0 +1,224 @@
+// Package jsontree exports the symbol tree of a module as JSON for external
+// tooling such as editor tree views.
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual"
+)
+
+// SchemaVersion is the version of the JSON structure written by this
+// package. It changes whenever fields are renamed or removed or their
+// meaning changes; fields may be added without a change.
+const SchemaVersion = 1
+
+// Node kinds
+const (
+	KindModule          = "module"
+	KindPackage         = "package"
+	KindFile            = "file"
+	KindType            = "type"
+	KindFunction        = "func"
+	KindMethod          = "method"
+	KindVariable        = "var"
+	KindConstant        = "const"
+	KindField           = "field"
+	KindInterfaceMethod = "interfaceMethod"
+)
+
+// Document is the top-level JSON object
+type Document struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Title         string `json:"title,omitempty"`
+	Module        *Node  `json:"module"`
+}
+
+// Node is an element of the tree: the module, a package, a file or a
+// symbol. Packages are sorted by import path and files by name; symbols,
+// fields and interface methods keep their source order.
+type Node struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Exported bool      `json:"exported"`
+	Detail   string    `json:"detail,omitempty"`   // Import path, type kind, signature or type of the element
+	Doc      string    `json:"doc,omitempty"`      // Documentation comment
+	Position *Position `json:"position,omitempty"` // Location in source, for files and symbols
+	Children []*Node   `json:"children,omitempty"`
+}
+
+// Position is the source range of a node. The file is slash-separated and
+// relative to the module directory; lines and columns are 1-based.
+type Position struct {
+	File      string `json:"file"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+}
+
+// JSONVisualizer renders the symbol tree of a module as JSON
+type JSONVisualizer struct {
+	options visual.BaseVisualizerOptions
+}
+
+// NewJSONVisualizer creates a new JSON visualizer
+func NewJSONVisualizer(options visual.BaseVisualizerOptions) *JSONVisualizer {
+	return &JSONVisualizer{options: options}
+}
+
+// Visualize implements the ModuleVisualizer interface
+func (v *JSONVisualizer) Visualize(mod *module.Module) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, v.options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Name returns the name of the visualizer
+func (v *JSONVisualizer) Name() string {
+	return "JSON"
+}
+
+// Description returns a description of what the visualizer produces
+func (v *JSONVisualizer) Description() string {
+	return "Exports the module, package, file and symbol tree as JSON"
+}
+
+// Export writes the symbol tree of a module as an indented JSON document
+func Export(w io.Writer, mod *module.Module, options visual.BaseVisualizerOptions) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Build(mod, options))
+}
+
+// Build returns the symbol tree of a module
+func Build(mod *module.Module, options visual.BaseVisualizerOptions) *Document {
+	root := &Node{Kind: KindModule, Name: mod.Path, Exported: true, Detail: mod.Version}
+
+	paths := make([]string, 0, len(mod.Packages))
+	for path := range mod.Packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		pkg := mod.Packages[path]
+		pkgNode := &Node{Kind: KindPackage, Name: pkg.Name, Exported: true, Detail: pkg.ImportPath, Doc: pkg.Documentation}
+
+		names := make([]string, 0, len(pkg.Files))
+		for name := range pkg.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			file := pkg.Files[name]
+			if (file.IsTest && !options.IncludeTests) || (file.IsGenerated && !options.IncludeGenerated) {
+				continue
+			}
+			pkgNode.Children = append(pkgNode.Children, fileNode(mod, file, options))
+		}
+		root.Children = append(root.Children, pkgNode)
+	}
+
+	return &Document{SchemaVersion: SchemaVersion, Title: options.Title, Module: root}
+}
+
+// fileNode returns the node of a file with its symbols in source order
+func fileNode(mod *module.Module, file *module.File, options visual.BaseVisualizerOptions) *Node {
+	node := &Node{Kind: KindFile, Name: file.Name, Exported: true, Position: &Position{File: relPath(mod, file)}}
+
+	type symbol struct {
+		pos  token.Pos
+		node *Node
+	}
+	var symbols []symbol
+	add := func(pos, end token.Pos, n *Node) {
+		if !n.Exported && !options.IncludePrivate {
+			return
+		}
+		n.Position = position(mod, file, pos, end)
+		symbols = append(symbols, symbol{pos, n})
+	}
+
+	for _, typ := range file.Types {
+		n := &Node{Kind: KindType, Name: typ.Name, Exported: typ.IsExported, Detail: typ.Kind, Doc: typ.Doc}
+		for _, field := range typ.Fields {
+			name := field.Name
+			if field.IsEmbedded {
+				name = field.Type
+			}
+			child := &Node{Kind: KindField, Name: name, Exported: field.IsEmbedded || ast.IsExported(name), Detail: field.Type, Doc: field.Doc}
+			if child.Exported || options.IncludePrivate {
+				child.Position = position(mod, file, field.Pos, field.End)
+				n.Children = append(n.Children, child)
+			}
+		}
+		for _, method := range typ.Interfaces {
+			child := &Node{Kind: KindInterfaceMethod, Name: method.Name, Exported: method.IsEmbedded || ast.IsExported(method.Name), Detail: method.Signature, Doc: method.Doc}
+			if child.Exported || options.IncludePrivate {
+				child.Position = position(mod, file, method.Pos, method.End)
+				n.Children = append(n.Children, child)
+			}
+		}
+		add(typ.Pos, typ.End, n)
+	}
+	for _, fn := range file.Functions {
+		n := &Node{Kind: KindFunction, Name: fn.Name, Exported: fn.IsExported, Detail: fn.Signature, Doc: fn.Doc}
+		if fn.IsMethod && fn.Receiver != nil {
+			n.Kind = KindMethod
+			n.Detail = "(" + fn.Receiver.Type + ") " + fn.Signature
+		}
+		add(fn.Pos, fn.End, n)
+	}
+	for _, v := range file.Variables {
+		add(v.Pos, v.End, &Node{Kind: KindVariable, Name: v.Name, Exported: v.IsExported, Detail: v.Type, Doc: v.Doc})
+	}
+	for _, c := range file.Constants {
+		add(c.Pos, c.End, &Node{Kind: KindConstant, Name: c.Name, Exported: c.IsExported, Detail: c.Type, Doc: c.Doc})
+	}
+
+	// Source order, with names breaking ties of symbols without positions
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].pos != symbols[j].pos {
+			return symbols[i].pos < symbols[j].pos
+		}
+		return symbols[i].node.Name < symbols[j].node.Name
+	})
+	for _, s := range symbols {
+		node.Children = append(node.Children, s.node)
+	}
+	return node
+}
+
+// position returns the position of an element of a file
+func position(mod *module.Module, file *module.File, pos, end token.Pos) *Position {
+	p := &Position{File: relPath(mod, file)}
+	if info := file.GetPositionInfo(pos, end); info != nil {
+		p.Line, p.Column = info.LineStart, info.ColStart
+		p.EndLine, p.EndColumn = info.LineEnd, info.ColEnd
+	}
+	return p
+}
+
+// relPath returns the path of a file relative to the module directory, or
+// its path as loaded if it is outside
+func relPath(mod *module.Module, file *module.File) string {
+	if mod.Dir != "" {
+		dir, dirErr := filepath.Abs(mod.Dir)
+		path, pathErr := filepath.Abs(file.Path)
+		if dirErr == nil && pathErr == nil {
+			if rel, err := filepath.Rel(dir, path); err == nil && filepath.IsLocal(rel) {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.ToSlash(file.Path)
+}
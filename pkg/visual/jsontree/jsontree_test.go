@@ -0,0 +1,111 @@
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual"
+)
+
+func TestExport(t *testing.T) {
+	mod := module.NewModule("example.com/app", "/app")
+	for _, path := range []string{"example.com/app/store", "example.com/app"} {
+		pkg := module.NewPackage(path[strings.LastIndex(path, "/")+1:], path, "")
+		mod.AddPackage(pkg)
+	}
+	pkg := mod.Packages["example.com/app"]
+
+	file := module.NewFile("/app/app.go", "app.go", false)
+	pkg.AddFile(file)
+	testFile := module.NewFile("/app/app_test.go", "app_test.go", true)
+	pkg.AddFile(testFile)
+
+	user := module.NewType("User", "struct", true)
+	user.Doc = "User is an account holder"
+	user.AddField("Name", "string", "", false, "")
+	user.AddField("password", "string", "", false, "")
+	user.Pos = 20
+	file.AddType(user)
+
+	reader := module.NewType("Reader", "interface", true)
+	reader.AddInterfaceMethod("Read", "func() string", false, "")
+	reader.Pos = 10
+	file.AddType(reader)
+
+	login := module.NewFunction("Login", true, false)
+	login.IsMethod = true
+	login.Receiver = &module.Receiver{Type: "*User"}
+	login.Signature = "func() error"
+	login.Pos = 30
+	file.AddFunction(login)
+
+	helper := module.NewFunction("helper", false, false)
+	helper.Pos = 40
+	file.AddFunction(helper)
+
+	testFile.AddFunction(module.NewFunction("TestLogin", true, true))
+
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, visual.BaseVisualizerOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var doc Document
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if doc.SchemaVersion != SchemaVersion || doc.Module.Kind != KindModule || doc.Module.Name != "example.com/app" {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+
+	// Packages sorted by import path, files without tests
+	var paths []string
+	for _, n := range doc.Module.Children {
+		paths = append(paths, n.Detail)
+	}
+	if got := strings.Join(paths, ","); got != "example.com/app,example.com/app/store" {
+		t.Errorf("Unexpected package order: %s", got)
+	}
+	files := doc.Module.Children[0].Children
+	if len(files) != 1 || files[0].Name != "app.go" || files[0].Position.File != "app.go" {
+		t.Fatalf("Expected only app.go, got %+v", files)
+	}
+
+	// Exported symbols in source order, with their members
+	var symbols []string
+	for _, n := range files[0].Children {
+		symbols = append(symbols, n.Kind+" "+n.Name)
+	}
+	if got := strings.Join(symbols, ","); got != "type Reader,type User,method Login" {
+		t.Errorf("Unexpected symbols: %s", got)
+	}
+	userNode := files[0].Children[1]
+	if userNode.Doc != "User is an account holder" || len(userNode.Children) != 1 || userNode.Children[0].Name != "Name" {
+		t.Errorf("Unexpected User node: %+v", userNode)
+	}
+	if read := files[0].Children[0].Children; len(read) != 1 || read[0].Kind != KindInterfaceMethod {
+		t.Errorf("Expected the interface method of Reader, got %+v", read)
+	}
+	if detail := files[0].Children[2].Detail; detail != "(*User) func() error" {
+		t.Errorf("Unexpected method detail: %s", detail)
+	}
+
+	// Private members and test files on request
+	options := visual.BaseVisualizerOptions{IncludePrivate: true, IncludeTests: true}
+	doc = *Build(mod, options)
+	files = doc.Module.Children[0].Children
+	if len(files) != 2 || len(files[0].Children) != 4 || len(files[0].Children[1].Children) != 2 {
+		t.Errorf("Expected private members and test files to be included, got %+v", files)
+	}
+
+	// Output is stable across runs
+	var again bytes.Buffer
+	if err := Export(&again, mod, visual.BaseVisualizerOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if again.String() != buf.String() {
+		t.Error("Expected identical output for the same module")
+	}
+}
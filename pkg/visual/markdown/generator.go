@@ -20,6 +20,10 @@ type Options struct {
 
 	// IncludeTOC determines whether to include a table of contents
 	IncludeTOC bool
+
+	// IncludeDocs renders doc comments as godoc does, with paragraphs, code
+	// blocks and the first sentence in bold, instead of verbatim
+	IncludeDocs bool
 }
 
 // DefaultOptions returns default Markdown options
@@ -59,3 +63,22 @@ func (g *Generator) Generate(mod *module.Module) (string, error) {
 	baseFormatter := formatter.NewBaseFormatter(visitor)
 	return baseFormatter.Format(mod)
 }
+
+// Visualize implements the ModuleVisualizer interface
+func (g *Generator) Visualize(mod *module.Module) ([]byte, error) {
+	content, err := g.Generate(mod)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// Name returns the name of the visualizer
+func (g *Generator) Name() string {
+	return "Markdown"
+}
+
+// Description returns a description of what the visualizer produces
+func (g *Generator) Description() string {
+	return "Generates Markdown documentation of the module"
+}
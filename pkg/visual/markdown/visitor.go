@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual/formatter"
 )
 
 // MarkdownVisitor implements the visitor interface for Markdown output
@@ -23,6 +24,15 @@ func NewMarkdownVisitor(options Options) *MarkdownVisitor {
 	}
 }
 
+// formatDoc formats a documentation comment as a Markdown block in the
+// configured style
+func (v *MarkdownVisitor) formatDoc(doc string) string {
+	if v.options.IncludeDocs {
+		return formatter.DocMarkdown(doc) + "\n"
+	}
+	return doc + "\n\n"
+}
+
 // VisitModule processes a module
 func (v *MarkdownVisitor) VisitModule(mod *module.Module) error {
 	// Add module title
@@ -42,7 +52,7 @@ func (v *MarkdownVisitor) VisitPackage(pkg *module.Package) error {
 
 	// Add package documentation if available
 	if pkg.Documentation != "" {
-		v.buffer.WriteString(pkg.Documentation + "\n\n")
+		v.buffer.WriteString(v.formatDoc(pkg.Documentation))
 	}
 
 	return nil
@@ -62,7 +72,7 @@ func (v *MarkdownVisitor) VisitType(typ *module.Type) error {
 
 	// Add type documentation if available
 	if typ.Doc != "" {
-		v.buffer.WriteString(typ.Doc + "\n\n")
+		v.buffer.WriteString(v.formatDoc(typ.Doc))
 	}
 
 	// Type doesn't have a Code field, so we'll just include a placeholder for the code block
@@ -85,7 +95,7 @@ func (v *MarkdownVisitor) VisitFunction(fn *module.Function) error {
 
 	// Add function documentation if available
 	if fn.Doc != "" {
-		v.buffer.WriteString(fn.Doc + "\n\n")
+		v.buffer.WriteString(v.formatDoc(fn.Doc))
 	}
 
 	// Add signature if available
@@ -117,7 +127,7 @@ func (v *MarkdownVisitor) VisitMethod(method *module.Method) error {
 
 	// Add method documentation if available
 	if method.Doc != "" {
-		v.buffer.WriteString(method.Doc + "\n\n")
+		v.buffer.WriteString(v.formatDoc(method.Doc))
 	}
 
 	// Add signature if available
@@ -153,7 +163,7 @@ func (v *MarkdownVisitor) VisitVariable(variable *module.Variable) error {
 	v.buffer.WriteString(fmt.Sprintf("### Variable: %s\n\n", variable.Name))
 
 	if variable.Doc != "" {
-		v.buffer.WriteString(variable.Doc + "\n\n")
+		v.buffer.WriteString(v.formatDoc(variable.Doc))
 	}
 
 	v.buffer.WriteString(fmt.Sprintf("**Type:** %s\n\n", variable.Type))
@@ -174,7 +184,7 @@ func (v *MarkdownVisitor) VisitConstant(constant *module.Constant) error {
 	v.buffer.WriteString(fmt.Sprintf("### Constant: %s\n\n", constant.Name))
 
 	if constant.Doc != "" {
-		v.buffer.WriteString(constant.Doc + "\n\n")
+		v.buffer.WriteString(v.formatDoc(constant.Doc))
 	}
 
 	v.buffer.WriteString(fmt.Sprintf("**Type:** %s\n\n", constant.Type))
@@ -0,0 +1,347 @@
+// Package mermaid renders the package dependency graph and type
+// relationships of a module as Mermaid diagrams embedded in Markdown, which
+// GitHub displays natively.
+package mermaid
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual"
+	"bitspark.dev/go-tree/pkg/visual/graph"
+)
+
+// StdlibNode is the label of the node standing for all standard library
+// imports
+const StdlibNode = "stdlib"
+
+// EdgeImplements connects a type to an interface of the module whose method
+// set it implements
+const EdgeImplements = "implements"
+
+// Options configures the diagrams
+type Options struct {
+	// Embed the common base options
+	visual.BaseVisualizerOptions
+
+	// Include a diagram of types with their embedding, field usage and
+	// interface implementation relationships
+	IncludeTypes bool
+
+	// Direction of the diagrams: "TD" (top down, the default) or "LR"
+	// (left to right)
+	Direction string
+}
+
+// DefaultOptions returns options including packages and types, top down
+func DefaultOptions() Options {
+	return Options{
+		IncludeTypes: true,
+		Direction:    "TD",
+	}
+}
+
+// MermaidVisualizer renders a module as Markdown with Mermaid diagrams
+type MermaidVisualizer struct {
+	options Options
+}
+
+// NewMermaidVisualizer creates a new Mermaid visualizer
+func NewMermaidVisualizer(options Options) *MermaidVisualizer {
+	return &MermaidVisualizer{options: options}
+}
+
+// Visualize implements the ModuleVisualizer interface
+func (v *MermaidVisualizer) Visualize(mod *module.Module) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, v.options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Name returns the name of the visualizer
+func (v *MermaidVisualizer) Name() string {
+	return "Mermaid"
+}
+
+// Description returns a description of what the visualizer produces
+func (v *MermaidVisualizer) Description() string {
+	return "Renders package dependencies and type relationships as Mermaid diagrams in Markdown"
+}
+
+// Export writes a Markdown document with a Mermaid diagram of the module's
+// package imports and, if requested, one of its types. Imports of the
+// standard library are collapsed into a single node and imports of other
+// modules into one node per required module. Interface implementations are
+// found from the method sets of type-checked packages, and by method names
+// for packages loaded without type information.
+func Export(w io.Writer, mod *module.Module, options Options) error {
+	direction := options.Direction
+	if direction == "" {
+		direction = "TD"
+	}
+	if direction != "TD" && direction != "LR" {
+		return fmt.Errorf("unsupported direction %q (supported: TD, LR)", direction)
+	}
+
+	graphOptions := graph.Options{BaseVisualizerOptions: options.BaseVisualizerOptions, IncludeTypes: options.IncludeTypes}
+	g := graph.Build(mod, graphOptions)
+
+	title := options.Title
+	if title == "" {
+		title = mod.Path
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n## Packages\n\n", title)
+	writeDiagram(&buf, direction, packageDiagram(mod, g, options))
+	if options.IncludeTypes {
+		buf.WriteString("\n## Types\n\n")
+		writeDiagram(&buf, direction, typeDiagram(mod, g))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// diagram is the content of a Mermaid flowchart
+type diagram struct {
+	labels []string       // Node labels, indexed by node number
+	nodes  map[string]int // Node numbers by key
+	edges  []string       // Rendered edges
+	seen   map[string]bool
+}
+
+func newDiagram() *diagram {
+	return &diagram{nodes: make(map[string]int), seen: make(map[string]bool)}
+}
+
+// node returns the Mermaid ID of a node, adding it if needed
+func (d *diagram) node(key, label string) string {
+	n, ok := d.nodes[key]
+	if !ok {
+		n = len(d.labels)
+		d.nodes[key] = n
+		d.labels = append(d.labels, label)
+	}
+	return fmt.Sprintf("n%d", n)
+}
+
+// edge adds an edge, ignoring duplicates and self-loops
+func (d *diagram) edge(from, to, arrow, label string) {
+	if from == to {
+		return
+	}
+	line := from + " " + arrow + " " + to
+	if label != "" {
+		line = from + " " + arrow + "|" + label + "| " + to
+	}
+	if !d.seen[line] {
+		d.seen[line] = true
+		d.edges = append(d.edges, line)
+	}
+}
+
+// writeDiagram writes a diagram as a fenced Mermaid block
+func writeDiagram(buf *bytes.Buffer, direction string, d *diagram) {
+	fmt.Fprintf(buf, "```mermaid\ngraph %s\n", direction)
+	for i, label := range d.labels {
+		fmt.Fprintf(buf, "    n%d[\"%s\"]\n", i, escapeLabel(label))
+	}
+	for _, edge := range d.edges {
+		fmt.Fprintf(buf, "    %s\n", edge)
+	}
+	buf.WriteString("```\n")
+}
+
+// packageDiagram builds the import graph of the module's packages
+func packageDiagram(mod *module.Module, g *graph.Graph, options Options) *diagram {
+	d := newDiagram()
+	imports := make(map[string]map[string]bool)
+	for _, n := range g.Nodes {
+		if n.Kind != graph.NodePackage {
+			continue
+		}
+		d.node(n.ID, n.Label)
+		pkg := mod.Packages[n.Package]
+		imports[n.ID] = make(map[string]bool)
+		for _, file := range pkg.Files {
+			if (file.IsTest && !options.IncludeTests) || (file.IsGenerated && !options.IncludeGenerated) {
+				continue
+			}
+			for _, imp := range file.Imports {
+				if _, ok := mod.Packages[imp.Path]; !ok {
+					imports[n.ID][externalNode(mod, imp.Path)] = true
+				}
+			}
+		}
+	}
+
+	for _, e := range g.Edges {
+		if e.Kind == graph.EdgeImports {
+			d.edge(d.node(e.From, ""), d.node(e.To, ""), "-->", "")
+		}
+	}
+	// External nodes follow the module's packages, in a stable order
+	external := make(map[string]bool)
+	for _, targets := range imports {
+		for target := range targets {
+			external[target] = true
+		}
+	}
+	for _, target := range sortedKeys(external) {
+		d.node("ext:"+target, target)
+	}
+	for _, n := range g.Nodes {
+		for _, target := range sortedKeys(imports[n.ID]) {
+			d.edge(d.node(n.ID, ""), d.node("ext:"+target, ""), "-->", "")
+		}
+	}
+	return d
+}
+
+// externalNode returns the node an import from outside the module is
+// collapsed into: the standard library or the required module providing it
+func externalNode(mod *module.Module, importPath string) string {
+	first := importPath
+	if i := strings.IndexByte(first, '/'); i >= 0 {
+		first = first[:i]
+	}
+	if !strings.Contains(first, ".") {
+		return StdlibNode
+	}
+	best := ""
+	for _, dep := range mod.Dependencies {
+		if (importPath == dep.Path || strings.HasPrefix(importPath, dep.Path+"/")) && len(dep.Path) > len(best) {
+			best = dep.Path
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return importPath
+}
+
+// typeDiagram builds the relationships between the module's types
+func typeDiagram(mod *module.Module, g *graph.Graph) *diagram {
+	d := newDiagram()
+	var included []*module.Type
+	for _, n := range g.Nodes {
+		if n.Kind != graph.NodeType {
+			continue
+		}
+		d.node(n.ID, path.Base(n.Package)+"."+n.Label)
+		if t := mod.Packages[n.Package].Types[n.Label]; t != nil {
+			included = append(included, t)
+		}
+	}
+
+	for _, e := range g.Edges {
+		switch e.Kind {
+		case graph.EdgeEmbeds, graph.EdgeUses:
+			d.edge(d.node(e.From, ""), d.node(e.To, ""), "-->", e.Kind)
+		}
+	}
+	for _, t := range included {
+		for _, iface := range included {
+			if t != iface && implements(t, iface) {
+				from := d.node(graph.TypeID(t.Package.ImportPath, t.Name), "")
+				to := d.node(graph.TypeID(iface.Package.ImportPath, iface.Name), "")
+				d.edge(from, to, "-.->", EdgeImplements)
+			}
+		}
+	}
+	return d
+}
+
+// implements reports whether a concrete type implements a non-empty
+// interface of the module, through its value or pointer method set
+func implements(t, iface *module.Type) bool {
+	if t.Kind == "interface" || iface.Kind != "interface" || t.Package == nil || iface.Package == nil {
+		return false
+	}
+	if named, ifaceType := typesNamed(t), typesInterface(iface); named != nil && ifaceType != nil {
+		if ifaceType.Empty() || isGeneric(named) || isGeneric(typesNamed(iface)) {
+			return false
+		}
+		return module.Implements(named, ifaceType)
+	}
+
+	// Without type information compare method names
+	methods := make(map[string]bool)
+	for _, fn := range t.Package.Functions {
+		if fn.Receiver != nil && receiverName(fn.Receiver) == t.Name {
+			methods[fn.Name] = true
+		}
+	}
+	required := 0
+	for _, m := range iface.Interfaces {
+		if m.IsEmbedded {
+			return false
+		}
+		if !methods[m.Name] {
+			return false
+		}
+		required++
+	}
+	return required > 0
+}
+
+// typesNamed returns the type-checked named type of a model type
+func typesNamed(t *module.Type) types.Type {
+	if t.Package.TypesPackage == nil {
+		return nil
+	}
+	obj, ok := t.Package.TypesPackage.Scope().Lookup(t.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	return obj.Type()
+}
+
+// typesInterface returns the type-checked interface of a model type
+func typesInterface(t *module.Type) *types.Interface {
+	named := typesNamed(t)
+	if named == nil {
+		return nil
+	}
+	iface, _ := named.Underlying().(*types.Interface)
+	return iface
+}
+
+// isGeneric reports whether a type is an uninstantiated generic type
+func isGeneric(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// receiverName returns the receiver's type name without pointer or type
+// parameters
+func receiverName(r *module.Receiver) string {
+	name := strings.TrimPrefix(r.Type, "*")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// escapeLabel escapes the characters Mermaid does not accept in a quoted
+// label
+func escapeLabel(label string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(label)
+}
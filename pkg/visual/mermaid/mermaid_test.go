@@ -0,0 +1,83 @@
+package mermaid
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+)
+
+func TestExport(t *testing.T) {
+	mod := module.NewModule("example.com/shapes", "")
+	mod.AddDependency("github.com/acme/geo", "v1.2.0", false)
+
+	root := module.NewPackage("shapes", "example.com/shapes", "")
+	mod.AddPackage(root)
+	rootFile := module.NewFile("/shapes/shapes.go", "shapes.go", false)
+	root.AddFile(rootFile)
+	rootFile.AddImport(&module.Import{Path: "example.com/shapes/square"})
+	rootFile.AddImport(&module.Import{Path: "fmt"})
+
+	shape := module.NewType("Shape", "interface", true)
+	shape.Interfaces = []*module.Method{{Name: "Area", Signature: "() float64"}}
+	rootFile.AddType(shape)
+	root.AddType(shape)
+
+	sq := module.NewPackage("square", "example.com/shapes/square", "")
+	mod.AddPackage(sq)
+	sqFile := module.NewFile("/shapes/square/square.go", "square.go", false)
+	sq.AddFile(sqFile)
+	for _, path := range []string{"math", "strings", "github.com/acme/geo/units", "example.org/other"} {
+		sqFile.AddImport(&module.Import{Path: path})
+	}
+	square := module.NewType("Square", "struct", true)
+	square.AddField("Side", "units.Length", "", false, "")
+	sqFile.AddType(square)
+	sq.AddType(square)
+	outline := module.NewType("Outline", "struct", true)
+	outline.AddField("", "Square", "", true, "")
+	sqFile.AddType(outline)
+	sq.AddType(outline)
+	area := &module.Function{Name: "Area", IsExported: true, IsMethod: true, Receiver: &module.Receiver{Name: "s", Type: "Square"}}
+	sqFile.AddFunction(area)
+	sq.AddFunction(area)
+
+	var buf bytes.Buffer
+	options := DefaultOptions()
+	options.Direction = "LR"
+	if err := Export(&buf, mod, options); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	expected := "# example.com/shapes\n\n" +
+		"## Packages\n\n" +
+		"```mermaid\ngraph LR\n" +
+		"    n0[\"example.com/shapes\"]\n" +
+		"    n1[\"example.com/shapes/square\"]\n" +
+		"    n2[\"example.org/other\"]\n" +
+		"    n3[\"github.com/acme/geo\"]\n" +
+		"    n4[\"stdlib\"]\n" +
+		"    n0 --> n1\n" +
+		"    n0 --> n4\n" +
+		"    n1 --> n2\n" +
+		"    n1 --> n3\n" +
+		"    n1 --> n4\n" +
+		"```\n\n" +
+		"## Types\n\n" +
+		"```mermaid\ngraph LR\n" +
+		"    n0[\"shapes.Shape\"]\n" +
+		"    n1[\"square.Outline\"]\n" +
+		"    n2[\"square.Square\"]\n" +
+		"    n1 -->|embeds| n2\n" +
+		"    n2 -.->|implements| n0\n" +
+		"```\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	options.Direction = "BT"
+	if err := Export(&buf, mod, options); err == nil || !strings.Contains(err.Error(), "unsupported direction") {
+		t.Errorf("Expected an error for an unsupported direction, got %v", err)
+	}
+}
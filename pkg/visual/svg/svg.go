@@ -0,0 +1,247 @@
+// Package svg renders the package import graph of a module as a standalone
+// SVG image, which wikis and documents can embed without running scripts.
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual"
+	"bitspark.dev/go-tree/pkg/visual/graph"
+)
+
+// Layout dimensions in pixels
+const (
+	margin      = 20  // Space around the drawing
+	titleHeight = 30  // Height of the title line
+	charWidth   = 7   // Approximate width of a label character
+	boxPadding  = 16  // Horizontal space between a label and its box
+	minWidth    = 80  // Width of the smallest box
+	minHeight   = 36  // Height of a package without symbols
+	symbolStep  = 2   // Height added per symbol
+	maxHeight   = 120 // Height of the largest box
+	hGap        = 24  // Space between boxes of a layer
+	vGap        = 60  // Space between layers
+)
+
+// SVGVisualizer renders a module's package imports as SVG
+type SVGVisualizer struct {
+	options visual.BaseVisualizerOptions
+}
+
+// NewSVGVisualizer creates a new SVG visualizer
+func NewSVGVisualizer(options visual.BaseVisualizerOptions) *SVGVisualizer {
+	return &SVGVisualizer{options: options}
+}
+
+// Visualize implements the ModuleVisualizer interface
+func (v *SVGVisualizer) Visualize(mod *module.Module) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, v.options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Name returns the name of the visualizer
+func (v *SVGVisualizer) Name() string {
+	return "SVG"
+}
+
+// Description returns a description of what the visualizer produces
+func (v *SVGVisualizer) Description() string {
+	return "Renders package imports as an SVG image"
+}
+
+// box is a package placed in the drawing
+type box struct {
+	id      string
+	label   string
+	symbols int
+	layer   int
+	x, y    int
+	w, h    int
+}
+
+// Export writes an SVG image of the module's packages and their imports
+// within the module. Packages are boxes whose height grows with their
+// number of symbols. They are placed in layers, importing packages above
+// the packages they import, and ordered within a layer to keep edges short.
+func Export(w io.Writer, mod *module.Module, options visual.BaseVisualizerOptions) error {
+	g := graph.Build(mod, graph.Options{BaseVisualizerOptions: options})
+
+	boxes := make(map[string]*box)
+	var order []*box
+	for _, n := range g.Nodes {
+		if n.Kind != graph.NodePackage {
+			continue
+		}
+		b := &box{id: n.ID, label: packageLabel(mod, n.Package), symbols: countSymbols(mod.Packages[n.Package], options)}
+		b.w = max(minWidth, len(b.label)*charWidth+boxPadding)
+		b.h = min(maxHeight, minHeight+b.symbols*symbolStep)
+		boxes[n.ID] = b
+		order = append(order, b)
+	}
+	importers := make(map[string][]string)
+	var edges []*graph.Edge
+	for _, e := range g.Edges {
+		if e.Kind == graph.EdgeImports && boxes[e.From] != nil && boxes[e.To] != nil {
+			importers[e.To] = append(importers[e.To], e.From)
+			edges = append(edges, e)
+		}
+	}
+
+	layers := assignLayers(order, importers)
+	width, height := place(layers, boxes, importers)
+
+	title := options.Title
+	if title == "" {
+		title = mod.Path
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	buf.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#555"/></marker></defs>` + "\n")
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" font-size="16" font-weight="bold">%s</text>`+"\n", margin, margin+16, escape(title))
+	for _, e := range edges {
+		from, to := boxes[e.From], boxes[e.To]
+		fmt.Fprintf(&buf, `<line class="import" x1="%d" y1="%d" x2="%d" y2="%d" stroke="#555" marker-end="url(#arrow)"/>`+"\n",
+			from.x+from.w/2, from.y+from.h, to.x+to.w/2, to.y)
+	}
+	for _, b := range order {
+		fmt.Fprintf(&buf, `<g class="package"><title>%s</title>`, escape(b.id[len("pkg:"):]))
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#eef3fb" stroke="#3b6cb7"/>`, b.x, b.y, b.w, b.h)
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle">%s</text>`, b.x+b.w/2, b.y+18, escape(b.label))
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" font-size="10" fill="#555">%d symbols</text></g>`+"\n",
+			b.x+b.w/2, b.y+31, b.symbols)
+	}
+	buf.WriteString("</svg>\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// assignLayers places each package one layer below the lowest package
+// importing it and returns the packages by layer
+func assignLayers(order []*box, importers map[string][]string) [][]*box {
+	byID := make(map[string]*box, len(order))
+	for _, b := range order {
+		byID[b.id] = b
+	}
+	done := make(map[string]bool)
+	active := make(map[string]bool)
+	var layer func(b *box) int
+	layer = func(b *box) int {
+		if done[b.id] || active[b.id] {
+			// Import cycles only occur in broken modules, cut them here
+			return b.layer
+		}
+		active[b.id] = true
+		for _, from := range importers[b.id] {
+			b.layer = max(b.layer, layer(byID[from])+1)
+		}
+		active[b.id] = false
+		done[b.id] = true
+		return b.layer
+	}
+
+	var layers [][]*box
+	for _, b := range order {
+		l := layer(b)
+		for len(layers) <= l {
+			layers = append(layers, nil)
+		}
+		layers[l] = append(layers[l], b)
+	}
+	return layers
+}
+
+// place orders the packages of each layer by the average position of
+// their importers, then by label, and assigns coordinates. It returns the
+// size of the drawing.
+func place(layers [][]*box, boxes map[string]*box, importers map[string][]string) (int, int) {
+	width, y := 0, margin+titleHeight
+	center := make(map[string]float64)
+	for _, layer := range layers {
+		key := make(map[*box]float64, len(layer))
+		for _, b := range layer {
+			var sum float64
+			for _, from := range importers[b.id] {
+				sum += center[from]
+			}
+			if n := len(importers[b.id]); n > 0 {
+				key[b] = sum / float64(n)
+			}
+		}
+		sort.SliceStable(layer, func(i, j int) bool {
+			if key[layer[i]] != key[layer[j]] {
+				return key[layer[i]] < key[layer[j]]
+			}
+			return layer[i].label < layer[j].label
+		})
+
+		x, height := margin, 0
+		for _, b := range layer {
+			b.x, b.y = x, y
+			center[b.id] = float64(x + b.w/2)
+			x += b.w + hGap
+			height = max(height, b.h)
+		}
+		width = max(width, x-hGap+margin)
+		y += height + vGap
+	}
+	return max(width, 2*margin+minWidth), y - vGap + margin
+}
+
+// packageLabel returns the path of a package relative to the module, or
+// the module path for its root package
+func packageLabel(mod *module.Module, importPath string) string {
+	if rel := strings.TrimPrefix(importPath, mod.Path+"/"); rel != importPath {
+		return rel
+	}
+	return importPath
+}
+
+// countSymbols returns the number of top-level declarations of a package
+// included by the options
+func countSymbols(pkg *module.Package, options visual.BaseVisualizerOptions) int {
+	count := 0
+	for _, file := range pkg.Files {
+		if (file.IsTest && !options.IncludeTests) || (file.IsGenerated && !options.IncludeGenerated) {
+			continue
+		}
+		for _, t := range file.Types {
+			if t.IsExported || options.IncludePrivate {
+				count++
+			}
+		}
+		for _, fn := range file.Functions {
+			if fn.IsExported || options.IncludePrivate {
+				count++
+			}
+		}
+		for _, v := range file.Variables {
+			if v.IsExported || options.IncludePrivate {
+				count++
+			}
+		}
+		for _, c := range file.Constants {
+			if c.IsExported || options.IncludePrivate {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// escape returns text escaped for XML character data and attributes
+func escape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
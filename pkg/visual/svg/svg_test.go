@@ -0,0 +1,89 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"bitspark.dev/go-tree/pkg/core/module"
+	"bitspark.dev/go-tree/pkg/visual"
+)
+
+// image is the part of an SVG document the tests inspect
+type image struct {
+	Groups []struct {
+		Title string `xml:"title"`
+		Rect  struct {
+			Y      int `xml:"y,attr"`
+			Height int `xml:"height,attr"`
+		} `xml:"rect"`
+		Texts []string `xml:"text"`
+	} `xml:"g"`
+	Lines []struct {
+		Class string `xml:"class,attr"`
+	} `xml:"line"`
+}
+
+func TestExport(t *testing.T) {
+	mod := module.NewModule("example.com/app", "")
+	imports := map[string][]string{
+		"example.com/app":       {"example.com/app/store", "example.com/app/util", "fmt"},
+		"example.com/app/store": {"example.com/app/util"},
+		"example.com/app/util":  nil,
+	}
+	for path, deps := range imports {
+		pkg := module.NewPackage(path[strings.LastIndex(path, "/")+1:], path, "")
+		mod.AddPackage(pkg)
+		file := module.NewFile("/"+path+"/file.go", "file.go", false)
+		pkg.AddFile(file)
+		for _, dep := range deps {
+			file.AddImport(module.NewImport(dep, "", false))
+		}
+	}
+	store := mod.Packages["example.com/app/store"].Files["file.go"]
+	for _, name := range []string{"Get", "Put", "delete"} {
+		store.AddFunction(module.NewFunction(name, name != "delete", false))
+	}
+
+	options := visual.BaseVisualizerOptions{Title: "App & friends"}
+	var buf bytes.Buffer
+	if err := Export(&buf, mod, options); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	var img image
+	if err := xml.Unmarshal(buf.Bytes(), &img); err != nil {
+		t.Fatalf("Invalid SVG: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "App &amp; friends") {
+		t.Error("Expected the title to be escaped")
+	}
+	if len(img.Groups) != 3 || len(img.Lines) != 3 {
+		t.Fatalf("Expected 3 packages and 3 imports within the module, got %d and %d", len(img.Groups), len(img.Lines))
+	}
+
+	// Importers are placed above the packages they import
+	y := make(map[string]int)
+	height := make(map[string]int)
+	for _, g := range img.Groups {
+		y[g.Title] = g.Rect.Y
+		height[g.Title] = g.Rect.Height
+	}
+	if !(y["example.com/app"] < y["example.com/app/store"] && y["example.com/app/store"] < y["example.com/app/util"]) {
+		t.Errorf("Unexpected layers: %v", y)
+	}
+
+	// Boxes grow with the included symbols
+	if height["example.com/app/store"] != minHeight+2*symbolStep || height["example.com/app/util"] != minHeight {
+		t.Errorf("Unexpected box heights: %v", height)
+	}
+	options.IncludePrivate = true
+	buf.Reset()
+	if err := Export(&buf, mod, options); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), ">3 symbols<") {
+		t.Error("Expected private symbols to be counted with IncludePrivate")
+	}
+}